@@ -0,0 +1,87 @@
+package postdog
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+type weightedBalancer struct {
+	transports []string
+	cumulative []int
+	total      int
+}
+
+// WithWeightedBalancer returns an OptionFunc that adds the transport group
+// group to a *Dog, whose mails are distributed across the transports in
+// weights proportionally to their weight. Mails are sent through the group
+// with the send.UseGroup() option:
+//
+//	dog := postdog.New(
+//		postdog.WithTransport("a", a),
+//		postdog.WithTransport("b", b),
+//		postdog.WithWeightedBalancer("bulk", map[string]int{"a": 80, "b": 20}),
+//	)
+//	dog.Send(context.TODO(), m, send.UseGroup("bulk"))
+//
+// The transport of every mail is selected randomly, so the distribution
+// converges to the configured ratio over many sends. Transports with a weight
+// <= 0 are never selected. If the selected transport has not been registered,
+// Send() returns ErrUnconfiguredTransport. Adding a balancer for an existing
+// group replaces it.
+func WithWeightedBalancer(group string, weights map[string]int) OptionFunc {
+	return func(dog *Dog) {
+		dog.balancers[group] = newWeightedBalancer(weights)
+	}
+}
+
+func newWeightedBalancer(weights map[string]int) *weightedBalancer {
+	names := make([]string, 0, len(weights))
+	for name, w := range weights {
+		if w > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	b := weightedBalancer{
+		transports: names,
+		cumulative: make([]int, len(names)),
+	}
+	for i, name := range names {
+		b.total += weights[name]
+		b.cumulative[i] = b.total
+	}
+	return &b
+}
+
+// pick returns the name of a random transport, selected proportionally to
+// the weights of the transports.
+func (b *weightedBalancer) pick() (string, bool) {
+	if b.total == 0 {
+		return "", false
+	}
+	// the top-level functions of math/rand are safe for concurrent use
+	r := rand.Intn(b.total)
+	i := sort.Search(len(b.cumulative), func(i int) bool {
+		return b.cumulative[i] > r
+	})
+	return b.transports[i], true
+}
+
+// balance returns the name of the transport that is selected by the balancer
+// of group.
+func (dog *Dog) balance(group string) (string, error) {
+	dog.mux.RLock()
+	b, ok := dog.balancers[group]
+	dog.mux.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnconfiguredGroup, group)
+	}
+
+	name, ok := b.pick()
+	if !ok {
+		return "", fmt.Errorf("%w: %s has no weighted transports", ErrUnconfiguredGroup, group)
+	}
+	return name, nil
+}
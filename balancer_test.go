@@ -0,0 +1,92 @@
+package postdog_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bounoable/postdog"
+	mock_postdog "github.com/bounoable/postdog/mocks"
+	"github.com/bounoable/postdog/send"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithWeightedBalancer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var sentA, sentB int64
+	a := mock_postdog.NewMockTransport(ctrl)
+	a.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, postdog.Mail) error {
+		atomic.AddInt64(&sentA, 1)
+		return nil
+	}).AnyTimes()
+	b := mock_postdog.NewMockTransport(ctrl)
+	b.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, postdog.Mail) error {
+		atomic.AddInt64(&sentB, 1)
+		return nil
+	}).AnyTimes()
+
+	dog := postdog.New(
+		postdog.WithTransport("a", a),
+		postdog.WithTransport("b", b),
+		postdog.WithTransport("unused", mock_postdog.NewMockTransport(ctrl)),
+		postdog.WithWeightedBalancer("bulk", map[string]int{"a": 80, "b": 20, "unused": 0}),
+	)
+
+	const workers, sends = 10, 2000
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < sends; j++ {
+				assert.Nil(t, dog.Send(context.Background(), mockMail(), send.UseGroup("bulk")))
+			}
+		}()
+	}
+	wg.Wait()
+
+	total := float64(workers * sends)
+	assert.Equal(t, total, float64(sentA+sentB))
+
+	// the standard deviation of the ratio is sqrt(0.8*0.2/20000) ≈ 0.0028
+	ratio := float64(sentA) / total
+	assert.True(t, math.Abs(ratio-0.8) < 0.015, "%.2f%% of the mails have been sent through a; want ~80%%", ratio*100)
+}
+
+func TestWithWeightedBalancer_use(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	a := mock_postdog.NewMockTransport(ctrl)
+	b := mock_postdog.NewMockTransport(ctrl)
+	b.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil)
+
+	dog := postdog.New(
+		postdog.WithTransport("a", a),
+		postdog.WithTransport("b", b),
+		postdog.WithWeightedBalancer("bulk", map[string]int{"a": 1}),
+	)
+
+	assert.Nil(t, dog.Send(context.Background(), mockMail(), send.UseGroup("bulk"), send.Use("b")))
+}
+
+func TestWithWeightedBalancer_unconfigured(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dog := postdog.New(
+		postdog.WithTransport("a", mock_postdog.NewMockTransport(ctrl)),
+		postdog.WithWeightedBalancer("empty", map[string]int{"a": 0}),
+		postdog.WithWeightedBalancer("missing", map[string]int{"b": 1}),
+	)
+
+	assert.True(t, errors.Is(dog.Send(context.Background(), mockMail(), send.UseGroup("other")), postdog.ErrUnconfiguredGroup))
+	assert.True(t, errors.Is(dog.Send(context.Background(), mockMail(), send.UseGroup("empty")), postdog.ErrUnconfiguredGroup))
+	assert.True(t, errors.Is(dog.Send(context.Background(), mockMail(), send.UseGroup("missing")), postdog.ErrUnconfiguredTransport))
+}
@@ -0,0 +1,387 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/mail"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bounoable/postdog/plugin/archive"
+	"github.com/bounoable/postdog/plugin/archive/query"
+	"github.com/google/uuid"
+)
+
+const archiveUsage = `Usage: postdog archive <command> [flags]
+
+Commands:
+  list      List archived mails, newest first.
+  show      Show an archived mail.
+  resend    Resend archived mails.
+`
+
+func (a *app) archive(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(a.stderr, archiveUsage)
+		return errUsage
+	}
+
+	switch args[0] {
+	case "list":
+		return a.archiveList(ctx, args[1:])
+	case "show":
+		return a.archiveShow(ctx, args[1:])
+	case "resend":
+		return a.archiveResend(ctx, args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(a.stdout, archiveUsage)
+		return nil
+	default:
+		fmt.Fprintf(a.stderr, "postdog: unknown archive command %q\n\n%s", args[0], archiveUsage)
+		return errUsage
+	}
+}
+
+// store opens the archive Store and returns a function that closes it.
+func (a *app) store(ctx context.Context, fs *flag.FlagSet, f storeFlags) (archive.Store, func(), error) {
+	if f.uri == "" {
+		return nil, nil, a.usageErrorf(fs, "-mongo-uri is required")
+	}
+
+	s, closeStore, err := a.openStore(ctx, f)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s, func() {
+		if err := closeStore(context.Background()); err != nil {
+			fmt.Fprintf(a.stderr, "postdog: close archive: %v\n", err)
+		}
+	}, nil
+}
+
+func (a *app) archiveList(ctx context.Context, args []string) error {
+	var (
+		sf            storeFlags
+		from, to      addressFlag
+		subject       string
+		failed        bool
+		since         time.Duration
+		page, perPage int
+	)
+
+	fs := a.flagSet("archive list", "")
+	sf.register(fs)
+	fs.Var(&from, "from", "only list mails from the address (repeatable, comma-separated)")
+	fs.Var(&to, "to", "only list mails to the address, including CC and BCC (repeatable, comma-separated)")
+	fs.StringVar(&subject, "subject", "", "only list mails with the subject")
+	fs.BoolVar(&failed, "failed", false, "only list mails that failed to send")
+	fs.DurationVar(&since, "since", 0, "only list mails that have been sent within the duration, e.g. 24h")
+	fs.IntVar(&page, "page", 1, "page of the list")
+	fs.IntVar(&perPage, "limit", 20, "number of mails per page")
+
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	if fs.NArg() > 0 {
+		return a.usageErrorf(fs, "unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	if page < 1 || perPage < 1 {
+		return a.usageErrorf(fs, "-page and -limit must be positive")
+	}
+
+	s, closeStore, err := a.store(ctx, fs, sf)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	opts := []query.Option{
+		query.Sort(query.SortSendTime, query.SortDesc),
+		query.WithoutAttachmentContent(),
+	}
+	if len(from) > 0 {
+		opts = append(opts, query.From(from...))
+	}
+	if len(to) > 0 {
+		opts = append(opts, query.Recipient(to...))
+	}
+	if subject != "" {
+		opts = append(opts, query.Subject(subject))
+	}
+	if since > 0 {
+		opts = append(opts, query.SentAfter(time.Now().Add(-since)))
+	}
+	if !failed {
+		// failed mails are filtered by the CLI, because queries can't filter
+		// by send errors, so they are paginated by the CLI, too
+		opts = append(opts, query.Paginate(page, perPage))
+	}
+
+	var skip int
+	if failed {
+		skip = (page - 1) * perPage
+	}
+
+	mails, err := queryMails(ctx, s, query.New(opts...), failed, skip, perPage)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSENT AT\tFROM\tTO\tSUBJECT\tSTATUS")
+	for _, m := range mails {
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%s\t%s\t%s\n",
+			m.ID(),
+			m.SentAt().Local().Format(time.RFC3339),
+			m.From().Address,
+			joinAddresses(m.Recipients(), false),
+			m.Subject(),
+			status(m),
+		)
+	}
+	return w.Flush()
+}
+
+func (a *app) archiveShow(ctx context.Context, args []string) error {
+	var (
+		sf  storeFlags
+		raw bool
+	)
+
+	fs := a.flagSet("archive show", " <id>")
+	sf.register(fs)
+	fs.BoolVar(&raw, "rfc", false, "print the RFC body of the mail")
+
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return a.usageErrorf(fs, "expected exactly one mail id")
+	}
+
+	id, err := uuid.Parse(fs.Arg(0))
+	if err != nil {
+		return a.usageErrorf(fs, "invalid mail id %q: %v", fs.Arg(0), err)
+	}
+
+	s, closeStore, err := a.store(ctx, fs, sf)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	m, err := s.Find(ctx, id)
+	if err != nil {
+		return fmt.Errorf("find mail %s: %w", id, err)
+	}
+
+	if raw {
+		_, err := io.WriteString(a.stdout, m.RFC())
+		return err
+	}
+
+	w := tabwriter.NewWriter(a.stdout, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "ID:\t%s\n", m.ID())
+	fmt.Fprintf(w, "Message-ID:\t%s\n", m.MessageID())
+	fmt.Fprintf(w, "Sent at:\t%s\n", m.SentAt().Local().Format(time.RFC3339))
+	fmt.Fprintf(w, "Status:\t%s\n", status(m))
+	from := m.From()
+	fmt.Fprintf(w, "From:\t%s\n", from.String())
+	if len(m.ReplyTo()) > 0 {
+		fmt.Fprintf(w, "Reply-To:\t%s\n", joinAddresses(m.ReplyTo(), true))
+	}
+	fmt.Fprintf(w, "To:\t%s\n", joinAddresses(m.To(), true))
+	if len(m.CC()) > 0 {
+		fmt.Fprintf(w, "CC:\t%s\n", joinAddresses(m.CC(), true))
+	}
+	if len(m.BCC()) > 0 {
+		fmt.Fprintf(w, "BCC:\t%s\n", joinAddresses(m.BCC(), true))
+	}
+	fmt.Fprintf(w, "Subject:\t%s\n", m.Subject())
+	if m.ContentHash() != "" {
+		fmt.Fprintf(w, "Content hash:\t%s\n", m.ContentHash())
+	}
+	for _, at := range m.Attachments() {
+		fmt.Fprintf(w, "Attachment:\t%s (%s, %d bytes)\n", at.Filename(), at.ContentType(), at.Size())
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	body := m.Text()
+	if body == "" {
+		body = m.HTML()
+	}
+	if body != "" {
+		fmt.Fprintf(a.stdout, "\n%s\n", strings.TrimRight(body, "\r\n"))
+	}
+
+	return nil
+}
+
+func (a *app) archiveResend(ctx context.Context, args []string) error {
+	var (
+		cfg    configFlags
+		sf     storeFlags
+		failed bool
+		since  time.Duration
+		limit  int
+	)
+
+	fs := a.flagSet("archive resend", " [<id>...]\n\nResends the mails with the given ids, or the failed mails if -failed is set.\nThe archived mails are updated with the result of the resend.")
+	cfg.register(fs)
+	sf.register(fs)
+	fs.BoolVar(&failed, "failed", false, "resend the mails that failed to send instead of the given ids")
+	fs.DurationVar(&since, "since", 24*time.Hour, "with -failed: only resend mails that have been sent within the duration")
+	fs.IntVar(&limit, "limit", 100, "with -failed: maximum number of mails to resend")
+
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	if failed == (fs.NArg() > 0) {
+		return a.usageErrorf(fs, "expected either mail ids or -failed")
+	}
+
+	if limit < 1 {
+		return a.usageErrorf(fs, "-limit must be positive")
+	}
+
+	ids := make([]uuid.UUID, fs.NArg())
+	for i, arg := range fs.Args() {
+		id, err := uuid.Parse(arg)
+		if err != nil {
+			return a.usageErrorf(fs, "invalid mail id %q: %v", arg, err)
+		}
+		ids[i] = id
+	}
+
+	s, closeStore, err := a.store(ctx, fs, sf)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	var mails []archive.Mail
+	if failed {
+		if mails, err = unresolvedFailures(ctx, s, time.Now().Add(-since), limit); err != nil {
+			return err
+		}
+	} else {
+		for _, id := range ids {
+			m, err := s.Find(ctx, id)
+			if err != nil {
+				return fmt.Errorf("find mail %s: %w", id, err)
+			}
+			mails = append(mails, m)
+		}
+	}
+
+	// a resend fails if its result can't be archived, so that a resent mail
+	// is never resent again because of a stale failure
+	dog, err := a.dog(ctx, cfg, archive.New(s, archive.Synchronous(), archive.FailOnInsertError()))
+	if err != nil {
+		return err
+	}
+
+	var failures int
+	for _, m := range mails {
+		// the result of the resend replaces the archived mail, which keeps
+		// the Message-ID unique within the archive
+		if err := dog.Send(archive.WithMailID(ctx, m.ID()), m.Letter, cfg.sendOptions()...); err != nil {
+			failures++
+			fmt.Fprintf(a.stderr, "%s: %v\n", m.ID(), err)
+			continue
+		}
+		fmt.Fprintf(a.stdout, "%s: resent\n", m.ID())
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("%d of %d mails could not be resent", failures, len(mails))
+	}
+
+	return nil
+}
+
+// queryMails returns the mails of q. If failed is true, only mails with a
+// send error are returned. The first skip of those mails are skipped, and at
+// most limit mails are returned.
+func queryMails(ctx context.Context, s archive.Store, q query.Query, failed bool, skip, limit int) ([]archive.Mail, error) {
+	cur, err := s.Query(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("query archive: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	var mails []archive.Mail
+	for len(mails) < limit && cur.Next(ctx) {
+		m := cur.Current()
+		if failed && m.SendError() == "" {
+			continue
+		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+		mails = append(mails, m)
+	}
+
+	if err := cur.Err(); err != nil {
+		return mails, fmt.Errorf("query archive: %w", err)
+	}
+
+	return mails, nil
+}
+
+// unresolvedFailures returns the mails that have been sent after t and whose
+// last send attempt failed. Resent mails replace the archived mail, so a
+// failed mail that has been resent successfully is not returned again. At most
+// limit mails are returned.
+func unresolvedFailures(ctx context.Context, s archive.Store, t time.Time, limit int) ([]archive.Mail, error) {
+	failures, err := queryMails(ctx, s, query.New(
+		query.Sort(query.SortSendTime, query.SortAsc),
+		query.SentAfter(t),
+		query.WithoutAttachmentContent(),
+	), true, 0, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	mails := make([]archive.Mail, len(failures))
+	for i, m := range failures {
+		// the attachment contents were not queried
+		if mails[i], err = s.Find(ctx, m.ID()); err != nil {
+			return nil, fmt.Errorf("find mail %s: %w", m.ID(), err)
+		}
+	}
+
+	return mails, nil
+}
+
+func status(m archive.Mail) string {
+	if m.SendError() != "" {
+		return "failed: " + m.SendError()
+	}
+	return "sent"
+}
+
+func joinAddresses(addrs []mail.Address, names bool) string {
+	res := make([]string, len(addrs))
+	for i, addr := range addrs {
+		if names {
+			res[i] = addr.String()
+		} else {
+			res[i] = addr.Address
+		}
+	}
+	return strings.Join(res, ", ")
+}
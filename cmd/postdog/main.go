@@ -0,0 +1,168 @@
+// Command postdog sends mails through the transports of a postdog
+// configuration file (see package config) and browses and replays the mails
+// of a mongo archive (see package archive).
+//
+// Usage:
+//
+//	postdog send [flags]
+//	postdog archive list [flags]
+//	postdog archive show [flags] <id>
+//	postdog archive resend [flags] [<id>...]
+//
+// Run `postdog <command> -h` for the flags of a command.
+//
+// The exit code is 0 on success, 1 if the command failed, e.g. because a mail
+// could not be sent, and 2 if the command was called with invalid flags or
+// arguments.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/bounoable/postdog/config"
+	"github.com/bounoable/postdog/plugin/archive"
+	"github.com/bounoable/postdog/transport/filesystem"
+	"github.com/bounoable/postdog/transport/gmail"
+	"github.com/bounoable/postdog/transport/maildir"
+	"github.com/bounoable/postdog/transport/nop"
+	"github.com/bounoable/postdog/transport/smtp"
+)
+
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+// errUsage means a command was called with invalid flags or arguments. The
+// problem has already been reported when errUsage is returned.
+var errUsage = errors.New("invalid usage")
+
+const usage = `Usage: postdog <command> [flags]
+
+Commands:
+  send              Send a mail through a configured transport.
+  archive list      List archived mails.
+  archive show      Show an archived mail.
+  archive resend    Resend archived mails.
+
+Run 'postdog <command> -h' for the flags of a command.
+`
+
+type app struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	// configOptions are passed to (*config.Config).Dog().
+	configOptions []config.Option
+
+	// openStore opens the archive Store. The returned function closes the Store.
+	openStore func(context.Context, storeFlags) (archive.Store, func(context.Context) error, error)
+}
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt)
+	go func() {
+		<-sigs
+		cancel()
+	}()
+
+	a := app{
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+		configOptions: []config.Option{
+			config.WithTransportFactory("smtp", config.TransportFactoryFunc(smtp.Factory)),
+			config.WithTransportFactory("gmail", config.TransportFactoryFunc(gmail.Factory)),
+			config.WithTransportFactory("filesystem", config.TransportFactoryFunc(filesystem.Factory)),
+			config.WithTransportFactory("maildir", config.TransportFactoryFunc(maildir.Factory)),
+			config.WithTransportFactory("nop", config.TransportFactoryFunc(nop.Factory)),
+		},
+		openStore: openMongoStore,
+	}
+
+	code := a.run(ctx, os.Args[1:])
+	cancel()
+	os.Exit(code)
+}
+
+// run runs the command in args and returns the exit code.
+func (a *app) run(ctx context.Context, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(a.stderr, usage)
+		return exitUsage
+	}
+
+	var err error
+	switch args[0] {
+	case "send":
+		err = a.send(ctx, args[1:])
+	case "archive":
+		err = a.archive(ctx, args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(a.stdout, usage)
+		return exitOK
+	default:
+		fmt.Fprintf(a.stderr, "postdog: unknown command %q\n\n%s", args[0], usage)
+		return exitUsage
+	}
+
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.Is(err, errUsage):
+		return exitUsage
+	default:
+		fmt.Fprintf(a.stderr, "postdog: %v\n", err)
+		return exitFailure
+	}
+}
+
+// flagSet returns a FlagSet for the command name that reports errors to
+// a.stderr instead of exiting.
+func (a *app) flagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(a.stderr, "Usage: postdog %s [flags]%s\n\nFlags:\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses args into fs and returns errUsage for invalid flags.
+func parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	return nil
+}
+
+// usageErrorf reports an invalid usage of the command of fs and returns
+// errUsage.
+func (a *app) usageErrorf(fs *flag.FlagSet, format string, v ...interface{}) error {
+	fmt.Fprintf(a.stderr, "postdog: "+format+"\n\n", v...)
+	fs.Usage()
+	return errUsage
+}
+
+// envDefault returns the value of the environment variable key, or def if the
+// variable is empty.
+func envDefault(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
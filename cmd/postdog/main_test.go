@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bounoable/postdog/config"
+	"github.com/bounoable/postdog/letter"
+	"github.com/bounoable/postdog/plugin/archive"
+	"github.com/bounoable/postdog/plugin/archive/memory"
+	"github.com/bounoable/postdog/plugin/archive/query"
+	"github.com/bounoable/postdog/transport/filesystem"
+	"github.com/bounoable/postdog/transport/nop"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type testApp struct {
+	app
+	stdout, stderr bytes.Buffer
+	store          *memory.Store
+}
+
+func newTestApp(stdin string) *testApp {
+	ta := testApp{store: memory.NewStore()}
+	ta.app = app{
+		stdin:  strings.NewReader(stdin),
+		stdout: &ta.stdout,
+		stderr: &ta.stderr,
+		configOptions: []config.Option{
+			config.WithTransportFactory("filesystem", config.TransportFactoryFunc(filesystem.Factory)),
+			config.WithTransportFactory("nop", config.TransportFactoryFunc(nop.Factory)),
+		},
+		openStore: func(context.Context, storeFlags) (archive.Store, func(context.Context) error, error) {
+			return ta.store, func(context.Context) error { return nil }, nil
+		},
+	}
+	return &ta
+}
+
+func TestRun_usage(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{name: "no command", args: nil, want: exitUsage},
+		{name: "unknown command", args: []string{"foo"}, want: exitUsage},
+		{name: "help", args: []string{"help"}, want: exitOK},
+		{name: "command help", args: []string{"send", "-h"}, want: exitOK},
+		{name: "unknown flag", args: []string{"send", "-foo"}, want: exitUsage},
+		{name: "missing sender", args: []string{"send", "-to", "linda@example.com"}, want: exitUsage},
+		{name: "missing recipients", args: []string{"send", "-from", "bob@example.com"}, want: exitUsage},
+		{name: "invalid address", args: []string{"send", "-from", "bob@example.com", "-to", "linda"}, want: exitUsage},
+		{name: "no archive command", args: []string{"archive"}, want: exitUsage},
+		{name: "unknown archive command", args: []string{"archive", "foo"}, want: exitUsage},
+		{name: "missing mongo uri", args: []string{"archive", "list"}, want: exitUsage},
+		{name: "invalid mail id", args: []string{"archive", "show", "-mongo-uri", "mongodb://test", "foo"}, want: exitUsage},
+		{name: "resend without ids", args: []string{"archive", "resend", "-mongo-uri", "mongodb://test"}, want: exitUsage},
+		{name: "resend ids and failed", args: []string{"archive", "resend", "-mongo-uri", "mongodb://test", "-failed", uuid.New().String()}, want: exitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ta := newTestApp("")
+			assert.Equal(t, tt.want, ta.run(context.Background(), tt.args))
+		})
+	}
+}
+
+func TestRun_send(t *testing.T) {
+	dir, err := ioutil.TempDir("", "postdog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	mailDir := filepath.Join(dir, "mails")
+	cfgPath := writeConfig(t, dir, fmt.Sprintf(`default: fs
+transports:
+  fs:
+    use: filesystem
+    config:
+      dir: %s
+`, mailDir))
+
+	ta := newTestApp("Hello from stdin.")
+	code := ta.run(context.Background(), []string{
+		"send",
+		"-config", cfgPath,
+		"-from", "Bob Belcher <bob@example.com>",
+		"-to", "Linda Belcher <linda@example.com>, tina@example.com",
+		"-subject", "Hi.",
+	})
+	assert.Equal(t, exitOK, code, ta.stderr.String())
+
+	files, err := ioutil.ReadDir(mailDir)
+	assert.Nil(t, err)
+	assert.Len(t, files, 1)
+
+	b, err := ioutil.ReadFile(filepath.Join(mailDir, files[0].Name()))
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), "To: \"Linda Belcher\" <linda@example.com>,<tina@example.com>")
+	assert.Contains(t, string(b), base64.StdEncoding.EncodeToString([]byte("Hello from stdin.")))
+}
+
+func TestRun_send_failure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "postdog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	args := []string{"send", "-from", "bob@example.com", "-to", "linda@example.com", "-text", "Hello."}
+
+	ta := newTestApp("")
+	assert.Equal(t, exitFailure, ta.run(context.Background(), append(args, "-config", filepath.Join(dir, "missing.yml"))))
+	assert.Contains(t, ta.stderr.String(), "load config")
+
+	cfgPath := writeConfig(t, dir, "transports:\n  test:\n    use: nop\n")
+	ta = newTestApp("")
+	assert.Equal(t, exitFailure, ta.run(context.Background(), append(args, "-config", cfgPath, "-transport", "foo")))
+	assert.Contains(t, ta.stderr.String(), "send mail")
+}
+
+func TestRun_archive(t *testing.T) {
+	ta := newTestApp("")
+	now := time.Now()
+
+	sent := archive.ExpandMail(letter.Write(
+		letter.From("Bob Belcher", "bob@example.com"),
+		letter.To("Linda Belcher", "linda@example.com"),
+		letter.Subject("Sent"),
+		letter.Text("Hello."),
+	)).WithID(uuid.New()).WithSendTime(now.Add(-time.Hour))
+
+	failed := archive.ExpandMail(letter.Write(
+		letter.From("Bob Belcher", "bob@example.com"),
+		letter.To("Tina Belcher", "tina@example.com"),
+		letter.Subject("Failed"),
+		letter.Text("Hello."),
+		letter.Attach("attach.txt", []byte("attachment"), letter.AttachmentType("text/plain")),
+	)).WithID(uuid.New()).WithSendTime(now.Add(-time.Minute)).WithSendError("connection refused")
+	// freeze the RFC body, like the mongo store does
+	failed.Letter = failed.Letter.WithRFC(failed.RFC())
+
+	ctx := context.Background()
+	assert.Nil(t, ta.store.Insert(ctx, sent))
+	assert.Nil(t, ta.store.Insert(ctx, failed))
+
+	run := func(args ...string) (int, string) {
+		ta.stdout.Reset()
+		ta.stderr.Reset()
+		code := ta.run(ctx, append([]string{"archive", args[0], "-mongo-uri", "mongodb://test"}, args[1:]...))
+		return code, ta.stdout.String()
+	}
+
+	t.Run("list", func(t *testing.T) {
+		code, out := run("list")
+		assert.Equal(t, exitOK, code)
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		assert.Len(t, lines, 3)
+		assert.Contains(t, lines[1], failed.ID().String())
+		assert.Contains(t, lines[1], "failed: connection refused")
+		assert.Contains(t, lines[2], sent.ID().String())
+
+		code, out = run("list", "-failed")
+		assert.Equal(t, exitOK, code)
+		assert.Contains(t, out, failed.ID().String())
+		assert.NotContains(t, out, sent.ID().String())
+	})
+
+	t.Run("show", func(t *testing.T) {
+		code, out := run("show", failed.ID().String())
+		assert.Equal(t, exitOK, code)
+		assert.Regexp(t, `Subject:\s+Failed\n`, out)
+		assert.Regexp(t, `Status:\s+failed: connection refused\n`, out)
+		assert.Contains(t, out, "Attachment: attach.txt (text/plain, 10 bytes)")
+		assert.Contains(t, out, "Hello.")
+
+		code, out = run("show", "-rfc", failed.ID().String())
+		assert.Equal(t, exitOK, code)
+		assert.Equal(t, failed.RFC(), out)
+
+		code, _ = run("show", uuid.New().String())
+		assert.Equal(t, exitFailure, code)
+	})
+
+	t.Run("resend", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "postdog")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		cfgPath := writeConfig(t, dir, "default: test\ntransports:\n  test:\n    use: nop\n")
+
+		code, out := run("resend", "-config", cfgPath, "-failed")
+		assert.Equal(t, exitOK, code, ta.stderr.String())
+		assert.Equal(t, failed.ID().String()+": resent\n", out)
+
+		cur, err := ta.store.Query(ctx, query.New())
+		assert.Nil(t, err)
+		mails, err := cur.All(ctx)
+		assert.Nil(t, err)
+		assert.Len(t, mails, 2)
+
+		// the resend replaced the archived failure
+		resent, err := ta.store.Find(ctx, failed.ID())
+		assert.Nil(t, err)
+		assert.Equal(t, failed.MessageID(), resent.MessageID())
+		assert.Equal(t, "", resent.SendError())
+		assert.True(t, resent.SentAt().After(failed.SentAt()))
+		assert.Len(t, resent.Attachments(), 1)
+
+		// the failure has been resolved by the resent mail
+		code, out = run("resend", "-config", cfgPath, "-failed")
+		assert.Equal(t, exitOK, code)
+		assert.Equal(t, "", out)
+
+		code, out = run("resend", "-config", cfgPath, sent.ID().String())
+		assert.Equal(t, exitOK, code)
+		assert.Equal(t, sent.ID().String()+": resent\n", out)
+	})
+}
+
+type failingInsertStore struct {
+	*memory.Store
+}
+
+func (s failingInsertStore) Insert(context.Context, archive.Mail) error {
+	return errors.New("duplicate key")
+}
+
+func TestRun_archive_resendInsertError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "postdog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cfgPath := writeConfig(t, dir, "default: test\ntransports:\n  test:\n    use: nop\n")
+
+	ctx := context.Background()
+	ta := newTestApp("")
+	failed := archive.ExpandMail(letter.Write(
+		letter.From("Bob Belcher", "bob@example.com"),
+		letter.To("Tina Belcher", "tina@example.com"),
+		letter.Text("Hello."),
+	)).WithID(uuid.New()).WithSendTime(time.Now().Add(-time.Minute)).WithSendError("connection refused")
+	assert.Nil(t, ta.store.Insert(ctx, failed))
+
+	ta.openStore = func(context.Context, storeFlags) (archive.Store, func(context.Context) error, error) {
+		return failingInsertStore{ta.store}, func(context.Context) error { return nil }, nil
+	}
+
+	code := ta.run(ctx, []string{"archive", "resend", "-mongo-uri", "mongodb://test", "-config", cfgPath, "-failed"})
+	assert.Equal(t, exitFailure, code)
+	assert.Contains(t, ta.stderr.String(), "duplicate key")
+	assert.Equal(t, "", ta.stdout.String())
+}
+
+func writeConfig(t *testing.T, dir, cfg string) string {
+	path := filepath.Join(dir, "postdog.yml")
+	if err := ioutil.WriteFile(path, []byte(cfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/mail"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/config"
+	"github.com/bounoable/postdog/letter"
+	"github.com/bounoable/postdog/send"
+)
+
+// addressFlag is a repeatable flag for mail addresses. Every value may contain
+// a comma-separated list of addresses.
+type addressFlag []mail.Address
+
+// stringsFlag is a repeatable string flag.
+type stringsFlag []string
+
+// configFlags are the flags for commands that send mails.
+type configFlags struct {
+	path      string
+	transport string
+	timeout   time.Duration
+}
+
+func (f *addressFlag) String() string {
+	addrs := make([]string, len(*f))
+	for i, addr := range *f {
+		addrs[i] = addr.String()
+	}
+	return strings.Join(addrs, ", ")
+}
+
+func (f *addressFlag) Set(v string) error {
+	addrs, err := mail.ParseAddressList(v)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		*f = append(*f, *addr)
+	}
+	return nil
+}
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ", ")
+}
+
+func (f *stringsFlag) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
+
+func (f *configFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.path, "config", envDefault("POSTDOG_CONFIG", "postdog.yml"), "path to the postdog configuration file ($POSTDOG_CONFIG)")
+	fs.StringVar(&f.transport, "transport", "", "name of the transport to send through (default: the default transport of the configuration)")
+	fs.DurationVar(&f.timeout, "timeout", 30*time.Second, "send timeout per mail")
+}
+
+// dog builds the Dog of the configuration file.
+func (a *app) dog(ctx context.Context, f configFlags, opts ...postdog.Option) (*postdog.Dog, error) {
+	cfg, err := config.File(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("load config %s: %w", f.path, err)
+	}
+
+	dog, err := cfg.Dog(ctx, append(a.configOptions, config.WithOptions(opts...))...)
+	if err != nil {
+		return nil, fmt.Errorf("build postdog from config %s: %w", f.path, err)
+	}
+
+	return dog, nil
+}
+
+func (f configFlags) sendOptions() []send.Option {
+	opts := []send.Option{send.Timeout(f.timeout)}
+	if f.transport != "" {
+		opts = append(opts, send.Use(f.transport))
+	}
+	return opts
+}
+
+func (a *app) send(ctx context.Context, args []string) error {
+	var (
+		cfg                      configFlags
+		from                     string
+		to, cc, bcc, replyTo     addressFlag
+		subject, text, html, org string
+		attachments              stringsFlag
+	)
+
+	fs := a.flagSet("send", "\n\nThe text body is read from stdin if neither -text nor -html is set.")
+	cfg.register(fs)
+	fs.StringVar(&from, "from", "", "sender address, e.g. \"Bob <bob@example.com>\" (required)")
+	fs.Var(&to, "to", "recipient address (repeatable, comma-separated)")
+	fs.Var(&cc, "cc", "CC recipient address (repeatable, comma-separated)")
+	fs.Var(&bcc, "bcc", "BCC recipient address (repeatable, comma-separated)")
+	fs.Var(&replyTo, "reply-to", "Reply-To address (repeatable, comma-separated)")
+	fs.StringVar(&subject, "subject", "", "subject of the mail")
+	fs.StringVar(&text, "text", "", "text body of the mail")
+	fs.StringVar(&html, "html", "", "HTML body of the mail")
+	fs.StringVar(&org, "organization", "", "organization of the sender")
+	fs.Var(&attachments, "attach", "path of a file to attach (repeatable)")
+
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	if fs.NArg() > 0 {
+		return a.usageErrorf(fs, "unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	if from == "" {
+		return a.usageErrorf(fs, "-from is required")
+	}
+
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return a.usageErrorf(fs, "invalid -from address: %v", err)
+	}
+
+	if len(to)+len(cc)+len(bcc) == 0 {
+		return a.usageErrorf(fs, "at least one of -to, -cc or -bcc is required")
+	}
+
+	if text == "" && html == "" {
+		b, err := ioutil.ReadAll(a.stdin)
+		if err != nil {
+			return fmt.Errorf("read text body from stdin: %w", err)
+		}
+		text = string(b)
+	}
+
+	opts := []letter.Option{
+		letter.FromAddress(*sender),
+		letter.ToAddress(to...),
+		letter.CCAddress(cc...),
+		letter.BCCAddress(bcc...),
+		letter.ReplyToAddress(replyTo...),
+		letter.Subject(subject),
+		letter.Content(text, html),
+	}
+	if org != "" {
+		opts = append(opts, letter.Organization(org))
+	}
+	for _, path := range attachments {
+		opts = append(opts, letter.AttachFile(filepath.Base(path), path))
+	}
+
+	let, err := letter.TryWrite(opts...)
+	if err != nil {
+		return fmt.Errorf("write letter: %w", err)
+	}
+
+	dog, err := a.dog(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := dog.Send(ctx, let, cfg.sendOptions()...); err != nil {
+		return fmt.Errorf("send mail: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/bounoable/postdog/plugin/archive"
+	mongostore "github.com/bounoable/postdog/plugin/archive/mongo"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// storeFlags are the flags for commands that use the archive Store.
+type storeFlags struct {
+	uri        string
+	database   string
+	collection string
+}
+
+func (f *storeFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.uri, "mongo-uri", envDefault("POSTDOG_MONGO_URI", ""), "connection string of the mongo archive ($POSTDOG_MONGO_URI)")
+	fs.StringVar(&f.database, "mongo-db", envDefault("POSTDOG_MONGO_DB", "postdog"), "database of the mongo archive ($POSTDOG_MONGO_DB)")
+	fs.StringVar(&f.collection, "mongo-collection", envDefault("POSTDOG_MONGO_COLLECTION", "mails"), "collection of the mongo archive ($POSTDOG_MONGO_COLLECTION)")
+}
+
+func openMongoStore(ctx context.Context, f storeFlags) (archive.Store, func(context.Context) error, error) {
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(f.uri))
+	if err != nil {
+		return nil, nil, fmt.Errorf("connect to mongo: %w", err)
+	}
+
+	s, err := mongostore.NewStore(ctx, client, mongostore.Database(f.database), mongostore.Collection(f.collection))
+	if err != nil {
+		client.Disconnect(ctx)
+		return nil, nil, fmt.Errorf("open mongo store: %w", err)
+	}
+
+	return s, client.Disconnect, nil
+}
@@ -1,3 +1,19 @@
+// Package config parses postdog configuration files and instantiates a
+// *postdog.Dog from them.
+//
+// The transports of a configuration are created by TransportFactories, which
+// are looked up by the `use` value of a transport. TransportFactories can be
+// provided explicitly for a single (*Config).Dog() call:
+//
+//	dog, err := cfg.Dog(ctx, config.WithTransportFactory("smtp", config.TransportFactoryFunc(smtp.Factory)))
+//
+// or registered globally, once, with Register() (or MustRegister() from an
+// init() function), so that every (*Config).Dog() call can use them:
+//
+//	config.MustRegister("smtp", config.TransportFactoryFunc(smtp.Factory))
+//	dog, err := cfg.Dog(ctx)
+//
+// Registering the same `use` value twice fails with ErrDuplicateFactory.
 package config
 
 //go:generate mockgen -source=config.go -destination=./mocks/config.go
@@ -10,24 +26,52 @@ import (
 	"io/ioutil"
 	"os"
 	"regexp"
+	"time"
 
 	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter/rfc"
+	"github.com/bounoable/postdog/middleware"
+	"github.com/bounoable/postdog/queue"
+	"github.com/bounoable/postdog/transport"
 	"gopkg.in/yaml.v3"
 )
 
 var (
 	// ErrUnknownTransport means a TransportFactory is missing for a transport.
 	ErrUnknownTransport = errors.New("unknown transport")
+	// ErrMissingValue means a required configuration value is missing.
+	ErrMissingValue = errors.New("missing value")
 )
 
+// InvalidConfigError is returned by TransportFactories when a value of the
+// transport configuration is missing or invalid.
+type InvalidConfigError struct {
+	// Key is the configuration key of the invalid value.
+	Key string
+	// Err describes why the value is invalid.
+	Err error
+}
+
 // Config is the postdog configuration.
 type Config struct {
 	transports         map[string]Transport
 	transportFactories map[string]TransportFactory
+	registry           *Registry
 	defaultTransport   string
+	queue              QueueConfig
 	opts               []postdog.Option
 }
 
+// QueueConfig is the queue configuration.
+type QueueConfig struct {
+	// Buffer is the buffer size of the queue.
+	Buffer int `yaml:"buffer"`
+	// Workers is the number of queue workers. Defaults to 1.
+	Workers int `yaml:"workers"`
+	// AutoRun determines if the queue is started by (*Config).NewQueue().
+	AutoRun bool `yaml:"autoRun"`
+}
+
 // Option is an option for the (*Config).Dog() method.
 type Option func(*Config)
 
@@ -35,6 +79,12 @@ type Option func(*Config)
 type Transport struct {
 	Use    string                 `yaml:"use"`
 	Config map[string]interface{} `yaml:"config"`
+	// Timeout is the default send timeout of the transport, e.g. `10s`.
+	// See (*Config).Dog().
+	Timeout time.Duration `yaml:"timeout"`
+	// MessageIDDomain is the domain of the Message-IDs of the mails that are
+	// sent through the transport, e.g. `mail.example.com`. See (*Config).Dog().
+	MessageIDDomain string `yaml:"messageIdDomain"`
 }
 
 // A TransportFactory accepts the transport-specific configuration and instantiates a transport from that configuration.
@@ -48,6 +98,7 @@ type TransportFactoryFunc func(context.Context, map[string]interface{}) (postdog
 type rawConfig struct {
 	Default    string               `yaml:"default"`
 	Transports map[string]Transport `yaml:"transports"`
+	Queue      QueueConfig          `yaml:"queue"`
 }
 
 // File parses the configuration file at path into a Config.
@@ -96,9 +147,15 @@ func (cfg *Config) Parse(raw []byte) error {
 	rawCfg.replaceVars()
 	cfg.transports = rawCfg.Transports
 	cfg.defaultTransport = rawCfg.Default
+	cfg.queue = rawCfg.Queue
 	return nil
 }
 
+// Queue returns the queue configuration.
+func (cfg *Config) Queue() QueueConfig {
+	return cfg.queue
+}
+
 // Transport returns the transport configuration for the given name,
 // or ok=false if the config doesn't have a transport with that name.
 func (cfg *Config) Transport(name string) (tr Transport, ok bool) {
@@ -112,17 +169,34 @@ func (cfg *Config) Transport(name string) (tr Transport, ok bool) {
 // Dog instantiates the *postdog.Dog from the parsed configuration.
 //
 // For every distinct `transport.use` config value a TransportFactory must be
-// provided. It will return ErrUnknownTransport if a TransportFactory is missing.
+// provided, either with the WithTransportFactory() option or by registering it
+// in the Registry (see Register() and WithRegistry()). TransportFactories that
+// are provided as options take precedence over registered ones. It will return
+// ErrUnknownTransport if a TransportFactory is missing.
+//
+// Transports with a configured `timeout` are wrapped by transport.WithTimeout(),
+// so that every send through them is canceled after the timeout. A shorter
+// timeout that is passed to a single send via send.Timeout() still takes
+// precedence.
+//
+// Transports with a configured `messageIdDomain` get a transport middleware
+// (see postdog.WithTransportMiddleware()) that generates the Message-IDs of
+// the mails that are sent through them with that domain, e.g. to stamp
+// brand-specific Message-IDs when each transport sends for another brand.
 func (cfg *Config) Dog(ctx context.Context, opts ...Option) (*postdog.Dog, error) {
 	var dogOpts []postdog.Option
 
 	cfg.transportFactories = make(map[string]TransportFactory)
+	cfg.registry = defaultRegistry
 	for _, opt := range opts {
 		opt(cfg)
 	}
 
 	for name, transportConfig := range cfg.transports {
 		factory, ok := cfg.transportFactories[transportConfig.Use]
+		if !ok {
+			factory, ok = cfg.registry.Factory(transportConfig.Use)
+		}
 		if !ok {
 			return nil, ErrUnknownTransport
 		}
@@ -134,7 +208,16 @@ func (cfg *Config) Dog(ctx context.Context, opts ...Option) (*postdog.Dog, error
 		if err != nil {
 			return nil, fmt.Errorf("make transport %s: %w", name, err)
 		}
+		if transportConfig.Timeout > 0 {
+			tr = transport.WithTimeout(tr, transportConfig.Timeout)
+		}
 		dogOpts = append(dogOpts, postdog.WithTransport(name, tr))
+		if transportConfig.MessageIDDomain != "" {
+			dogOpts = append(dogOpts, postdog.WithTransportMiddleware(
+				name,
+				middleware.MessageID(rfc.UUIDGenerator(transportConfig.MessageIDDomain)),
+			))
+		}
 	}
 
 	dogOpts = append(dogOpts, cfg.opts...)
@@ -147,15 +230,63 @@ func (cfg *Config) Dog(ctx context.Context, opts ...Option) (*postdog.Dog, error
 	return dog, nil
 }
 
+// Options returns the queue.Options for the queue configuration.
+func (cfg QueueConfig) Options() []queue.Option {
+	var opts []queue.Option
+	if cfg.Buffer > 0 {
+		opts = append(opts, queue.Buffer(cfg.Buffer))
+	}
+	if cfg.Workers > 0 {
+		opts = append(opts, queue.Workers(cfg.Workers))
+	}
+	return opts
+}
+
+// NewQueue returns a *queue.Queue that sends mails through m and is
+// configured by the queue configuration. Additional opts are applied after
+// the configured options.
+//
+// If the queue configuration has AutoRun enabled, the queue is started before
+// it is returned, so dispatched mails are sent without calling q.Start(). When
+// ctx is canceled, the queue is stopped after the already dispatched mails
+// have been processed. Otherwise the caller is responsible for starting and
+// stopping the queue.
+func (cfg *Config) NewQueue(ctx context.Context, m queue.Mailer, opts ...queue.Option) (*queue.Queue, error) {
+	q := queue.New(m, append(cfg.queue.Options(), opts...)...)
+	if !cfg.queue.AutoRun {
+		return q, nil
+	}
+
+	if err := q.Start(); err != nil {
+		return nil, fmt.Errorf("start queue: %w", err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		q.Stop(context.Background())
+	}()
+
+	return q, nil
+}
+
 // Transport accepts the transport-specific configuration and instantiates a transport from that configuration.
 func (fn TransportFactoryFunc) Transport(ctx context.Context, m map[string]interface{}) (postdog.Transport, error) {
 	return fn(ctx, m)
 }
 
+func (err *InvalidConfigError) Error() string {
+	return fmt.Sprintf("invalid config %q: %s", err.Key, err.Err)
+}
+
+func (err *InvalidConfigError) Unwrap() error {
+	return err.Err
+}
+
 func (cfg *rawConfig) replaceVars() {
 	cfg.Default = replaceEnvVars(cfg.Default)
 	for name, trans := range cfg.Transports {
 		trans.Use = replaceEnvVars(trans.Use)
+		trans.MessageIDDomain = replaceEnvVars(trans.MessageIDDomain)
 		replaceMapEnvVars(trans.Config)
 		cfg.Transports[name] = trans
 	}
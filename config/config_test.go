@@ -4,13 +4,20 @@ import (
 	"context"
 	"errors"
 	"io/ioutil"
+	"net/mail"
 	"os"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/bounoable/postdog"
 	"github.com/bounoable/postdog/config"
 	mock_config "github.com/bounoable/postdog/config/mocks"
+	"github.com/bounoable/postdog/letter"
 	mock_postdog "github.com/bounoable/postdog/mocks"
+	"github.com/bounoable/postdog/queue"
+	mock_queue "github.com/bounoable/postdog/queue/mocks"
+	"github.com/bounoable/postdog/send"
 	"github.com/golang/mock/gomock"
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -93,6 +100,91 @@ func TestConfig(t *testing.T) {
 			})
 		})
 
+		Convey("NewQueue()", func() {
+			Convey("Given a configuration with a queue configuration", WithParsedConfig("./testdata/with_queue.yml", func(cfg *config.Config) {
+				Convey("The parsed config should include the queue config", func() {
+					So(cfg.Queue(), ShouldResemble, config.QueueConfig{
+						Buffer:  10,
+						Workers: 4,
+					})
+				})
+
+				Convey("When I create a queue", func() {
+					q, err := cfg.NewQueue(context.Background(), mock_queue.NewMockMailer(ctrl))
+
+					Convey("It shouldn't fail", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("The queue should not be started", func() {
+						So(q.Started(), ShouldBeFalse)
+					})
+
+					Convey("The queue should use the configured buffer size and worker count", func() {
+						So(q.Buffer(), ShouldEqual, 10)
+						So(q.Workers(), ShouldEqual, 4)
+					})
+				})
+
+				Convey("When I create a queue with additional options", func() {
+					q, _ := cfg.NewQueue(context.Background(), mock_queue.NewMockMailer(ctrl), queue.Workers(2))
+
+					Convey("The additional options should override the configuration", func() {
+						So(q.Buffer(), ShouldEqual, 10)
+						So(q.Workers(), ShouldEqual, 2)
+					})
+				})
+			}))
+
+			Convey("Given a configuration with an auto-run queue", WithParsedConfig("./testdata/with_queue_autorun.yml", func(cfg *config.Config) {
+				Convey("When I create a queue", func() {
+					ctx, cancel := context.WithCancel(context.Background())
+					Reset(cancel)
+
+					mailer := mock_queue.NewMockMailer(ctrl)
+					q, err := cfg.NewQueue(ctx, mailer)
+
+					Convey("It shouldn't fail", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("The queue should be started", func() {
+						So(q.Started(), ShouldBeTrue)
+					})
+
+					Convey("Dispatched mails should be sent", func() {
+						mailer.EXPECT().SendConfig(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+						job, err := q.Dispatch(context.Background(), mockMail{})
+						So(err, ShouldBeNil)
+
+						<-job.Done()
+						So(job.Err(), ShouldBeNil)
+					})
+
+					Convey("When I cancel the context", func() {
+						cancel()
+
+						Convey("The queue should be stopped", func() {
+							<-time.After(20 * time.Millisecond)
+							So(q.Started(), ShouldBeFalse)
+						})
+					})
+				})
+			}))
+
+			Convey("Given a configuration without a queue configuration", WithParsedConfig("./testdata/single.yml", func(cfg *config.Config) {
+				Convey("When I create a queue", func() {
+					q, _ := cfg.NewQueue(context.Background(), mock_queue.NewMockMailer(ctrl))
+
+					Convey("The queue should use the default buffer size and worker count", func() {
+						So(q.Buffer(), ShouldEqual, 0)
+						So(q.Workers(), ShouldEqual, 1)
+					})
+				})
+			}))
+		})
+
 		Convey("Dog()", func() {
 			Convey("Given a parsed single-transport configuration", WithParsedConfig("./testdata/single.yml", func(cfg *config.Config) {
 				Convey("When I instantiate *postdog.Dog without providing a config.TransportFactory", func() {
@@ -180,6 +272,109 @@ func TestConfig(t *testing.T) {
 				})
 			}))
 
+			Convey("Given a configuration with transport timeouts", WithParsedConfig("./testdata/with_timeouts.yml", func(cfg *config.Config) {
+				Convey("The parsed config should include the timeouts", func() {
+					trcfg, _ := cfg.Transport("test1")
+					So(trcfg.Timeout, ShouldEqual, 10*time.Second)
+
+					trcfg, _ = cfg.Transport("test2")
+					So(trcfg.Timeout, ShouldEqual, 500*time.Millisecond)
+
+					trcfg, _ = cfg.Transport("test3")
+					So(trcfg.Timeout, ShouldEqual, 0)
+				})
+
+				Convey("When I instantiate *postdog.Dog", func() {
+					deadlines := make(map[string]time.Duration)
+					factory := mock_config.NewMockTransportFactory(ctrl)
+					factory.EXPECT().
+						Transport(gomock.Any(), gomock.Any()).
+						DoAndReturn(func(context.Context, map[string]interface{}) (postdog.Transport, error) {
+							tr := mock_postdog.NewMockTransport(ctrl)
+							tr.EXPECT().
+								Send(gomock.Any(), gomock.Any()).
+								DoAndReturn(func(ctx context.Context, _ postdog.Mail) error {
+									var timeout time.Duration
+									if deadline, ok := ctx.Deadline(); ok {
+										timeout = time.Until(deadline)
+									}
+									deadlines[ctx.Value(ctxKey("transport")).(string)] = timeout
+									return nil
+								}).
+								AnyTimes()
+							return tr, nil
+						}).
+						Times(3)
+
+					dog, err := cfg.Dog(context.Background(), config.WithTransportFactory("trans1", factory))
+
+					Convey("It shouldn't fail", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("Sends should time out after the configured timeout", func() {
+						for _, name := range []string{"test1", "test2", "test3"} {
+							ctx := context.WithValue(context.Background(), ctxKey("transport"), name)
+							So(dog.Send(ctx, mockMail{}, send.Use(name)), ShouldBeNil)
+						}
+
+						So(deadlines["test1"], ShouldBeBetween, 9*time.Second, 10*time.Second)
+						So(deadlines["test2"], ShouldBeBetween, 400*time.Millisecond, 500*time.Millisecond)
+						So(deadlines["test3"], ShouldEqual, 0)
+					})
+
+					Convey("A shorter send timeout should override the configured timeout", func() {
+						ctx := context.WithValue(context.Background(), ctxKey("transport"), "test1")
+						So(dog.Send(ctx, mockMail{}, send.Use("test1"), send.Timeout(time.Second)), ShouldBeNil)
+						So(deadlines["test1"], ShouldBeBetween, 900*time.Millisecond, time.Second)
+					})
+				})
+			}))
+
+			Convey("Given a configuration with transport-specific Message-ID domains", WithParsedConfig("./testdata/with_message_id_domains.yml", func(cfg *config.Config) {
+				Convey("The parsed config should include the domains", func() {
+					trcfg, _ := cfg.Transport("bobs")
+					So(trcfg.MessageIDDomain, ShouldEqual, "mail.bobsburgers.com")
+
+					trcfg, _ = cfg.Transport("plain")
+					So(trcfg.MessageIDDomain, ShouldBeEmpty)
+				})
+
+				Convey("When I instantiate *postdog.Dog", func() {
+					rfcs := make(map[string]string)
+					factory := mock_config.NewMockTransportFactory(ctrl)
+					factory.EXPECT().
+						Transport(gomock.Any(), gomock.Any()).
+						DoAndReturn(func(context.Context, map[string]interface{}) (postdog.Transport, error) {
+							tr := mock_postdog.NewMockTransport(ctrl)
+							tr.EXPECT().
+								Send(gomock.Any(), gomock.Any()).
+								DoAndReturn(func(ctx context.Context, m postdog.Mail) error {
+									rfcs[postdog.SentVia(ctx)] = m.RFC()
+									return nil
+								}).
+								AnyTimes()
+							return tr, nil
+						}).
+						Times(3)
+
+					dog, err := cfg.Dog(context.Background(), config.WithTransportFactory("trans1", factory))
+					So(err, ShouldBeNil)
+
+					Convey("The Message-IDs should use the domain of the transport", func() {
+						l := letter.Write(letter.From("Bob Belcher", "bob@example.com"), letter.Text("Hello."))
+						for _, name := range []string{"bobs", "pestos", "plain"} {
+							So(dog.Send(context.Background(), l, send.Use(name)), ShouldBeNil)
+						}
+
+						So(rfcs["bobs"], ShouldContainSubstring, "@mail.bobsburgers.com>\r\n")
+						So(rfcs["pestos"], ShouldContainSubstring, "@mail.pestos.com>\r\n")
+						So(rfcs["plain"], ShouldNotContainSubstring, "@mail.bobsburgers.com>")
+						So(rfcs["plain"], ShouldNotContainSubstring, "@mail.pestos.com>")
+					})
+				})
+			}))
+
 			Convey("Given a configuration with a default transport", WithParsedConfig("./testdata/with_default.yml", func(cfg *config.Config) {
 				Convey("When I instantiate postdog.Dog and provide the config.TransportFactories", func() {
 					factory1 := mock_config.NewMockTransportFactory(ctrl)
@@ -274,6 +469,22 @@ func TestConfig(t *testing.T) {
 	})
 }
 
+type ctxKey string
+
+type mockMail struct{}
+
+func (mockMail) From() mail.Address {
+	return mail.Address{}
+}
+
+func (mockMail) Recipients() []mail.Address {
+	return nil
+}
+
+func (mockMail) RFC() string {
+	return ""
+}
+
 func WithParsedConfig(path string, fn func(*config.Config)) func() {
 	return func() {
 		var cfg config.Config
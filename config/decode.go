@@ -0,0 +1,247 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidType means a configuration value has a type that cannot be
+	// decoded into the type of the destination field.
+	ErrInvalidType = errors.New("invalid type")
+
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// Decode decodes the transport configuration m into dst, which must be a
+// pointer to a struct. It allows TransportFactories to decode their
+// configuration into a typed struct instead of type-asserting every value.
+//
+// The configuration key of a struct field is specified by the `config` struct
+// tag. Fields without a tag use their field name as the key. Keys are matched
+// case-insensitively. Fields with the tag `config:"-"` are ignored, as are keys
+// in m that have no corresponding field.
+//
+// Strings are converted into numbers, booleans and time.Durations, so that
+// values can be provided through `${VAR}` placeholders. Empty strings leave
+// non-string fields untouched, so that fields can be pre-filled with defaults.
+// Nested maps are decoded into nested structs or maps, lists into slices.
+//
+// If a value cannot be decoded, Decode returns an *InvalidConfigError whose
+// Key is the dot-separated path to the value.
+func Decode(m map[string]interface{}, dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("decode: destination must be a non-nil pointer to a struct, got %T", dst)
+	}
+	return decodeStruct("", m, v.Elem())
+}
+
+func decodeStruct(path string, m map[string]interface{}, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		key := field.Tag.Get("config")
+		if key == "-" {
+			continue
+		}
+		if key == "" {
+			key = field.Name
+		}
+
+		raw, ok := lookup(m, key)
+		if !ok || raw == nil || (raw == "" && field.Type.Kind() != reflect.String) {
+			continue
+		}
+
+		if err := decodeValue(joinPath(path, key), raw, v.Field(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func lookup(m map[string]interface{}, key string) (interface{}, bool) {
+	if v, ok := m[key]; ok {
+		return v, true
+	}
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func decodeValue(path string, raw interface{}, v reflect.Value) error {
+	if v.Type() == durationType {
+		d, err := toDuration(raw)
+		if err != nil {
+			return &InvalidConfigError{Key: path, Err: err}
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		switch rv := raw.(type) {
+		case string:
+			v.SetString(rv)
+		case int, int64, float64, bool:
+			v.SetString(fmt.Sprint(rv))
+		default:
+			return invalidType(path, raw, v.Type())
+		}
+
+	case reflect.Bool:
+		switch rv := raw.(type) {
+		case bool:
+			v.SetBool(rv)
+		case string:
+			b, err := strconv.ParseBool(rv)
+			if err != nil {
+				return &InvalidConfigError{Key: path, Err: err}
+			}
+			v.SetBool(b)
+		default:
+			return invalidType(path, raw, v.Type())
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := toInt(raw)
+		if err != nil {
+			return &InvalidConfigError{Key: path, Err: err}
+		}
+		if v.OverflowInt(i) {
+			return &InvalidConfigError{Key: path, Err: fmt.Errorf("%d overflows %s", i, v.Type())}
+		}
+		v.SetInt(i)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := toInt(raw)
+		if err != nil {
+			return &InvalidConfigError{Key: path, Err: err}
+		}
+		if i < 0 || v.OverflowUint(uint64(i)) {
+			return &InvalidConfigError{Key: path, Err: fmt.Errorf("%d overflows %s", i, v.Type())}
+		}
+		v.SetUint(uint64(i))
+
+	case reflect.Float32, reflect.Float64:
+		switch rv := raw.(type) {
+		case float64:
+			v.SetFloat(rv)
+		case int:
+			v.SetFloat(float64(rv))
+		case string:
+			f, err := strconv.ParseFloat(rv, 64)
+			if err != nil {
+				return &InvalidConfigError{Key: path, Err: err}
+			}
+			v.SetFloat(f)
+		default:
+			return invalidType(path, raw, v.Type())
+		}
+
+	case reflect.Slice:
+		rv := reflect.ValueOf(raw)
+		if rv.Kind() != reflect.Slice {
+			return invalidType(path, raw, v.Type())
+		}
+		s := reflect.MakeSlice(v.Type(), rv.Len(), rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			if err := decodeValue(fmt.Sprintf("%s.%d", path, i), rv.Index(i).Interface(), s.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+
+	case reflect.Map:
+		rm, ok := raw.(map[string]interface{})
+		if !ok || v.Type().Key().Kind() != reflect.String {
+			return invalidType(path, raw, v.Type())
+		}
+		m := reflect.MakeMapWithSize(v.Type(), len(rm))
+		for k, val := range rm {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := decodeValue(joinPath(path, k), val, elem); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(k).Convert(v.Type().Key()), elem)
+		}
+		v.Set(m)
+
+	case reflect.Struct:
+		rm, ok := raw.(map[string]interface{})
+		if !ok {
+			return invalidType(path, raw, v.Type())
+		}
+		return decodeStruct(path, rm, v)
+
+	case reflect.Interface:
+		rv := reflect.ValueOf(raw)
+		if !rv.Type().AssignableTo(v.Type()) {
+			return invalidType(path, raw, v.Type())
+		}
+		v.Set(rv)
+
+	default:
+		return invalidType(path, raw, v.Type())
+	}
+
+	return nil
+}
+
+func toInt(raw interface{}) (int64, error) {
+	switch rv := raw.(type) {
+	case int:
+		return int64(rv), nil
+	case int64:
+		return rv, nil
+	case float64:
+		if rv != float64(int64(rv)) {
+			return 0, fmt.Errorf("%w: %v is not an integer", ErrInvalidType, rv)
+		}
+		return int64(rv), nil
+	case string:
+		return strconv.ParseInt(rv, 10, 64)
+	default:
+		return 0, fmt.Errorf("%w: cannot decode %T into an integer", ErrInvalidType, raw)
+	}
+}
+
+func toDuration(raw interface{}) (time.Duration, error) {
+	switch rv := raw.(type) {
+	case string:
+		return time.ParseDuration(rv)
+	case int:
+		return time.Duration(rv), nil
+	case int64:
+		return time.Duration(rv), nil
+	default:
+		return 0, fmt.Errorf("%w: cannot decode %T into a duration", ErrInvalidType, raw)
+	}
+}
+
+func invalidType(path string, raw interface{}, t reflect.Type) error {
+	return &InvalidConfigError{
+		Key: path,
+		Err: fmt.Errorf("%w: cannot decode %T into %s", ErrInvalidType, raw, t),
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
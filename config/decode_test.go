@@ -0,0 +1,131 @@
+package config_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bounoable/postdog/config"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type decodeTarget struct {
+	Host    string        `config:"host"`
+	Port    int           `config:"port"`
+	Secure  bool          `config:"secure"`
+	Timeout time.Duration `config:"timeout"`
+	Scopes  []string      `config:"scopes"`
+	Auth    struct {
+		Username string `config:"username"`
+		Password string `config:"password"`
+	} `config:"auth"`
+	Labels  map[string]string
+	Ignored string `config:"-"`
+}
+
+func TestDecode(t *testing.T) {
+	Convey("Decode()", t, func() {
+		Convey("Given a configuration map", func() {
+			m := map[string]interface{}{
+				"host":    "smtp.example.com",
+				"port":    587,
+				"secure":  true,
+				"timeout": "3s",
+				"scopes":  []interface{}{"scope-a", "scope-b"},
+				"auth": map[string]interface{}{
+					"username": "bob",
+					"password": "secret",
+				},
+				"labels": map[string]interface{}{
+					"env": "test",
+				},
+				"ignored": "value",
+			}
+
+			Convey("When I decode it into a struct", func() {
+				var dst decodeTarget
+				err := config.Decode(m, &dst)
+
+				Convey("It shouldn't fail", func() {
+					So(err, ShouldBeNil)
+				})
+
+				Convey("The struct should be filled", func() {
+					So(dst.Host, ShouldEqual, "smtp.example.com")
+					So(dst.Port, ShouldEqual, 587)
+					So(dst.Secure, ShouldBeTrue)
+					So(dst.Timeout, ShouldEqual, 3*time.Second)
+					So(dst.Scopes, ShouldResemble, []string{"scope-a", "scope-b"})
+					So(dst.Auth.Username, ShouldEqual, "bob")
+					So(dst.Auth.Password, ShouldEqual, "secret")
+					So(dst.Labels, ShouldResemble, map[string]string{"env": "test"})
+					So(dst.Ignored, ShouldBeEmpty)
+				})
+			})
+		})
+
+		Convey("Given a configuration map with string values", func() {
+			m := map[string]interface{}{
+				"port":   "2525",
+				"secure": "false",
+			}
+
+			Convey("When I decode it into a struct", func() {
+				dst := decodeTarget{Secure: true}
+				err := config.Decode(m, &dst)
+
+				Convey("The strings should be converted", func() {
+					So(err, ShouldBeNil)
+					So(dst.Port, ShouldEqual, 2525)
+					So(dst.Secure, ShouldBeFalse)
+				})
+			})
+		})
+
+		Convey("Given a configuration map with empty string values", func() {
+			m := map[string]interface{}{
+				"port":    "",
+				"timeout": "",
+			}
+
+			Convey("When I decode it into a struct with defaults", func() {
+				dst := decodeTarget{Port: 587, Timeout: time.Second}
+				err := config.Decode(m, &dst)
+
+				Convey("The defaults should be kept", func() {
+					So(err, ShouldBeNil)
+					So(dst.Port, ShouldEqual, 587)
+					So(dst.Timeout, ShouldEqual, time.Second)
+				})
+			})
+		})
+
+		Convey("Given a configuration map with a type mismatch", func() {
+			m := map[string]interface{}{
+				"auth": map[string]interface{}{
+					"username": []interface{}{"bob"},
+				},
+			}
+
+			Convey("When I decode it into a struct", func() {
+				var dst decodeTarget
+				err := config.Decode(m, &dst)
+
+				Convey("It should fail with an *InvalidConfigError", func() {
+					var cfgErr *config.InvalidConfigError
+					So(errors.As(err, &cfgErr), ShouldBeTrue)
+					So(cfgErr.Key, ShouldEqual, "auth.username")
+					So(errors.Is(err, config.ErrInvalidType), ShouldBeTrue)
+				})
+			})
+		})
+
+		Convey("When I decode into a non-pointer", func() {
+			err := config.Decode(map[string]interface{}{}, decodeTarget{})
+
+			Convey("It should fail", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
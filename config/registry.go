@@ -0,0 +1,96 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	// ErrDuplicateFactory means a TransportFactory has already been registered
+	// for a `transport.use` value.
+	ErrDuplicateFactory = errors.New("duplicate transport factory")
+
+	defaultRegistry = NewRegistry()
+)
+
+// A Registry holds TransportFactories by their `transport.use` value. A
+// Registry is safe for concurrent use.
+//
+// Pass a Registry to (*Config).Dog() with the WithRegistry() option, or use
+// the package-level Register() function to register TransportFactories in the
+// default Registry, which is used by (*Config).Dog() if no other Registry is
+// provided.
+type Registry struct {
+	mux       sync.RWMutex
+	factories map[string]TransportFactory
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{factories: make(map[string]TransportFactory)}
+}
+
+// Register registers factory for the `transport.use` value use. It returns
+// an error that unwraps to ErrDuplicateFactory if a TransportFactory has
+// already been registered for use.
+func (r *Registry) Register(use string, factory TransportFactory) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if _, ok := r.factories[use]; ok {
+		return fmt.Errorf("register %q: %w", use, ErrDuplicateFactory)
+	}
+	r.factories[use] = factory
+	return nil
+}
+
+// Factory returns the TransportFactory that has been registered for use, or
+// ok=false if there's no such TransportFactory.
+func (r *Registry) Factory(use string) (factory TransportFactory, ok bool) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	factory, ok = r.factories[use]
+	return
+}
+
+// Register registers factory for the `transport.use` value use in the default
+// Registry. It returns an error that unwraps to ErrDuplicateFactory if a
+// TransportFactory has already been registered for use.
+//
+// Register is an explicit alternative to passing WithTransportFactory() to
+// every (*Config).Dog() call:
+//
+//	func main() {
+//		if err := config.Register("smtp", config.TransportFactoryFunc(smtp.Factory)); err != nil {
+//			log.Fatal(err)
+//		}
+//		cfg, err := config.File("postdog.yml")
+//		// handle err
+//		dog, err := cfg.Dog(context.Background())
+//	}
+func Register(use string, factory TransportFactory) error {
+	return defaultRegistry.Register(use, factory)
+}
+
+// MustRegister does the same as Register(), but panics if the registration
+// fails. It can be used to register TransportFactories from the init()
+// function of a provider package, so that importing the package is enough
+// to make its transport available:
+//
+//	func init() {
+//		config.MustRegister("smtp", config.TransportFactoryFunc(Factory))
+//	}
+func MustRegister(use string, factory TransportFactory) {
+	if err := Register(use, factory); err != nil {
+		panic(err)
+	}
+}
+
+// WithRegistry returns an Option that makes (*Config).Dog() look up
+// TransportFactories in r instead of the default Registry. TransportFactories
+// that are provided with WithTransportFactory() still take precedence.
+func WithRegistry(r *Registry) Option {
+	return func(cfg *Config) {
+		cfg.registry = r
+	}
+}
@@ -0,0 +1,116 @@
+package config_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bounoable/postdog/config"
+	mock_config "github.com/bounoable/postdog/config/mocks"
+	mock_postdog "github.com/bounoable/postdog/mocks"
+	"github.com/golang/mock/gomock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRegistry(t *testing.T) {
+	Convey("Registry", t, func() {
+		ctrl := gomock.NewController(t)
+		Reset(ctrl.Finish)
+
+		Convey("Given an empty Registry", func() {
+			reg := config.NewRegistry()
+
+			Convey("When I register a config.TransportFactory", func() {
+				factory := mock_config.NewMockTransportFactory(ctrl)
+				err := reg.Register("trans1", factory)
+
+				Convey("It shouldn't fail", func() {
+					So(err, ShouldBeNil)
+				})
+
+				Convey("The factory should be registered", func() {
+					f, ok := reg.Factory("trans1")
+					So(ok, ShouldBeTrue)
+					So(f, ShouldEqual, factory)
+				})
+
+				Convey("When I register another config.TransportFactory for the same name", func() {
+					err := reg.Register("trans1", mock_config.NewMockTransportFactory(ctrl))
+
+					Convey("It should fail with ErrDuplicateFactory", func() {
+						So(errors.Is(err, config.ErrDuplicateFactory), ShouldBeTrue)
+					})
+
+					Convey("The first factory should still be registered", func() {
+						f, _ := reg.Factory("trans1")
+						So(f, ShouldEqual, factory)
+					})
+				})
+			})
+		})
+
+		Convey("Given a parsed single-transport configuration", WithParsedConfig("./testdata/single.yml", func(cfg *config.Config) {
+			Convey("When I instantiate *postdog.Dog with a Registry", func() {
+				reg := config.NewRegistry()
+				factory := mock_config.NewMockTransportFactory(ctrl)
+				mockTransport := mock_postdog.NewMockTransport(ctrl)
+				factory.EXPECT().Transport(gomock.Any(), gomock.Any()).Return(mockTransport, nil)
+				So(reg.Register("trans1", factory), ShouldBeNil)
+
+				dog, err := cfg.Dog(context.Background(), config.WithRegistry(reg))
+
+				Convey("It shouldn't fail", func() {
+					So(err, ShouldBeNil)
+				})
+
+				Convey("dog should use the registered factory", func() {
+					tr, err := dog.Transport("test")
+					So(err, ShouldBeNil)
+					So(tr, ShouldEqual, mockTransport)
+				})
+			})
+
+			Convey("When I instantiate *postdog.Dog with a Registry and a config.TransportFactory option", func() {
+				reg := config.NewRegistry()
+				So(reg.Register("trans1", mock_config.NewMockTransportFactory(ctrl)), ShouldBeNil)
+
+				factory := mock_config.NewMockTransportFactory(ctrl)
+				mockTransport := mock_postdog.NewMockTransport(ctrl)
+				factory.EXPECT().Transport(gomock.Any(), gomock.Any()).Return(mockTransport, nil)
+
+				dog, err := cfg.Dog(context.Background(), config.WithRegistry(reg), config.WithTransportFactory("trans1", factory))
+
+				Convey("It shouldn't fail", func() {
+					So(err, ShouldBeNil)
+				})
+
+				Convey("dog should use the factory that has been passed as an option", func() {
+					tr, err := dog.Transport("test")
+					So(err, ShouldBeNil)
+					So(tr, ShouldEqual, mockTransport)
+				})
+			})
+		}))
+	})
+}
+
+func TestRegister(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	factory := mock_config.NewMockTransportFactory(ctrl)
+	if err := config.Register("register-test", factory); err != nil {
+		t.Fatalf("Register() failed: %v", err)
+	}
+
+	if err := config.Register("register-test", factory); !errors.Is(err, config.ErrDuplicateFactory) {
+		t.Fatalf("Register() should fail with %q; got %v", config.ErrDuplicateFactory, err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("MustRegister() should panic for a duplicate registration")
+		}
+	}()
+	config.MustRegister("register-test", factory)
+}
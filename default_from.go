@@ -0,0 +1,19 @@
+package postdog
+
+import (
+	"context"
+	"net/mail"
+)
+
+// WithDefaultFrom returns an Option that adds a Middleware which sets the
+// sender of every mail without a sender address (`From().Address`) to addr
+// (see WithFrom()). Mails with an explicitly set sender are passed through
+// unchanged.
+func WithDefaultFrom(addr mail.Address) OptionFunc {
+	return WithMiddlewareFunc(func(ctx context.Context, m Mail, next NextMiddleware) (Mail, error) {
+		if m.From().Address != "" {
+			return next(ctx, m)
+		}
+		return next(ctx, WithFrom(m, addr))
+	})
+}
@@ -0,0 +1,52 @@
+package postdog_test
+
+import (
+	"context"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithDefaultFrom(t *testing.T) {
+	def := mail.Address{Name: "Postdog", Address: "noreply@example.com"}
+
+	tests := []struct {
+		name     string
+		give     letter.Letter
+		expected mail.Address
+	}{
+		{
+			name:     "without sender",
+			give:     letter.Write(letter.To("Linda Belcher", "linda@example.com")),
+			expected: def,
+		},
+		{
+			name:     "with name only",
+			give:     letter.Write(letter.From("Bob Belcher", ""), letter.To("Linda Belcher", "linda@example.com")),
+			expected: def,
+		},
+		{
+			name: "with sender",
+			give: letter.Write(
+				letter.From("Bob Belcher", "bob@example.com"),
+				letter.To("Linda Belcher", "linda@example.com"),
+			),
+			expected: mail.Address{Name: "Bob Belcher", Address: "bob@example.com"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dog := postdog.New(postdog.WithDefaultFrom(def))
+			_, m, err := postdog.ApplyMiddleware(context.Background(), test.give, dog.Middlewares()...)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, m.From())
+			assert.Equal(t, test.expected, letter.Expand(m).From())
+			assert.Contains(t, strings.Split(m.RFC(), "\r\n"), "From: "+test.expected.String())
+		})
+	}
+}
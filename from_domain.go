@@ -0,0 +1,71 @@
+package postdog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrFromDomainNotAllowed means the domain of the sender of a mail is not
+	// allowed by the middleware of WithAllowedFromDomains().
+	ErrFromDomainNotAllowed = errors.New("sender domain not allowed")
+)
+
+// FromDomainError is returned by the middleware of WithAllowedFromDomains()
+// when the sender domain of a mail is not allowed.
+type FromDomainError struct {
+	// Address is the sender address of the mail.
+	Address string
+	// Domain is the domain of Address.
+	Domain string
+	// Err is ErrFromDomainNotAllowed.
+	Err error
+}
+
+// WithAllowedFromDomains returns an Option that adds a Middleware which
+// rejects mails whose sender (`From().Address`) doesn't belong to one of the
+// domains returned by allowed. allowed is called with the context of every
+// Send() call, so the allowed domains can be request-scoped, e.g. per tenant:
+//
+//	dog := postdog.New(
+//		postdog.WithAllowedFromDomains(func(ctx context.Context) []string {
+//			return tenant.FromContext(ctx).Domains
+//		}),
+//	)
+//
+// Domains are compared case-insensitively and must match exactly, so
+// subdomains must be allowed explicitly. If allowed returns no domains, every
+// mail is rejected. Rejected mails are not sent and Send() returns a
+// *FromDomainError.
+//
+// Senders that are overridden with send.From() are applied after the
+// middlewares have run and are therefore not checked.
+func WithAllowedFromDomains(allowed func(context.Context) []string) OptionFunc {
+	return WithMiddlewareFunc(func(ctx context.Context, m Mail, next NextMiddleware) (Mail, error) {
+		addr := m.From().Address
+		domain := strings.ToLower(addr)
+		if i := strings.LastIndex(domain, "@"); i >= 0 {
+			domain = domain[i+1:]
+		} else {
+			domain = ""
+		}
+
+		for _, d := range allowed(ctx) {
+			if domain != "" && strings.ToLower(strings.TrimSpace(d)) == domain {
+				return next(ctx, m)
+			}
+		}
+
+		return m, &FromDomainError{Address: addr, Domain: domain, Err: ErrFromDomainNotAllowed}
+	})
+}
+
+func (err *FromDomainError) Error() string {
+	return fmt.Sprintf("from %s: %s", err.Address, err.Err)
+}
+
+func (err *FromDomainError) Unwrap() error {
+	return err.Err
+}
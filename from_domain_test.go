@@ -0,0 +1,87 @@
+package postdog_test
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"testing"
+
+	"github.com/bounoable/postdog"
+	mock_postdog "github.com/bounoable/postdog/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+type tenantKey struct{}
+
+func TestWithAllowedFromDomains(t *testing.T) {
+	tests := []struct {
+		name      string
+		from      string
+		domains   []string
+		wantError bool
+	}{
+		{
+			name:    "allowed",
+			from:    "bob@Example.com",
+			domains: []string{"example.org", "EXAMPLE.com"},
+		},
+		{
+			name:      "other domain",
+			from:      "bob@example.com",
+			domains:   []string{"example.org"},
+			wantError: true,
+		},
+		{
+			name:      "subdomain",
+			from:      "bob@mail.example.com",
+			domains:   []string{"example.com"},
+			wantError: true,
+		},
+		{
+			name:      "no allowed domains",
+			from:      "bob@example.com",
+			wantError: true,
+		},
+		{
+			name:      "invalid address",
+			from:      "bob",
+			domains:   []string{"bob"},
+			wantError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			tr := mock_postdog.NewMockTransport(ctrl)
+			if !test.wantError {
+				tr.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil)
+			}
+
+			dog := postdog.New(
+				postdog.WithTransport("test", tr),
+				postdog.WithAllowedFromDomains(func(ctx context.Context) []string {
+					domains, _ := ctx.Value(tenantKey{}).([]string)
+					return domains
+				}),
+			)
+
+			ctx := context.WithValue(context.Background(), tenantKey{}, test.domains)
+			m := postdog.RawMail(mail.Address{Address: test.from}, []mail.Address{{Address: "linda@example.com"}}, "Subject: Hi.\r\n\r\nHello.")
+			err := dog.Send(ctx, m)
+
+			if !test.wantError {
+				assert.Nil(t, err)
+				return
+			}
+
+			assert.True(t, errors.Is(err, postdog.ErrFromDomainNotAllowed))
+			var domainErr *postdog.FromDomainError
+			assert.True(t, errors.As(err, &domainErr))
+			assert.Equal(t, test.from, domainErr.Address)
+		})
+	}
+}
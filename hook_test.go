@@ -0,0 +1,137 @@
+package postdog_test
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"testing"
+	"time"
+
+	"github.com/bounoable/postdog"
+	mock_postdog "github.com/bounoable/postdog/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListener_context(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tr := mock_postdog.NewMockTransport(ctrl)
+	tr.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil)
+
+	started := make(chan context.Context, 1)
+	stopped := make(chan error, 1)
+	dog := postdog.New(
+		postdog.WithTransport("test", tr),
+		postdog.WithHook(postdog.AfterSend, postdog.ListenerFunc(func(ctx context.Context, _ postdog.Hook, _ postdog.Mail) {
+			started <- ctx
+			<-ctx.Done()
+			stopped <- ctx.Err()
+		})),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	assert.Nil(t, dog.Send(ctx, mockMail()))
+
+	lisCtx := <-started
+	assert.Equal(t, "test", postdog.SentVia(lisCtx))
+
+	select {
+	case err := <-stopped:
+		t.Fatalf("listener context should not be cancelled when Send() returns: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	cancel()
+
+	select {
+	case err := <-stopped:
+		assert.True(t, errors.Is(err, context.Canceled))
+	case <-time.After(time.Second):
+		t.Fatal("listener context should be cancelled with the context of Send()")
+	}
+}
+
+func TestWithHookTimeout_listener(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tr := mock_postdog.NewMockTransport(ctrl)
+	tr.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil)
+
+	stopped := make(chan error, 1)
+	dog := postdog.New(
+		postdog.WithTransport("test", tr),
+		postdog.WithHookTimeout(20*time.Millisecond),
+		postdog.WithHook(postdog.AfterSend, postdog.ListenerFunc(func(ctx context.Context, _ postdog.Hook, _ postdog.Mail) {
+			<-ctx.Done()
+			stopped <- ctx.Err()
+		})),
+	)
+
+	assert.Nil(t, dog.Send(context.Background(), mockMail()))
+
+	select {
+	case err := <-stopped:
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	case <-time.After(time.Second):
+		t.Fatal("listener context should time out")
+	}
+}
+
+func TestWithHookTimeout_syncListener(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tr := mock_postdog.NewMockTransport(ctrl)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	dog := postdog.New(
+		postdog.WithTransport("test", tr),
+		postdog.WithHookTimeout(20*time.Millisecond),
+		postdog.WithSyncHook(postdog.BeforeSend, postdog.SyncListenerFunc(func(ctx context.Context, _ postdog.Hook, _ postdog.Mail) error {
+			<-release // ignores the context
+			return nil
+		})),
+	)
+
+	start := time.Now()
+	err := dog.Send(context.Background(), mockMail())
+	assert.True(t, errors.Is(err, postdog.ErrHookTimeout))
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+}
+
+func TestWithHookTimeout_syncListenerReturns(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tr := mock_postdog.NewMockTransport(ctrl)
+	tr.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil)
+
+	mockError := errors.New("mock error")
+	dog := postdog.New(
+		postdog.WithTransport("test", tr),
+		postdog.WithHookTimeout(time.Second),
+		postdog.WithSyncHook(postdog.AfterSend, postdog.SyncListenerFunc(func(ctx context.Context, _ postdog.Hook, _ postdog.Mail) error {
+			if _, ok := ctx.Deadline(); !ok {
+				return errors.New("context has no deadline")
+			}
+			return mockError
+		})),
+	)
+
+	assert.True(t, errors.Is(dog.Send(context.Background(), mockMail()), mockError))
+}
+
+func mockMail() postdog.Mail {
+	return postdog.RawMail(
+		mail.Address{Address: "bob@example.com"},
+		[]mail.Address{{Address: "linda@example.com"}},
+		"Subject: Hi.\r\n\r\nHello.",
+	)
+}
@@ -0,0 +1,17 @@
+// Package fold provides case-insensitive string searches.
+package fold
+
+import "strings"
+
+// LastIndex returns the index of the last case-insensitive occurrence of
+// substr in s, or -1 if s doesn't contain substr. Unlike searching in
+// strings.ToLower(s), the index always refers to s, even if lowercasing
+// changes the length of s (e.g. "İ").
+func LastIndex(s, substr string) int {
+	for i := len(s) - len(substr); i >= 0; i-- {
+		if strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
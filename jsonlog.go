@@ -0,0 +1,70 @@
+package postdog
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JSONLogListener returns a Listener that writes a JSON object for every sent
+// mail to w, one object per line:
+//
+//	{"messageId":"<...>","from":"bob@example.com","recipientCount":2,"subject":"Hi.","transport":"smtp","error":"...","durationMs":12.5,"timestamp":"2021-..."}
+//
+// The listener only handles the AfterSend Hook, so it should be registered
+// with WithHook(AfterSend, JSONLogListener(w)). `error` is omitted if the mail
+// has been sent successfully. `transport`, `error`, `durationMs` and
+// `timestamp` are read from the context using SentVia(), SendError(),
+// SendDuration() and SendTime().
+//
+// The message ID is read from the `Message-ID` header of the RFC body of the
+// mail. Mails that generate a new message ID every time their RFC body is
+// built (e.g. a letter.Letter without a fixed message ID) are logged with an
+// ID that differs from the sent one.
+//
+// Writes to w are serialized, so w doesn't need to be safe for concurrent use.
+// Write errors are ignored.
+func JSONLogListener(w io.Writer) Listener {
+	var mux sync.Mutex
+	return ListenerFunc(func(ctx context.Context, h Hook, m Mail) {
+		if h != AfterSend {
+			return
+		}
+
+		entry := jsonLogEntry{
+			MessageID:      strings.TrimSpace(headerValue(m.RFC(), "Message-ID")),
+			From:           m.From().Address,
+			RecipientCount: len(m.Recipients()),
+			Subject:        Subject(m),
+			Transport:      SentVia(ctx),
+			DurationMs:     float64(SendDuration(ctx)) / float64(time.Millisecond),
+			Timestamp:      SendTime(ctx),
+		}
+		if err := SendError(ctx); err != nil {
+			entry.Error = err.Error()
+		}
+
+		b, err := json.Marshal(entry)
+		if err != nil {
+			return
+		}
+
+		mux.Lock()
+		defer mux.Unlock()
+		w.Write(append(b, '\n'))
+	})
+}
+
+type jsonLogEntry struct {
+	MessageID      string    `json:"messageId"`
+	From           string    `json:"from"`
+	RecipientCount int       `json:"recipientCount"`
+	Subject        string    `json:"subject"`
+	Transport      string    `json:"transport"`
+	Error          string    `json:"error,omitempty"`
+	DurationMs     float64   `json:"durationMs"`
+	Timestamp      time.Time `json:"timestamp"`
+}
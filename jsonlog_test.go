@@ -0,0 +1,83 @@
+package postdog_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/mail"
+	"testing"
+	"time"
+
+	"github.com/bounoable/postdog"
+	mock_postdog "github.com/bounoable/postdog/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+type chanWriter chan []byte
+
+func (w chanWriter) Write(p []byte) (int, error) {
+	w <- append([]byte(nil), p...)
+	return len(p), nil
+}
+
+func TestJSONLogListener(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockError := errors.New("mock error")
+	tr := mock_postdog.NewMockTransport(ctrl)
+	tr.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, postdog.Mail) error {
+		time.Sleep(20 * time.Millisecond)
+		return mockError
+	})
+
+	w := make(chanWriter, 1)
+	dog := postdog.New(
+		postdog.WithTransport("smtp", tr),
+		postdog.WithHook(postdog.BeforeSend, postdog.JSONLogListener(w)),
+		postdog.WithHook(postdog.AfterSend, postdog.JSONLogListener(w)),
+	)
+
+	m := postdog.RawMail(
+		mail.Address{Address: "bob@example.com"},
+		[]mail.Address{{Address: "linda@example.com"}, {Address: "tina@example.com"}},
+		"Message-ID: <123@example.com>\r\nSubject: =?utf-8?q?Hell=C3=B6?=\r\n\r\nHello.",
+	)
+	start := time.Now()
+	assert.True(t, errors.Is(dog.Send(context.Background(), m), mockError))
+
+	var line []byte
+	select {
+	case line = <-w:
+	case <-time.After(time.Second):
+		t.Fatal("no log line written")
+	}
+	assert.Equal(t, byte('\n'), line[len(line)-1])
+
+	var entry struct {
+		MessageID      string    `json:"messageId"`
+		From           string    `json:"from"`
+		RecipientCount int       `json:"recipientCount"`
+		Subject        string    `json:"subject"`
+		Transport      string    `json:"transport"`
+		Error          string    `json:"error"`
+		DurationMs     float64   `json:"durationMs"`
+		Timestamp      time.Time `json:"timestamp"`
+	}
+	assert.Nil(t, json.Unmarshal(line, &entry))
+	assert.Equal(t, "<123@example.com>", entry.MessageID)
+	assert.Equal(t, "bob@example.com", entry.From)
+	assert.Equal(t, 2, entry.RecipientCount)
+	assert.Equal(t, "Hellö", entry.Subject)
+	assert.Equal(t, "smtp", entry.Transport)
+	assert.Equal(t, "mock error", entry.Error)
+	assert.GreaterOrEqual(t, entry.DurationMs, float64(20))
+	assert.True(t, entry.Timestamp.After(start))
+
+	select {
+	case line = <-w:
+		t.Fatalf("unexpected log line: %s", line)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,51 @@
+package letter
+
+import (
+	"reflect"
+
+	"github.com/bounoable/postdog"
+)
+
+// AsLetter returns m as a Letter. Middlewares should use AsLetter instead of
+// Expand() to read and modify mails.
+//
+// If m is a Letter, it is returned as-is and ok is true; this fast path
+// doesn't convert anything. Otherwise m is converted with Expand() and ok is false.
+// Unlike Expand(), AsLetter preserves the RFC body of mails that don't
+// provide their content through the optional Text(), HTML() or Attachments()
+// methods (e.g. mails returned by postdog.RawMail()): the RFC body of m is
+// used as the custom RFC body of the returned Letter, so that sending the
+// Letter sends the same message as sending m.
+func AsLetter(m postdog.Mail) (l Letter, ok bool) {
+	if l, ok := m.(Letter); ok {
+		return l, true
+	}
+
+	l = Expand(m)
+	if l.L.RFC == "" && !hasContent(m) {
+		l.L.RFC = m.RFC()
+	}
+
+	return l, false
+}
+
+// hasContent determines if m, or the Mail that is wrapped by m, provides its
+// content through any of the Text(), HTML() or Attachments() methods.
+func hasContent(m postdog.Mail) bool {
+	for {
+		switch m.(type) {
+		case Letter,
+			interface{ Text() string },
+			interface{ HTML() string }:
+			return true
+		}
+		if _, ok := reflect.TypeOf(m).MethodByName("Attachments"); ok {
+			return true
+		}
+		wm, ok := m.(interface{ Unwrap() postdog.Mail })
+		if !ok {
+			return false
+		}
+		m = wm.Unwrap()
+	}
+}
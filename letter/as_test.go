@@ -0,0 +1,80 @@
+package letter_test
+
+import (
+	"net/mail"
+	"testing"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter"
+	"github.com/stretchr/testify/assert"
+)
+
+type textMail struct {
+	postdog.Mail
+}
+
+func (m textMail) Text() string {
+	return "Hello from Text()."
+}
+
+func TestAsLetter(t *testing.T) {
+	let := letter.Write(letter.From("Bob Belcher", "bob@example.com"), letter.Text("Hello."))
+	l, ok := letter.AsLetter(let)
+	assert.True(t, ok)
+	assert.Equal(t, let, l)
+
+	body := "Subject: Hi.\r\nFrom: bob@example.com\r\n\r\nHello."
+	raw := postdog.RawMail(mail.Address{Address: "bob@example.com"}, []mail.Address{{Address: "linda@example.com"}}, body)
+
+	l, ok = letter.AsLetter(raw)
+	assert.False(t, ok)
+	assert.Equal(t, body, l.RFC())
+	assert.Equal(t, raw.From(), l.From())
+	assert.Equal(t, raw.Recipients(), l.Recipients())
+
+	wrapped := postdog.WithFrom(raw, mail.Address{Address: "tina@example.com"})
+	l, ok = letter.AsLetter(wrapped)
+	assert.False(t, ok)
+	assert.Equal(t, wrapped.RFC(), l.RFC())
+	assert.Equal(t, "tina@example.com", l.From().Address)
+
+	l, ok = letter.AsLetter(textMail{raw})
+	assert.False(t, ok)
+	assert.Equal(t, "Hello from Text().", l.Text())
+	assert.NotEqual(t, body, l.RFC())
+}
+
+var benchLetter letter.Letter
+
+func BenchmarkAsLetter(b *testing.B) {
+	let := letter.Write(
+		letter.From("Bob Belcher", "bob@example.com"),
+		letter.To("Linda Belcher", "linda@example.com"),
+		letter.Content("Hello.", "<p>Hello.</p>"),
+		letter.Attach("attach.txt", []byte("Hello.")),
+	)
+	var m postdog.Mail = let
+	raw := postdog.RawMail(let.From(), let.Recipients(), let.RFC())
+
+	b.Run("Letter", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			benchLetter, _ = letter.AsLetter(m)
+		}
+	})
+
+	b.Run("wrapped Letter", func(b *testing.B) {
+		m := postdog.WithSubject(let, "Hi.")
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			benchLetter, _ = letter.AsLetter(m)
+		}
+	})
+
+	b.Run("RawMail", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			benchLetter, _ = letter.AsLetter(raw)
+		}
+	})
+}
@@ -0,0 +1,190 @@
+package letter
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/bounoable/postdog/letter/mapper"
+)
+
+// generatedAttachmentHeaders are the attachment headers that are generated by
+// NewAttachment().
+var generatedAttachmentHeaders = []string{
+	"Content-Type",
+	"Content-Disposition",
+	"Content-Id",
+	"Content-Transfer-Encoding",
+}
+
+// EqualOption is an option for Equal() and Diff().
+type EqualOption func(*equalConfig)
+
+type equalConfig struct {
+	ignoreRFC               bool
+	ignoreGeneratedHeaders  bool
+	ignoreAttachmentContent bool
+}
+
+// Equal determines if the letters a and b are equal. Letters are compared by
+// their mapped form (see (Letter).Map()), so that letters that went through a
+// Map() / Parse() round-trip (e.g. in a Store) are still equal.
+func Equal(a, b Letter, opts ...EqualOption) bool {
+	return Diff(a, b, opts...) == ""
+}
+
+// Diff returns a human-readable description of the differences between the
+// letters a and b, one difference per line, or an empty string if a and b are
+// equal. Diff accepts the same options as Equal().
+func Diff(a, b Letter, opts ...EqualOption) string {
+	var cfg equalConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var diffs []string
+	diffValues(&diffs, "", cfg.normalize(a), cfg.normalize(b))
+	return strings.Join(diffs, "\n")
+}
+
+// IgnoreRFC returns an EqualOption that ignores custom RFC bodies (see
+// WithRFC()).
+func IgnoreRFC() EqualOption {
+	return func(cfg *equalConfig) {
+		cfg.ignoreRFC = true
+	}
+}
+
+// IgnoreGeneratedHeaders returns an EqualOption that ignores generated
+// headers: the `Message-ID` and `Date` headers of custom RFC bodies and the
+// attachment headers that are generated by NewAttachment().
+func IgnoreGeneratedHeaders() EqualOption {
+	return func(cfg *equalConfig) {
+		cfg.ignoreGeneratedHeaders = true
+	}
+}
+
+// IgnoreAttachmentContent returns an EqualOption that ignores the content of
+// attachments. The attachment sizes are still compared.
+func IgnoreAttachmentContent() EqualOption {
+	return func(cfg *equalConfig) {
+		cfg.ignoreAttachmentContent = true
+	}
+}
+
+func (cfg equalConfig) normalize(l Letter) map[string]interface{} {
+	var mapOpts []mapper.Option
+	if cfg.ignoreAttachmentContent {
+		mapOpts = append(mapOpts, mapper.WithoutAttachmentContent())
+	}
+
+	m := l.Map(mapOpts...)
+
+	if cfg.ignoreRFC {
+		delete(m, "rfc")
+	}
+
+	if cfg.ignoreGeneratedHeaders {
+		if body, ok := m["rfc"].(string); ok {
+			m["rfc"] = withoutHeaders(body, "Message-Id", "Date")
+		}
+		if ats, ok := m["attachments"].([]interface{}); ok {
+			for _, at := range ats {
+				if h, ok := at.(map[string]interface{})["header"].(map[string]interface{}); ok {
+					for _, key := range generatedAttachmentHeaders {
+						delete(h, key)
+					}
+				}
+			}
+		}
+	}
+
+	return m
+}
+
+// withoutHeaders removes the headers keys from the header section of the RFC
+// body.
+func withoutHeaders(body string, keys ...string) string {
+	header, rest := body, ""
+	if i := strings.Index(body, "\r\n\r\n"); i >= 0 {
+		header, rest = body[:i], body[i:]
+	}
+
+	var lines []string
+	var skipping bool
+	for _, line := range strings.Split(header, "\r\n") {
+		if skipping && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			continue
+		}
+		skipping = false
+
+		if i := strings.Index(line, ":"); i > 0 {
+			for _, key := range keys {
+				if strings.EqualFold(strings.TrimSpace(line[:i]), key) {
+					skipping = true
+					break
+				}
+			}
+		}
+
+		if !skipping {
+			lines = append(lines, line)
+		}
+	}
+
+	return strings.Join(lines, "\r\n") + rest
+}
+
+func diffValues(diffs *[]string, path string, a, b interface{}) {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		for _, key := range mapKeys(av, bv) {
+			diffValues(diffs, joinDiffPath(path, key), av[key], bv[key])
+		}
+		return
+
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		if len(av) != len(bv) {
+			*diffs = append(*diffs, fmt.Sprintf("%s: length %d != %d", path, len(av), len(bv)))
+			return
+		}
+		for i := range av {
+			diffValues(diffs, fmt.Sprintf("%s[%d]", path, i), av[i], bv[i])
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*diffs = append(*diffs, fmt.Sprintf("%s: %#v != %#v", path, a, b))
+	}
+}
+
+func mapKeys(a, b map[string]interface{}) []string {
+	keys := make([]string, 0, len(a))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinDiffPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
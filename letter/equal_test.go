@@ -0,0 +1,128 @@
+package letter_test
+
+import (
+	"testing"
+
+	"github.com/bounoable/postdog/letter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEqual(t *testing.T) {
+	base := letter.Write(
+		letter.Subject("Hi."),
+		letter.From("Bob Belcher", "bob@example.com"),
+		letter.To("Linda Belcher", "linda@example.com"),
+		letter.Text("Hello."),
+		letter.Attach("attach.txt", []byte("Hello.")),
+	)
+
+	tests := []struct {
+		name     string
+		a        letter.Letter
+		b        letter.Letter
+		opts     []letter.EqualOption
+		expected bool
+	}{
+		{
+			name:     "same letter",
+			a:        base,
+			b:        base,
+			expected: true,
+		},
+		{
+			name:     "different subject",
+			a:        base,
+			b:        base.WithSubject("Bye."),
+			expected: false,
+		},
+		{
+			name:     "different rfc body",
+			a:        base,
+			b:        base.WithRFC("Subject: Hi."),
+			expected: false,
+		},
+		{
+			name:     "different rfc body (IgnoreRFC)",
+			a:        base,
+			b:        base.WithRFC("Subject: Hi."),
+			opts:     []letter.EqualOption{letter.IgnoreRFC()},
+			expected: true,
+		},
+		{
+			name:     "different generated rfc headers",
+			a:        base.WithRFC("Message-ID: <1@example.com>\r\nDate: Mon, 02 Jan 2006 15:04:05 -0700\r\nSubject: Hi.\r\n\r\nHello."),
+			b:        base.WithRFC("Message-ID: <2@example.com>\r\nDate: Tue, 03 Jan 2006 15:04:05 -0700\r\nSubject: Hi.\r\n\r\nHello."),
+			expected: false,
+		},
+		{
+			name:     "different generated rfc headers (IgnoreGeneratedHeaders)",
+			a:        base.WithRFC("Message-ID: <1@example.com>\r\nDate: Mon, 02 Jan 2006 15:04:05 -0700\r\nSubject: Hi.\r\n\r\nHello."),
+			b:        base.WithRFC("Message-ID: <2@example.com>\r\nDate: Tue, 03 Jan 2006 15:04:05 -0700\r\nSubject: Hi.\r\n\r\nHello."),
+			opts:     []letter.EqualOption{letter.IgnoreGeneratedHeaders()},
+			expected: true,
+		},
+		{
+			name:     "different rfc subject (IgnoreGeneratedHeaders)",
+			a:        base.WithRFC("Message-ID: <1@example.com>\r\nSubject: Hi.\r\n\r\nHello."),
+			b:        base.WithRFC("Message-ID: <1@example.com>\r\nSubject: Bye.\r\n\r\nHello."),
+			opts:     []letter.EqualOption{letter.IgnoreGeneratedHeaders()},
+			expected: false,
+		},
+		{
+			name:     "different attachment type (IgnoreGeneratedHeaders)",
+			a:        base,
+			b:        base.WithAttachments(letter.NewAttachment("attach.txt", []byte("Hello."), letter.AttachmentType("text/html"))),
+			opts:     []letter.EqualOption{letter.IgnoreGeneratedHeaders()},
+			expected: false,
+		},
+		{
+			name:     "different attachment content",
+			a:        base,
+			b:        base.WithAttachments(letter.NewAttachment("attach.txt", []byte("Bye..."))),
+			expected: false,
+		},
+		{
+			name:     "different attachment content (IgnoreAttachmentContent)",
+			a:        base,
+			b:        base.WithAttachments(letter.NewAttachment("attach.txt", []byte("Bye..."))),
+			opts:     []letter.EqualOption{letter.IgnoreAttachmentContent(), letter.IgnoreGeneratedHeaders()},
+			expected: true,
+		},
+		{
+			name:     "different attachment size (IgnoreAttachmentContent)",
+			a:        base,
+			b:        base.WithAttachments(letter.NewAttachment("attach.txt", []byte("Bye."))),
+			opts:     []letter.EqualOption{letter.IgnoreAttachmentContent(), letter.IgnoreGeneratedHeaders()},
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, letter.Equal(test.a, test.b, test.opts...))
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := letter.Write(
+		letter.Subject("Hi."),
+		letter.To("Linda Belcher", "linda@example.com"),
+		letter.To("Tina Belcher", "tina@example.com"),
+	)
+
+	b := letter.Write(
+		letter.Subject("Bye."),
+		letter.To("Linda Belcher", "linda@example.com"),
+		letter.To("Gene Belcher", "gene@example.com"),
+	)
+
+	assert.Equal(t, "", letter.Diff(a, a))
+	assert.Equal(t, `recipients[1].address: "tina@example.com" != "gene@example.com"
+recipients[1].name: "Tina Belcher" != "Gene Belcher"
+subject: "Hi." != "Bye."
+to[1].address: "tina@example.com" != "gene@example.com"
+to[1].name: "Tina Belcher" != "Gene Belcher"`, letter.Diff(a, b))
+
+	assert.Contains(t, letter.Diff(a, a.WithTo(a.To()[0])), "to: length 2 != 1")
+}
@@ -2,6 +2,7 @@ package letter
 
 import (
 	"bytes"
+	"encoding/base64"
 	"net/mail"
 	"net/textproto"
 	"testing"
@@ -204,6 +205,22 @@ func TestExpand(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "mail with overridden sender",
+			give: postdog.WithFrom(
+				Write(From("Bob Belcher", "bob@example.com"), To("Linda Belcher", "linda@example.com")),
+				mail.Address{Name: "Tina Belcher", Address: "tina@example.com"},
+			),
+			want: Write(From("Tina Belcher", "tina@example.com"), To("Linda Belcher", "linda@example.com")),
+		},
+		{
+			name: "mail with overridden subject",
+			give: postdog.WithSubject(
+				Write(From("Bob Belcher", "bob@example.com"), Subject("Hi.")),
+				"[STAGING] Hi.",
+			),
+			want: Write(From("Bob Belcher", "bob@example.com"), Subject("[STAGING] Hi.")),
+		},
 		{
 			name: "mail with Attachments() method",
 			give: attachmentMail{
@@ -224,6 +241,28 @@ func TestExpand(t *testing.T) {
 	}
 }
 
+func TestExpand_header(t *testing.T) {
+	l := Write(From("Bob Belcher", "bob@example.com"), Subject("Hi."), Text("Hello."))
+	m := postdog.WithSubject(postdog.WithHeader(l, "X-Request-ID", "foo"), "[STAGING] Hi.")
+
+	expanded := Expand(m)
+	assert.Equal(t, "[STAGING] Hi.", expanded.Subject())
+	assert.Equal(t, "Hello.", expanded.Text())
+	assert.Equal(t, textproto.MIMEHeader{"X-Request-ID": {"foo"}}, expanded.Header())
+	assert.Contains(t, expanded.RFC(), "\r\nX-Request-ID: foo\r\n")
+
+	// the RFC body is not frozen, so that later modifications are applied
+	assert.Equal(t, "", expanded.L.RFC)
+	modified := expanded.WithText("Bye.")
+	assert.Contains(t, modified.RFC(), "\r\nX-Request-ID: foo\r\n")
+	assert.Contains(t, modified.RFC(), base64.StdEncoding.EncodeToString([]byte("Bye.")))
+
+	// mails with a custom RFC body keep the header in the body
+	raw := Write(RFC("Subject: Hi.\r\n\r\nHello."))
+	expanded = Expand(postdog.WithHeader(raw, "X-Request-ID", "foo"))
+	assert.Equal(t, "X-Request-ID: foo\r\nSubject: Hi.\r\n\r\nHello.", expanded.RFC())
+}
+
 func (m basicMail) From() mail.Address {
 	return m.from
 }
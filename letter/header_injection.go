@@ -0,0 +1,106 @@
+package letter
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+// checkHeaders returns an error that unwraps to ErrHeaderInjection if a header
+// value of l contains a line break.
+func (l Letter) checkHeaders() error {
+	values := []struct {
+		field string
+		value string
+	}{
+		{"subject", l.L.Subject},
+		{"auto-submitted", l.L.AutoSubmitted},
+		{"precedence", l.L.Precedence},
+		{"organization", l.L.Organization},
+		{"return path", l.L.ReturnPath},
+	}
+	for _, v := range values {
+		if err := checkHeader(v.field, v.value); err != nil {
+			return err
+		}
+	}
+
+	if err := checkAddresses("from", l.L.From); err != nil {
+		return err
+	}
+
+	for _, addrs := range []struct {
+		field string
+		addrs []mail.Address
+	}{
+		{"recipient", l.L.Recipients},
+		{"to", l.L.To},
+		{"cc", l.L.CC},
+		{"bcc", l.L.BCC},
+		{"reply-to", l.L.ReplyTo},
+	} {
+		if err := checkAddresses(addrs.field, addrs.addrs...); err != nil {
+			return err
+		}
+	}
+
+	for _, g := range append(append([]Group(nil), l.L.ToGroups...), l.L.CCGroups...) {
+		if err := checkHeader("group", g.Name); err != nil {
+			return err
+		}
+		if err := checkAddresses("group "+g.Name, g.Addresses...); err != nil {
+			return err
+		}
+	}
+
+	for key, vals := range l.header {
+		if err := checkHeader("header key", key); err != nil {
+			return err
+		}
+		for _, val := range vals {
+			if err := checkHeader("header "+key, val); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, alt := range l.L.Alternatives {
+		if err := checkHeader("alternative content type", alt.ContentType); err != nil {
+			return err
+		}
+	}
+
+	for _, at := range l.L.Attachments {
+		if err := checkHeader("attachment filename", at.A.Filename); err != nil {
+			return err
+		}
+		for key, vals := range at.A.Header {
+			for _, val := range vals {
+				if err := checkHeader(fmt.Sprintf("attachment %s: %s", at.A.Filename, key), val); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func checkAddresses(field string, addrs ...mail.Address) error {
+	for _, addr := range addrs {
+		if err := checkHeader(field+" name", addr.Name); err != nil {
+			return err
+		}
+		if err := checkHeader(field+" address", addr.Address); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkHeader(field, value string) error {
+	if strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("%w: %s contains a line break", ErrHeaderInjection, field)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package letter_test
+
+import (
+	"errors"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/bounoable/postdog/letter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTryWrite_headerInjection(t *testing.T) {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", "text/plain")
+	header.Set("X-Custom", "foo\r\nBcc: attacker@evil.com")
+
+	tests := []struct {
+		name string
+		opt  letter.Option
+	}{
+		{
+			name: "subject",
+			opt:  letter.Subject("Hi\r\nBcc: attacker@evil.com"),
+		},
+		{
+			name: "sender name",
+			opt:  letter.From("Bob\r\nBcc: attacker@evil.com", "bob@example.com"),
+		},
+		{
+			name: "recipient address",
+			opt:  letter.To("Linda", "linda@example.com\nBcc: attacker@evil.com"),
+		},
+		{
+			name: "group name",
+			opt:  letter.ToGroup("Kids\rBcc: attacker@evil.com"),
+		},
+		{
+			name: "precedence",
+			opt:  letter.Precedence("bulk\r\nBcc: attacker@evil.com"),
+		},
+		{
+			name: "attachment header",
+			opt:  letter.AttachWithHeader("attach.txt", []byte("Hello."), header),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := letter.TryWrite(test.opt)
+			assert.True(t, errors.Is(err, letter.ErrHeaderInjection), "%v", err)
+		})
+	}
+}
+
+func TestLetter_RFC_headerInjection(t *testing.T) {
+	// modified letters are not validated, so the RFC builder must neutralize
+	// the line breaks
+	l := letter.Write(letter.Text("Hello.")).
+		WithSubject("Hi\r\nBcc: attacker@evil.com").
+		WithFrom("Bob\r\nBcc: attacker@evil.com", "bob@example.com").
+		WithTo(mail.Address{Address: "linda@example.com\r\nBcc: attacker@evil.com"}).
+		WithPrecedence("bulk\r\nBcc: attacker@evil.com")
+
+	for _, line := range strings.Split(l.RFC(), "\r\n") {
+		assert.False(t, strings.HasPrefix(line, "Bcc:"), "injected header: %q", line)
+	}
+}
+
+func TestWrite_headerInjection(t *testing.T) {
+	var l letter.Letter
+	assert.NotPanics(t, func() {
+		l = letter.Write(
+			letter.Subject("Hi\r\nBcc: attacker@evil.com"),
+			letter.To("Linda", "linda@example.com"),
+			letter.Text("Hello."),
+		)
+	})
+	assert.Equal(t, "Hi\r\nBcc: attacker@evil.com", l.Subject())
+
+	for _, line := range strings.Split(l.RFC(), "\r\n") {
+		assert.False(t, strings.HasPrefix(line, "Bcc:"), "injected header: %q", line)
+	}
+}
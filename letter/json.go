@@ -0,0 +1,30 @@
+package letter
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// FromJSON parses the JSON encoding of the map that is returned by (Letter).Map()
+// into a Letter, e.g. to send a letter that has been stored in a queue.
+//
+// The RFC body of the returned Letter is only identical to the RFC body of the
+// original letter if the original letter had a custom RFC body (see RFC()),
+// because a new Message-ID and Date are generated otherwise. Use
+// l.WithRFC(l.RFC()) before encoding a letter to send it byte-identically:
+//
+//	b, err := json.Marshal(l.WithRFC(l.RFC()).Map())
+//	// store b and later
+//	l, err := letter.FromJSON(b)
+//	err = dog.Send(ctx, l)
+func FromJSON(b []byte) (Letter, error) {
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return Letter{}, fmt.Errorf("unmarshal json: %w", err)
+	}
+
+	var l Letter
+	l.Parse(m)
+
+	return l, nil
+}
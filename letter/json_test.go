@@ -0,0 +1,52 @@
+package letter_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter"
+	mock_postdog "github.com/bounoable/postdog/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromJSON(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	l := letter.Write(
+		letter.From("Bob Belcher", "bob@example.com"),
+		letter.To("Linda Belcher", "linda@example.com"),
+		letter.BCC("Gene Belcher", "gene@example.com"),
+		letter.Subject("Hi."),
+		letter.Content("Hello.", "<p>Hello.</p>"),
+		letter.Attach("attach.txt", []byte("Hello.")),
+	)
+	l = l.WithRFC(l.RFC())
+
+	b, err := json.Marshal(l.Map())
+	assert.Nil(t, err)
+
+	parsed, err := letter.FromJSON(b)
+	assert.Nil(t, err)
+	assert.True(t, letter.Equal(l, parsed), letter.Diff(l, parsed))
+
+	var sent postdog.Mail
+	tr := mock_postdog.NewMockTransport(ctrl)
+	tr.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, m postdog.Mail) error {
+		sent = m
+		return nil
+	})
+
+	dog := postdog.New(postdog.WithTransport("test", tr))
+	assert.Nil(t, dog.Send(context.Background(), parsed))
+	assert.Equal(t, l.RFC(), sent.RFC())
+	assert.Equal(t, l.Recipients(), sent.Recipients())
+}
+
+func TestFromJSON_invalid(t *testing.T) {
+	_, err := letter.FromJSON([]byte("{"))
+	assert.NotNil(t, err)
+}
@@ -3,6 +3,7 @@ package letter
 import (
 	"crypto/sha1"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -14,6 +15,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 
 	"github.com/bounoable/postdog"
 	"github.com/bounoable/postdog/internal/encode"
@@ -21,26 +23,83 @@ import (
 	"github.com/bounoable/postdog/letter/rfc"
 )
 
+var (
+	// ErrMissingHeader means a required header is missing.
+	ErrMissingHeader = errors.New("missing header")
+	// ErrHeaderInjection means a header value contains a line break, which
+	// could be used to inject additional headers into the mail.
+	ErrHeaderInjection = errors.New("header injection")
+)
+
+// autoEncoding is the placeholder `Content-Transfer-Encoding` of attachments
+// whose encoding is selected automatically (see AttachmentAutoEncoding()).
+const autoEncoding = "auto"
+
+// Values for the `Auto-Submitted` header (RFC 3834). See AutoSubmitted().
+const (
+	// AutoGenerated marks a mail as automatically generated, e.g. a
+	// notification or a transactional mail.
+	AutoGenerated = "auto-generated"
+	// AutoReplied marks a mail as an automatic reply to another mail.
+	AutoReplied = "auto-replied"
+)
+
+// Conventional values for the non-standard `Precedence` header. See
+// Precedence().
+const (
+	// PrecedenceBulk marks a mail as bulk mail, e.g. a newsletter.
+	PrecedenceBulk = "bulk"
+	// PrecedenceList marks a mail as sent through a mailing list.
+	PrecedenceList = "list"
+	// PrecedenceJunk marks a mail as low-priority mail that may be discarded.
+	PrecedenceJunk = "junk"
+)
+
 // Letter represents a mail.
 type Letter struct {
 	L
 
-	rfcConfig rfc.Config
+	rfcConfig                rfc.Config
+	header                   textproto.MIMEHeader
+	allowDuplicateRecipients bool
 }
 
 // L contains the fields of a Letter.
 type L struct {
-	Subject     string
-	From        mail.Address
-	Recipients  []mail.Address
-	To          []mail.Address
-	CC          []mail.Address
-	BCC         []mail.Address
-	ReplyTo     []mail.Address
-	RFC         string
-	Text        string
-	HTML        string
-	Attachments []Attachment
+	Subject       string
+	From          mail.Address
+	Recipients    []mail.Address
+	To            []mail.Address
+	ToGroups      []Group
+	CC            []mail.Address
+	CCGroups      []Group
+	BCC           []mail.Address
+	ReplyTo       []mail.Address
+	AutoSubmitted string
+	Precedence    string
+	Organization  string
+	ReturnPath    string
+	RFC           string
+	Text          string
+	HTML          string
+	Alternatives  []Alternative
+	Attachments   []Attachment
+}
+
+// Alternative is an additional alternative representation of the content of
+// a letter, e.g. an AMP part (`text/x-amp-html`).
+type Alternative struct {
+	ContentType string
+	Content     []byte
+	// BeforeHTML places the alternative between the text and the HTML part.
+	BeforeHTML bool
+}
+
+// Group is an RFC 5322 address group in the `To` or `Cc` header of a letter,
+// e.g. `Team: a@example.com,b@example.com;`.
+type Group struct {
+	Name      string
+	Addresses []mail.Address
 }
 
 // Attachment is a file attachment.
@@ -63,9 +122,17 @@ type Option func(*Letter) error
 // AttachmentOption configures an attachment.
 type AttachmentOption func(*Attachment)
 
-// Write a letter with the given opts. Panics if TryWrite() returns an error.
+// AlternativeOption configures an Alternative.
+type AlternativeOption func(*Alternative)
+
+// Write a letter with the given opts. Panics if an option returns an error.
+//
+// Unlike TryWrite(), Write doesn't reject header values that contain line
+// breaks, so that it can be used to rebuild existing mails, e.g. mails that
+// are read from a store. The line breaks are neutralized when the RFC body is
+// built (see rfc.Build()).
 func Write(opts ...Option) Letter {
-	return Must(TryWrite(opts...))
+	return Must(write(opts...))
 }
 
 // Must panics if err is not nil and otherwise returns let.
@@ -77,7 +144,24 @@ func Must(let Letter, err error) Letter {
 }
 
 // TryWrite a letter with the given opts.
+//
+// TryWrite returns an error that unwraps to ErrHeaderInjection if a header
+// value, e.g. the subject, a display name or an attachment header, contains a
+// line break. Such values are often user-supplied and could otherwise inject
+// additional headers (e.g. `Bcc`) into the mail.
 func TryWrite(opts ...Option) (Letter, error) {
+	let, err := write(opts...)
+	if err != nil {
+		return let, err
+	}
+	if err = let.checkHeaders(); err != nil {
+		return let, err
+	}
+	return let, nil
+}
+
+// write does the same as TryWrite() but doesn't check the header values.
+func write(opts ...Option) (Letter, error) {
 	var let Letter
 	var err error
 	for _, opt := range opts {
@@ -169,6 +253,25 @@ func CCAddress(addrs ...mail.Address) Option {
 	}
 }
 
+// ToGroup adds an address group to the `To` header of the letter. The
+// addresses of the group are also recipients of the letter. A group without
+// addresses is rendered as `name:;`.
+func ToGroup(name string, addrs ...mail.Address) Option {
+	return func(l *Letter) error {
+		l.L.ToGroups = append(l.L.ToGroups, Group{Name: name, Addresses: addrs})
+		return nil
+	}
+}
+
+// CCGroup adds an address group to the `Cc` header of the letter. The
+// addresses of the group are also recipients of the letter.
+func CCGroup(name string, addrs ...mail.Address) Option {
+	return func(l *Letter) error {
+		l.L.CCGroups = append(l.L.CCGroups, Group{Name: name, Addresses: addrs})
+		return nil
+	}
+}
+
 // BCC adds a `Bcc` recipient to the letter.
 func BCC(name, addr string) Option {
 	return BCCAddress(mail.Address{Name: name, Address: addr})
@@ -203,6 +306,71 @@ func ReplyToAddress(addrs ...mail.Address) Option {
 	}
 }
 
+// AutoSubmitted sets the `Auto-Submitted` header (RFC 3834) of the letter,
+// so that auto-responders (e.g. out-of-office replies) don't reply to it.
+// Use the AutoGenerated and AutoReplied constants for v.
+func AutoSubmitted(v string) Option {
+	return func(l *Letter) error {
+		l.L.AutoSubmitted = v
+		return nil
+	}
+}
+
+// Precedence sets the `Precedence` header of the letter. The header is not
+// standardized, but mail servers and auto-responders conventionally don't
+// reply to mails with the values "bulk", "list" or "junk" (see RFC 3834,
+// section 2). Use the PrecedenceBulk, PrecedenceList and PrecedenceJunk
+// constants for v.
+func Precedence(v string) Option {
+	return func(l *Letter) error {
+		l.L.Precedence = v
+		return nil
+	}
+}
+
+// Organization sets the `Organization` header of the letter, which names the
+// organization to which the sender belongs (see RFC 4021, section 2.1.15).
+func Organization(name string) Option {
+	return func(l *Letter) error {
+		l.L.Organization = name
+		return nil
+	}
+}
+
+// ReturnPath sets the bounce address of the letter, which is emitted as the
+// `Return-Path` header. Note that many MTAs set the `Return-Path` header
+// themselves from the envelope sender. Transports that support it (e.g. SMTP)
+// use addr as the envelope sender (`MAIL FROM`) of the letter.
+func ReturnPath(addr string) Option {
+	return func(l *Letter) error {
+		l.L.ReturnPath = addr
+		return nil
+	}
+}
+
+// Header sets the header key of the letter to value, e.g. `X-Request-ID`. An
+// existing header with the same key is replaced, including headers that are
+// generated from the other fields of the letter, e.g. `Subject`.
+func Header(key, value string) Option {
+	return func(l *Letter) error {
+		*l = l.WithHeader(key, value)
+		return nil
+	}
+}
+
+// AllowDuplicateRecipients returns an Option that disables the deduplication
+// of recipients across the `To`, `Cc` and `Bcc` fields.
+//
+// By default, an address that appears in `To` is removed from `Cc` and `Bcc`,
+// and an address that appears in `Cc` is removed from `Bcc`, so that a
+// recipient doesn't receive the same mail multiple times.
+func AllowDuplicateRecipients() Option {
+	return func(l *Letter) error {
+		l.allowDuplicateRecipients = true
+		return nil
+	}
+}
+
 // Text sets the text content of the letter.
 func Text(s string) Option {
 	return func(l *Letter) error {
@@ -228,6 +396,42 @@ func Content(text, html string) Option {
 	}
 }
 
+// AlternativePart adds an additional alternative representation of the
+// content to the letter, e.g. an AMP part:
+//	letter.AlternativePart("text/x-amp-html", []byte(amp))
+// Alternatives are placed in the `multipart/alternative` block in the order
+// text, HTML, alternatives. Use the BeforeHTML() option to place an
+// alternative before the HTML part instead.
+func AlternativePart(contentType string, content []byte, opts ...AlternativeOption) Option {
+	return func(l *Letter) error {
+		alt := Alternative{ContentType: contentType, Content: content}
+		for _, opt := range opts {
+			opt(&alt)
+		}
+		l.L.Alternatives = append(l.L.Alternatives, alt)
+		return nil
+	}
+}
+
+// BeforeHTML returns an AlternativeOption that places the alternative between
+// the text and the HTML part.
+func BeforeHTML() AlternativeOption {
+	return func(alt *Alternative) {
+		alt.BeforeHTML = true
+	}
+}
+
+// AlternativeTypeParams returns an AlternativeOption that adds the MIME
+// parameters params to the `Content-Type` of the alternative, e.g.:
+//	letter.AlternativePart("text/calendar", ics, letter.AlternativeTypeParams(map[string]string{"method": "REQUEST"}))
+// The parameters are formatted with rfc.FormatContentType(). Text
+// alternatives without a `charset` parameter are declared as UTF-8.
+func AlternativeTypeParams(params map[string]string) AlternativeOption {
+	return func(alt *Alternative) {
+		alt.ContentType = rfc.FormatContentType(alt.ContentType, params)
+	}
+}
+
 // RFC returns an Option that
 func RFC(body string) Option {
 	return func(l *Letter) error {
@@ -239,7 +443,11 @@ func RFC(body string) Option {
 // Attach adds a file attachment to the letter.
 func Attach(filename string, content []byte, opts ...AttachmentOption) Option {
 	return func(l *Letter) error {
-		l.L.Attachments = append(l.L.Attachments, NewAttachment(filename, content, opts...))
+		at := NewAttachment(filename, content, opts...)
+		if err := rfc.ValidateEncoding(at.Header().Get("Content-Transfer-Encoding"), at.Content()); err != nil {
+			return fmt.Errorf("attachment %s: %w", filename, err)
+		}
+		l.L.Attachments = append(l.L.Attachments, at)
 		return nil
 	}
 }
@@ -278,6 +486,66 @@ func AttachFile(filename, path string, opts ...AttachmentOption) Option {
 	}
 }
 
+// AttachWithHeader adds a file attachment with the MIME header header to the
+// letter, e.g. to forward an attachment of another mail with its original
+// `Content-ID`. Unlike Attach(), the header is used as-is instead of being
+// generated, so opts can't modify the header. They can still be used to set
+// the size of the attachment with AttachmentSize().
+//
+// header must contain a `Content-Type`, otherwise AttachWithHeader returns an
+// error that unwraps to ErrMissingHeader. If header has no
+// `Content-Transfer-Encoding`, "base64" is used. The `Content-Disposition`
+// and `Content-ID` are generated when the RFC body is built if header doesn't
+// contain them.
+func AttachWithHeader(filename string, content []byte, header textproto.MIMEHeader, opts ...AttachmentOption) Option {
+	return func(l *Letter) error {
+		at, err := NewAttachmentWithHeader(filename, content, header, opts...)
+		if err != nil {
+			return fmt.Errorf("attachment %s: %w", filename, err)
+		}
+		l.L.Attachments = append(l.L.Attachments, at)
+		return nil
+	}
+}
+
+// NewAttachmentWithHeader creates an Attachment from the given filename,
+// content and MIME header. See AttachWithHeader().
+func NewAttachmentWithHeader(filename string, content []byte, header textproto.MIMEHeader, opts ...AttachmentOption) (Attachment, error) {
+	ct := header.Get("Content-Type")
+	if ct == "" {
+		return Attachment{}, fmt.Errorf("%w: Content-Type", ErrMissingHeader)
+	}
+
+	at := Attachment{
+		A{
+			Filename: filename,
+			Content:  content,
+			Header:   make(textproto.MIMEHeader),
+		},
+	}
+
+	for _, opt := range opts {
+		opt(&at)
+	}
+
+	for key, vals := range header {
+		at.A.Header[textproto.CanonicalMIMEHeaderKey(key)] = append([]string(nil), vals...)
+	}
+
+	at.A.ContentType = ct
+	if mediaType, _, err := mime.ParseMediaType(ct); err == nil {
+		at.A.ContentType = mediaType
+	}
+
+	at.resolveEncoding()
+
+	if err := rfc.ValidateEncoding(at.A.Header.Get("Content-Transfer-Encoding"), content); err != nil {
+		return Attachment{}, err
+	}
+
+	return at, nil
+}
+
 // AttachmentType sets the `Content-Type` of the attachment.
 func AttachmentType(ct string) AttachmentOption {
 	return func(at *Attachment) {
@@ -285,6 +553,56 @@ func AttachmentType(ct string) AttachmentOption {
 	}
 }
 
+// AttachmentTypeParams returns an AttachmentOption that sets the
+// `Content-Type` of the attachment to ct with the MIME parameters params, e.g.:
+//	letter.AttachmentTypeParams("text/calendar", map[string]string{"method": "REQUEST"})
+// The parameters are formatted with rfc.FormatContentType(). The `name`
+// parameter is always set to the filename of the attachment.
+func AttachmentTypeParams(ct string, params map[string]string) AttachmentOption {
+	return func(at *Attachment) {
+		filtered := make(map[string]string, len(params))
+		for k, v := range params {
+			if !strings.EqualFold(k, "name") {
+				filtered[k] = v
+			}
+		}
+		at.A.ContentType = rfc.FormatContentType(ct, filtered)
+	}
+}
+
+// AttachmentEncoding returns an AttachmentOption that sets the
+// `Content-Transfer-Encoding` of the attachment. Supported encodings are
+// "base64" (default), "quoted-printable", "7bit" and "8bit". Attach() returns
+// an error that unwraps to rfc.ErrInvalidEncoding if the encoding is unknown
+// or cannot be used for the attachment content (e.g. "7bit" for non-ASCII
+// content).
+func AttachmentEncoding(enc string) AttachmentOption {
+	return func(at *Attachment) {
+		at.A.Header.Set("Content-Transfer-Encoding", strings.ToLower(enc))
+	}
+}
+
+// AttachmentAutoEncoding returns an AttachmentOption that enables or
+// disables the automatic selection of the `Content-Transfer-Encoding` of the
+// attachment. If enabled, text attachments (`text/*`) are encoded with "7bit"
+// if their content is ASCII without NUL bytes and overlong lines, and with
+// "quoted-printable" otherwise, which is more readable and smaller than
+// base64. All other attachments are encoded with "base64". An encoding that
+// is set with AttachmentEncoding() or by the header of AttachWithHeader()
+// takes precedence. The automatic selection is disabled by default.
+func AttachmentAutoEncoding(auto bool) AttachmentOption {
+	return func(at *Attachment) {
+		// the encoding is selected when the attachment is created, after the
+		// content type is known
+		switch enc := at.A.Header.Get("Content-Transfer-Encoding"); {
+		case auto && enc == "":
+			at.A.Header.Set("Content-Transfer-Encoding", autoEncoding)
+		case !auto && enc == autoEncoding:
+			at.A.Header.Del("Content-Transfer-Encoding")
+		}
+	}
+}
+
 // AttachmentSize returns an AttachmentOption that explicitly sets / overrides it's size.
 func AttachmentSize(s int) AttachmentOption {
 	return func(at *Attachment) {
@@ -322,11 +640,40 @@ func NewAttachment(filename string, content []byte, opts ...AttachmentOption) At
 	at.A.Header.Set("Content-Type", fmt.Sprintf(`%s; name="%s"`, at.A.ContentType, filename8))
 	at.A.Header.Set("Content-ID", fmt.Sprintf("<%s_%s>", fmt.Sprintf("%x", sha1.Sum(at.Content()))[:12], filenameASCII))
 	at.A.Header.Set("Content-Disposition", fmt.Sprintf(`attachment; size=%d; filename="%s"`, at.Size(), filename8))
-	at.A.Header.Set("Content-Transfer-Encoding", "base64")
+	at.resolveEncoding()
 
 	return at
 }
 
+// resolveEncoding sets the `Content-Transfer-Encoding` of an attachment that
+// has no explicit encoding. See AttachmentAutoEncoding().
+func (at *Attachment) resolveEncoding() {
+	switch at.A.Header.Get("Content-Transfer-Encoding") {
+	case "":
+		at.A.Header.Set("Content-Transfer-Encoding", rfc.Base64)
+	case autoEncoding:
+		at.A.Header.Set("Content-Transfer-Encoding", at.selectEncoding())
+	}
+}
+
+// selectEncoding returns the `Content-Transfer-Encoding` that is selected by
+// AttachmentAutoEncoding() for the attachment.
+func (at Attachment) selectEncoding() string {
+	mediaType := at.A.ContentType
+	if mt, _, err := mime.ParseMediaType(mediaType); err == nil {
+		mediaType = mt
+	}
+	if !strings.HasPrefix(strings.ToLower(mediaType), "text/") {
+		return rfc.Base64
+	}
+
+	if rfc.ValidateEncoding(rfc.SevenBit, at.A.Content) == nil {
+		return rfc.SevenBit
+	}
+
+	return rfc.QuotedPrintable
+}
+
 // Expand converts the postdog.Mail pm to a Letter.
 //
 // Add additional information
@@ -341,11 +688,40 @@ func NewAttachment(filename string, content []byte, opts ...AttachmentOption) At
 //
 // If pm implements an RFCConfig() method, it will be used to add an rfc.Config
 // to the Letter.
+//
+// If pm implements an Unwrap() method (e.g. a Mail returned by
+// postdog.WithFrom() or postdog.WithSubject()), the unwrapped Mail is expanded
+// instead and the sender and subject of pm are applied to the returned Letter.
+// If pm sets a header (see postdog.WithHeader()), the header is added to the
+// Letter (see Header()). If pm implements a Header() textproto.MIMEHeader
+// method, the headers are added to the Letter, too. If pm has a frozen RFC
+// body (see postdog.FreezeRFC()), the returned Letter has the same RFC body.
+//
+// Expand doesn't preserve the RFC body of mails that have no optional content
+// methods, e.g. mails returned by postdog.RawMail(). Use AsLetter() to
+// preserve it.
 func Expand(pm postdog.Mail) Letter {
 	if l, ok := pm.(Letter); ok {
 		return l
 	}
 
+	if wm, ok := pm.(interface{ Unwrap() postdog.Mail }); ok {
+		l := Expand(wm.Unwrap()).WithFromAddress(pm.From())
+		if sMail, ok := pm.(interface{ Subject() string }); ok {
+			l = l.WithSubject(sMail.Subject())
+		}
+		if fMail, ok := pm.(interface{ FrozenRFC() string }); ok {
+			l.L.RFC = fMail.FrozenRFC()
+		} else if l.L.RFC != "" {
+			// the custom RFC body can't be rebuilt, so the modifications of
+			// pm are applied to it
+			l.L.RFC = pm.RFC()
+		} else if hMail, ok := pm.(interface{ RFCHeader() (string, string) }); ok {
+			l = l.WithHeader(hMail.RFCHeader())
+		}
+		return l
+	}
+
 	letterOpts := []Option{
 		FromAddress(pm.From()),
 		RecipientAddress(pm.Recipients()...),
@@ -371,6 +747,22 @@ func Expand(pm postdog.Mail) Letter {
 		letterOpts = append(letterOpts, Subject(sMail.Subject()))
 	}
 
+	if asMail, ok := pm.(interface{ AutoSubmitted() string }); ok {
+		letterOpts = append(letterOpts, AutoSubmitted(asMail.AutoSubmitted()))
+	}
+
+	if pMail, ok := pm.(interface{ Precedence() string }); ok {
+		letterOpts = append(letterOpts, Precedence(pMail.Precedence()))
+	}
+
+	if oMail, ok := pm.(interface{ Organization() string }); ok {
+		letterOpts = append(letterOpts, Organization(oMail.Organization()))
+	}
+
+	if rpMail, ok := pm.(interface{ ReturnPath() string }); ok {
+		letterOpts = append(letterOpts, ReturnPath(rpMail.ReturnPath()))
+	}
+
 	if textMail, ok := pm.(interface{ Text() string }); ok {
 		letterOpts = append(letterOpts, Text(textMail.Text()))
 	}
@@ -391,6 +783,13 @@ func Expand(pm postdog.Mail) Letter {
 		l = l.WithRFCConfig(rfcm.RFCConfig())
 	}
 
+	if hMail, ok := pm.(interface{ Header() textproto.MIMEHeader }); ok && len(hMail.Header()) > 0 {
+		l.header = make(textproto.MIMEHeader, len(hMail.Header()))
+		for key, vals := range hMail.Header() {
+			l.header[key] = append([]string(nil), vals...)
+		}
+	}
+
 	return l
 }
 
@@ -432,6 +831,28 @@ func (l Letter) WithTo(addrs ...mail.Address) Letter {
 	return l
 }
 
+// ToGroups returns the address groups in the `To` header of the letter.
+func (l Letter) ToGroups() []Group {
+	return l.L.ToGroups
+}
+
+// WithToGroups returns a copy of l with groups as it's `To` address groups.
+func (l Letter) WithToGroups(groups ...Group) Letter {
+	l.L.ToGroups = groups
+	return l
+}
+
+// CCGroups returns the address groups in the `Cc` header of the letter.
+func (l Letter) CCGroups() []Group {
+	return l.L.CCGroups
+}
+
+// WithCCGroups returns a copy of l with groups as it's `Cc` address groups.
+func (l Letter) WithCCGroups(groups ...Group) Letter {
+	l.L.CCGroups = groups
+	return l
+}
+
 // CC returns the `Cc` recipients of the letter.
 func (l Letter) CC() []mail.Address {
 	return l.L.CC
@@ -465,9 +886,78 @@ func (l Letter) WithReplyTo(addrs ...mail.Address) Letter {
 	return l
 }
 
-// Recipients returns all recipients of the letter.
+// AutoSubmitted returns the value of the `Auto-Submitted` header of the letter.
+func (l Letter) AutoSubmitted() string {
+	return l.L.AutoSubmitted
+}
+
+// WithAutoSubmitted returns a copy of l with it's `Auto-Submitted` header set to v.
+func (l Letter) WithAutoSubmitted(v string) Letter {
+	l.L.AutoSubmitted = v
+	return l
+}
+
+// Precedence returns the value of the `Precedence` header of the letter.
+func (l Letter) Precedence() string {
+	return l.L.Precedence
+}
+
+// WithPrecedence returns a copy of l with it's `Precedence` header set to v.
+func (l Letter) WithPrecedence(v string) Letter {
+	l.L.Precedence = v
+	return l
+}
+
+// Organization returns the value of the `Organization` header of the letter.
+func (l Letter) Organization() string {
+	return l.L.Organization
+}
+
+// WithOrganization returns a copy of l with it's `Organization` header set to
+// name.
+func (l Letter) WithOrganization(name string) Letter {
+	l.L.Organization = name
+	return l
+}
+
+// ReturnPath returns the bounce address of the letter.
+func (l Letter) ReturnPath() string {
+	return l.L.ReturnPath
+}
+
+// WithReturnPath returns a copy of l with it's bounce address set to addr.
+func (l Letter) WithReturnPath(addr string) Letter {
+	l.L.ReturnPath = addr
+	return l
+}
+
+// Header returns the additional headers of the letter (see Header()). The
+// returned header must not be modified. Its keys are not canonicalized (see
+// WithHeader()).
+func (l Letter) Header() textproto.MIMEHeader {
+	return l.header
+}
+
+// WithHeader returns a copy of l with it's header key set to value. The key
+// is used as-is instead of being canonicalized, e.g. `X-Request-ID` stays
+// `X-Request-ID`. l itself is not modified.
+func (l Letter) WithHeader(key, value string) Letter {
+	header := make(textproto.MIMEHeader, len(l.header)+1)
+	for k, v := range l.header {
+		if !strings.EqualFold(k, key) {
+			header[k] = v
+		}
+	}
+	header[key] = []string{value}
+	l.header = header
+	return l
+}
+
+// Recipients returns all recipients of the letter, including the addresses
+// of address groups.
 func (l Letter) Recipients() []mail.Address {
-	count := len(l.L.Recipients) + len(l.L.To) + len(l.L.CC) + len(l.L.BCC)
+	groupAddrs := groupAddresses(l.L.ToGroups, l.L.CCGroups)
+	count := len(l.L.Recipients) + len(l.L.To) + len(l.L.CC) + len(l.L.BCC) + len(groupAddrs)
 	if count == 0 {
 		return nil
 	}
@@ -476,6 +966,11 @@ func (l Letter) Recipients() []mail.Address {
 	rcpts = append(rcpts, l.L.To...)
 	rcpts = append(rcpts, l.L.CC...)
 	rcpts = append(rcpts, l.L.BCC...)
+	for _, addr := range groupAddrs {
+		if !containsAddress(rcpts, addr) {
+			rcpts = append(rcpts, addr)
+		}
+	}
 	return rcpts
 }
 
@@ -518,6 +1013,17 @@ func (l Letter) WithContent(text, html string) Letter {
 	return l.WithText(text).WithHTML(html)
 }
 
+// Alternatives returns the additional alternative parts of the letter.
+func (l Letter) Alternatives() []Alternative {
+	return l.L.Alternatives
+}
+
+// WithAlternatives returns a copy of l with alts as it's alternative parts.
+func (l Letter) WithAlternatives(alts ...Alternative) Letter {
+	l.L.Alternatives = alts
+	return l
+}
+
 // Attachments returns the attachments of the letter.
 func (l Letter) Attachments() []Attachment {
 	return l.L.Attachments
@@ -554,17 +1060,55 @@ func (l Letter) RFC() string {
 	if l.L.RFC != "" {
 		return l.L.RFC
 	}
-	return rfc.BuildConfig(rfc.Mail{
-		Subject:     l.Subject(),
-		From:        l.From(),
-		To:          l.To(),
-		CC:          l.CC(),
-		BCC:         l.BCC(),
-		ReplyTo:     l.ReplyTo(),
-		Text:        l.Text(),
-		HTML:        l.HTML(),
-		Attachments: rfcAttachments(l.Attachments()),
-	}, l.rfcConfig)
+	return rfc.BuildConfig(l.rfcMail(), l.rfcConfig)
+}
+
+// TryRFC does the same as RFC() but also returns the error of a text part that
+// could not be transcoded to the charset of the RFC config (see
+// rfc.WithCharset()).
+func (l Letter) TryRFC() (string, error) {
+	if l.L.RFC != "" {
+		return l.L.RFC, nil
+	}
+	return rfc.TryBuildConfig(l.rfcMail(), l.rfcConfig)
+}
+
+// Structure returns the MIME structure of the RFC body that RFC() builds for
+// l. A custom RFC body (see WithRFC()) is ignored. See rfc.Inspect().
+func (l Letter) Structure() rfc.Structure {
+	return rfc.InspectConfig(l.rfcMail(), l.rfcConfig)
+}
+
+// EstimatedSize returns the approximate size in bytes of the RFC body of l,
+// without building it. If l has a custom RFC body (see WithRFC()), the size of
+// that body is returned. See rfc.EstimateSize().
+func (l Letter) EstimatedSize() int64 {
+	if l.L.RFC != "" {
+		return int64(len(l.L.RFC))
+	}
+	return rfc.EstimateSize(l.rfcMail())
+}
+
+func (l Letter) rfcMail() rfc.Mail {
+	return rfc.Mail{
+		Subject:       l.Subject(),
+		From:          l.From(),
+		To:            l.To(),
+		ToGroups:      rfcGroups(l.ToGroups()),
+		CC:            l.CC(),
+		CCGroups:      rfcGroups(l.CCGroups()),
+		BCC:           l.BCC(),
+		ReplyTo:       l.ReplyTo(),
+		AutoSubmitted: l.AutoSubmitted(),
+		Precedence:    l.Precedence(),
+		Organization:  l.Organization(),
+		ReturnPath:    l.ReturnPath(),
+		Text:          l.Text(),
+		HTML:          l.HTML(),
+		Alternatives:  rfcParts(l.Alternatives()),
+		Attachments:   rfcAttachments(l.Attachments()),
+		Header:        l.header,
+	}
 }
 
 // WithRFC returns a copy of l with it's rfc body replaced by rfc.
@@ -585,23 +1129,38 @@ func (l Letter) Map(opts ...mapper.Option) map[string]interface{} {
 		attachments[i] = at.Map(opts...)
 	}
 
+	alternatives := make([]interface{}, len(l.L.Alternatives))
+	for i, alt := range l.L.Alternatives {
+		alternatives[i] = alt.Map()
+	}
+
 	var rfc string
 	if l.L.RFC != "" {
 		rfc = l.L.RFC
 	}
 
 	return map[string]interface{}{
-		"from":        mapAddress(l.From()),
-		"recipients":  mapAddresses(l.Recipients()...),
-		"to":          mapAddresses(l.To()...),
-		"cc":          mapAddresses(l.CC()...),
-		"bcc":         mapAddresses(l.BCC()...),
-		"replyTo":     mapAddresses(l.ReplyTo()...),
-		"subject":     l.Subject(),
-		"text":        l.Text(),
-		"html":        l.HTML(),
-		"rfc":         rfc,
-		"attachments": attachments,
+		"from":          mapAddress(l.From()),
+		"recipients":    mapAddresses(l.Recipients()...),
+		"to":            mapAddresses(l.To()...),
+		"toGroups":      mapGroups(l.ToGroups()),
+		"cc":            mapAddresses(l.CC()...),
+		"ccGroups":      mapGroups(l.CCGroups()),
+		"bcc":           mapAddresses(l.BCC()...),
+		"replyTo":       mapAddresses(l.ReplyTo()...),
+		"autoSubmitted": l.AutoSubmitted(),
+		"precedence":    l.Precedence(),
+		"organization":  l.Organization(),
+		"returnPath":    l.ReturnPath(),
+		"subject":       l.Subject(),
+		"text":          l.Text(),
+		"html":          l.HTML(),
+		"rfc":           rfc,
+		"alternatives":  alternatives,
+		"attachments":   attachments,
+		"header":        headerToMap(l.header),
+
+		"allowDuplicateRecipients": l.allowDuplicateRecipients,
 	}
 }
 
@@ -619,10 +1178,18 @@ func (l *Letter) Parse(m map[string]interface{}) {
 		l.L.To = parseIFaceAddresses(to...)
 	}
 
+	if toGroups, ok := m["toGroups"].([]interface{}); ok && len(toGroups) > 0 {
+		l.L.ToGroups = parseGroups(toGroups)
+	}
+
 	if cc, ok := m["cc"].([]interface{}); ok && len(cc) > 0 {
 		l.L.CC = parseIFaceAddresses(cc...)
 	}
 
+	if ccGroups, ok := m["ccGroups"].([]interface{}); ok && len(ccGroups) > 0 {
+		l.L.CCGroups = parseGroups(ccGroups)
+	}
+
 	if bcc, ok := m["bcc"].([]interface{}); ok && len(bcc) > 0 {
 		l.L.BCC = parseIFaceAddresses(bcc...)
 	}
@@ -631,6 +1198,22 @@ func (l *Letter) Parse(m map[string]interface{}) {
 		l.L.ReplyTo = parseIFaceAddresses(replyTo...)
 	}
 
+	if autoSubmitted, ok := m["autoSubmitted"].(string); ok && len(autoSubmitted) > 0 {
+		l.L.AutoSubmitted = autoSubmitted
+	}
+
+	if precedence, ok := m["precedence"].(string); ok && len(precedence) > 0 {
+		l.L.Precedence = precedence
+	}
+
+	if organization, ok := m["organization"].(string); ok && len(organization) > 0 {
+		l.L.Organization = organization
+	}
+
+	if returnPath, ok := m["returnPath"].(string); ok && len(returnPath) > 0 {
+		l.L.ReturnPath = returnPath
+	}
+
 	if subject, ok := m["subject"].(string); ok && len(subject) > 0 {
 		l.L.Subject = subject
 	}
@@ -647,6 +1230,26 @@ func (l *Letter) Parse(m map[string]interface{}) {
 		l.L.RFC = rfc
 	}
 
+	if header, ok := m["header"].(map[string]interface{}); ok && len(header) > 0 {
+		l.header = mapToHeader(header)
+	}
+
+	if allow, ok := m["allowDuplicateRecipients"].(bool); ok {
+		l.allowDuplicateRecipients = allow
+	}
+
+	if alternatives, ok := m["alternatives"].([]interface{}); ok && len(alternatives) > 0 {
+		alts := make([]Alternative, 0, len(alternatives))
+		for _, v := range alternatives {
+			if m, ok := v.(map[string]interface{}); ok {
+				var alt Alternative
+				alt.Parse(m)
+				alts = append(alts, alt)
+			}
+		}
+		l.L.Alternatives = alts
+	}
+
 	if attachments, ok := m["attachments"].([]interface{}); ok && len(attachments) > 0 {
 		ats := make([]Attachment, 0, len(attachments))
 		for _, v := range attachments {
@@ -671,6 +1274,12 @@ func (l *Letter) normalizeRecipients() {
 	l.removeRecipients(l.L.To)
 	l.removeRecipients(l.L.CC)
 	l.removeRecipients(l.L.BCC)
+	l.removeRecipients(groupAddresses(l.L.ToGroups, l.L.CCGroups))
+
+	if !l.allowDuplicateRecipients {
+		l.L.CC = withoutAddresses(l.L.CC, l.L.To)
+		l.L.BCC = withoutAddresses(l.L.BCC, l.L.To, l.L.CC)
+	}
 }
 
 func (l *Letter) removeRecipients(addrs []mail.Address) {
@@ -771,6 +1380,32 @@ func (at *Attachment) Parse(m map[string]interface{}) {
 	at.normalize()
 }
 
+// Map maps alt to a map[string]interface{}.
+func (alt Alternative) Map() map[string]interface{} {
+	return map[string]interface{}{
+		"contentType": alt.ContentType,
+		"content":     base64.StdEncoding.EncodeToString(alt.Content),
+		"beforeHtml":  alt.BeforeHTML,
+	}
+}
+
+// Parse parses the map m and applies the values to alt.
+func (alt *Alternative) Parse(m map[string]interface{}) {
+	if contentType, ok := m["contentType"].(string); ok {
+		alt.ContentType = contentType
+	}
+
+	if content, ok := m["content"].(string); ok {
+		if b, err := base64.StdEncoding.DecodeString(content); err == nil {
+			alt.Content = b
+		}
+	}
+
+	if beforeHTML, ok := m["beforeHtml"].(bool); ok {
+		alt.BeforeHTML = beforeHTML
+	}
+}
+
 func (at *Attachment) normalize() {
 	if at.A.Size != 0 && at.A.Size == len(at.A.Content) {
 		at.A.Size = 0
@@ -841,6 +1476,70 @@ func getAttachments(m postdog.Mail) []Attachment {
 	return result
 }
 
+func rfcParts(alts []Alternative) []rfc.Part {
+	if len(alts) == 0 {
+		return nil
+	}
+	res := make([]rfc.Part, len(alts))
+	for i, alt := range alts {
+		res[i] = rfc.Part{
+			ContentType: alt.ContentType,
+			Content:     alt.Content,
+			BeforeHTML:  alt.BeforeHTML,
+		}
+	}
+	return res
+}
+
+func rfcGroups(groups []Group) []rfc.Group {
+	if len(groups) == 0 {
+		return nil
+	}
+	res := make([]rfc.Group, len(groups))
+	for i, g := range groups {
+		res[i] = rfc.Group{Name: g.Name, Addresses: g.Addresses}
+	}
+	return res
+}
+
+func groupAddresses(groups ...[]Group) []mail.Address {
+	var addrs []mail.Address
+	for _, gs := range groups {
+		for _, g := range gs {
+			addrs = append(addrs, g.Addresses...)
+		}
+	}
+	return addrs
+}
+
+func mapGroups(groups []Group) []interface{} {
+	res := make([]interface{}, len(groups))
+	for i, g := range groups {
+		res[i] = map[string]interface{}{
+			"name":      g.Name,
+			"addresses": mapAddresses(g.Addresses...),
+		}
+	}
+	return res
+}
+
+func parseGroups(vals []interface{}) []Group {
+	groups := make([]Group, 0, len(vals))
+	for _, v := range vals {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var g Group
+		g.Name, _ = m["name"].(string)
+		if addrs, ok := m["addresses"].([]interface{}); ok && len(addrs) > 0 {
+			g.Addresses = parseIFaceAddresses(addrs...)
+		}
+		groups = append(groups, g)
+	}
+	return groups
+}
+
 func rfcAttachments(ats []Attachment) []rfc.Attachment {
 	res := make([]rfc.Attachment, len(ats))
 	for i, at := range ats {
@@ -862,6 +1561,42 @@ func containsAddress(addrs []mail.Address, addr mail.Address) bool {
 	return false
 }
 
+// withoutAddresses returns addrs without the addresses that are contained in
+// any of remove. Addresses are compared case-insensitively by their address
+// part only. addrs is never modified in place.
+func withoutAddresses(addrs []mail.Address, remove ...[]mail.Address) []mail.Address {
+	var res []mail.Address
+	for i, addr := range addrs {
+		if !containsAddressPart(addr.Address, remove...) {
+			if res != nil {
+				res = append(res, addr)
+			}
+			continue
+		}
+		if res == nil {
+			res = append(make([]mail.Address, 0, len(addrs)), addrs[:i]...)
+		}
+	}
+	if res == nil {
+		return addrs
+	}
+	if len(res) == 0 {
+		return nil
+	}
+	return res
+}
+
+func containsAddressPart(addr string, lists ...[]mail.Address) bool {
+	for _, list := range lists {
+		for _, a := range list {
+			if strings.EqualFold(a.Address, addr) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func mapAddress(addr mail.Address) map[string]interface{} {
 	return map[string]interface{}{
 		"name":    addr.Name,
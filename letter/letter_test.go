@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"net/mail"
+	"net/textproto"
 	"strings"
 	"testing"
 	"time"
@@ -204,6 +205,69 @@ func TestWrite(t *testing.T) {
 				}, l.ReplyTo())
 			},
 		},
+		{
+			name: "ToGroup() & CCGroup()",
+			opts: []letter.Option{
+				letter.To("Linda Belcher", "linda@example.com"),
+				letter.ToGroup("Kids", mail.Address{Name: "Tina Belcher", Address: "tina@example.com"}, mail.Address{Name: "Linda Belcher", Address: "linda@example.com"}),
+				letter.CCGroup("Nobody"),
+			},
+			expect: func(t *testing.T, l letter.Letter) {
+				assert.Equal(t, []letter.Group{{Name: "Kids", Addresses: []mail.Address{
+					{Name: "Tina Belcher", Address: "tina@example.com"},
+					{Name: "Linda Belcher", Address: "linda@example.com"},
+				}}}, l.ToGroups())
+				assert.Equal(t, []letter.Group{{Name: "Nobody"}}, l.CCGroups())
+				assert.Equal(t, []mail.Address{
+					{Name: "Linda Belcher", Address: "linda@example.com"},
+					{Name: "Tina Belcher", Address: "tina@example.com"},
+				}, l.Recipients())
+
+				lines := strings.Split(l.RFC(), "\r\n")
+				assert.Contains(t, lines, `To: "Linda Belcher" <linda@example.com>,Kids:"Tina Belcher" <tina@example.com>,"Linda Belcher" <linda@example.com>;`)
+				assert.Contains(t, lines, "Cc: Nobody:;")
+			},
+		},
+		{
+			name: "AutoSubmitted()",
+			opts: []letter.Option{
+				letter.AutoSubmitted(letter.AutoGenerated),
+			},
+			expect: func(t *testing.T, l letter.Letter) {
+				assert.Equal(t, "auto-generated", l.AutoSubmitted())
+				assert.Contains(t, strings.Split(l.RFC(), "\r\n"), "Auto-Submitted: auto-generated")
+			},
+		},
+		{
+			name: "Precedence()",
+			opts: []letter.Option{
+				letter.Precedence(letter.PrecedenceBulk),
+			},
+			expect: func(t *testing.T, l letter.Letter) {
+				assert.Equal(t, "bulk", l.Precedence())
+				assert.Contains(t, strings.Split(l.RFC(), "\r\n"), "Precedence: bulk")
+			},
+		},
+		{
+			name: "Organization()",
+			opts: []letter.Option{
+				letter.Organization("Bob's Burgers"),
+			},
+			expect: func(t *testing.T, l letter.Letter) {
+				assert.Equal(t, "Bob's Burgers", l.Organization())
+				assert.Contains(t, strings.Split(l.RFC(), "\r\n"), "Organization: "+encode.UTF8("Bob's Burgers"))
+			},
+		},
+		{
+			name: "ReturnPath()",
+			opts: []letter.Option{
+				letter.ReturnPath("bounces+linda=example.com@example.com"),
+			},
+			expect: func(t *testing.T, l letter.Letter) {
+				assert.Equal(t, "bounces+linda=example.com@example.com", l.ReturnPath())
+				assert.True(t, strings.HasPrefix(l.RFC(), "Return-Path: <bounces+linda=example.com@example.com>\r\n"))
+			},
+		},
 		{
 			name: "Text()",
 			opts: []letter.Option{
@@ -400,6 +464,185 @@ func (mr mockReader) Read(b []byte) (int, error) {
 
 var mockError = errors.New("mock error")
 
+func TestAttachmentEncoding(t *testing.T) {
+	let, err := letter.TryWrite(
+		letter.Attach("attach1", []byte("Hello."), letter.AttachmentEncoding("7BIT")),
+		letter.Attach("attach2", []byte("Hello.")),
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, "7bit", let.Attachments()[0].Header().Get("Content-Transfer-Encoding"))
+	assert.Equal(t, "base64", let.Attachments()[1].Header().Get("Content-Transfer-Encoding"))
+
+	_, err = letter.TryWrite(letter.Attach("attach", []byte("Hällo."), letter.AttachmentEncoding("7bit")))
+	assert.True(t, errors.Is(err, rfc.ErrInvalidEncoding))
+
+	_, err = letter.TryWrite(letter.Attach("attach", []byte("Hello."), letter.AttachmentEncoding("binary")))
+	assert.True(t, errors.Is(err, rfc.ErrInvalidEncoding))
+}
+
+func TestAttachmentAutoEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		content  []byte
+		opts     []letter.AttachmentOption
+		expected string
+	}{
+		{
+			name:     "ascii text",
+			filename: "readme.txt",
+			content:  []byte("Hello.\nline 2"),
+			expected: rfc.SevenBit,
+		},
+		{
+			name:     "non-ascii text",
+			filename: "readme.txt",
+			content:  []byte("Hällo."),
+			expected: rfc.QuotedPrintable,
+		},
+		{
+			name:     "text with long lines",
+			filename: "data.csv",
+			content:  bytes.Repeat([]byte("a"), 1000),
+			expected: rfc.QuotedPrintable,
+		},
+		{
+			name:     "html",
+			filename: "page.html",
+			content:  []byte("<p>Hello.</p>"),
+			expected: rfc.SevenBit,
+		},
+		{
+			name:     "detected text",
+			filename: "readme",
+			content:  []byte("Hello."),
+			expected: rfc.SevenBit,
+		},
+		{
+			name:     "binary",
+			filename: "logo.png",
+			content:  []byte{0x89, 'P', 'N', 'G', 0},
+			expected: rfc.Base64,
+		},
+		{
+			name:     "pdf",
+			filename: "invoice.pdf",
+			content:  []byte("%PDF-1.4"),
+			expected: rfc.Base64,
+		},
+		{
+			name:     "explicit encoding",
+			filename: "readme.txt",
+			content:  []byte("Hello."),
+			opts:     []letter.AttachmentOption{letter.AttachmentEncoding(rfc.Base64)},
+			expected: rfc.Base64,
+		},
+		{
+			name:     "disabled",
+			filename: "readme.txt",
+			content:  []byte("Hello."),
+			opts:     []letter.AttachmentOption{letter.AttachmentAutoEncoding(false)},
+			expected: rfc.Base64,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := append([]letter.AttachmentOption{letter.AttachmentAutoEncoding(true)}, test.opts...)
+			let, err := letter.TryWrite(letter.Attach(test.filename, test.content, opts...))
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, let.Attachments()[0].Header().Get("Content-Transfer-Encoding"))
+		})
+	}
+}
+
+func TestAttachmentAutoEncoding_withHeader(t *testing.T) {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	let, err := letter.TryWrite(letter.AttachWithHeader("readme.txt", []byte("Hällo."), header, letter.AttachmentAutoEncoding(true)))
+	assert.Nil(t, err)
+	assert.Equal(t, rfc.QuotedPrintable, let.Attachments()[0].Header().Get("Content-Transfer-Encoding"))
+	assert.Contains(t, let.RFC(), "\r\n\r\nH=C3=A4llo.\r\n")
+
+	header.Set("Content-Transfer-Encoding", rfc.Base64)
+	let, err = letter.TryWrite(letter.AttachWithHeader("readme.txt", []byte("Hällo."), header, letter.AttachmentAutoEncoding(true)))
+	assert.Nil(t, err)
+	assert.Equal(t, rfc.Base64, let.Attachments()[0].Header().Get("Content-Transfer-Encoding"))
+}
+
+func TestAttachmentTypeParams(t *testing.T) {
+	params := map[string]string{"method": "REQUEST", "charset": "UTF-8", "name": "ignored"}
+	let := letter.Write(
+		letter.Text("Hello."),
+		letter.AlternativePart("text/calendar", []byte("BEGIN:VCALENDAR"), letter.AlternativeTypeParams(map[string]string{"method": "REQUEST"})),
+		letter.Attach("invite.ics", []byte("BEGIN:VCALENDAR"), letter.AttachmentTypeParams("text/calendar", params)),
+	)
+
+	at := let.Attachments()[0]
+	assert.Equal(t, "text/calendar; charset=UTF-8; method=REQUEST", at.ContentType())
+	assert.True(t, strings.HasPrefix(at.Header().Get("Content-Type"), "text/calendar; charset=UTF-8; method=REQUEST; name="))
+	assert.Equal(t, "ignored", params["name"])
+
+	body := let.RFC()
+	assert.Contains(t, body, "Content-Type: text/calendar; method=REQUEST; charset=utf-8\r\n")
+	assert.Contains(t, body, "Content-Type: "+at.Header().Get("Content-Type")+"\r\n")
+}
+
+func TestAttachWithHeader(t *testing.T) {
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Type", `image/png; name="logo.png"`)
+	header.Set("Content-Disposition", `inline; filename="logo.png"`)
+	header.Set("Content-ID", "<logo@example.com>")
+	header.Set("X-Attachment-Id", "f_1")
+
+	let, err := letter.TryWrite(
+		letter.From("Bob Belcher", "bob@example.com"),
+		letter.To("Linda Belcher", "linda@example.com"),
+		letter.AttachWithHeader("logo.png", []byte{1, 2, 3}, header),
+	)
+	assert.Nil(t, err)
+
+	at := let.Attachments()[0]
+	assert.Equal(t, "image/png", at.ContentType())
+	assert.Equal(t, 3, at.Size())
+	assert.Equal(t, "<logo@example.com>", at.Header().Get("Content-ID"))
+	assert.Equal(t, `inline; filename="logo.png"`, at.Header().Get("Content-Disposition"))
+	assert.Equal(t, "base64", at.Header().Get("Content-Transfer-Encoding"))
+
+	header.Set("Content-ID", "<changed@example.com>")
+	assert.Equal(t, "<logo@example.com>", at.Header().Get("Content-ID"))
+
+	body := let.RFC()
+	assert.Contains(t, body, "Content-ID: <logo@example.com>\r\n")
+	assert.Contains(t, body, "Content-Disposition: inline; filename=\"logo.png\"\r\n")
+	assert.Contains(t, body, "X-Attachment-Id: f_1\r\n")
+
+	_, err = letter.TryWrite(letter.AttachWithHeader("logo.png", []byte{1, 2, 3}, textproto.MIMEHeader{}))
+	assert.True(t, errors.Is(err, letter.ErrMissingHeader))
+
+	header.Set("Content-Transfer-Encoding", "7bit")
+	_, err = letter.TryWrite(letter.AttachWithHeader("logo.png", []byte("Hällo."), header))
+	assert.True(t, errors.Is(err, rfc.ErrInvalidEncoding))
+}
+
+func TestLetter_Structure(t *testing.T) {
+	let := letter.Write(
+		letter.Text("Hello."),
+		letter.HTML("<p>Hello.</p>"),
+		letter.Attach("attach1", []byte{1, 2, 3}, letter.AttachmentType("application/octet-stream")),
+	)
+
+	s := let.Structure()
+	assert.Equal(t, "multipart/mixed", s.ContentType)
+	assert.Len(t, s.Parts, 2)
+	assert.Equal(t, "multipart/alternative", s.Parts[0].ContentType)
+	assert.Len(t, s.Parts[0].Parts, 2)
+	assert.Equal(t, "attach1", s.Parts[1].Filename)
+	assert.Equal(t, 3, s.Parts[1].Size)
+	assert.Contains(t, let.RFC(), s.Boundary)
+}
+
 func TestLetter_Recipients(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -466,6 +709,79 @@ func TestLetter_Recipients(t *testing.T) {
 	}
 }
 
+func TestLetter_deduplicateRecipients(t *testing.T) {
+	bob := mail.Address{Name: "Bob Belcher", Address: "bob@example.com"}
+	linda := mail.Address{Name: "Linda Belcher", Address: "linda@example.com"}
+	tina := mail.Address{Name: "Tina Belcher", Address: "tina@example.com"}
+
+	tests := []struct {
+		name    string
+		opts    []letter.Option
+		wantTo  []mail.Address
+		wantCC  []mail.Address
+		wantBCC []mail.Address
+	}{
+		{
+			name: "To & CC overlap",
+			opts: []letter.Option{
+				letter.ToAddress(bob),
+				letter.CCAddress(bob, linda),
+			},
+			wantTo: []mail.Address{bob},
+			wantCC: []mail.Address{linda},
+		},
+		{
+			name: "To & BCC overlap",
+			opts: []letter.Option{
+				letter.ToAddress(bob),
+				letter.BCCAddress(bob),
+			},
+			wantTo: []mail.Address{bob},
+		},
+		{
+			name: "CC & BCC overlap",
+			opts: []letter.Option{
+				letter.ToAddress(bob),
+				letter.CCAddress(linda),
+				letter.BCCAddress(linda, tina),
+			},
+			wantTo:  []mail.Address{bob},
+			wantCC:  []mail.Address{linda},
+			wantBCC: []mail.Address{tina},
+		},
+		{
+			name: "overlap with different name & case",
+			opts: []letter.Option{
+				letter.ToAddress(bob),
+				letter.CC("Bob", "BOB@example.com"),
+			},
+			wantTo: []mail.Address{bob},
+		},
+		{
+			name: "AllowDuplicateRecipients()",
+			opts: []letter.Option{
+				letter.ToAddress(bob),
+				letter.CCAddress(bob),
+				letter.BCCAddress(bob),
+				letter.AllowDuplicateRecipients(),
+			},
+			wantTo:  []mail.Address{bob},
+			wantCC:  []mail.Address{bob},
+			wantBCC: []mail.Address{bob},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			let, err := letter.TryWrite(test.opts...)
+			assert.Nil(t, err)
+			assert.Equal(t, test.wantTo, let.To())
+			assert.Equal(t, test.wantCC, let.CC())
+			assert.Equal(t, test.wantBCC, let.BCC())
+		})
+	}
+}
+
 func TestLetter_WithSubject(t *testing.T) {
 	assert.Equal(t, "foo", letter.Write().WithSubject("foo").Subject())
 }
@@ -548,6 +864,77 @@ func TestLetter_WithReplyTo(t *testing.T) {
 	assert.Equal(t, addrs, letter.Write().WithReplyTo(addrs...).ReplyTo())
 }
 
+func TestLetter_groups_map(t *testing.T) {
+	l := letter.Write(
+		letter.ToGroup("Kids", mail.Address{Name: "Tina Belcher", Address: "tina@example.com"}),
+		letter.CCGroup("Nobody"),
+	)
+
+	var parsed letter.Letter
+	parsed.Parse(l.Map())
+	assert.Equal(t, l.ToGroups(), parsed.ToGroups())
+	assert.Equal(t, l.CCGroups(), parsed.CCGroups())
+}
+
+func TestLetter_WithAutoSubmitted(t *testing.T) {
+	assert.Equal(t, letter.AutoReplied, letter.Write().WithAutoSubmitted(letter.AutoReplied).AutoSubmitted())
+}
+
+func TestLetter_AutoSubmitted_map(t *testing.T) {
+	l := letter.Write(letter.AutoSubmitted(letter.AutoGenerated))
+
+	var parsed letter.Letter
+	parsed.Parse(l.Map())
+	assert.Equal(t, letter.AutoGenerated, parsed.AutoSubmitted())
+}
+
+func TestLetter_WithPrecedence(t *testing.T) {
+	assert.Equal(t, letter.PrecedenceList, letter.Write().WithPrecedence(letter.PrecedenceList).Precedence())
+}
+
+func TestLetter_Precedence_map(t *testing.T) {
+	l := letter.Write(letter.Precedence(letter.PrecedenceJunk))
+
+	var parsed letter.Letter
+	parsed.Parse(l.Map())
+	assert.Equal(t, letter.PrecedenceJunk, parsed.Precedence())
+}
+
+func TestLetter_Organization_map(t *testing.T) {
+	l := letter.Write(letter.Organization("Bob's Burgers"))
+
+	var parsed letter.Letter
+	parsed.Parse(l.Map())
+	assert.Equal(t, "Bob's Burgers", parsed.Organization())
+	assert.Equal(t, "Bob's Burgers", l.WithOrganization("").WithOrganization("Bob's Burgers").Organization())
+}
+
+func TestLetter_WithReturnPath(t *testing.T) {
+	l := letter.Write().WithReturnPath("bounces@example.com")
+	assert.Equal(t, "bounces@example.com", l.ReturnPath())
+
+	var parsed letter.Letter
+	parsed.Parse(l.Map())
+	assert.Equal(t, "bounces@example.com", parsed.ReturnPath())
+}
+
+func TestLetter_WithHeader(t *testing.T) {
+	l := letter.Write(letter.Header("X-Request-ID", "foo"), letter.Text("Hello."))
+	assert.Equal(t, textproto.MIMEHeader{"X-Request-ID": {"foo"}}, l.Header())
+	assert.Contains(t, l.RFC(), "\r\nX-Request-ID: foo\r\n")
+
+	replaced := l.WithHeader("x-request-id", "bar")
+	assert.Equal(t, textproto.MIMEHeader{"x-request-id": {"bar"}}, replaced.Header())
+	assert.Equal(t, textproto.MIMEHeader{"X-Request-ID": {"foo"}}, l.Header())
+
+	var parsed letter.Letter
+	parsed.Parse(l.Map())
+	assert.Equal(t, l.Header(), parsed.Header())
+
+	_, err := letter.TryWrite(letter.Header("X-Request-ID", "foo\r\nBcc: attacker@example.com"))
+	assert.True(t, errors.Is(err, letter.ErrHeaderInjection))
+}
+
 func TestLetter_WithText(t *testing.T) {
 	assert.Equal(t, "foo", letter.Write().WithText("foo").Text())
 }
@@ -655,6 +1042,18 @@ func TestLetter_RFC(t *testing.T) {
 	assert.Equal(t, expected, let.WithRFCOptions(rfc.WithClock(clock), rfc.WithMessageID("foobar")).RFC())
 }
 
+func TestLetter_TryRFC(t *testing.T) {
+	let := letter.Write(letter.Text("Grüße ☃")).WithRFCOptions(rfc.WithCharset("iso-8859-1"))
+
+	body, err := let.TryRFC()
+	assert.True(t, errors.Is(err, rfc.ErrUnrepresentable))
+	assert.Equal(t, let.RFC()[strings.Index(let.RFC(), "Content-Type"):], body[strings.Index(body, "Content-Type"):])
+
+	body, err = let.WithRFC("rfc body").TryRFC()
+	assert.Nil(t, err)
+	assert.Equal(t, "rfc body", body)
+}
+
 func TestLetter_RFC_override(t *testing.T) {
 	let := letter.Write(
 		letter.From("Bob Belcher", "bob@example.com"),
@@ -181,6 +181,33 @@ func TestAttachment_Parse(t *testing.T) {
 	})
 }
 
+func TestAlternative_mapParse(t *testing.T) {
+	l := Write(
+		Text("Hello."),
+		HTML("<p>Hello.</p>"),
+		AlternativePart("text/x-amp-html", []byte("<p>AMP</p>"), BeforeHTML()),
+		AlternativePart("text/markdown", []byte("*Hello.*")),
+	)
+
+	m := l.Map()
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"contentType": "text/x-amp-html",
+			"content":     base64.StdEncoding.EncodeToString([]byte("<p>AMP</p>")),
+			"beforeHtml":  true,
+		},
+		map[string]interface{}{
+			"contentType": "text/markdown",
+			"content":     base64.StdEncoding.EncodeToString([]byte("*Hello.*")),
+			"beforeHtml":  false,
+		},
+	}, m["alternatives"])
+
+	var parsed Letter
+	parsed.Parse(m)
+	assert.Equal(t, l.Alternatives(), parsed.Alternatives())
+}
+
 func TestLetter_Map(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -245,9 +272,17 @@ func TestLetter_Map(t *testing.T) {
 							"address": "louise@example.com",
 						},
 					},
-					"subject": "Hi.",
-					"text":    "Hello.",
-					"html":    "<p>Hello.</p>",
+					"toGroups":      []interface{}{},
+					"ccGroups":      []interface{}{},
+					"autoSubmitted": "",
+					"precedence":    "",
+					"organization":  "",
+					"header":        map[string]interface{}{},
+					"returnPath":    "",
+					"subject":       "Hi.",
+					"text":          "Hello.",
+					"html":          "<p>Hello.</p>",
+					"alternatives":  []interface{}{},
 					"attachments": []interface{}{
 						map[string]interface{}{
 							"filename":    "at1",
@@ -257,7 +292,8 @@ func TestLetter_Map(t *testing.T) {
 							"header":      headerToMap(l.L.Attachments[0].A.Header),
 						},
 					},
-					"rfc": "",
+					"rfc":                      "",
+					"allowDuplicateRecipients": false,
 				}
 			},
 		},
@@ -319,9 +355,17 @@ func TestLetter_Map(t *testing.T) {
 							"address": "louise@example.com",
 						},
 					},
-					"subject": "Hi.",
-					"text":    "Hello.",
-					"html":    "<p>Hello.</p>",
+					"toGroups":      []interface{}{},
+					"ccGroups":      []interface{}{},
+					"autoSubmitted": "",
+					"precedence":    "",
+					"organization":  "",
+					"header":        map[string]interface{}{},
+					"returnPath":    "",
+					"subject":       "Hi.",
+					"text":          "Hello.",
+					"html":          "<p>Hello.</p>",
+					"alternatives":  []interface{}{},
 					"attachments": []interface{}{
 						map[string]interface{}{
 							"filename":    "at1",
@@ -331,7 +375,8 @@ func TestLetter_Map(t *testing.T) {
 							"header":      headerToMap(l.L.Attachments[0].A.Header),
 						},
 					},
-					"rfc": "rfc body",
+					"rfc":                      "rfc body",
+					"allowDuplicateRecipients": false,
 				}
 			},
 		},
@@ -396,9 +441,17 @@ func TestLetter_Map(t *testing.T) {
 							"address": "louise@example.com",
 						},
 					},
-					"subject": "Hi.",
-					"text":    "Hello.",
-					"html":    "<p>Hello.</p>",
+					"toGroups":      []interface{}{},
+					"ccGroups":      []interface{}{},
+					"autoSubmitted": "",
+					"precedence":    "",
+					"organization":  "",
+					"header":        map[string]interface{}{},
+					"returnPath":    "",
+					"subject":       "Hi.",
+					"text":          "Hello.",
+					"html":          "<p>Hello.</p>",
+					"alternatives":  []interface{}{},
 					"attachments": []interface{}{
 						map[string]interface{}{
 							"filename":    "at1",
@@ -408,7 +461,8 @@ func TestLetter_Map(t *testing.T) {
 							"header":      headerToMap(l.L.Attachments[0].A.Header),
 						},
 					},
-					"rfc": "rfc body",
+					"rfc":                      "rfc body",
+					"allowDuplicateRecipients": false,
 				}
 			},
 		},
@@ -626,6 +680,30 @@ func TestLetter_Parse(t *testing.T) {
 				}, l.Attachments())
 			},
 		},
+		{
+			name: "allow duplicate recipients",
+			give: map[string]interface{}{
+				"to": []interface{}{
+					map[string]interface{}{
+						"name":    "Linda Belcher",
+						"address": "linda@example.com",
+					},
+				},
+				"cc": []interface{}{
+					map[string]interface{}{
+						"name":    "Linda Belcher",
+						"address": "linda@example.com",
+					},
+				},
+				"allowDuplicateRecipients": true,
+			},
+			assert: func(t *testing.T, l Letter) {
+				assert.Equal(t, []mail.Address{
+					{Name: "Linda Belcher", Address: "linda@example.com"},
+				}, l.CC())
+				assert.Equal(t, true, l.Map()["allowDuplicateRecipients"])
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,70 @@
+package letter
+
+import (
+	"encoding/base64"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var cidRE = regexp.MustCompile(`(?i)cid:([^"'\s)>]+)`)
+
+// PreviewHTML returns the HTML body of the letter in a form that can be saved
+// to a file and viewed in a browser, e.g. to preview templates while designing
+// them. `cid:` references to attachments (e.g. embedded images) are
+// replaced by base64 data URIs of the attachments, so that the preview matches
+// what a mail client shows. References to unknown Content-IDs are kept as-is.
+//
+// If the letter has no HTML body, the escaped text body is returned inside a
+// <pre> element instead.
+func (l Letter) PreviewHTML() string {
+	if l.HTML() == "" {
+		return "<pre>" + html.EscapeString(l.Text()) + "</pre>"
+	}
+	return ResolveContentIDs(l.HTML(), l.Attachments())
+}
+
+// ResolveContentIDs replaces the `cid:` references in the HTML body body with
+// base64 data URIs of the attachments in ats that have a matching `Content-ID`
+// header. References to unknown Content-IDs are kept as-is.
+func ResolveContentIDs(body string, ats []Attachment) string {
+	return ReplaceContentIDs(body, ats, func(at Attachment) string {
+		return "data:" + at.ContentType() + ";base64," + base64.StdEncoding.EncodeToString(at.Content())
+	})
+}
+
+// ReplaceContentIDs replaces the `cid:` references in the HTML body body with
+// the URLs that are returned by replace for the attachments in ats that have a
+// matching `Content-ID` header. References to unknown Content-IDs and
+// references for which replace returns an empty string are kept as-is.
+func ReplaceContentIDs(body string, ats []Attachment, replace func(Attachment) string) string {
+	if len(ats) == 0 {
+		return body
+	}
+
+	byID := make(map[string]Attachment, len(ats))
+	for _, at := range ats {
+		id := strings.Trim(at.Header().Get("Content-ID"), "<>")
+		if id == "" {
+			continue
+		}
+		byID[id] = at
+	}
+
+	return cidRE.ReplaceAllStringFunc(body, func(ref string) string {
+		id := ref[len("cid:"):]
+		// Content-IDs in URLs are URL-encoded (RFC 2392)
+		if unescaped, err := url.PathUnescape(id); err == nil {
+			id = unescaped
+		}
+		at, ok := byID[id]
+		if !ok {
+			return ref
+		}
+		if u := replace(at); u != "" {
+			return u
+		}
+		return ref
+	})
+}
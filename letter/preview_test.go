@@ -0,0 +1,59 @@
+package letter_test
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/bounoable/postdog/letter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLetter_PreviewHTML(t *testing.T) {
+	logo := []byte{0x89, 'P', 'N', 'G'}
+	at := letter.NewAttachment("logo.png", logo, letter.AttachmentType("image/png"))
+	cid := strings.Trim(at.Header().Get("Content-ID"), "<>")
+	dataURI := "data:image/png;base64," + base64.StdEncoding.EncodeToString(logo)
+
+	tests := []struct {
+		name string
+		opts []letter.Option
+		want string
+	}{
+		{
+			name: "resolves content ids",
+			opts: []letter.Option{
+				letter.HTML(`<img src="cid:` + cid + `"><img src='CID:` + cid + `'>`),
+				letter.Attach("logo.png", logo, letter.AttachmentType("image/png")),
+			},
+			want: `<img src="` + dataURI + `"><img src='` + dataURI + `'>`,
+		},
+		{
+			name: "url-encoded content id",
+			opts: []letter.Option{
+				letter.HTML(`<div style="background: url(cid:` + strings.Replace(cid, "_", "%5F", 1) + `)"></div>`),
+				letter.Attach("logo.png", logo, letter.AttachmentType("image/png")),
+			},
+			want: `<div style="background: url(` + dataURI + `)"></div>`,
+		},
+		{
+			name: "unknown content id",
+			opts: []letter.Option{
+				letter.HTML(`<img src="cid:unknown@example.com">`),
+				letter.Attach("logo.png", logo, letter.AttachmentType("image/png")),
+			},
+			want: `<img src="cid:unknown@example.com">`,
+		},
+		{
+			name: "text only",
+			opts: []letter.Option{letter.Text("Hello <Linda>.")},
+			want: "<pre>Hello &lt;Linda&gt;.</pre>",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, letter.Write(test.opts...).PreviewHTML())
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package rfc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/ianaindex"
+)
+
+var (
+	// ErrUnknownCharset means a charset is not supported.
+	ErrUnknownCharset = errors.New("unknown charset")
+	// ErrUnrepresentable means some content contains characters that cannot
+	// be represented in a charset.
+	ErrUnrepresentable = errors.New("unrepresentable character")
+)
+
+// WithCharset returns an Option that sets the charset of the text, HTML and
+// text alternative parts, e.g. "iso-8859-1" or "shift_jis". Charsets are
+// looked up by their IANA names. The content of the parts is transcoded from
+// UTF-8 to charset and the parts declare charset in their `Content-Type`.
+// Alternatives whose content type already has a charset parameter are not
+// transcoded. Defaults to UTF-8.
+//
+// Parts that cannot be transcoded, because charset is unknown or the content
+// contains characters that are unrepresentable in charset, are built in UTF-8
+// instead. Use TryBuild() to get an error for such parts.
+func WithCharset(charset string) Option {
+	return func(cfg *Config) {
+		cfg.Charset = charset
+	}
+}
+
+// Transcode transcodes the UTF-8 content to charset. It returns an error that
+// unwraps to ErrUnknownCharset if charset is not supported, or to
+// ErrUnrepresentable if content contains a character that cannot be
+// represented in charset.
+func Transcode(charset string, content []byte) ([]byte, error) {
+	if isUTF8(charset) {
+		return content, nil
+	}
+
+	enc, err := ianaindex.IANA.Encoding(charset)
+	if err != nil || enc == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCharset, charset)
+	}
+
+	res, err := enc.NewEncoder().Bytes(content)
+	if err == nil {
+		return res, nil
+	}
+
+	return nil, unrepresentable(enc, charset, content)
+}
+
+// unrepresentable returns an error for the first character of content that
+// cannot be encoded with enc.
+func unrepresentable(enc encoding.Encoding, charset string, content []byte) error {
+	for i, r := range string(content) {
+		if r == utf8.RuneError {
+			return fmt.Errorf("%w: invalid UTF-8 at byte %d for charset %s", ErrUnrepresentable, i, charset)
+		}
+		if _, err := enc.NewEncoder().String(string(r)); err != nil {
+			return fmt.Errorf("%w: %q at byte %d for charset %s", ErrUnrepresentable, r, i, charset)
+		}
+	}
+	return fmt.Errorf("%w: charset %s", ErrUnrepresentable, charset)
+}
+
+// textPart returns the Content-Type and the content of p. Text parts without
+// a charset parameter are transcoded to the configured charset and declare
+// it. If transcoding fails, the error is recorded in b.err and the part is
+// declared as UTF-8.
+func (b *builder) textPart(p Part) (string, []byte) {
+	ct := p.ContentType
+	lower := strings.ToLower(ct)
+	if !strings.HasPrefix(lower, "text/") || strings.Contains(lower, "charset=") {
+		return ct, p.Content
+	}
+
+	if isUTF8(b.cfg.Charset) {
+		return ct + "; charset=utf-8", p.Content
+	}
+
+	content, err := Transcode(b.cfg.Charset, p.Content)
+	if err != nil {
+		if b.err == nil {
+			b.err = fmt.Errorf("%s part: %w", ct, err)
+		}
+		return ct + "; charset=utf-8", p.Content
+	}
+
+	return ct + "; charset=" + b.cfg.Charset, content
+}
+
+func isUTF8(charset string) bool {
+	switch strings.ToLower(charset) {
+	case "", "utf-8", "utf8":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package rfc_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/bounoable/postdog/letter/rfc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuild_charset(t *testing.T) {
+	mail := rfc.Mail{
+		Text: "Grüße",
+		HTML: "<p>Grüße</p>",
+		Alternatives: []rfc.Part{
+			{ContentType: "text/markdown; charset=utf-8", Content: []byte("*Grüße*")},
+		},
+	}
+
+	body, err := rfc.TryBuild(mail, rfc.WithCharset("iso-8859-1"))
+	assert.Nil(t, err)
+	assert.Contains(t, body, "Content-Type: text/plain; charset=iso-8859-1\r\nContent-Transfer-Encoding: base64\r\n\r\n"+
+		base64.StdEncoding.EncodeToString([]byte("Gr\xfc\xdfe"))+"\r\n")
+	assert.Contains(t, body, "Content-Type: text/html; charset=iso-8859-1\r\n")
+	assert.Contains(t, body, "Content-Type: text/markdown; charset=utf-8\r\nContent-Transfer-Encoding: base64\r\n\r\n"+
+		base64.StdEncoding.EncodeToString([]byte("*Grüße*"))+"\r\n")
+
+	assert.Contains(t, rfc.Build(mail), "Content-Type: text/plain; charset=utf-8\r\n")
+}
+
+func TestBuild_charset_unrepresentable(t *testing.T) {
+	mail := rfc.Mail{Text: "Grüße", HTML: "<p>Grüße ☃</p>"}
+
+	body, err := rfc.TryBuild(mail, rfc.WithCharset("iso-8859-1"))
+	assert.True(t, errors.Is(err, rfc.ErrUnrepresentable))
+	assert.Contains(t, err.Error(), `'☃'`)
+	assert.Contains(t, body, "Content-Type: text/plain; charset=iso-8859-1\r\n")
+	assert.Contains(t, body, "Content-Type: text/html; charset=utf-8\r\n")
+
+	assert.Contains(t, rfc.Build(mail, rfc.WithCharset("iso-8859-1")), "Content-Type: text/html; charset=utf-8\r\n")
+}
+
+func TestTranscode(t *testing.T) {
+	tests := []struct {
+		name      string
+		charset   string
+		content   string
+		expected  string
+		wantError error
+	}{
+		{name: "utf-8", charset: "UTF-8", content: "Grüße ☃", expected: "Grüße ☃"},
+		{name: "empty charset", content: "Grüße ☃", expected: "Grüße ☃"},
+		{name: "latin-1", charset: "ISO-8859-1", content: "Grüße", expected: "Gr\xfc\xdfe"},
+		{name: "shift_jis", charset: "shift_jis", content: "こんにちは", expected: "\x82\xb1\x82\xf1\x82\xc9\x82\xbf\x82\xcd"},
+		{name: "unrepresentable", charset: "iso-8859-1", content: "こんにちは", wantError: rfc.ErrUnrepresentable},
+		{name: "unknown charset", charset: "x-unknown", content: "Hello.", wantError: rfc.ErrUnknownCharset},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := rfc.Transcode(test.charset, []byte(test.content))
+			if test.wantError != nil {
+				assert.True(t, errors.Is(err, test.wantError), err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, string(res))
+		})
+	}
+}
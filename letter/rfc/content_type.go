@@ -0,0 +1,30 @@
+package rfc
+
+import "mime"
+
+// FormatContentType returns the `Content-Type` value of the media type ct with
+// the parameters params. ct may already contain parameters; they are merged
+// with params, and params take precedence. Parameters are sorted by name and
+// their values are quoted if they aren't RFC 2045 tokens. Non-ASCII values are
+// encoded as defined in RFC 2231. If ct is not a valid media type, ct is
+// returned unchanged.
+func FormatContentType(ct string, params map[string]string) string {
+	mediaType, existing, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return ct
+	}
+
+	merged := make(map[string]string, len(existing)+len(params))
+	for k, v := range existing {
+		merged[k] = v
+	}
+	for k, v := range params {
+		merged[k] = v
+	}
+
+	if formatted := mime.FormatMediaType(mediaType, merged); formatted != "" {
+		return formatted
+	}
+
+	return ct
+}
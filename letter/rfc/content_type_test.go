@@ -0,0 +1,59 @@
+package rfc_test
+
+import (
+	"testing"
+
+	"github.com/bounoable/postdog/letter/rfc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatContentType(t *testing.T) {
+	tests := []struct {
+		name     string
+		ct       string
+		params   map[string]string
+		expected string
+	}{
+		{
+			name:     "without params",
+			ct:       "text/calendar",
+			expected: "text/calendar",
+		},
+		{
+			name:     "sorted params",
+			ct:       "text/calendar",
+			params:   map[string]string{"method": "REQUEST", "charset": "UTF-8", "component": "VEVENT"},
+			expected: "text/calendar; charset=UTF-8; component=VEVENT; method=REQUEST",
+		},
+		{
+			name:     "quoted values",
+			ct:       "application/x-custom",
+			params:   map[string]string{"title": "Q3 report", "path": `a\b"c`, "empty": ""},
+			expected: `application/x-custom; empty=""; path="a\\b\"c"; title="Q3 report"`,
+		},
+		{
+			name:     "merge with existing params",
+			ct:       "text/calendar; method=PUBLISH; charset=us-ascii",
+			params:   map[string]string{"method": "REQUEST"},
+			expected: "text/calendar; charset=us-ascii; method=REQUEST",
+		},
+		{
+			name:     "non-ascii value",
+			ct:       "application/octet-stream",
+			params:   map[string]string{"name": "ä.txt"},
+			expected: "application/octet-stream; name*=utf-8''%C3%A4.txt",
+		},
+		{
+			name:     "invalid media type",
+			ct:       "invalid;;",
+			params:   map[string]string{"method": "REQUEST"},
+			expected: "invalid;;",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, rfc.FormatContentType(test.ct, test.params))
+		})
+	}
+}
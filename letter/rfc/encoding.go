@@ -0,0 +1,80 @@
+package rfc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime/quotedprintable"
+	"strings"
+)
+
+// Content transfer encodings.
+const (
+	Base64          = "base64"
+	QuotedPrintable = "quoted-printable"
+	SevenBit        = "7bit"
+	EightBit        = "8bit"
+)
+
+// maxLineLength is the maximum line length (excluding CRLF) for 7bit and 8bit
+// data, as defined in RFC 5322.
+const maxLineLength = 998
+
+var (
+	// ErrInvalidEncoding means a content transfer encoding is unknown or
+	// cannot be used for some content.
+	ErrInvalidEncoding = errors.New("invalid content transfer encoding")
+)
+
+// ValidateEncoding checks if content can be transferred using the content
+// transfer encoding enc. An empty enc is treated as Base64. "7bit" requires
+// ASCII content, and both "7bit" and "8bit" forbid NUL bytes and lines that
+// are longer than 998 characters.
+func ValidateEncoding(enc string, content []byte) error {
+	switch strings.ToLower(enc) {
+	case "", Base64, QuotedPrintable:
+		return nil
+	case SevenBit, EightBit:
+	default:
+		return fmt.Errorf("%w: unknown encoding %q", ErrInvalidEncoding, enc)
+	}
+
+	if bytes.IndexByte(content, 0) >= 0 {
+		return fmt.Errorf("%w: %s content must not contain NUL bytes", ErrInvalidEncoding, enc)
+	}
+
+	if strings.ToLower(enc) == SevenBit {
+		for _, b := range content {
+			if b > 127 {
+				return fmt.Errorf("%w: %s content must be ASCII", ErrInvalidEncoding, enc)
+			}
+		}
+	}
+
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		if len(bytes.TrimSuffix(line, []byte("\r"))) > maxLineLength {
+			return fmt.Errorf("%w: %s content must not contain lines longer than %d characters", ErrInvalidEncoding, enc, maxLineLength)
+		}
+	}
+
+	return nil
+}
+
+// encodeContent encodes content with the Content-Transfer-Encoding enc.
+// Base64-encoded content is folded after lineLength characters.
+func encodeContent(enc string, content []byte, lineLength int) string {
+	switch strings.ToLower(enc) {
+	case QuotedPrintable:
+		var buf bytes.Buffer
+		w := quotedprintable.NewWriter(&buf)
+		w.Write(content)
+		w.Close()
+		return buf.String()
+	case SevenBit, EightBit:
+		s := strings.ReplaceAll(string(content), "\r\n", "\n")
+		return strings.ReplaceAll(s, "\n", "\r\n")
+	default:
+		return fold(base64.StdEncoding.EncodeToString(content), lineLength)
+	}
+}
@@ -0,0 +1,75 @@
+package rfc
+
+import (
+	"fmt"
+	"mime"
+	"net/mail"
+	"strings"
+)
+
+// Group is an RFC 5322 address group, e.g. `Team: a@example.com,b@example.com;`.
+// A group without addresses is rendered as `Team:;`, which can be used to
+// hide the actual recipients of a mail.
+type Group struct {
+	Name      string
+	Addresses []mail.Address
+}
+
+// String returns the group in RFC 5322 group syntax.
+func (g Group) String() string {
+	return fmt.Sprintf("%s:%s;", groupName(g.Name), joinAddresses(g.Addresses...))
+}
+
+// DefaultUndisclosedRecipients is the conventional name of the placeholder
+// group for mails without visible recipients.
+const DefaultUndisclosedRecipients = "undisclosed-recipients"
+
+// WithUndisclosedRecipients returns an Option that adds the placeholder header
+// `To: <name>:;` to mails that only have BCC recipients, because some servers
+// reject mails without a `To` or `Cc` header. The placeholder uses the RFC 5322
+// group syntax (`display-name ":" [group-list] ";"`) with an empty member list,
+// so it names the recipients without disclosing any address. An empty name
+// uses DefaultUndisclosedRecipients.
+func WithUndisclosedRecipients(name string) Option {
+	if name == "" {
+		name = DefaultUndisclosedRecipients
+	}
+	return func(cfg *Config) {
+		cfg.UndisclosedRecipients = name
+	}
+}
+
+// onlyBCC determines if mail has BCC recipients but no `To` or `Cc` recipients.
+func onlyBCC(mail Mail) bool {
+	return len(mail.BCC) > 0 &&
+		len(mail.To) == 0 && len(mail.ToGroups) == 0 &&
+		len(mail.CC) == 0 && len(mail.CCGroups) == 0
+}
+
+// joinRecipients joins the addresses and groups of an address header.
+func joinRecipients(addrs []mail.Address, groups []Group) string {
+	vals := make([]string, 0, 2)
+	if len(addrs) > 0 {
+		vals = append(vals, joinAddresses(addrs...))
+	}
+	for _, g := range groups {
+		vals = append(vals, g.String())
+	}
+	return strings.Join(vals, ",")
+}
+
+// groupName encodes the display name of a group. Non-ASCII names are
+// Q-encoded and names that contain special characters are quoted.
+func groupName(name string) string {
+	for _, r := range name {
+		if r > 127 {
+			return mime.QEncoding.Encode("utf-8", name)
+		}
+	}
+
+	if strings.ContainsAny(name, "()<>[]:;@\\,.\"") {
+		return fmt.Sprintf(`"%s"`, strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(name))
+	}
+
+	return name
+}
@@ -0,0 +1,98 @@
+package rfc_test
+
+import (
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/bounoable/postdog/letter/rfc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup_String(t *testing.T) {
+	tests := []struct {
+		name     string
+		group    rfc.Group
+		expected string
+	}{
+		{
+			name: "with addresses",
+			group: rfc.Group{Name: "Team", Addresses: []mail.Address{
+				{Address: "a@example.com"},
+				{Name: "B", Address: "b@example.com"},
+			}},
+			expected: `Team:<a@example.com>,"B" <b@example.com>;`,
+		},
+		{
+			name:     "empty",
+			group:    rfc.Group{Name: "undisclosed-recipients"},
+			expected: "undisclosed-recipients:;",
+		},
+		{
+			name:     "special characters",
+			group:    rfc.Group{Name: `Team "A", B`},
+			expected: `"Team \"A\", B":;`,
+		},
+		{
+			name:     "non-ascii",
+			group:    rfc.Group{Name: "Tëam"},
+			expected: "=?utf-8?q?T=C3=ABam?=:;",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.group.String())
+		})
+	}
+}
+
+func TestBuild_groups(t *testing.T) {
+	team := []mail.Address{{Name: "Tina Belcher", Address: "tina@example.com"}, {Address: "gene@example.com"}}
+
+	s := rfc.Build(rfc.Mail{
+		Subject:  "Hi.",
+		To:       []mail.Address{{Name: "Linda Belcher", Address: "linda@example.com"}},
+		ToGroups: []rfc.Group{{Name: "Team", Addresses: team}},
+		CCGroups: []rfc.Group{{Name: "Nobody"}},
+		Text:     "Hello.",
+	})
+
+	assert.Contains(t, s, "\r\n"+`To: "Linda Belcher" <linda@example.com>,Team:"Tina Belcher" <tina@example.com>,<gene@example.com>;`+"\r\n")
+	assert.Contains(t, s, "\r\nCc: Nobody:;\r\n")
+
+	msg, err := mail.ReadMessage(strings.NewReader(s))
+	assert.Nil(t, err)
+
+	to, err := msg.Header.AddressList("To")
+	assert.Nil(t, err)
+	assert.Equal(t, []*mail.Address{
+		{Name: "Linda Belcher", Address: "linda@example.com"},
+		{Name: "Tina Belcher", Address: "tina@example.com"},
+		{Address: "gene@example.com"},
+	}, to)
+}
+
+func TestWithUndisclosedRecipients(t *testing.T) {
+	bcc := []mail.Address{{Address: "gene@example.com"}}
+
+	s := rfc.Build(rfc.Mail{Subject: "Hi.", BCC: bcc, Text: "Hello."})
+	assert.NotContains(t, s, "\r\nTo: ")
+
+	s = rfc.Build(rfc.Mail{Subject: "Hi.", BCC: bcc, Text: "Hello."}, rfc.WithUndisclosedRecipients(""))
+	assert.Contains(t, s, "\r\nTo: undisclosed-recipients:;\r\n")
+
+	s = rfc.Build(rfc.Mail{Subject: "Hi.", BCC: bcc, Text: "Hello."}, rfc.WithUndisclosedRecipients("Newsletter subscribers"))
+	assert.Contains(t, s, "\r\nTo: Newsletter subscribers:;\r\n")
+
+	s = rfc.Build(rfc.Mail{
+		Subject: "Hi.",
+		CC:      []mail.Address{{Address: "tina@example.com"}},
+		BCC:     bcc,
+		Text:    "Hello.",
+	}, rfc.WithUndisclosedRecipients(""))
+	assert.NotContains(t, s, "\r\nTo: ")
+
+	s = rfc.Build(rfc.Mail{Subject: "Hi.", Text: "Hello."}, rfc.WithUndisclosedRecipients(""))
+	assert.NotContains(t, s, "\r\nTo: ")
+}
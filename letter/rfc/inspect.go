@@ -0,0 +1,147 @@
+package rfc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Structure describes a MIME part of a mail. Multipart parts contain their
+// sub-parts in Parts.
+type Structure struct {
+	// ContentType is the `Content-Type` of the part without the boundary
+	// parameter.
+	ContentType string
+	// Boundary is the boundary of a multipart part.
+	Boundary string
+	// Encoding is the `Content-Transfer-Encoding` of a non-multipart part.
+	Encoding string
+	// Filename is the filename of an attachment.
+	Filename string
+	// Size is the size of the unencoded content of a non-multipart part in
+	// bytes.
+	Size int
+	// Parts are the sub-parts of a multipart part.
+	Parts []Structure
+}
+
+// Inspect returns the MIME structure of the mail that Build() would build for
+// mail and opts, without encoding the content of the mail. It is meant to
+// help debugging why a client renders the wrong part.
+func Inspect(mail Mail, opts ...Option) Structure {
+	var cfg Config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return InspectConfig(mail, cfg)
+}
+
+// InspectConfig does the same as Inspect() but accepts a Config instead of
+// Options.
+func InspectConfig(mail Mail, cfg Config) Structure {
+	b := builder{cfg: cfg}
+	return b.inspect(mail)
+}
+
+func (b *builder) inspect(mail Mail) Structure {
+	parts := alternativeParts(mail)
+	inline, attachments := splitInline(mail.Attachments)
+
+	if len(attachments) == 0 {
+		return b.inspectRelated(parts, inline)
+	}
+
+	s := Structure{ContentType: "multipart/mixed", Boundary: b.newBoundary()}
+	if len(parts) > 0 || len(inline) > 0 {
+		s.Parts = append(s.Parts, b.inspectRelated(parts, inline))
+	}
+	for _, at := range attachments {
+		s.Parts = append(s.Parts, inspectAttachment(at))
+	}
+
+	return s
+}
+
+func (b *builder) inspectRelated(parts []Part, inline []Attachment) Structure {
+	if len(inline) == 0 {
+		return b.inspectBody(parts)
+	}
+
+	s := Structure{ContentType: "multipart/related", Boundary: b.newBoundary()}
+	if len(parts) > 0 {
+		s.Parts = append(s.Parts, b.inspectBody(parts))
+	}
+	for _, at := range inline {
+		s.Parts = append(s.Parts, inspectAttachment(at))
+	}
+
+	return s
+}
+
+func inspectAttachment(at Attachment) Structure {
+	return Structure{
+		ContentType: at.Header.Get("Content-Type"),
+		Encoding:    attachmentEncoding(at),
+		Filename:    at.Filename,
+		Size:        len(at.Content),
+	}
+}
+
+func (b *builder) inspectBody(parts []Part) Structure {
+	switch len(parts) {
+	case 0:
+		return Structure{}
+	case 1:
+		return b.inspectPart(parts[0])
+	}
+
+	s := Structure{ContentType: "multipart/alternative", Boundary: b.newBoundary()}
+	for _, p := range parts {
+		s.Parts = append(s.Parts, b.inspectPart(p))
+	}
+
+	return s
+}
+
+func (b *builder) inspectPart(p Part) Structure {
+	ct, content := b.textPart(p)
+	return Structure{
+		ContentType: ct,
+		Encoding:    Base64,
+		Size:        len(content),
+	}
+}
+
+// String returns an indented tree representation of s, e.g.:
+//
+//	multipart/mixed (boundary=...)
+//	  text/plain; charset=utf-8 (base64, 6 bytes)
+//	  application/pdf (filename=invoice.pdf, base64, 1024 bytes)
+func (s Structure) String() string {
+	var sb strings.Builder
+	s.write(&sb, 0)
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func (s Structure) write(sb *strings.Builder, depth int) {
+	sb.WriteString(strings.Repeat("  ", depth))
+	sb.WriteString(s.ContentType)
+
+	var info []string
+	if s.Boundary != "" {
+		info = append(info, fmt.Sprintf("boundary=%s", s.Boundary))
+	}
+	if s.Filename != "" {
+		info = append(info, fmt.Sprintf("filename=%s", s.Filename))
+	}
+	if s.Encoding != "" {
+		info = append(info, s.Encoding, fmt.Sprintf("%d bytes", s.Size))
+	}
+	if len(info) > 0 {
+		fmt.Fprintf(sb, " (%s)", strings.Join(info, ", "))
+	}
+	sb.WriteString("\n")
+
+	for _, p := range s.Parts {
+		p.write(sb, depth+1)
+	}
+}
@@ -0,0 +1,147 @@
+package rfc_test
+
+import (
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/bounoable/postdog/letter/rfc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInspect(t *testing.T) {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", "application/pdf")
+
+	inline := textproto.MIMEHeader{}
+	inline.Set("Content-Type", "image/png")
+	inline.Set("Content-Disposition", `inline; filename="logo.png"`)
+
+	tests := []struct {
+		name     string
+		mail     rfc.Mail
+		expected rfc.Structure
+	}{
+		{
+			name:     "empty",
+			expected: rfc.Structure{},
+		},
+		{
+			name: "text",
+			mail: rfc.Mail{Text: "Hello."},
+			expected: rfc.Structure{
+				ContentType: "text/plain; charset=utf-8",
+				Encoding:    "base64",
+				Size:        6,
+			},
+		},
+		{
+			name: "text & html",
+			mail: rfc.Mail{Text: "Hello.", HTML: "<p>Hello.</p>"},
+			expected: rfc.Structure{
+				ContentType: "multipart/alternative",
+				Boundary:    boundary(0),
+				Parts: []rfc.Structure{
+					{ContentType: "text/plain; charset=utf-8", Encoding: "base64", Size: 6},
+					{ContentType: "text/html; charset=utf-8", Encoding: "base64", Size: 13},
+				},
+			},
+		},
+		{
+			name: "text, html, amp & attachment",
+			mail: rfc.Mail{
+				Text: "Hello.",
+				HTML: "<p>Hello.</p>",
+				Alternatives: []rfc.Part{
+					{ContentType: "text/x-amp-html", Content: []byte("<p>AMP</p>"), BeforeHTML: true},
+				},
+				Attachments: []rfc.Attachment{
+					{Filename: "invoice.pdf", Content: []byte{1, 2, 3}, Header: header},
+				},
+			},
+			expected: rfc.Structure{
+				ContentType: "multipart/mixed",
+				Boundary:    boundary(0),
+				Parts: []rfc.Structure{
+					{
+						ContentType: "multipart/alternative",
+						Boundary:    boundary(1),
+						Parts: []rfc.Structure{
+							{ContentType: "text/plain; charset=utf-8", Encoding: "base64", Size: 6},
+							{ContentType: "text/x-amp-html; charset=utf-8", Encoding: "base64", Size: 10},
+							{ContentType: "text/html; charset=utf-8", Encoding: "base64", Size: 13},
+						},
+					},
+					{ContentType: "application/pdf", Encoding: "base64", Filename: "invoice.pdf", Size: 3},
+				},
+			},
+		},
+		{
+			name: "html, inline attachment & attachment",
+			mail: rfc.Mail{
+				HTML: `<img src="cid:logo@example.com">`,
+				Attachments: []rfc.Attachment{
+					{Filename: "invoice.pdf", Content: []byte{1, 2, 3}, Header: header},
+					{Filename: "logo.png", Content: []byte{4, 5}, Header: inline},
+				},
+			},
+			expected: rfc.Structure{
+				ContentType: "multipart/mixed",
+				Boundary:    boundary(0),
+				Parts: []rfc.Structure{
+					{
+						ContentType: "multipart/related",
+						Boundary:    boundary(1),
+						Parts: []rfc.Structure{
+							{ContentType: "text/html; charset=utf-8", Encoding: "base64", Size: 32},
+							{ContentType: "image/png", Encoding: "base64", Filename: "logo.png", Size: 2},
+						},
+					},
+					{ContentType: "application/pdf", Encoding: "base64", Filename: "invoice.pdf", Size: 3},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := rfc.Inspect(test.mail)
+			assert.Equal(t, test.expected, s)
+
+			body := rfc.Build(test.mail)
+			for _, p := range flatten(s) {
+				if p.Boundary != "" {
+					assert.Contains(t, body, p.Boundary)
+				}
+				if p.ContentType != "" {
+					assert.Contains(t, body, "Content-Type: "+p.ContentType)
+				}
+			}
+		})
+	}
+}
+
+func TestStructure_String(t *testing.T) {
+	s := rfc.Structure{
+		ContentType: "multipart/mixed",
+		Boundary:    "abc",
+		Parts: []rfc.Structure{
+			{ContentType: "text/plain; charset=utf-8", Encoding: "base64", Size: 6},
+			{ContentType: "application/pdf", Encoding: "base64", Filename: "invoice.pdf", Size: 3},
+		},
+	}
+
+	assert.Equal(t, strings.Join([]string{
+		"multipart/mixed (boundary=abc)",
+		"  text/plain; charset=utf-8 (base64, 6 bytes)",
+		"  application/pdf (filename=invoice.pdf, base64, 3 bytes)",
+	}, "\n"), s.String())
+}
+
+func flatten(s rfc.Structure) []rfc.Structure {
+	res := []rfc.Structure{s}
+	for _, p := range s.Parts {
+		res = append(res, flatten(p)...)
+	}
+	return res
+}
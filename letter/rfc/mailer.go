@@ -0,0 +1,49 @@
+package rfc
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+const modulePath = "github.com/bounoable/postdog"
+
+var (
+	mailerIdentityOnce sync.Once
+	mailerIdentity     string
+)
+
+// DefaultMailerIdentity returns the default value of the `X-Mailer` and
+// `User-Agent` headers (see WithMailerIdentity()), which is "postdog/<version>"
+// with the version of postdog that is compiled into the binary, or "postdog"
+// if the version is unknown, e.g. in development builds.
+func DefaultMailerIdentity() string {
+	mailerIdentityOnce.Do(func() {
+		mailerIdentity = "postdog"
+		if v := moduleVersion(); v != "" {
+			mailerIdentity += "/" + v
+		}
+	})
+	return mailerIdentity
+}
+
+func moduleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+
+	mod := &info.Main
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			mod = dep
+			break
+		}
+	}
+	if mod.Path != modulePath || mod.Version == "(devel)" {
+		return ""
+	}
+	if mod.Replace != nil && mod.Replace.Version != "" {
+		return mod.Replace.Version
+	}
+	return mod.Version
+}
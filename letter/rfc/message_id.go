@@ -1,7 +1,11 @@
 package rfc
 
 import (
+	"crypto/sha256"
 	"fmt"
+	"io"
+	"net/mail"
+	"strings"
 
 	"github.com/google/uuid"
 )
@@ -23,3 +27,96 @@ func UUIDGenerator(domain string) MessageIDFactory {
 func (gen uuidGenerator) GenerateID(Mail) string {
 	return fmt.Sprintf("<%s@%s>", uuid.New().String(), gen.domain)
 }
+
+type senderDomainGenerator struct {
+	domain func(mail.Address) string
+}
+
+// SenderDomainGenerator returns a Message-ID factory using UUIDs, whose domain
+// is derived from the sender of the mail by calling domain. Using the domain
+// of the sender aligns the Message-ID with the `From` header, which some spam
+// filters expect. If domain is nil, or returns an empty string, FromDomain()
+// is used. The generated IDs have the following format: <UUID@DOMAIN>
+func SenderDomainGenerator(domain func(from mail.Address) string) MessageIDFactory {
+	return senderDomainGenerator{domain: domain}
+}
+
+// WithMessageIDDomain returns an Option that generates the Message-ID using a
+// SenderDomainGenerator(domain).
+func WithMessageIDDomain(domain func(from mail.Address) string) Option {
+	return WithMessageIDFactory(SenderDomainGenerator(domain))
+}
+
+// FromDomain returns the domain of the address from, or "localhost" if from
+// has no domain.
+func FromDomain(from mail.Address) string {
+	if i := strings.LastIndex(from.Address, "@"); i >= 0 && i < len(from.Address)-1 {
+		return from.Address[i+1:]
+	}
+	return "localhost"
+}
+
+func (gen senderDomainGenerator) GenerateID(m Mail) string {
+	var domain string
+	if gen.domain != nil {
+		domain = gen.domain(m.From)
+	}
+	if domain == "" {
+		domain = FromDomain(m.From)
+	}
+	return fmt.Sprintf("<%s@%s>", uuid.New().String(), domain)
+}
+
+type contentHashGenerator struct {
+	domain string
+}
+
+// ContentHashGenerator returns a Message-ID factory that derives the
+// Message-ID from the content of the mail, so that identical mails get
+// identical Message-IDs (e.g. for deduplication across restarts). The ID is
+// the hex-encoded SHA-256 hash of the sender, recipients, subject, text, HTML
+// and attachments of the mail. If domain is an empty string, it is set to
+// "localhost". The generated IDs have the following format: <HASH@DOMAIN>
+func ContentHashGenerator(domain string) MessageIDFactory {
+	if domain == "" {
+		domain = "localhost"
+	}
+	return contentHashGenerator{domain: domain}
+}
+
+// WithContentHashMessageID returns an Option that generates the Message-ID
+// using a ContentHashGenerator(domain).
+func WithContentHashMessageID(domain string) Option {
+	return WithMessageIDFactory(ContentHashGenerator(domain))
+}
+
+func (gen contentHashGenerator) GenerateID(m Mail) string {
+	h := sha256.New()
+	write := func(s string) {
+		// length-prefix every field, so that moving bytes between adjacent
+		// fields changes the hash
+		fmt.Fprintf(h, "%d:", len(s))
+		io.WriteString(h, s)
+	}
+	writeAddrs := func(addrs []mail.Address) {
+		write(fmt.Sprint(len(addrs)))
+		for _, addr := range addrs {
+			write(addr.String())
+		}
+	}
+
+	write(m.From.String())
+	writeAddrs(m.To)
+	writeAddrs(m.CC)
+	writeAddrs(m.BCC)
+	write(m.Subject)
+	write(m.Text)
+	write(m.HTML)
+	write(fmt.Sprint(len(m.Attachments)))
+	for _, at := range m.Attachments {
+		write(at.Filename)
+		write(string(at.Content))
+	}
+
+	return fmt.Sprintf("<%x@%s>", h.Sum(nil), gen.domain)
+}
@@ -1,6 +1,7 @@
 package rfc_test
 
 import (
+	"net/mail"
 	"strings"
 	"testing"
 
@@ -49,3 +50,104 @@ func TestUUIDGenerator_withDomain(t *testing.T) {
 
 	assert.Equal(t, "foo>", right)
 }
+
+func TestContentHashGenerator(t *testing.T) {
+	m := rfc.Mail{
+		Subject: "Hi.",
+		From:    mail.Address{Name: "Bob Belcher", Address: "bob@example.com"},
+		To:      []mail.Address{{Name: "Linda Belcher", Address: "linda@example.com"}},
+		Text:    "Hello.",
+		Attachments: []rfc.Attachment{
+			{Filename: "attach1", Content: []byte("Attachment 1")},
+		},
+	}
+
+	gen := rfc.ContentHashGenerator("example.com")
+	id := gen.GenerateID(m)
+	assert.Len(t, id, 64+11+2+1) // SHA-256 + "example.com" + "<" & ">" + "@"
+	assert.True(t, strings.HasPrefix(id, "<"))
+	assert.True(t, strings.HasSuffix(id, "@example.com>"))
+	assert.Equal(t, id, gen.GenerateID(m))
+
+	changed := m
+	changed.Subject = "Hi!"
+	assert.NotEqual(t, id, gen.GenerateID(changed))
+
+	changed = m
+	changed.Subject, changed.Text = "Hi.Hello.", ""
+	assert.NotEqual(t, id, gen.GenerateID(changed))
+
+	changed = m
+	changed.CC = []mail.Address{{Address: "tina@example.com"}}
+	assert.NotEqual(t, id, gen.GenerateID(changed))
+
+	changed = m
+	changed.Attachments = []rfc.Attachment{{Filename: "attach1", Content: []byte("Attachment 2")}}
+	assert.NotEqual(t, id, gen.GenerateID(changed))
+
+	assert.True(t, strings.HasSuffix(rfc.ContentHashGenerator("").GenerateID(m), "@localhost>"))
+}
+
+func TestWithContentHashMessageID(t *testing.T) {
+	m := rfc.Mail{
+		Subject: "Hi.",
+		From:    mail.Address{Address: "bob@example.com"},
+		To:      []mail.Address{{Address: "linda@example.com"}},
+		Text:    "Hello.",
+	}
+
+	a := rfc.Build(m, rfc.WithContentHashMessageID("example.com"))
+	b := rfc.Build(m, rfc.WithContentHashMessageID("example.com"))
+	id := rfc.ContentHashGenerator("example.com").GenerateID(m)
+
+	assert.Contains(t, a, "Message-ID: "+id+"\r\n")
+	assert.Contains(t, b, "Message-ID: "+id+"\r\n")
+}
+
+func TestWithMessageIDDomain(t *testing.T) {
+	tests := []struct {
+		name   string
+		from   mail.Address
+		domain func(mail.Address) string
+		want   string
+	}{
+		{
+			name: "default",
+			from: mail.Address{Address: "bob@example.com"},
+			want: "example.com",
+		},
+		{
+			name: "custom domain",
+			from: mail.Address{Address: "bob@mail.example.com"},
+			domain: func(from mail.Address) string {
+				return strings.TrimPrefix(rfc.FromDomain(from), "mail.")
+			},
+			want: "example.com",
+		},
+		{
+			name:   "empty custom domain",
+			from:   mail.Address{Address: "bob@example.com"},
+			domain: func(mail.Address) string { return "" },
+			want:   "example.com",
+		},
+		{
+			name: "without sender",
+			want: "localhost",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := rfc.Mail{From: test.from, Text: "Hello."}
+			msg, err := mail.ReadMessage(strings.NewReader(rfc.Build(m, rfc.WithMessageIDDomain(test.domain))))
+			assert.Nil(t, err)
+
+			id := msg.Header.Get("Message-ID")
+			assert.True(t, strings.HasPrefix(id, "<"))
+			assert.True(t, strings.HasSuffix(id, "@"+test.want+">"), id)
+
+			_, err = uuid.Parse(strings.TrimPrefix(strings.Split(id, "@")[0], "<"))
+			assert.Nil(t, err)
+		})
+	}
+}
@@ -5,10 +5,10 @@ package rfc
 import (
 	"crypto/md5"
 	"crypto/sha1"
-	"encoding/base64"
 	"fmt"
 	"net/mail"
 	"net/textproto"
+	"sort"
 	"strings"
 	"time"
 
@@ -17,28 +17,74 @@ import (
 
 // Mail contains the data of a mail.
 type Mail struct {
-	Subject     string
-	From        mail.Address
-	To          []mail.Address
-	CC          []mail.Address
-	BCC         []mail.Address
-	ReplyTo     []mail.Address
-	Text        string
-	HTML        string
-	Attachments []Attachment
-}
-
-// Attachment is a mail attachment.
+	Subject       string
+	From          mail.Address
+	To            []mail.Address
+	ToGroups      []Group
+	CC            []mail.Address
+	CCGroups      []Group
+	BCC           []mail.Address
+	ReplyTo       []mail.Address
+	AutoSubmitted string
+	Precedence    string
+	Organization  string
+	ReturnPath    string
+	Text          string
+	HTML          string
+	Alternatives  []Part
+	Attachments   []Attachment
+	// Header contains additional top-level headers of the mail, e.g.
+	// `X-Request-ID`. A header replaces the header with the same key that is
+	// generated from the other fields.
+	Header textproto.MIMEHeader
+}
+
+// Part is an additional alternative representation of the content of a mail.
+// Parts are placed in the `multipart/alternative` block after the HTML part,
+// in the order they are provided. Parts with BeforeHTML set to true are
+// placed between the text and the HTML part instead.
+type Part struct {
+	ContentType string
+	Content     []byte
+	BeforeHTML  bool
+}
+
+// Attachment is a mail attachment. Attachments with an `inline`
+// `Content-Disposition` header are placed in a `multipart/related` part
+// together with the text and HTML content, all others are placed in the
+// `multipart/mixed` part.
 type Attachment struct {
 	Filename string
 	Content  []byte
 	Header   textproto.MIMEHeader
 }
 
+// DefaultLineLength is the default length of base64-encoded lines.
+const DefaultLineLength = 76
+
 // Config is the builder config.
 type Config struct {
 	Clock     Clock
 	MessageID MessageIDFactory
+
+	// BodyLineLength is the length of the base64-encoded lines of the text,
+	// HTML and alternative parts. Defaults to DefaultLineLength.
+	BodyLineLength int
+	// AttachmentLineLength is the length of the base64-encoded lines of
+	// attachments. Defaults to DefaultLineLength.
+	AttachmentLineLength int
+	// Charset is the charset of the text parts. Defaults to UTF-8.
+	Charset string
+	// UndisclosedRecipients is the name of the empty group that is used as
+	// the `To` header of mails that only have BCC recipients. No placeholder
+	// is added if it's empty.
+	UndisclosedRecipients string
+	// WithoutGeneratedHeaders disables the generation of the `Message-ID` and
+	// `Date` headers.
+	WithoutGeneratedHeaders bool
+	// MailerIdentity is the value of the `X-Mailer` and `User-Agent` headers.
+	// The headers are omitted if it's empty.
+	MailerIdentity string
 }
 
 // A Clock provides the current time.
@@ -63,6 +109,7 @@ type Option func(*Config)
 type builder struct {
 	cfg        Config
 	boundaries int
+	err        error
 }
 
 // Build the mail according to RFC 5322.
@@ -76,6 +123,25 @@ func Build(mail Mail, opts ...Option) string {
 
 // BuildConfig the mail according to RFC 5322.
 func BuildConfig(mail Mail, cfg Config) string {
+	s, _ := TryBuildConfig(mail, cfg)
+	return s
+}
+
+// TryBuild does the same as Build() but also returns the error of a text part
+// that could not be transcoded to the configured charset (see WithCharset()).
+// The returned body is valid even if the error is not nil; the text parts that
+// couldn't be transcoded are built in UTF-8.
+func TryBuild(mail Mail, opts ...Option) (string, error) {
+	var cfg Config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return TryBuildConfig(mail, cfg)
+}
+
+// TryBuildConfig does the same as BuildConfig() but returns the error of a
+// text part that could not be transcoded. See TryBuild().
+func TryBuildConfig(mail Mail, cfg Config) (string, error) {
 	if cfg.Clock == nil {
 		cfg.Clock = ClockFunc(time.Now)
 	}
@@ -83,7 +149,8 @@ func BuildConfig(mail Mail, cfg Config) string {
 		cfg.MessageID = UUIDGenerator("")
 	}
 	b := builder{cfg: cfg}
-	return b.build(mail)
+	s := b.build(mail)
+	return s, b.err
 }
 
 // WithClock returns an Option that overrides the used Clock.
@@ -93,6 +160,27 @@ func WithClock(c Clock) Option {
 	}
 }
 
+// WithBodyLineLength returns an Option that sets the length of the
+// base64-encoded lines of the text, HTML and alternative parts. Values below 1
+// reset the length to DefaultLineLength. Note that RFC 2045 doesn't allow lines
+// longer than 76 characters.
+func WithBodyLineLength(n int) Option {
+	return func(cfg *Config) {
+		cfg.BodyLineLength = n
+	}
+}
+
+// WithAttachmentLineLength returns an Option that sets the length of the
+// base64-encoded lines of attachments. Values below 1 reset the length to
+// DefaultLineLength. Note that RFC 2045 doesn't allow lines longer than 76
+// characters. Attachments with another Content-Transfer-Encoding are not
+// affected.
+func WithAttachmentLineLength(n int) Option {
+	return func(cfg *Config) {
+		cfg.AttachmentLineLength = n
+	}
+}
+
 // WithMessageIDFactory returns an Option that specifies the used MessageIDFactory.
 func WithMessageIDFactory(id MessageIDFactory) Option {
 	return func(cfg *Config) {
@@ -107,13 +195,55 @@ func WithMessageID(id string) Option {
 	}
 }
 
+// WithoutGeneratedHeaders returns an Option that disables the generation of
+// the `Message-ID` and `Date` headers, so that the mail only contains the
+// headers that are explicitly set. Use it to relay a mail whose Message-ID and
+// Date are added by the relaying MTA, e.g. to not break threading or DKIM
+// signatures.
+func WithoutGeneratedHeaders() Option {
+	return func(cfg *Config) {
+		cfg.WithoutGeneratedHeaders = true
+	}
+}
+
+// WithMailerIdentity returns an Option that adds the `X-Mailer` and
+// `User-Agent` headers with the value name to the mail, which identify the
+// software that sent the mail, e.g. to diagnose delivery issues. If name is
+// empty, DefaultMailerIdentity() is used. The headers are omitted by default,
+// because they disclose the software (and its version) to every recipient.
+func WithMailerIdentity(name string) Option {
+	if name == "" {
+		name = DefaultMailerIdentity()
+	}
+	return func(cfg *Config) {
+		cfg.MailerIdentity = name
+	}
+}
+
+// WithoutMailerIdentity returns an Option that omits the `X-Mailer` and
+// `User-Agent` headers, e.g. to reset a previous WithMailerIdentity() option.
+func WithoutMailerIdentity() Option {
+	return func(cfg *Config) {
+		cfg.MailerIdentity = ""
+	}
+}
+
 var emptyAddr mail.Address
 
 func (b *builder) build(mail Mail) string {
-	lines := []string{
-		"MIME-Version: 1.0",
-		fmt.Sprintf("Message-ID: %s", b.cfg.MessageID.GenerateID(mail)),
-		fmt.Sprintf("Date: %s", b.cfg.Clock.Now().Format(time.RFC1123Z)),
+	lines := []string{"MIME-Version: 1.0"}
+
+	if !b.cfg.WithoutGeneratedHeaders {
+		lines = append(
+			lines,
+			fmt.Sprintf("Message-ID: %s", b.cfg.MessageID.GenerateID(mail)),
+			fmt.Sprintf("Date: %s", b.cfg.Clock.Now().Format(time.RFC1123Z)),
+		)
+	}
+
+	if mail.ReturnPath != "" {
+		// trace fields are placed at the top of the header
+		lines = append([]string{fmt.Sprintf("Return-Path: <%s>", mail.ReturnPath)}, lines...)
 	}
 
 	if mail.Subject != "" {
@@ -124,12 +254,14 @@ func (b *builder) build(mail Mail) string {
 		lines = append(lines, fmt.Sprintf("From: %s", mail.From.String()))
 	}
 
-	if len(mail.To) > 0 {
-		lines = append(lines, fmt.Sprintf("To: %s", joinAddresses(mail.To...)))
+	if len(mail.To) > 0 || len(mail.ToGroups) > 0 {
+		lines = append(lines, fmt.Sprintf("To: %s", joinRecipients(mail.To, mail.ToGroups)))
+	} else if b.cfg.UndisclosedRecipients != "" && onlyBCC(mail) {
+		lines = append(lines, fmt.Sprintf("To: %s", Group{Name: b.cfg.UndisclosedRecipients}))
 	}
 
-	if len(mail.CC) > 0 {
-		lines = append(lines, fmt.Sprintf("Cc: %s", joinAddresses(mail.CC...)))
+	if len(mail.CC) > 0 || len(mail.CCGroups) > 0 {
+		lines = append(lines, fmt.Sprintf("Cc: %s", joinRecipients(mail.CC, mail.CCGroups)))
 	}
 
 	if len(mail.BCC) > 0 {
@@ -140,27 +272,41 @@ func (b *builder) build(mail Mail) string {
 		lines = append(lines, fmt.Sprintf("Reply-To: %s", joinAddresses(mail.ReplyTo...)))
 	}
 
-	textLines := b.textLines(mail.Text)
-	htmlLines := b.htmlLines(mail.HTML)
+	if mail.AutoSubmitted != "" {
+		lines = append(lines, fmt.Sprintf("Auto-Submitted: %s", mail.AutoSubmitted))
+	}
+
+	if mail.Precedence != "" {
+		lines = append(lines, fmt.Sprintf("Precedence: %s", mail.Precedence))
+	}
+
+	if mail.Organization != "" {
+		lines = append(lines, fmt.Sprintf("Organization: %s", encode.UTF8(mail.Organization)))
+	}
+
+	if b.cfg.MailerIdentity != "" {
+		lines = append(
+			lines,
+			fmt.Sprintf("X-Mailer: %s", b.cfg.MailerIdentity),
+			fmt.Sprintf("User-Agent: %s", b.cfg.MailerIdentity),
+		)
+	}
+
+	lines = withCustomHeaders(lines, mail.Header)
+	sanitizeHeaders(lines)
 
-	if len(mail.Attachments) == 0 {
-		return strings.Join(append(lines, b.bodyWithoutAttachments(textLines, htmlLines)...), "\r\n")
+	parts := alternativeParts(mail)
+	inline, attachments := splitInline(mail.Attachments)
+
+	if len(attachments) == 0 {
+		return strings.Join(append(lines, b.relatedBody(parts, inline)...), "\r\n")
 	}
 
 	lines = append(lines, b.contentType("multipart/mixed", func(bd string) []string {
-		lines := append([]string{startBoundary(bd)}, b.bodyWithoutAttachments(textLines, htmlLines)...)
-		for _, at := range mail.Attachments {
-			lines = append(
-				lines,
-				startBoundary(bd),
-				fmt.Sprintf("Content-Type: %s", at.Header.Get("Content-Type")),
-				fmt.Sprintf(`Content-Disposition: attachment; size=%d; filename="%s"`, len(at.Content), encode.UTF8(at.Filename)),
-				fmt.Sprintf("Content-ID: <%s_%s>", fmt.Sprintf("%x", sha1.Sum(at.Content))[:12], encode.ToASCII(at.Filename)),
-				"Content-Transfer-Encoding: base64",
-				"",
-				fold(base64.StdEncoding.EncodeToString(at.Content), 76),
-				"",
-			)
+		lines := append([]string{startBoundary(bd)}, b.relatedBody(parts, inline)...)
+		for _, at := range attachments {
+			lines = append(lines, startBoundary(bd))
+			lines = append(lines, b.attachmentLines(at)...)
 		}
 		return append(lines, endBoundary(bd))
 	})...)
@@ -168,52 +314,78 @@ func (b *builder) build(mail Mail) string {
 	return strings.Join(lines, "\r\n")
 }
 
-func (b *builder) bodyWithoutAttachments(text []string, html []string) (lines []string) {
-	if len(text) > 0 && len(html) > 0 {
-		lines = append(lines, b.contentType("multipart/alternative", func(bd string) []string {
-			lines := append([]string{startBoundary(bd)}, text...)
-			lines = append(lines, append([]string{startBoundary(bd)}, html...)...)
-			lines = append(lines, endBoundary(bd))
-			return lines
-		})...)
-	} else if len(text) > 0 {
-		lines = append(lines, text...)
-	} else if len(html) > 0 {
-		lines = append(lines, html...)
+// relatedBody returns the body of the mail without the regular attachments.
+// If there are inline attachments, the alternative parts and the inline
+// attachments are wrapped in a `multipart/related` part, so that the HTML part
+// can reference the inline attachments by their `Content-ID`.
+func (b *builder) relatedBody(parts []Part, inline []Attachment) []string {
+	if len(inline) == 0 {
+		return b.bodyWithoutAttachments(parts)
 	}
-	return
-}
 
-func (b *builder) contentType(ct string, fn func(string) []string) []string {
-	bd := b.newBoundary()
-	lines := []string{fmt.Sprintf(`Content-Type: %s; boundary="%s"`, ct, bd), "", ""}
-	return append(lines, fn(bd)...)
+	return b.contentType("multipart/related", func(bd string) []string {
+		var lines []string
+		if len(parts) > 0 {
+			lines = append(lines, startBoundary(bd))
+			lines = append(lines, b.bodyWithoutAttachments(parts)...)
+		}
+		for _, at := range inline {
+			lines = append(lines, startBoundary(bd))
+			lines = append(lines, b.attachmentLines(at)...)
+		}
+		return append(lines, endBoundary(bd))
+	})
 }
 
-func (b *builder) textLines(text string) []string {
-	if text == "" {
-		return nil
+func (b *builder) attachmentLines(at Attachment) []string {
+	enc := attachmentEncoding(at)
+	lines := []string{
+		fmt.Sprintf("Content-Type: %s", at.Header.Get("Content-Type")),
+		fmt.Sprintf("Content-Disposition: %s", attachmentDisposition(at)),
+		fmt.Sprintf("Content-ID: %s", attachmentID(at)),
+		fmt.Sprintf("Content-Transfer-Encoding: %s", enc),
 	}
-
-	return []string{
-		"Content-Type: text/plain; charset=utf-8",
-		"Content-Transfer-Encoding: base64",
+	lines = append(lines, additionalHeaders(at.Header)...)
+	sanitizeHeaders(lines)
+	return append(
+		lines,
 		"",
-		fold(base64.StdEncoding.EncodeToString([]byte(text)), 76),
+		encodeContent(enc, at.Content, lineLength(b.cfg.AttachmentLineLength)),
 		"",
-	}
+	)
 }
 
-func (b *builder) htmlLines(html string) []string {
-	if html == "" {
+func (b *builder) bodyWithoutAttachments(parts []Part) (lines []string) {
+	switch len(parts) {
+	case 0:
 		return nil
+	case 1:
+		return b.partLines(parts[0])
 	}
 
+	return b.contentType("multipart/alternative", func(bd string) []string {
+		var lines []string
+		for _, part := range parts {
+			lines = append(lines, startBoundary(bd))
+			lines = append(lines, b.partLines(part)...)
+		}
+		return append(lines, endBoundary(bd))
+	})
+}
+
+func (b *builder) contentType(ct string, fn func(string) []string) []string {
+	bd := b.newBoundary()
+	lines := []string{fmt.Sprintf(`Content-Type: %s; boundary="%s"`, ct, bd), "", ""}
+	return append(lines, fn(bd)...)
+}
+
+func (b *builder) partLines(p Part) []string {
+	ct, content := b.textPart(p)
 	return []string{
-		"Content-Type: text/html; charset=utf-8",
+		sanitizeHeader(fmt.Sprintf("Content-Type: %s", ct)),
 		"Content-Transfer-Encoding: base64",
 		"",
-		fold(base64.StdEncoding.EncodeToString([]byte(html)), 76),
+		encodeContent(Base64, content, lineLength(b.cfg.BodyLineLength)),
 		"",
 	}
 }
@@ -234,6 +406,155 @@ func (id MessageIDFunc) GenerateID(m Mail) string {
 	return id(m)
 }
 
+// alternativeParts returns the non-empty alternative parts of mail in the
+// order text, parts before HTML, HTML, remaining parts.
+func alternativeParts(mail Mail) []Part {
+	var parts []Part
+	add := func(p Part) {
+		if len(p.Content) > 0 {
+			parts = append(parts, p)
+		}
+	}
+
+	add(Part{ContentType: "text/plain", Content: []byte(mail.Text)})
+	for _, p := range mail.Alternatives {
+		if p.BeforeHTML {
+			add(p)
+		}
+	}
+	add(Part{ContentType: "text/html", Content: []byte(mail.HTML)})
+	for _, p := range mail.Alternatives {
+		if !p.BeforeHTML {
+			add(p)
+		}
+	}
+
+	return parts
+}
+
+// attachmentDisposition returns the `Content-Disposition` of at, which is
+// generated if at.Header doesn't contain it.
+func attachmentDisposition(at Attachment) string {
+	if disposition := at.Header.Get("Content-Disposition"); disposition != "" {
+		return disposition
+	}
+	return fmt.Sprintf(`attachment; size=%d; filename="%s"`, len(at.Content), encode.UTF8(at.Filename))
+}
+
+// splitInline splits ats into the attachments that have an `inline`
+// `Content-Disposition` and the remaining attachments.
+func splitInline(ats []Attachment) (inline, attachments []Attachment) {
+	for _, at := range ats {
+		if isInline(at) {
+			inline = append(inline, at)
+			continue
+		}
+		attachments = append(attachments, at)
+	}
+	return
+}
+
+func isInline(at Attachment) bool {
+	disposition := strings.SplitN(at.Header.Get("Content-Disposition"), ";", 2)[0]
+	return strings.EqualFold(strings.TrimSpace(disposition), "inline")
+}
+
+// attachmentID returns the `Content-ID` of at, which is generated if
+// at.Header doesn't contain it.
+func attachmentID(at Attachment) string {
+	if id := at.Header.Get("Content-ID"); id != "" {
+		return id
+	}
+	return fmt.Sprintf("<%s_%s>", fmt.Sprintf("%x", sha1.Sum(at.Content))[:12], encode.ToASCII(at.Filename))
+}
+
+// additionalHeaders returns the lines of the headers in h that are not
+// written by the builder itself, sorted by key.
+func additionalHeaders(h textproto.MIMEHeader) []string {
+	var keys []string
+	for key := range h {
+		switch textproto.CanonicalMIMEHeaderKey(key) {
+		case "Content-Type", "Content-Disposition", "Content-Id", "Content-Transfer-Encoding":
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var lines []string
+	for _, key := range keys {
+		for _, val := range h[key] {
+			lines = append(lines, fmt.Sprintf("%s: %s", key, val))
+		}
+	}
+	return lines
+}
+
+// withCustomHeaders returns lines with the headers in h. A header in h
+// replaces the lines of the header with the same key; other headers are
+// appended, sorted by key.
+func withCustomHeaders(lines []string, h textproto.MIMEHeader) []string {
+	if len(h) == 0 {
+		return lines
+	}
+
+	keys := make([]string, 0, len(h))
+	for key := range h {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		var custom []string
+		for _, val := range h[key] {
+			custom = append(custom, fmt.Sprintf("%s: %s", key, val))
+		}
+
+		result := make([]string, 0, len(lines)+len(custom))
+		replaced := false
+		for _, line := range lines {
+			if i := strings.Index(line, ":"); i > 0 && strings.EqualFold(line[:i], key) {
+				if !replaced {
+					result = append(result, custom...)
+					replaced = true
+				}
+				continue
+			}
+			result = append(result, line)
+		}
+		if !replaced {
+			result = append(result, custom...)
+		}
+		lines = result
+	}
+
+	return lines
+}
+
+var lineBreaks = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+// sanitizeHeader replaces the line breaks in the header line with spaces, so
+// that values which contain line breaks (e.g. a display name with a CRLF
+// sequence) can't inject additional headers or a premature body into the
+// mail. letter.TryWrite() rejects such values, but mails that are built from
+// an rfc.Mail directly or from a modified letter.Letter are not validated.
+func sanitizeHeader(line string) string {
+	return lineBreaks.Replace(line)
+}
+
+func sanitizeHeaders(lines []string) {
+	for i, line := range lines {
+		lines[i] = sanitizeHeader(line)
+	}
+}
+
+func attachmentEncoding(at Attachment) string {
+	if enc := strings.ToLower(at.Header.Get("Content-Transfer-Encoding")); enc != "" {
+		return enc
+	}
+	return Base64
+}
+
 func joinAddresses(addrs ...mail.Address) string {
 	addrstrs := make([]string, len(addrs))
 	for i, addr := range addrs {
@@ -250,19 +571,23 @@ func endBoundary(bd string) string {
 	return fmt.Sprintf("%s--", startBoundary(bd))
 }
 
-func fold(s string, after int) string {
-	sub := ""
-	subs := []string{}
+// fold inserts a CRLF line break after every n characters of s.
+func fold(s string, n int) string {
 	runes := []rune(s)
-	l := len(runes)
+	var b strings.Builder
+	b.Grow(len(s) + 2*(len(runes)/n))
 	for i, r := range runes {
-		sub = sub + string(r)
-		if (i+1)%after == 0 {
-			subs = append(subs, sub)
-			sub = ""
-		} else if (i + 1) == l {
-			subs = append(subs, sub)
+		if i > 0 && i%n == 0 {
+			b.WriteString("\r\n")
 		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func lineLength(n int) int {
+	if n < 1 {
+		return DefaultLineLength
 	}
-	return strings.Join(subs, "\r\n")
+	return n
 }
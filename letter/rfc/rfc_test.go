@@ -1,10 +1,17 @@
 package rfc_test
 
 import (
+	"bytes"
 	"crypto/md5"
 	"crypto/sha1"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
 	"strings"
 	"testing"
 	"time"
@@ -252,6 +259,330 @@ func TestBuild(t *testing.T) {
 	}
 }
 
+func TestBuild_attachmentEncoding(t *testing.T) {
+	content := []byte("Hällo.\nline 2")
+
+	tests := []struct {
+		name     string
+		encoding string
+		content  []byte
+		expected string
+	}{
+		{
+			name:     "default",
+			content:  content,
+			expected: join("Content-Transfer-Encoding: base64", "", fold(base64.StdEncoding.EncodeToString(content), 76)),
+		},
+		{
+			name:     "quoted-printable",
+			encoding: rfc.QuotedPrintable,
+			content:  content,
+			expected: join("Content-Transfer-Encoding: quoted-printable", "", "H=C3=A4llo.", "line 2"),
+		},
+		{
+			name:     "7bit",
+			encoding: rfc.SevenBit,
+			content:  []byte("Hello.\nline 2"),
+			expected: join("Content-Transfer-Encoding: 7bit", "", "Hello.", "line 2"),
+		},
+		{
+			name:     "8bit",
+			encoding: "8BIT",
+			content:  content,
+			expected: join("Content-Transfer-Encoding: 8bit", "", "Hällo.", "line 2"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var opts []letter.AttachmentOption
+			if test.encoding != "" {
+				opts = append(opts, letter.AttachmentEncoding(test.encoding))
+			}
+
+			let, err := letter.TryWrite(append(baseLetterOpts, letter.Attach("attach", test.content, opts...))...)
+			assert.Nil(t, err)
+
+			s := rfc.Build(rfc.Mail{
+				Subject:     let.Subject(),
+				From:        let.From(),
+				To:          let.To(),
+				Attachments: mapAttachments(let.Attachments()...),
+			})
+
+			assert.Contains(t, s, test.expected)
+		})
+	}
+}
+
+func TestBuild_lineLength(t *testing.T) {
+	content := bytes.Repeat([]byte("a"), 150)
+	encoded := base64.StdEncoding.EncodeToString(content)
+	m := rfc.Mail{
+		Text:        string(content),
+		Attachments: []rfc.Attachment{{Filename: "attach", Content: content, Header: textproto.MIMEHeader{}}},
+	}
+
+	s := rfc.Build(m)
+	assert.Equal(t, 2, strings.Count(s, "\r\n"+encoded[:76]+"\r\n"+encoded[76:152]+"\r\n"))
+
+	s = rfc.Build(m, rfc.WithBodyLineLength(64), rfc.WithAttachmentLineLength(76))
+	assert.Contains(t, s, "\r\n"+encoded[:64]+"\r\n"+encoded[64:128]+"\r\n")
+	assert.Contains(t, s, "\r\n"+encoded[:76]+"\r\n"+encoded[76:152]+"\r\n")
+
+	s = rfc.Build(m, rfc.WithAttachmentLineLength(40))
+	assert.Contains(t, s, "\r\n"+encoded[:76]+"\r\n")
+	assert.Contains(t, s, "\r\n"+encoded[:40]+"\r\n"+encoded[40:80]+"\r\n")
+}
+
+func TestBuild_autoSubmitted(t *testing.T) {
+	let := letter.Write(append(baseLetterOpts, letter.ReplyTo("Bosco", "bosco@example.com"), letter.Text("Hello."))...)
+
+	s := rfc.Build(rfc.Mail{
+		Subject:       let.Subject(),
+		ReplyTo:       let.ReplyTo(),
+		AutoSubmitted: letter.AutoGenerated,
+		Text:          let.Text(),
+	})
+
+	assert.Contains(t, s, join(
+		`Reply-To: "Bosco" <bosco@example.com>`,
+		"Auto-Submitted: auto-generated",
+		"Content-Type: text/plain; charset=utf-8",
+	))
+
+	s = rfc.Build(rfc.Mail{Subject: let.Subject(), Text: let.Text()})
+	assert.NotContains(t, s, "Auto-Submitted")
+}
+
+func TestBuild_header(t *testing.T) {
+	s := rfc.Build(rfc.Mail{
+		Subject: "Hi.",
+		Text:    "Hello.",
+		Header: textproto.MIMEHeader{
+			"X-Request-Id": {"foo"},
+			"Subject":      {"Replaced"},
+			"X-Injected":   {"bar\r\nBcc: attacker@example.com"},
+		},
+	}, rfc.WithoutGeneratedHeaders())
+
+	assert.True(t, strings.HasPrefix(s, join(
+		"MIME-Version: 1.0",
+		"Subject: Replaced",
+		"X-Injected: bar Bcc: attacker@example.com",
+		"X-Request-Id: foo",
+		"Content-Type: text/plain; charset=utf-8",
+	)))
+}
+
+func TestWithoutGeneratedHeaders(t *testing.T) {
+	m := rfc.Mail{Subject: "Hi.", Text: "Hello."}
+
+	s := rfc.Build(m)
+	assert.Contains(t, s, "\r\nMessage-ID: ")
+	assert.Contains(t, s, "\r\nDate: ")
+
+	s = rfc.Build(m, rfc.WithoutGeneratedHeaders(), rfc.WithMessageID("<foo@example.com>"))
+	assert.True(t, strings.HasPrefix(s, join("MIME-Version: 1.0", "Subject: "+encode.UTF8("Hi."))))
+	assert.NotContains(t, s, "Message-ID")
+	assert.NotContains(t, s, "Date")
+}
+
+func TestWithMailerIdentity(t *testing.T) {
+	m := rfc.Mail{Subject: "Hi.", Text: "Hello."}
+
+	lines := strings.Split(rfc.Build(m), "\r\n")
+	for _, line := range lines {
+		assert.False(t, strings.HasPrefix(line, "X-Mailer:"))
+		assert.False(t, strings.HasPrefix(line, "User-Agent:"))
+	}
+
+	lines = strings.Split(rfc.Build(m, rfc.WithMailerIdentity("Bob's Mailer/1.0")), "\r\n")
+	assert.Contains(t, lines, "X-Mailer: Bob's Mailer/1.0")
+	assert.Contains(t, lines, "User-Agent: Bob's Mailer/1.0")
+
+	lines = strings.Split(rfc.Build(m, rfc.WithMailerIdentity("")), "\r\n")
+	assert.Contains(t, lines, "X-Mailer: "+rfc.DefaultMailerIdentity())
+	assert.True(t, strings.HasPrefix(rfc.DefaultMailerIdentity(), "postdog"))
+
+	s := rfc.Build(m, rfc.WithMailerIdentity("Bob's Mailer/1.0"), rfc.WithoutMailerIdentity())
+	assert.NotContains(t, s, "X-Mailer")
+	assert.NotContains(t, s, "User-Agent")
+}
+
+func TestBuild_alternatives(t *testing.T) {
+	tests := []struct {
+		name         string
+		text         string
+		html         string
+		alternatives []rfc.Part
+		expected     []string
+	}{
+		{
+			name:         "after html",
+			text:         "Hello.",
+			html:         "<p>Hello.</p>",
+			alternatives: []rfc.Part{{ContentType: "text/x-amp-html", Content: []byte("<p>AMP</p>")}},
+			expected: []string{
+				"Content-Type: text/plain; charset=utf-8",
+				"Content-Type: text/html; charset=utf-8",
+				"Content-Type: text/x-amp-html; charset=utf-8",
+			},
+		},
+		{
+			name: "before html",
+			text: "Hello.",
+			html: "<p>Hello.</p>",
+			alternatives: []rfc.Part{
+				{ContentType: "text/markdown; charset=us-ascii", Content: []byte("*Hello.*")},
+				{ContentType: "text/x-amp-html", Content: []byte("<p>AMP</p>"), BeforeHTML: true},
+			},
+			expected: []string{
+				"Content-Type: text/plain; charset=utf-8",
+				"Content-Type: text/x-amp-html; charset=utf-8",
+				"Content-Type: text/html; charset=utf-8",
+				"Content-Type: text/markdown; charset=us-ascii",
+			},
+		},
+		{
+			name:         "without text",
+			html:         "<p>Hello.</p>",
+			alternatives: []rfc.Part{{ContentType: "text/x-amp-html", Content: []byte("<p>AMP</p>"), BeforeHTML: true}},
+			expected: []string{
+				"Content-Type: text/x-amp-html; charset=utf-8",
+				"Content-Type: text/html; charset=utf-8",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := rfc.Build(rfc.Mail{
+				Text:         test.text,
+				HTML:         test.html,
+				Alternatives: test.alternatives,
+			})
+
+			assert.Contains(t, s, fmt.Sprintf(`Content-Type: multipart/alternative; boundary="%s"`, boundary(0)))
+
+			var pos []int
+			for _, ct := range test.expected {
+				i := strings.Index(s, startBoundary(0)+"\r\n"+ct+"\r\n")
+				assert.True(t, i >= 0, "missing part: %s", ct)
+				pos = append(pos, i)
+			}
+			for i := 1; i < len(pos); i++ {
+				assert.True(t, pos[i-1] < pos[i], "wrong order: %s", test.expected[i])
+			}
+		})
+	}
+}
+
+func TestBuild_inlineAttachments(t *testing.T) {
+	logo := textproto.MIMEHeader{}
+	logo.Set("Content-Type", "image/png")
+	logo.Set("Content-Disposition", `Inline; filename="logo.png"`)
+	logo.Set("Content-ID", "<logo@example.com>")
+
+	pdf := textproto.MIMEHeader{}
+	pdf.Set("Content-Type", "application/pdf")
+
+	inline := rfc.Attachment{Filename: "logo.png", Content: []byte{1, 2, 3}, Header: logo}
+	attachment := rfc.Attachment{Filename: "invoice.pdf", Content: []byte{4, 5, 6}, Header: pdf}
+
+	tests := []struct {
+		name     string
+		mail     rfc.Mail
+		expected string
+	}{
+		{
+			name:     "inline only",
+			mail:     rfc.Mail{Text: "Hello.", HTML: `<img src="cid:logo@example.com">`, Attachments: []rfc.Attachment{inline}},
+			expected: "multipart/related[multipart/alternative[text/plain,text/html],image/png]",
+		},
+		{
+			name:     "inline & attachment",
+			mail:     rfc.Mail{Text: "Hello.", HTML: `<img src="cid:logo@example.com">`, Attachments: []rfc.Attachment{attachment, inline}},
+			expected: "multipart/mixed[multipart/related[multipart/alternative[text/plain,text/html],image/png],application/pdf]",
+		},
+		{
+			name:     "html & inline",
+			mail:     rfc.Mail{HTML: `<img src="cid:logo@example.com">`, Attachments: []rfc.Attachment{inline}},
+			expected: "multipart/related[text/html,image/png]",
+		},
+		{
+			name:     "attachment only",
+			mail:     rfc.Mail{Text: "Hello.", Attachments: []rfc.Attachment{attachment}},
+			expected: "multipart/mixed[text/plain,application/pdf]",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := rfc.Build(test.mail)
+
+			msg, err := mail.ReadMessage(strings.NewReader(s))
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, mimeTree(t, textproto.MIMEHeader(msg.Header), msg.Body))
+		})
+	}
+}
+
+// mimeTree returns the nesting of the MIME parts of a mail as a string, e.g.
+// `multipart/mixed[text/plain,application/pdf]`.
+func mimeTree(t *testing.T, h textproto.MIMEHeader, body io.Reader) string {
+	mediaType, params, err := mime.ParseMediaType(h.Get("Content-Type"))
+	assert.Nil(t, err)
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		return mediaType
+	}
+
+	var parts []string
+	r := multipart.NewReader(body, params["boundary"])
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		parts = append(parts, mimeTree(t, p.Header, p))
+	}
+
+	return fmt.Sprintf("%s[%s]", mediaType, strings.Join(parts, ","))
+}
+
+func TestValidateEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		content  []byte
+		valid    bool
+	}{
+		{name: "empty", content: []byte{0, 200}, valid: true},
+		{name: "base64", encoding: rfc.Base64, content: []byte{0, 200}, valid: true},
+		{name: "quoted-printable", encoding: rfc.QuotedPrintable, content: []byte{0, 200}, valid: true},
+		{name: "7bit", encoding: rfc.SevenBit, content: []byte("Hello."), valid: true},
+		{name: "7bit, non-ASCII", encoding: rfc.SevenBit, content: []byte("Hällo.")},
+		{name: "7bit, NUL", encoding: rfc.SevenBit, content: []byte{'a', 0}},
+		{name: "8bit", encoding: rfc.EightBit, content: []byte("Hällo."), valid: true},
+		{name: "8bit, NUL", encoding: rfc.EightBit, content: []byte{'a', 0}},
+		{name: "8bit, long line", encoding: rfc.EightBit, content: []byte(strings.Repeat("a", 999))},
+		{name: "8bit, max line length", encoding: rfc.EightBit, content: []byte(strings.Repeat("a", 998) + "\r\n"), valid: true},
+		{name: "unknown", encoding: "binary", content: []byte("Hello.")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := rfc.ValidateEncoding(test.encoding, test.content)
+			if test.valid {
+				assert.Nil(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, rfc.ErrInvalidEncoding))
+		})
+	}
+}
+
 func join(lines ...string) string {
 	return strings.Join(lines, "\r\n")
 }
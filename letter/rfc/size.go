@@ -0,0 +1,120 @@
+package rfc
+
+import (
+	"net/mail"
+
+	"github.com/bounoable/postdog/internal/encode"
+)
+
+// Approximate sizes of the parts of a built mail that are not derived from
+// the mail's data.
+const (
+	// MIME-Version, Message-ID and Date headers
+	sizeGeneratedHeaders = 19 + 64 + 39
+	// Content-Type + Content-Transfer-Encoding headers of a part
+	sizePartHeaders = 75
+	// boundary line of a part in a multipart block
+	sizeBoundary = 36
+	// Content-Type header of a multipart block and the closing boundary
+	sizeMultipart = 90 + 38
+	// Content-Type, Content-Disposition, Content-ID and Content-Transfer-Encoding
+	// headers of an attachment, excluding the filename and content type
+	sizeAttachmentHeaders = 160
+)
+
+// EstimateSize returns the approximate size in bytes of the RFC body that
+// Build() builds for m, without actually building it. The size of the
+// body parts and attachments is estimated from the length of their content
+// and their transfer encoding, e.g. base64 encoded content is estimated with
+// 4/3 of its length plus line breaks.
+func EstimateSize(m Mail) int64 {
+	size := int64(sizeGeneratedHeaders)
+
+	if m.Subject != "" {
+		size += headerSize("Subject", encode.UTF8(m.Subject))
+	}
+
+	if m.From != emptyAddr {
+		size += headerSize("From", m.From.String())
+	}
+
+	for _, h := range []struct {
+		key    string
+		addrs  []mail.Address
+		groups []Group
+	}{
+		{"To", m.To, m.ToGroups},
+		{"Cc", m.CC, m.CCGroups},
+		{"Bcc", m.BCC, nil},
+		{"Reply-To", m.ReplyTo, nil},
+	} {
+		if len(h.addrs) > 0 || len(h.groups) > 0 {
+			size += headerSize(h.key, joinRecipients(h.addrs, h.groups))
+		}
+	}
+
+	if m.AutoSubmitted != "" {
+		size += headerSize("Auto-Submitted", m.AutoSubmitted)
+	}
+
+	if m.Precedence != "" {
+		size += headerSize("Precedence", m.Precedence)
+	}
+
+	if m.Organization != "" {
+		size += headerSize("Organization", encode.UTF8(m.Organization))
+	}
+
+	if m.ReturnPath != "" {
+		size += headerSize("Return-Path", "<"+m.ReturnPath+">")
+	}
+
+	for key, vals := range m.Header {
+		for _, val := range vals {
+			size += headerSize(key, val)
+		}
+	}
+
+	parts := alternativeParts(m)
+	if len(parts) > 1 {
+		size += sizeMultipart + sizeBoundary*int64(len(parts))
+	}
+	for _, p := range parts {
+		size += sizePartHeaders + int64(len(p.ContentType)) + encodedSize(Base64, len(p.Content))
+	}
+
+	if len(m.Attachments) > 0 {
+		size += sizeMultipart + sizeBoundary
+	}
+	for _, at := range m.Attachments {
+		size += sizeBoundary + sizeAttachmentHeaders +
+			int64(len(at.Header.Get("Content-Type"))) +
+			3*int64(len(at.Filename)) +
+			encodedSize(attachmentEncoding(at), len(at.Content))
+	}
+
+	return size
+}
+
+func headerSize(key, val string) int64 {
+	return int64(len(key) + len(": ") + len(val) + len("\r\n"))
+}
+
+// encodedSize returns the approximate size of n bytes of content that are
+// encoded with the content transfer encoding enc, including line breaks.
+func encodedSize(enc string, n int) int64 {
+	switch enc {
+	case QuotedPrintable:
+		// most content is ASCII, soft line breaks every 76 characters
+		size := int64(n) + int64(n)/10
+		return size + 3*(size/76)
+	case SevenBit, EightBit:
+		return int64(n)
+	default:
+		size := int64((n + 2) / 3 * 4)
+		if size > 0 {
+			size += 2 * ((size - 1) / 76)
+		}
+		return size
+	}
+}
@@ -0,0 +1,72 @@
+package rfc_test
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/bounoable/postdog/letter"
+	"github.com/bounoable/postdog/letter/rfc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimateSize(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []letter.Option
+	}{
+		{
+			name: "text only",
+			opts: []letter.Option{letter.Text("Hello.")},
+		},
+		{
+			name: "text & html",
+			opts: []letter.Option{letter.Content("Hello.", "<p>Hello.</p>")},
+		},
+		{
+			name: "large body",
+			opts: []letter.Option{
+				letter.Content(
+					string(bytes.Repeat([]byte("Hello. "), 2000)),
+					string(bytes.Repeat([]byte("<p>Hello.</p>"), 2000)),
+				),
+			},
+		},
+		{
+			name: "attachments",
+			opts: []letter.Option{
+				letter.Content("Hello.", "<p>Hello.</p>"),
+				letter.CC("Tina Belcher", "tina@example.com"),
+				letter.Attach("attach1.bin", bytes.Repeat([]byte{1, 2, 3}, 10000)),
+				letter.Attach("attach2.txt", bytes.Repeat([]byte("line\n"), 2000), letter.AttachmentEncoding(rfc.QuotedPrintable)),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			let := letter.Write(append(baseLetterOpts, test.opts...)...)
+			actual := float64(len(rfc.Build(rfc.Mail{
+				Subject:     let.Subject(),
+				From:        let.From(),
+				To:          let.To(),
+				CC:          let.CC(),
+				Text:        let.Text(),
+				HTML:        let.HTML(),
+				Attachments: mapAttachments(let.Attachments()...),
+			})))
+
+			estimated := float64(let.EstimatedSize())
+			assert.True(
+				t,
+				math.Abs(estimated-actual) <= math.Max(actual*0.05, 50),
+				"estimated size %v should be within 5%% of actual size %v", estimated, actual,
+			)
+		})
+	}
+}
+
+func TestEstimateSize_customRFC(t *testing.T) {
+	let := letter.Write(append(baseLetterOpts, letter.RFC("Subject: Hi.\r\n\r\nHello."))...)
+	assert.Equal(t, int64(len("Subject: Hi.\r\n\r\nHello.")), let.EstimatedSize())
+}
@@ -0,0 +1,101 @@
+package letter
+
+import (
+	"net/mail"
+	"strings"
+)
+
+// SplitByRecipientDomain splits l into one letter per recipient domain. Each
+// returned letter is a copy of l whose recipients, To, CC and BCC addresses
+// and group members are reduced to the addresses of a single domain. Groups
+// that have no members left are removed, except for groups that have no
+// members in l (e.g. `undisclosed-recipients:;`), which are kept in every
+// copy. Domains are compared case-insensitively and the letters are returned
+// in the order in which their domains first appear in l.Recipients().
+//
+// The content, alternatives and attachments of the copies share the memory
+// of l. If l has a custom RFC body (see RFC()), the body is not modified, so
+// only the recipients of the envelope differ. If l has no recipients, the
+// returned slice contains only l.
+func SplitByRecipientDomain(l Letter) []Letter {
+	var domains []string
+	for _, rcpt := range l.Recipients() {
+		if d := addressDomain(rcpt); !containsString(domains, d) {
+			domains = append(domains, d)
+		}
+	}
+
+	if len(domains) == 0 {
+		return []Letter{l}
+	}
+
+	letters := make([]Letter, len(domains))
+	for i, domain := range domains {
+		c := l
+		c.L.Recipients = filterDomain(l.L.Recipients, domain)
+		c.L.To = filterDomain(l.L.To, domain)
+		c.L.CC = filterDomain(l.L.CC, domain)
+		c.L.BCC = filterDomain(l.L.BCC, domain)
+		c.L.ToGroups = filterGroupDomain(l.L.ToGroups, domain)
+		c.L.CCGroups = filterGroupDomain(l.L.CCGroups, domain)
+		letters[i] = c
+	}
+
+	return letters
+}
+
+func filterDomain(addrs []mail.Address, domain string) []mail.Address {
+	var res []mail.Address
+	for _, addr := range addrs {
+		if addressDomain(addr) == domain {
+			res = append(res, addr)
+		}
+	}
+	return res
+}
+
+func filterGroupDomain(groups []Group, domain string) []Group {
+	var res []Group
+	for _, g := range groups {
+		if len(g.Addresses) == 0 {
+			res = append(res, g)
+			continue
+		}
+		if addrs := filterDomain(g.Addresses, domain); len(addrs) > 0 {
+			res = append(res, Group{Name: g.Name, Addresses: addrs})
+		}
+	}
+	return res
+}
+
+// RecipientDomains returns the lower-cased domains of the recipients of l
+// (see Recipients()) without duplicates, in the order in which they first
+// appear. Malformed addresses that have no domain are skipped.
+func (l Letter) RecipientDomains() []string {
+	var domains []string
+	for _, rcpt := range l.Recipients() {
+		if d := addressDomain(rcpt); d != "" && !containsString(domains, d) {
+			domains = append(domains, d)
+		}
+	}
+	return domains
+}
+
+// addressDomain returns the lower-cased domain of addr, or an empty string if
+// addr is malformed.
+func addressDomain(addr mail.Address) string {
+	i := strings.LastIndex(addr.Address, "@")
+	if i < 0 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(addr.Address[i+1:]))
+}
+
+func containsString(vals []string, v string) bool {
+	for _, val := range vals {
+		if val == v {
+			return true
+		}
+	}
+	return false
+}
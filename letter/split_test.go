@@ -0,0 +1,75 @@
+package letter_test
+
+import (
+	"net/mail"
+	"testing"
+
+	"github.com/bounoable/postdog/letter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitByRecipientDomain(t *testing.T) {
+	l := letter.Write(
+		letter.Subject("Hi."),
+		letter.From("Bob Belcher", "bob@example.com"),
+		letter.To("Linda Belcher", "linda@example.com"),
+		letter.To("Jimmy Pesto", "jimmy@pestos.com"),
+		letter.CC("Tina Belcher", "tina@EXAMPLE.com"),
+		letter.BCC("Andy Pesto", "andy@pestos.com"),
+		letter.Recipient("Teddy", "teddy@teddy.net"),
+		letter.ToGroup("Kids", mail.Address{Name: "Gene Belcher", Address: "gene@example.com"}, mail.Address{Name: "Ollie Pesto", Address: "ollie@pestos.com"}),
+		letter.CCGroup("undisclosed-recipients"),
+		letter.Text("Hello."),
+		letter.Attach("attach.txt", []byte("Hello.")),
+	)
+
+	letters := letter.SplitByRecipientDomain(l)
+	assert.Len(t, letters, 3)
+
+	example := letters[1]
+	assert.Equal(t, []mail.Address{{Name: "Linda Belcher", Address: "linda@example.com"}}, example.To())
+	assert.Equal(t, []mail.Address{{Name: "Tina Belcher", Address: "tina@EXAMPLE.com"}}, example.CC())
+	assert.Empty(t, example.BCC())
+	assert.Equal(t, []letter.Group{{Name: "Kids", Addresses: []mail.Address{{Name: "Gene Belcher", Address: "gene@example.com"}}}}, example.ToGroups())
+	assert.Equal(t, []letter.Group{{Name: "undisclosed-recipients"}}, example.CCGroups())
+	assert.Len(t, example.Recipients(), 3)
+
+	pestos := letters[2]
+	assert.Equal(t, []mail.Address{{Name: "Jimmy Pesto", Address: "jimmy@pestos.com"}}, pestos.To())
+	assert.Empty(t, pestos.CC())
+	assert.Equal(t, []mail.Address{{Name: "Andy Pesto", Address: "andy@pestos.com"}}, pestos.BCC())
+	assert.Equal(t, []letter.Group{{Name: "Kids", Addresses: []mail.Address{{Name: "Ollie Pesto", Address: "ollie@pestos.com"}}}}, pestos.ToGroups())
+	assert.Len(t, pestos.Recipients(), 3)
+
+	teddy := letters[0]
+	assert.Empty(t, teddy.To())
+	assert.Empty(t, teddy.ToGroups())
+	assert.Equal(t, []mail.Address{{Name: "Teddy", Address: "teddy@teddy.net"}}, teddy.Recipients())
+
+	for _, split := range letters {
+		assert.Equal(t, l.Subject(), split.Subject())
+		assert.Equal(t, l.From(), split.From())
+		assert.Equal(t, l.Text(), split.Text())
+		assert.Same(t, &l.Attachments()[0], &split.Attachments()[0])
+	}
+}
+
+func TestSplitByRecipientDomain_noRecipients(t *testing.T) {
+	l := letter.Write(letter.Text("Hello."))
+	assert.Equal(t, []letter.Letter{l}, letter.SplitByRecipientDomain(l))
+}
+
+func TestLetter_RecipientDomains(t *testing.T) {
+	l := letter.Write(
+		letter.To("Linda Belcher", "linda@example.com"),
+		letter.To("Jimmy Pesto", "jimmy@pestos.com"),
+		letter.CC("Tina Belcher", "tina@EXAMPLE.com"),
+		letter.BCC("Teddy", "teddy@teddy.net"),
+		letter.BCC("Mort", "mort"),
+		letter.BCC("Hugo", "hugo@"),
+		letter.ToGroup("Kids", mail.Address{Name: "Ollie Pesto", Address: "ollie@pestos.com"}),
+	)
+
+	assert.Equal(t, []string{"example.com", "pestos.com", "teddy.net"}, l.RecipientDomains())
+	assert.Empty(t, letter.Write().RecipientDomains())
+}
@@ -0,0 +1,256 @@
+package postdog
+
+import (
+	"fmt"
+	"mime"
+	"net/mail"
+	"strings"
+
+	"github.com/bounoable/postdog/internal/encode"
+)
+
+type fromMail struct {
+	Mail
+	from mail.Address
+}
+
+type subjectMail struct {
+	Mail
+	subject string
+}
+
+type headerMail struct {
+	Mail
+	key   string
+	value string
+}
+
+type frozenMail struct {
+	Mail
+	rfc string
+}
+
+type rawRFCMail struct {
+	from       mail.Address
+	recipients []mail.Address
+	rfc        string
+}
+
+// RawMail returns a Mail that sends the pre-built RFC 5322 message rfc from
+// the sender from to the recipients rcpts, e.g. to relay or forward a message
+// that has been received from another system. rfc is sent as-is; the sender
+// and recipients are only used for the envelope and don't need to match the
+// headers of rfc.
+func RawMail(from mail.Address, rcpts []mail.Address, rfc string) Mail {
+	return rawRFCMail{
+		from:       from,
+		recipients: append([]mail.Address(nil), rcpts...),
+		rfc:        rfc,
+	}
+}
+
+func (m rawRFCMail) From() mail.Address {
+	return m.from
+}
+
+func (m rawRFCMail) Recipients() []mail.Address {
+	return m.recipients
+}
+
+func (m rawRFCMail) RFC() string {
+	return m.rfc
+}
+
+// WithFrom returns a Mail that wraps m and overrides its sender with from.
+// The `From` header of the RFC body of m is replaced accordingly. m itself is
+// not modified.
+//
+// The returned Mail implements an Unwrap() method that returns m, so that
+// letter.Expand() can still access the optional methods of m.
+func WithFrom(m Mail, from mail.Address) Mail {
+	if fm, ok := m.(fromMail); ok {
+		m = fm.Mail
+	}
+	return fromMail{Mail: m, from: from}
+}
+
+func (m fromMail) From() mail.Address {
+	return m.from
+}
+
+func (m fromMail) RFC() string {
+	return replaceHeader(m.Mail.RFC(), "From", m.from.String())
+}
+
+func (m fromMail) Unwrap() Mail {
+	return m.Mail
+}
+
+// WithSubject returns a Mail that wraps m and overrides its subject. The
+// `Subject` header of the RFC body of m is replaced accordingly. m itself is
+// not modified.
+//
+// Like WithFrom(), the returned Mail implements an Unwrap() method that
+// returns m.
+func WithSubject(m Mail, subject string) Mail {
+	if sm, ok := m.(subjectMail); ok {
+		m = sm.Mail
+	}
+	return subjectMail{Mail: m, subject: subject}
+}
+
+// Subject returns the subject of m. If m doesn't implement a Subject() method,
+// the subject is read from the `Subject` header of the RFC body of m.
+func Subject(m Mail) string {
+	if sm, ok := m.(interface{ Subject() string }); ok {
+		return sm.Subject()
+	}
+	subject := headerValue(m.RFC(), "Subject")
+	if decoded, err := new(mime.WordDecoder).DecodeHeader(subject); err == nil {
+		return decoded
+	}
+	return subject
+}
+
+func (m subjectMail) Subject() string {
+	return m.subject
+}
+
+func (m subjectMail) RFC() string {
+	subject := m.subject
+	if subject != encode.ToASCII(subject) {
+		subject = encode.UTF8(subject)
+	}
+	return replaceHeader(m.Mail.RFC(), "Subject", subject)
+}
+
+func (m subjectMail) Unwrap() Mail {
+	return m.Mail
+}
+
+// WithHeader returns a Mail that wraps m and sets the header key of the RFC
+// body of m to value. An existing header with the same key is replaced. m
+// itself is not modified.
+//
+// Like WithFrom(), the returned Mail implements an Unwrap() method that
+// returns m. letter.Expand() adds the header to the expanded Letter (see
+// letter.Header()), so that middlewares which expand and modify the returned
+// Mail keep the header.
+func WithHeader(m Mail, key, value string) Mail {
+	return headerMail{Mail: m, key: key, value: value}
+}
+
+func (m headerMail) RFC() string {
+	return replaceHeader(m.Mail.RFC(), m.key, m.value)
+}
+
+// RFCHeader returns the key and value of the header that m sets.
+func (m headerMail) RFCHeader() (string, string) {
+	return m.key, m.value
+}
+
+func (m headerMail) Unwrap() Mail {
+	return m.Mail
+}
+
+// FreezeRFC returns a Mail that wraps m and always returns the RFC body that
+// m returns at the time of the call, e.g. to keep the `Message-ID` and `Date`
+// headers of a letter that builds new ones every time its RFC body is built.
+//
+// Like WithFrom(), the returned Mail implements an Unwrap() method that
+// returns m. letter.Expand() returns a Letter with the frozen RFC body.
+func FreezeRFC(m Mail) Mail {
+	if fm, ok := m.(frozenMail); ok {
+		return fm
+	}
+	return frozenMail{Mail: m, rfc: m.RFC()}
+}
+
+func (m frozenMail) RFC() string {
+	return m.rfc
+}
+
+// FrozenRFC returns the frozen RFC body of m.
+func (m frozenMail) FrozenRFC() string {
+	return m.rfc
+}
+
+func (m frozenMail) Unwrap() Mail {
+	return m.Mail
+}
+
+// headerValue returns the unfolded value of the header key in the RFC 5322
+// message body.
+func headerValue(body, key string) string {
+	nl := newline(body)
+	header := body
+	if i := strings.Index(body, nl+nl); i >= 0 {
+		header = body[:i]
+	}
+
+	var value []string
+	var found bool
+	for _, line := range strings.Split(header, nl) {
+		if found {
+			if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+				value = append(value, strings.TrimSpace(line))
+				continue
+			}
+			break
+		}
+		if i := strings.Index(line, ":"); i > 0 && strings.EqualFold(strings.TrimSpace(line[:i]), key) {
+			found = true
+			value = append(value, strings.TrimSpace(line[i+1:]))
+		}
+	}
+
+	return strings.Join(value, " ")
+}
+
+var lineBreaks = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+// replaceHeader replaces the header key in the RFC 5322 message body with the
+// given value. If body has no such header, it is added as the first header.
+func replaceHeader(body, key, value string) string {
+	nl := newline(body)
+	header, rest := body, ""
+	if i := strings.Index(body, nl+nl); i >= 0 {
+		header, rest = body[:i], body[i:]
+	}
+
+	// line breaks in the key or value would inject additional headers
+	newLine := lineBreaks.Replace(fmt.Sprintf("%s: %s", key, value))
+	lines := strings.Split(header, nl)
+	result := make([]string, 0, len(lines)+1)
+	var replaced, skipping bool
+	for _, line := range lines {
+		if skipping && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			continue
+		}
+		skipping = false
+
+		if i := strings.Index(line, ":"); i > 0 && strings.EqualFold(strings.TrimSpace(line[:i]), key) {
+			skipping = true
+			if !replaced {
+				result = append(result, newLine)
+				replaced = true
+			}
+			continue
+		}
+
+		result = append(result, line)
+	}
+
+	if !replaced {
+		result = append([]string{newLine}, result...)
+	}
+
+	return strings.Join(result, nl) + rest
+}
+
+func newline(body string) string {
+	if !strings.Contains(body, "\r\n") && strings.Contains(body, "\n") {
+		return "\n"
+	}
+	return "\r\n"
+}
@@ -0,0 +1,118 @@
+package postdog
+
+import (
+	"fmt"
+	"net/mail"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type rawMail string
+
+func (m rawMail) From() mail.Address {
+	return mail.Address{Address: "bob@example.com"}
+}
+
+func (m rawMail) Recipients() []mail.Address {
+	return []mail.Address{{Address: "linda@example.com"}}
+}
+
+func (m rawMail) RFC() string {
+	return string(m)
+}
+
+func TestRawMail(t *testing.T) {
+	body := "From: bob@example.com\r\nSubject: Hi.\r\n\r\nHello."
+	rcpts := []mail.Address{{Address: "linda@example.com"}, {Address: "tina@example.com"}}
+
+	m := RawMail(mail.Address{Address: "bounces@example.com"}, rcpts, body)
+	rcpts[0].Address = "gene@example.com"
+
+	assert.Equal(t, mail.Address{Address: "bounces@example.com"}, m.From())
+	assert.Equal(t, []mail.Address{{Address: "linda@example.com"}, {Address: "tina@example.com"}}, m.Recipients())
+	assert.Equal(t, body, m.RFC())
+	assert.Equal(t, "Hi.", Subject(m))
+}
+
+func TestReplaceHeader(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		key   string
+		value string
+		want  string
+	}{
+		{
+			name:  "replace header",
+			body:  "From: bob@example.com\r\nSubject: Hi.\r\n\r\nHello.",
+			key:   "Subject",
+			value: "Hello.",
+			want:  "From: bob@example.com\r\nSubject: Hello.\r\n\r\nHello.",
+		},
+		{
+			name:  "folded header",
+			body:  "Subject: Hi,\r\n there.\r\nFrom: bob@example.com\r\n\r\nHello.",
+			key:   "subject",
+			value: "Hello.",
+			want:  "subject: Hello.\r\nFrom: bob@example.com\r\n\r\nHello.",
+		},
+		{
+			name:  "missing header",
+			body:  "From: bob@example.com\n\nSubject: body",
+			key:   "Subject",
+			value: "Hi.",
+			want:  "Subject: Hi.\nFrom: bob@example.com\n\nSubject: body",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, replaceHeader(test.body, test.key, test.value))
+		})
+	}
+}
+
+func TestWithSubject(t *testing.T) {
+	m := rawMail("From: bob@example.com\r\nSubject: Hi,\r\n there.\r\n\r\nHello.")
+	assert.Equal(t, "Hi, there.", Subject(m))
+
+	sm := WithSubject(m, "Grüße")
+	assert.Equal(t, "Grüße", Subject(sm))
+	assert.Equal(t, "From: bob@example.com\r\nSubject: =?utf-8?B?R3LDvMOfZQ==?=\r\n\r\nHello.", sm.RFC())
+	assert.Equal(t, "Grüße", Subject(rawMail(sm.RFC())))
+	assert.Equal(t, "Hi, there.", Subject(m))
+}
+
+func TestWithHeader(t *testing.T) {
+	m := WithHeader(rawMail("Subject: Hi.\r\nX-Request-ID: foo\r\n\r\nHello."), "X-Request-ID", "bar")
+	assert.Equal(t, "Subject: Hi.\r\nX-Request-ID: bar\r\n\r\nHello.", m.RFC())
+	assert.Equal(t, mail.Address{Address: "bob@example.com"}, m.From())
+	assert.Equal(t, rawMail("Subject: Hi.\r\nX-Request-ID: foo\r\n\r\nHello."), m.(interface{ Unwrap() Mail }).Unwrap())
+}
+
+func TestWithHeader_injection(t *testing.T) {
+	m := WithHeader(rawMail("Subject: Hi.\r\n\r\nHello."), "X-Request-ID", "bar\r\nBcc: attacker@evil.com")
+	assert.Equal(t, "X-Request-ID: bar Bcc: attacker@evil.com\r\nSubject: Hi.\r\n\r\nHello.", m.RFC())
+}
+
+type counterMail struct {
+	rawMail
+	calls *int
+}
+
+func (m counterMail) RFC() string {
+	*m.calls++
+	return fmt.Sprintf("Message-ID: <%d@example.com>\r\n\r\nHello.", *m.calls)
+}
+
+func TestFreezeRFC(t *testing.T) {
+	var calls int
+	m := FreezeRFC(WithSubject(counterMail{calls: &calls}, "Hi."))
+
+	assert.Equal(t, "Subject: Hi.\r\nMessage-ID: <1@example.com>\r\n\r\nHello.", m.RFC())
+	assert.Equal(t, m.RFC(), m.RFC())
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, "Hi.", Subject(m.(interface{ Unwrap() Mail }).Unwrap()))
+	assert.Equal(t, m, FreezeRFC(m))
+}
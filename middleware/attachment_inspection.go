@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter"
+)
+
+var (
+	// ErrAttachmentTooLarge means an attachment exceeds the maximum file size.
+	ErrAttachmentTooLarge = errors.New("attachment too large")
+	// ErrAttachmentExtension means an attachment has a blocked file extension.
+	ErrAttachmentExtension = errors.New("blocked attachment extension")
+)
+
+// An AttachmentInspector inspects attachments before they are sent.
+// Inspect should return a non-nil error if the attachment must not be sent.
+type AttachmentInspector interface {
+	Inspect(context.Context, letter.Attachment) error
+}
+
+// AttachmentInspectorFunc allows functions to be used as AttachmentInspectors.
+type AttachmentInspectorFunc func(context.Context, letter.Attachment) error
+
+// AttachmentError is returned by the AttachmentInspection middleware when an
+// AttachmentInspector rejects an attachment.
+type AttachmentError struct {
+	// Index is the index of the rejected attachment in the mail.
+	Index int
+	// Filename is the filename of the rejected attachment.
+	Filename string
+	// Err is the error returned by the AttachmentInspector.
+	Err error
+}
+
+// AttachmentInspection returns a Middleware that runs every attachment of a
+// mail through the provided AttachmentInspectors. If an inspector returns an
+// error, the mail is not sent and the middleware returns an *AttachmentError
+// that identifies the rejected attachment.
+func AttachmentInspection(inspectors ...AttachmentInspector) postdog.MiddlewareFunc {
+	return func(ctx context.Context, m postdog.Mail, next postdog.NextMiddleware) (postdog.Mail, error) {
+		l, _ := letter.AsLetter(m)
+		for i, at := range l.Attachments() {
+			for _, insp := range inspectors {
+				if err := insp.Inspect(ctx, at); err != nil {
+					return m, &AttachmentError{
+						Index:    i,
+						Filename: at.Filename(),
+						Err:      err,
+					}
+				}
+			}
+		}
+		return next(ctx, m)
+	}
+}
+
+// AttachmentLimits returns an AttachmentInspector that rejects attachments
+// that are larger than maxSize bytes or whose filename has one of the
+// blocked extensions. A maxSize of 0 disables the size check. Extensions are
+// compared case-insensitively and may be given with or without a leading dot.
+func AttachmentLimits(maxSize int, blockedExtensions ...string) AttachmentInspector {
+	blocked := make(map[string]bool, len(blockedExtensions))
+	for _, ext := range blockedExtensions {
+		blocked[strings.ToLower(strings.TrimPrefix(ext, "."))] = true
+	}
+
+	return AttachmentInspectorFunc(func(_ context.Context, at letter.Attachment) error {
+		if maxSize > 0 && at.Size() > maxSize {
+			return fmt.Errorf("%w: %d bytes exceed the limit of %d bytes", ErrAttachmentTooLarge, at.Size(), maxSize)
+		}
+
+		ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(at.Filename()), "."))
+		if ext != "" && blocked[ext] {
+			return fmt.Errorf("%w: .%s", ErrAttachmentExtension, ext)
+		}
+
+		return nil
+	})
+}
+
+// Inspect calls fn(ctx, at).
+func (fn AttachmentInspectorFunc) Inspect(ctx context.Context, at letter.Attachment) error {
+	return fn(ctx, at)
+}
+
+func (err *AttachmentError) Error() string {
+	return fmt.Sprintf("attachment #%d (%s): %s", err.Index, err.Filename, err.Err)
+}
+
+func (err *AttachmentError) Unwrap() error {
+	return err.Err
+}
@@ -0,0 +1,91 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter"
+	"github.com/bounoable/postdog/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttachmentInspection(t *testing.T) {
+	tests := []struct {
+		name         string
+		give         letter.Letter
+		inspector    middleware.AttachmentInspector
+		wantError    error
+		wantFilename string
+		wantIndex    int
+	}{
+		{
+			name: "no attachments",
+			give: letter.Write(),
+			inspector: middleware.AttachmentInspectorFunc(func(context.Context, letter.Attachment) error {
+				return mockError
+			}),
+		},
+		{
+			name: "inspector error",
+			give: letter.Write(
+				letter.Attach("attach1.txt", []byte("foo")),
+				letter.Attach("attach2.txt", []byte("bar")),
+			),
+			inspector: middleware.AttachmentInspectorFunc(func(_ context.Context, at letter.Attachment) error {
+				if at.Filename() == "attach2.txt" {
+					return mockError
+				}
+				return nil
+			}),
+			wantError:    mockError,
+			wantFilename: "attach2.txt",
+			wantIndex:    1,
+		},
+		{
+			name:      "AttachmentLimits(): allowed",
+			give:      letter.Write(letter.Attach("attach1.txt", []byte("foo"))),
+			inspector: middleware.AttachmentLimits(3, "exe"),
+		},
+		{
+			name:         "AttachmentLimits(): too large",
+			give:         letter.Write(letter.Attach("attach1.txt", []byte("foobar"))),
+			inspector:    middleware.AttachmentLimits(3),
+			wantError:    middleware.ErrAttachmentTooLarge,
+			wantFilename: "attach1.txt",
+		},
+		{
+			name: "AttachmentLimits(): blocked extension",
+			give: letter.Write(
+				letter.Attach("attach1.txt", []byte("foo")),
+				letter.Attach("attach2.EXE", []byte("bar")),
+			),
+			inspector:    middleware.AttachmentLimits(0, ".exe"),
+			wantError:    middleware.ErrAttachmentExtension,
+			wantFilename: "attach2.EXE",
+			wantIndex:    1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mw := middleware.AttachmentInspection(test.inspector)
+			_, _, err := postdog.ApplyMiddleware(context.Background(), test.give, mw)
+
+			if test.wantError == nil {
+				assert.Nil(t, err)
+				return
+			}
+
+			assert.True(t, errors.Is(err, test.wantError))
+
+			var atErr *middleware.AttachmentError
+			assert.True(t, errors.As(err, &atErr))
+			assert.Equal(t, test.wantFilename, atErr.Filename)
+			assert.Equal(t, test.wantIndex, atErr.Index)
+		})
+	}
+}
+
+var mockError = errors.New("mock error")
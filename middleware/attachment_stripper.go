@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/internal/fold"
+	"github.com/bounoable/postdog/letter"
+)
+
+const ctxStrippedAttachments = ctxKey("strippedAttachments")
+
+type ctxKey string
+
+// StrippedAttachment is an attachment that has been removed from a mail by the
+// AttachmentStripper middleware.
+type StrippedAttachment struct {
+	// Filename is the filename of the attachment.
+	Filename string
+	// Size is the size of the attachment in bytes.
+	Size int
+	// Link is the download link that replaced the attachment.
+	Link string
+}
+
+// AttachmentStripper returns a Middleware that removes attachments from mails
+// whose attachments exceed threshold bytes in total. Attachments are removed
+// in descending order of their size until the remaining attachments don't
+// exceed threshold anymore. For every removed attachment, linkFunc is called
+// to get a download link, and the links are appended to the text and HTML
+// bodies of the mail.
+//
+// The removed attachments can be retrieved from the Context that is passed to
+// the following middlewares, hooks and the transport with
+// StrippedAttachments().
+func AttachmentStripper(threshold int64, linkFunc func(letter.Attachment) string) postdog.MiddlewareFunc {
+	return func(ctx context.Context, m postdog.Mail, next postdog.NextMiddleware) (postdog.Mail, error) {
+		l, _ := letter.AsLetter(m)
+		attachments := l.Attachments()
+
+		var total int64
+		for _, at := range attachments {
+			total += int64(at.Size())
+		}
+		if total <= threshold {
+			return next(ctx, m)
+		}
+
+		bySize := make([]int, len(attachments))
+		for i := range bySize {
+			bySize[i] = i
+		}
+		sort.SliceStable(bySize, func(a, b int) bool {
+			return attachments[bySize[a]].Size() > attachments[bySize[b]].Size()
+		})
+
+		strip := make(map[int]bool)
+		for _, i := range bySize {
+			if total <= threshold {
+				break
+			}
+			strip[i] = true
+			total -= int64(attachments[i].Size())
+		}
+
+		keep := make([]letter.Attachment, 0, len(attachments)-len(strip))
+		var stripped []StrippedAttachment
+		for i, at := range attachments {
+			if !strip[i] {
+				keep = append(keep, at)
+				continue
+			}
+			stripped = append(stripped, StrippedAttachment{
+				Filename: at.Filename(),
+				Size:     at.Size(),
+				Link:     linkFunc(at),
+			})
+		}
+
+		l = l.WithAttachments(keep...)
+		if text := l.Text(); text != "" {
+			l = l.WithText(appendTextLinks(text, stripped))
+		}
+		if body := l.HTML(); body != "" {
+			l = l.WithHTML(appendHTMLLinks(body, stripped))
+		}
+
+		ctx = context.WithValue(ctx, ctxStrippedAttachments, append(StrippedAttachments(ctx), stripped...))
+
+		return next(ctx, l)
+	}
+}
+
+// StrippedAttachments returns the attachments that have been removed by the
+// AttachmentStripper middleware.
+func StrippedAttachments(ctx context.Context) []StrippedAttachment {
+	stripped, _ := ctx.Value(ctxStrippedAttachments).([]StrippedAttachment)
+	return stripped
+}
+
+func appendTextLinks(body string, stripped []StrippedAttachment) string {
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(body, "\r\n"))
+	b.WriteString("\n\nAttachments:\n")
+	for _, at := range stripped {
+		b.WriteString(fmt.Sprintf("- %s (%d bytes): %s\n", at.Filename, at.Size, at.Link))
+	}
+	return b.String()
+}
+
+func appendHTMLLinks(body string, stripped []StrippedAttachment) string {
+	var b strings.Builder
+	b.WriteString("<p>Attachments:</p><ul>")
+	for _, at := range stripped {
+		b.WriteString(fmt.Sprintf(
+			`<li><a href="%s">%s</a> (%d bytes)</li>`,
+			html.EscapeString(at.Link),
+			html.EscapeString(at.Filename),
+			at.Size,
+		))
+	}
+	b.WriteString("</ul>")
+
+	if i := fold.LastIndex(body, "</body>"); i >= 0 {
+		return body[:i] + b.String() + body[i:]
+	}
+	return body + b.String()
+}
@@ -0,0 +1,107 @@
+package middleware_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter"
+	"github.com/bounoable/postdog/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttachmentStripper(t *testing.T) {
+	link := func(at letter.Attachment) string {
+		return "https://example.com/" + at.Filename()
+	}
+
+	tests := []struct {
+		name            string
+		give            letter.Letter
+		threshold       int64
+		wantAttachments []string
+		wantStripped    []middleware.StrippedAttachment
+		wantText        string
+		wantHTML        string
+	}{
+		{
+			name: "below threshold",
+			give: letter.Write(
+				letter.Content("Hello.", "<p>Hello.</p>"),
+				letter.Attach("a.txt", []byte("foo")),
+				letter.Attach("b.txt", []byte("bar")),
+			),
+			threshold:       6,
+			wantAttachments: []string{"a.txt", "b.txt"},
+			wantText:        "Hello.",
+			wantHTML:        "<p>Hello.</p>",
+		},
+		{
+			name: "above threshold",
+			give: letter.Write(
+				letter.Content("Hello.\n", "<html><body><p>Hello.</p></body></html>"),
+				letter.Attach("a.txt", []byte("foo")),
+				letter.Attach("b.txt", []byte("foobarbaz")),
+				letter.Attach("c.txt", []byte("foobar")),
+			),
+			threshold:       5,
+			wantAttachments: []string{"a.txt"},
+			wantStripped: []middleware.StrippedAttachment{
+				{Filename: "b.txt", Size: 9, Link: "https://example.com/b.txt"},
+				{Filename: "c.txt", Size: 6, Link: "https://example.com/c.txt"},
+			},
+			wantText: "Hello.\n\nAttachments:\n" +
+				"- b.txt (9 bytes): https://example.com/b.txt\n" +
+				"- c.txt (6 bytes): https://example.com/c.txt\n",
+			wantHTML: `<html><body><p>Hello.</p><p>Attachments:</p><ul>` +
+				`<li><a href="https://example.com/b.txt">b.txt</a> (9 bytes)</li>` +
+				`<li><a href="https://example.com/c.txt">c.txt</a> (6 bytes)</li>` +
+				`</ul></body></html>`,
+		},
+		{
+			name: "html with non-ascii characters",
+			give: letter.Write(
+				letter.HTML("<HTML><BODY><p>ȺȺȺȺ ȺȺȺȺ</p></BODY></HTML>"),
+				letter.Attach("a.txt", []byte("foo")),
+			),
+			threshold: 0,
+			wantStripped: []middleware.StrippedAttachment{
+				{Filename: "a.txt", Size: 3, Link: "https://example.com/a.txt"},
+			},
+			wantHTML: `<HTML><BODY><p>ȺȺȺȺ ȺȺȺȺ</p><p>Attachments:</p><ul>` +
+				`<li><a href="https://example.com/a.txt">a.txt</a> (3 bytes)</li>` +
+				`</ul></BODY></HTML>`,
+		},
+		{
+			name: "text only",
+			give: letter.Write(
+				letter.Text("Hello."),
+				letter.Attach("a.txt", []byte("foo")),
+			),
+			threshold: 0,
+			wantStripped: []middleware.StrippedAttachment{
+				{Filename: "a.txt", Size: 3, Link: "https://example.com/a.txt"},
+			},
+			wantText: "Hello.\n\nAttachments:\n- a.txt (3 bytes): https://example.com/a.txt\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mw := middleware.AttachmentStripper(test.threshold, link)
+			ctx, m, err := postdog.ApplyMiddleware(context.Background(), test.give, mw)
+			assert.Nil(t, err)
+
+			l := letter.Expand(m)
+			var filenames []string
+			for _, at := range l.Attachments() {
+				filenames = append(filenames, at.Filename())
+			}
+
+			assert.Equal(t, test.wantAttachments, filenames)
+			assert.Equal(t, test.wantStripped, middleware.StrippedAttachments(ctx))
+			assert.Equal(t, test.wantText, l.Text())
+			assert.Equal(t, test.wantHTML, l.HTML())
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"context"
+	"net/mail"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter"
+)
+
+// DefaultReplyTo returns a Middleware that sets the `Reply-To` addresses of a
+// mail to addrs if the mail has no `Reply-To` addresses. Use
+// postdog.WithTransportMiddleware() to configure different defaults per
+// transport.
+func DefaultReplyTo(addrs ...mail.Address) postdog.MiddlewareFunc {
+	return func(ctx context.Context, m postdog.Mail, next postdog.NextMiddleware) (postdog.Mail, error) {
+		l, _ := letter.AsLetter(m)
+		if len(l.ReplyTo()) > 0 || len(addrs) == 0 {
+			return next(ctx, m)
+		}
+		return next(ctx, l.WithReplyTo(addrs...))
+	}
+}
@@ -0,0 +1,78 @@
+package middleware_test
+
+import (
+	"context"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter"
+	"github.com/bounoable/postdog/middleware"
+	"github.com/bounoable/postdog/send"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultReplyTo(t *testing.T) {
+	def := mail.Address{Name: "Support", Address: "support@example.com"}
+
+	tests := []struct {
+		name     string
+		give     letter.Letter
+		expected []mail.Address
+	}{
+		{
+			name:     "without reply-to",
+			give:     letter.Write(letter.To("Linda Belcher", "linda@example.com")),
+			expected: []mail.Address{def},
+		},
+		{
+			name: "with reply-to",
+			give: letter.Write(
+				letter.To("Linda Belcher", "linda@example.com"),
+				letter.ReplyTo("Bob Belcher", "bob@example.com"),
+			),
+			expected: []mail.Address{{Name: "Bob Belcher", Address: "bob@example.com"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, m, err := postdog.ApplyMiddleware(context.Background(), test.give, middleware.DefaultReplyTo(def))
+			assert.Nil(t, err)
+
+			l := letter.Expand(m)
+			assert.Equal(t, test.expected, l.ReplyTo())
+			assert.Contains(t, strings.Split(l.RFC(), "\r\n"), "Reply-To: "+test.expected[0].String())
+		})
+	}
+}
+
+func TestDefaultReplyTo_perTransport(t *testing.T) {
+	brandA := mail.Address{Name: "Brand A", Address: "support@a.example.com"}
+	brandB := mail.Address{Name: "Brand B", Address: "support@b.example.com"}
+
+	trA, trB := &recordingTransport{}, &recordingTransport{}
+	dog := postdog.New(
+		postdog.WithTransport("a", trA),
+		postdog.WithTransport("b", trB),
+		postdog.WithTransportMiddleware("a", middleware.DefaultReplyTo(brandA)),
+		postdog.WithTransportMiddleware("b", middleware.DefaultReplyTo(brandB)),
+	)
+
+	m := letter.Write(letter.To("Linda Belcher", "linda@example.com"))
+	assert.Nil(t, dog.Send(context.Background(), m, send.Use("a")))
+	assert.Nil(t, dog.Send(context.Background(), m, send.Use("b")))
+
+	assert.Equal(t, []mail.Address{brandA}, letter.Expand(trA.mails[0]).ReplyTo())
+	assert.Equal(t, []mail.Address{brandB}, letter.Expand(trB.mails[0]).ReplyTo())
+}
+
+type recordingTransport struct {
+	mails []postdog.Mail
+}
+
+func (tr *recordingTransport) Send(_ context.Context, m postdog.Mail) error {
+	tr.mails = append(tr.mails, m)
+	return nil
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter"
+)
+
+// DefaultHTMLAttachmentFilename is the default filename of the attachment that
+// is added by HTMLAttachmentFallback().
+const DefaultHTMLAttachmentFilename = "index.html"
+
+// HTMLAttachmentFallback returns a Middleware that additionally attaches the
+// HTML body of a mail as a `text/html` file, so that recipients whose filters
+// strip HTML bodies can still open the HTML version. If filename is empty,
+// DefaultHTMLAttachmentFilename is used. Mails without an HTML body are not
+// modified.
+//
+// The attachment is only added once: if the mail already has an attachment
+// with the same filename and the HTML body as content, e.g. because the mail
+// passes through the middleware twice, the mail is not modified.
+func HTMLAttachmentFallback(filename string) postdog.MiddlewareFunc {
+	if filename == "" {
+		filename = DefaultHTMLAttachmentFilename
+	}
+
+	return func(ctx context.Context, m postdog.Mail, next postdog.NextMiddleware) (postdog.Mail, error) {
+		l, _ := letter.AsLetter(m)
+		html := []byte(l.HTML())
+		if len(html) == 0 {
+			return next(ctx, m)
+		}
+
+		for _, at := range l.Attachments() {
+			if at.Filename() == filename && bytes.Equal(at.Content(), html) {
+				return next(ctx, m)
+			}
+		}
+
+		at := letter.NewAttachment(filename, html, letter.AttachmentType("text/html; charset=utf-8"))
+		attachments := append(append([]letter.Attachment(nil), l.Attachments()...), at)
+
+		return next(ctx, l.WithAttachments(attachments...))
+	}
+}
@@ -0,0 +1,57 @@
+package middleware_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter"
+	"github.com/bounoable/postdog/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTMLAttachmentFallback(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		give     letter.Letter
+		expected []string
+	}{
+		{
+			name:     "html",
+			give:     letter.Write(letter.Content("Hello.", "<p>Hello.</p>"), letter.Attach("attach.txt", []byte("Hello."))),
+			expected: []string{"attach.txt", "index.html"},
+		},
+		{
+			name:     "custom filename",
+			filename: "mail.html",
+			give:     letter.Write(letter.HTML("<p>Hello.</p>")),
+			expected: []string{"mail.html"},
+		},
+		{
+			name: "text only",
+			give: letter.Write(letter.Text("Hello.")),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mw := middleware.HTMLAttachmentFallback(test.filename)
+			_, m, err := postdog.ApplyMiddleware(context.Background(), test.give, mw, mw)
+			assert.Nil(t, err)
+
+			l := letter.Expand(m)
+			var filenames []string
+			for _, at := range l.Attachments() {
+				filenames = append(filenames, at.Filename())
+			}
+			assert.Equal(t, test.expected, filenames)
+
+			if len(test.expected) > 0 {
+				at := l.Attachments()[len(l.Attachments())-1]
+				assert.Equal(t, l.HTML(), string(at.Content()))
+				assert.Equal(t, "text/html; charset=utf-8", at.ContentType())
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter"
+)
+
+// InlineAttachmentURLs returns a Middleware that replaces the `cid:`
+// references in the HTML body of mails with the URLs that are returned by
+// urlFunc for the referenced attachments, and removes these attachments from
+// the mail. It is the inverse of embedding images and is meant for transports
+// that don't support embedded images, e.g. API-based providers that mangle
+// `cid:` references. Use postdog.WithTransportMiddleware() to apply it only to
+// these transports:
+//
+//	dog := postdog.New(
+//		postdog.WithTransport("smtp", smtpTransport),
+//		postdog.WithTransport("api", apiTransport),
+//		postdog.WithTransportMiddleware("api", middleware.InlineAttachmentURLs(
+//			func(at letter.Attachment) string {
+//				return cdn.Upload(at.Filename(), at.Content())
+//			},
+//		)),
+//	)
+//
+// urlFunc is called once per referenced attachment. If it returns an empty
+// string, the references are kept and the attachment stays embedded.
+// Attachments that aren't referenced by the HTML body are not modified.
+func InlineAttachmentURLs(urlFunc func(letter.Attachment) string) postdog.MiddlewareFunc {
+	return func(ctx context.Context, m postdog.Mail, next postdog.NextMiddleware) (postdog.Mail, error) {
+		l, _ := letter.AsLetter(m)
+		if l.HTML() == "" || len(l.Attachments()) == 0 {
+			return next(ctx, m)
+		}
+
+		urls := make(map[string]string)
+		body := letter.ReplaceContentIDs(l.HTML(), l.Attachments(), func(at letter.Attachment) string {
+			id := contentID(at)
+			if u, ok := urls[id]; ok {
+				return u
+			}
+			u := urlFunc(at)
+			urls[id] = u
+			return u
+		})
+
+		var keep []letter.Attachment
+		for _, at := range l.Attachments() {
+			if urls[contentID(at)] == "" {
+				keep = append(keep, at)
+			}
+		}
+		if len(keep) == len(l.Attachments()) {
+			return next(ctx, m)
+		}
+
+		return next(ctx, l.WithHTML(body).WithAttachments(keep...))
+	}
+}
+
+func contentID(at letter.Attachment) string {
+	return strings.Trim(at.Header().Get("Content-ID"), "<>")
+}
@@ -0,0 +1,70 @@
+package middleware_test
+
+import (
+	"context"
+	"net/textproto"
+	"testing"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter"
+	"github.com/bounoable/postdog/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInlineAttachmentURLs(t *testing.T) {
+	logoHeader := textproto.MIMEHeader{}
+	logoHeader.Set("Content-Type", "image/png")
+	logoHeader.Set("Content-Disposition", `inline; filename="logo.png"`)
+	logoHeader.Set("Content-ID", "<logo@example.com>")
+
+	bannerHeader := textproto.MIMEHeader{}
+	bannerHeader.Set("Content-Type", "image/png")
+	bannerHeader.Set("Content-Disposition", `inline; filename="banner.png"`)
+	bannerHeader.Set("Content-ID", "<banner@example.com>")
+
+	l := letter.Write(
+		letter.Content("Hello.", `<img src="cid:logo@example.com"><img src="cid:banner@example.com"><img src="cid:logo@example.com">`),
+		letter.AttachWithHeader("logo.png", []byte("logo"), logoHeader),
+		letter.AttachWithHeader("banner.png", []byte("banner"), bannerHeader),
+		letter.Attach("report.pdf", []byte("report"), letter.AttachmentType("application/pdf")),
+	)
+
+	var calls []string
+	mw := middleware.InlineAttachmentURLs(func(at letter.Attachment) string {
+		calls = append(calls, at.Filename())
+		if at.Filename() == "banner.png" {
+			return ""
+		}
+		return "https://cdn.example.com/" + at.Filename()
+	})
+
+	_, m, err := postdog.ApplyMiddleware(context.Background(), l, mw)
+	assert.Nil(t, err)
+
+	res := letter.Expand(m)
+	assert.Equal(t, `<img src="https://cdn.example.com/logo.png"><img src="cid:banner@example.com"><img src="https://cdn.example.com/logo.png">`, res.HTML())
+	assert.Equal(t, "Hello.", res.Text())
+	assert.ElementsMatch(t, []string{"logo.png", "banner.png"}, calls)
+
+	var filenames []string
+	for _, at := range res.Attachments() {
+		filenames = append(filenames, at.Filename())
+	}
+	assert.Equal(t, []string{"banner.png", "report.pdf"}, filenames)
+}
+
+func TestInlineAttachmentURLs_noReferences(t *testing.T) {
+	l := letter.Write(
+		letter.Content("Hello.", "<p>Hello.</p>"),
+		letter.Attach("report.pdf", []byte("report")),
+	)
+
+	mw := middleware.InlineAttachmentURLs(func(letter.Attachment) string {
+		t.Fatal("urlFunc should not be called")
+		return ""
+	})
+
+	_, m, err := postdog.ApplyMiddleware(context.Background(), l, mw)
+	assert.Nil(t, err)
+	assert.Equal(t, l, m)
+}
@@ -10,10 +10,12 @@ import (
 
 // MessageID returns a Middleware that specifies the rfc.MessageIDFactory
 // that is used for generating unique Message-IDs for the RFC body of the Mail.
+// Mails with a pre-built RFC body (e.g. mails returned by postdog.RawMail())
+// are sent as-is and keep their Message-ID.
 func MessageID(factory rfc.MessageIDFactory) postdog.MiddlewareFunc {
 	opt := rfc.WithMessageIDFactory(factory)
 	return func(ctx context.Context, m postdog.Mail, next postdog.NextMiddleware) (postdog.Mail, error) {
-		l := letter.Expand(m)
+		l, _ := letter.AsLetter(m)
 		cfg := l.RFCConfig()
 		opt(&cfg)
 		l = l.WithRFCConfig(cfg)
@@ -2,6 +2,7 @@ package middleware_test
 
 import (
 	"context"
+	"net/mail"
 	"testing"
 
 	"github.com/bounoable/postdog"
@@ -24,3 +25,26 @@ func TestMessageID(t *testing.T) {
 	body := l.RFC()
 	assert.Contains(t, body, "Message-ID: <foo@example.com>")
 }
+
+func TestMessageID_rawMail(t *testing.T) {
+	var factory rfc.MessageIDFactory = rfc.MessageIDFunc(func(rfc.Mail) string {
+		return "<foo@example.com>"
+	})
+
+	tr := &recordingTransport{}
+	dog := postdog.New(
+		postdog.WithTransport("test", tr),
+		postdog.WithTransportMiddleware("test", middleware.MessageID(factory)),
+	)
+
+	body := "From: bob@example.com\r\nTo: linda@example.com\r\nMessage-ID: <raw@example.com>\r\nSubject: Hi.\r\n\r\nHello."
+	m := postdog.RawMail(
+		mail.Address{Address: "bob@example.com"},
+		[]mail.Address{{Address: "linda@example.com"}},
+		body,
+	)
+	assert.Nil(t, dog.Send(context.Background(), m))
+
+	assert.Len(t, tr.mails, 1)
+	assert.Equal(t, body, tr.mails[0].RFC())
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"context"
+	"net/mail"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter"
+)
+
+// OverrideRecipients returns a Middleware that replaces all recipients of a
+// mail with the given addresses. The addresses become the `To` recipients of
+// the mail; `Cc` and `Bcc` recipients and the address groups of the `To` and
+// `Cc` headers are removed.
+func OverrideRecipients(to ...mail.Address) postdog.MiddlewareFunc {
+	return func(ctx context.Context, m postdog.Mail, next postdog.NextMiddleware) (postdog.Mail, error) {
+		l, _ := letter.AsLetter(m)
+		l = l.WithRecipients().
+			WithTo(to...).
+			WithToGroups().
+			WithCC().
+			WithCCGroups().
+			WithBCC()
+		return next(ctx, l)
+	}
+}
@@ -0,0 +1,51 @@
+package middleware_test
+
+import (
+	"context"
+	"net/mail"
+	"testing"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter"
+	"github.com/bounoable/postdog/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverrideRecipients(t *testing.T) {
+	to := mail.Address{Name: "Dev", Address: "dev@example.com"}
+	give := letter.Write(
+		letter.From("Bob Belcher", "bob@example.com"),
+		letter.To("Linda Belcher", "linda@example.com"),
+		letter.CC("Tina Belcher", "tina@example.com"),
+		letter.BCC("Gene Belcher", "gene@example.com"),
+	)
+
+	_, m, err := postdog.ApplyMiddleware(context.Background(), give, middleware.OverrideRecipients(to))
+	assert.Nil(t, err)
+
+	l := letter.Expand(m)
+	assert.Equal(t, []mail.Address{to}, l.To())
+	assert.Empty(t, l.CC())
+	assert.Empty(t, l.BCC())
+	assert.Equal(t, []mail.Address{to}, l.Recipients())
+	assert.Len(t, give.Recipients(), 3)
+}
+
+func TestOverrideRecipients_groups(t *testing.T) {
+	to := mail.Address{Name: "Dev", Address: "dev@example.com"}
+	give := letter.Write(
+		letter.From("Bob Belcher", "bob@example.com"),
+		letter.ToGroup("Kids", mail.Address{Address: "tina@example.com"}, mail.Address{Address: "gene@example.com"}),
+		letter.CCGroup("Parents", mail.Address{Address: "linda@example.com"}),
+	)
+
+	_, m, err := postdog.ApplyMiddleware(context.Background(), give, middleware.OverrideRecipients(to))
+	assert.Nil(t, err)
+
+	l := letter.Expand(m)
+	assert.Empty(t, l.ToGroups())
+	assert.Empty(t, l.CCGroups())
+	assert.Equal(t, []mail.Address{to}, l.Recipients())
+	assert.NotContains(t, m.RFC(), "tina@example.com")
+	assert.NotContains(t, m.RFC(), "linda@example.com")
+}
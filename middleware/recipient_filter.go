@@ -0,0 +1,192 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter"
+)
+
+var (
+	// ErrRecipientBlocked means a recipient is denied or not allowed by the
+	// RecipientFilter middleware.
+	ErrRecipientBlocked = errors.New("recipient blocked")
+	// ErrNoPermittedRecipients means all recipients of a mail have been
+	// dropped by the RecipientFilter middleware.
+	ErrNoPermittedRecipients = errors.New("no permitted recipients")
+)
+
+// RecipientError is returned by the RecipientFilter middleware when a mail
+// has a blocked recipient.
+type RecipientError struct {
+	// Recipient is the blocked recipient.
+	Recipient mail.Address
+	// Err is ErrRecipientBlocked.
+	Err error
+}
+
+// RecipientFilterOption is an option for the RecipientFilter middleware.
+type RecipientFilterOption func(*recipientFilter)
+
+type recipientFilter struct {
+	allowDomains   map[string]bool
+	allowAddresses map[string]bool
+	denyDomains    map[string]bool
+	denyAddresses  map[string]bool
+	drop           bool
+}
+
+// RecipientFilter returns a Middleware that checks the recipients (`To`,
+// `Cc`, `Bcc` & the addresses of address groups) of a mail against allow- and
+// denylists of domains and addresses. Domains and addresses are compared
+// case-insensitively.
+//
+// A recipient is blocked if its address or domain is denied. If any domains
+// or addresses are allowed, a recipient is also blocked if neither its
+// address nor its domain is allowed. Denials take precedence over allowances.
+//
+// By default, the middleware fails with a *RecipientError for the first
+// blocked recipient and the mail is not sent. Use the DropBlockedRecipients()
+// option to remove blocked recipients from the mail instead. If all
+// recipients are dropped, the middleware fails with ErrNoPermittedRecipients.
+// Address groups whose addresses are all dropped are removed from the mail.
+func RecipientFilter(opts ...RecipientFilterOption) postdog.MiddlewareFunc {
+	f := recipientFilter{
+		allowDomains:   make(map[string]bool),
+		allowAddresses: make(map[string]bool),
+		denyDomains:    make(map[string]bool),
+		denyAddresses:  make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(&f)
+	}
+
+	return func(ctx context.Context, m postdog.Mail, next postdog.NextMiddleware) (postdog.Mail, error) {
+		l, _ := letter.AsLetter(m)
+
+		if !f.drop {
+			for _, rcpt := range l.Recipients() {
+				if f.blocked(rcpt) {
+					return m, &RecipientError{Recipient: rcpt, Err: ErrRecipientBlocked}
+				}
+			}
+			return next(ctx, m)
+		}
+
+		filtered := l.
+			WithRecipients(f.filter(l.L.Recipients)...).
+			WithTo(f.filter(l.To())...).
+			WithToGroups(f.filterGroups(l.ToGroups())...).
+			WithCC(f.filter(l.CC())...).
+			WithCCGroups(f.filterGroups(l.CCGroups())...).
+			WithBCC(f.filter(l.BCC())...)
+
+		if len(filtered.Recipients()) == 0 {
+			return m, ErrNoPermittedRecipients
+		}
+
+		if len(filtered.Recipients()) == len(l.Recipients()) {
+			return next(ctx, m)
+		}
+
+		return next(ctx, filtered)
+	}
+}
+
+// AllowDomains returns a RecipientFilterOption that allows recipients of the
+// given domains.
+func AllowDomains(domains ...string) RecipientFilterOption {
+	return func(f *recipientFilter) {
+		addLower(f.allowDomains, domains...)
+	}
+}
+
+// AllowAddresses returns a RecipientFilterOption that allows the given
+// recipient addresses.
+func AllowAddresses(addrs ...string) RecipientFilterOption {
+	return func(f *recipientFilter) {
+		addLower(f.allowAddresses, addrs...)
+	}
+}
+
+// DenyDomains returns a RecipientFilterOption that blocks recipients of the
+// given domains.
+func DenyDomains(domains ...string) RecipientFilterOption {
+	return func(f *recipientFilter) {
+		addLower(f.denyDomains, domains...)
+	}
+}
+
+// DenyAddresses returns a RecipientFilterOption that blocks the given
+// recipient addresses.
+func DenyAddresses(addrs ...string) RecipientFilterOption {
+	return func(f *recipientFilter) {
+		addLower(f.denyAddresses, addrs...)
+	}
+}
+
+// DropBlockedRecipients returns a RecipientFilterOption that removes blocked
+// recipients from mails instead of failing the send.
+func DropBlockedRecipients() RecipientFilterOption {
+	return func(f *recipientFilter) {
+		f.drop = true
+	}
+}
+
+func (f recipientFilter) blocked(rcpt mail.Address) bool {
+	addr := strings.ToLower(rcpt.Address)
+	domain := addr
+	if i := strings.LastIndex(addr, "@"); i >= 0 {
+		domain = addr[i+1:]
+	}
+
+	if f.denyAddresses[addr] || f.denyDomains[domain] {
+		return true
+	}
+
+	if len(f.allowAddresses) == 0 && len(f.allowDomains) == 0 {
+		return false
+	}
+
+	return !f.allowAddresses[addr] && !f.allowDomains[domain]
+}
+
+func (f recipientFilter) filter(addrs []mail.Address) []mail.Address {
+	var res []mail.Address
+	for _, addr := range addrs {
+		if !f.blocked(addr) {
+			res = append(res, addr)
+		}
+	}
+	return res
+}
+
+func (f recipientFilter) filterGroups(groups []letter.Group) []letter.Group {
+	var res []letter.Group
+	for _, g := range groups {
+		addrs := f.filter(g.Addresses)
+		if len(addrs) == 0 && len(g.Addresses) > 0 {
+			continue
+		}
+		res = append(res, letter.Group{Name: g.Name, Addresses: addrs})
+	}
+	return res
+}
+
+func addLower(set map[string]bool, vals ...string) {
+	for _, v := range vals {
+		set[strings.ToLower(strings.TrimSpace(v))] = true
+	}
+}
+
+func (err *RecipientError) Error() string {
+	return fmt.Sprintf("recipient %s: %s", err.Recipient.Address, err.Err)
+}
+
+func (err *RecipientError) Unwrap() error {
+	return err.Err
+}
@@ -0,0 +1,164 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"testing"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter"
+	"github.com/bounoable/postdog/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecipientFilter(t *testing.T) {
+	give := letter.Write(
+		letter.From("Bob Belcher", "bob@example.com"),
+		letter.To("Linda Belcher", "linda@example.com"),
+		letter.CC("Jimmy Pesto", "jimmy@pesto.com"),
+		letter.BCC("Tina Belcher", "tina@Example.com"),
+	)
+
+	tests := []struct {
+		name          string
+		opts          []middleware.RecipientFilterOption
+		wantBlocked   string
+		wantError     error
+		wantTo        []string
+		wantCC        []string
+		wantBCC       []string
+		wantUnchanged bool
+	}{
+		{
+			name:          "no lists",
+			wantUnchanged: true,
+		},
+		{
+			name:        "deny domain",
+			opts:        []middleware.RecipientFilterOption{middleware.DenyDomains("PESTO.com")},
+			wantBlocked: "jimmy@pesto.com",
+			wantError:   middleware.ErrRecipientBlocked,
+		},
+		{
+			name:        "deny address",
+			opts:        []middleware.RecipientFilterOption{middleware.DenyAddresses("tina@example.com")},
+			wantBlocked: "tina@Example.com",
+			wantError:   middleware.ErrRecipientBlocked,
+		},
+		{
+			name:        "allow domain",
+			opts:        []middleware.RecipientFilterOption{middleware.AllowDomains("example.com")},
+			wantBlocked: "jimmy@pesto.com",
+			wantError:   middleware.ErrRecipientBlocked,
+		},
+		{
+			name: "allow domain & address",
+			opts: []middleware.RecipientFilterOption{
+				middleware.AllowDomains("example.com"),
+				middleware.AllowAddresses("jimmy@pesto.com"),
+			},
+			wantUnchanged: true,
+		},
+		{
+			name: "deny takes precedence",
+			opts: []middleware.RecipientFilterOption{
+				middleware.AllowDomains("example.com"),
+				middleware.DenyAddresses("linda@example.com"),
+			},
+			wantBlocked: "linda@example.com",
+			wantError:   middleware.ErrRecipientBlocked,
+		},
+		{
+			name: "drop blocked",
+			opts: []middleware.RecipientFilterOption{
+				middleware.DenyDomains("pesto.com"),
+				middleware.DropBlockedRecipients(),
+			},
+			wantTo:  []string{"linda@example.com"},
+			wantBCC: []string{"tina@Example.com"},
+		},
+		{
+			name: "drop blocked, allowlist",
+			opts: []middleware.RecipientFilterOption{
+				middleware.AllowAddresses("jimmy@pesto.com"),
+				middleware.DropBlockedRecipients(),
+			},
+			wantCC: []string{"jimmy@pesto.com"},
+		},
+		{
+			name: "drop all",
+			opts: []middleware.RecipientFilterOption{
+				middleware.AllowDomains("example.org"),
+				middleware.DropBlockedRecipients(),
+			},
+			wantError: middleware.ErrNoPermittedRecipients,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, m, err := postdog.ApplyMiddleware(context.Background(), give, middleware.RecipientFilter(test.opts...))
+
+			if test.wantError != nil {
+				assert.True(t, errors.Is(err, test.wantError))
+				if test.wantBlocked != "" {
+					var rcptErr *middleware.RecipientError
+					assert.True(t, errors.As(err, &rcptErr))
+					assert.Equal(t, test.wantBlocked, rcptErr.Recipient.Address)
+				}
+				return
+			}
+
+			assert.Nil(t, err)
+
+			if test.wantUnchanged {
+				assert.Equal(t, give, m)
+				return
+			}
+
+			l := letter.Expand(m)
+			assert.Equal(t, test.wantTo, addresses(l.To()))
+			assert.Equal(t, test.wantCC, addresses(l.CC()))
+			assert.Equal(t, test.wantBCC, addresses(l.BCC()))
+			assert.Len(t, give.Recipients(), 3)
+		})
+	}
+}
+
+func TestRecipientFilter_groups(t *testing.T) {
+	give := letter.Write(
+		letter.From("Bob Belcher", "bob@example.com"),
+		letter.To("Linda Belcher", "linda@example.com"),
+		letter.ToGroup("Kids", mail.Address{Address: "tina@example.com"}, mail.Address{Address: "jimmy.jr@pesto.com"}),
+		letter.CCGroup("Pestos", mail.Address{Address: "jimmy@pesto.com"}),
+	)
+
+	_, _, err := postdog.ApplyMiddleware(context.Background(), give, middleware.RecipientFilter(
+		middleware.DenyDomains("pesto.com"),
+	))
+	var rcptErr *middleware.RecipientError
+	assert.True(t, errors.As(err, &rcptErr))
+	assert.Equal(t, "jimmy.jr@pesto.com", rcptErr.Recipient.Address)
+
+	_, m, err := postdog.ApplyMiddleware(context.Background(), give, middleware.RecipientFilter(
+		middleware.DenyDomains("pesto.com"),
+		middleware.DropBlockedRecipients(),
+	))
+	assert.Nil(t, err)
+
+	l := letter.Expand(m)
+	assert.Equal(t, []letter.Group{{Name: "Kids", Addresses: []mail.Address{{Address: "tina@example.com"}}}}, l.ToGroups())
+	assert.Empty(t, l.CCGroups())
+	assert.Equal(t, []string{"linda@example.com", "tina@example.com"}, addresses(l.Recipients()))
+	assert.NotContains(t, m.RFC(), "pesto.com")
+	assert.Len(t, give.Recipients(), 4)
+}
+
+func addresses(addrs []mail.Address) []string {
+	var res []string
+	for _, addr := range addrs {
+		res = append(res, addr.Address)
+	}
+	return res
+}
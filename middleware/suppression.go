@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"strings"
+	"sync"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter"
+)
+
+var (
+	// ErrAllRecipientsSuppressed means all recipients of a mail have been
+	// removed by the Suppression middleware.
+	ErrAllRecipientsSuppressed = errors.New("all recipients suppressed")
+)
+
+// A SuppressionList contains addresses that must not receive any mails, e.g.
+// addresses that have unsubscribed or hard-bounced.
+type SuppressionList interface {
+	// Contains returns whether addr is suppressed.
+	Contains(addr string) bool
+}
+
+// MemorySuppressionList is an in-memory SuppressionList. Addresses are
+// compared case-insensitively. A MemorySuppressionList is safe for concurrent
+// use.
+type MemorySuppressionList struct {
+	mux   sync.RWMutex
+	addrs map[string]bool
+}
+
+// Suppression returns a Middleware that removes the recipients (`To`, `Cc` &
+// `Bcc`, including address groups) of a mail that are contained in list.
+// Groups whose members are all suppressed are removed. If no recipients
+// remain, the middleware fails with ErrAllRecipientsSuppressed and the mail is
+// not sent. Mails without suppressed recipients are passed through unchanged.
+func Suppression(list SuppressionList) postdog.MiddlewareFunc {
+	return func(ctx context.Context, m postdog.Mail, next postdog.NextMiddleware) (postdog.Mail, error) {
+		l, _ := letter.AsLetter(m)
+
+		var suppressed bool
+		for _, rcpt := range l.Recipients() {
+			if list.Contains(rcpt.Address) {
+				suppressed = true
+				break
+			}
+		}
+		if !suppressed {
+			return next(ctx, m)
+		}
+
+		filtered := l.
+			WithRecipients(suppress(list, l.L.Recipients)...).
+			WithTo(suppress(list, l.To())...).
+			WithCC(suppress(list, l.CC())...).
+			WithBCC(suppress(list, l.BCC())...).
+			WithToGroups(suppressGroups(list, l.ToGroups())...).
+			WithCCGroups(suppressGroups(list, l.CCGroups())...)
+
+		if len(filtered.Recipients()) == 0 {
+			return m, ErrAllRecipientsSuppressed
+		}
+
+		return next(ctx, filtered)
+	}
+}
+
+// NewSuppressionList returns a MemorySuppressionList that contains addrs.
+func NewSuppressionList(addrs ...string) *MemorySuppressionList {
+	list := MemorySuppressionList{addrs: make(map[string]bool)}
+	list.Add(addrs...)
+	return &list
+}
+
+// Add adds addrs to the list.
+func (list *MemorySuppressionList) Add(addrs ...string) {
+	list.mux.Lock()
+	defer list.mux.Unlock()
+	addLower(list.addrs, addrs...)
+}
+
+// Remove removes addrs from the list.
+func (list *MemorySuppressionList) Remove(addrs ...string) {
+	list.mux.Lock()
+	defer list.mux.Unlock()
+	for _, addr := range addrs {
+		delete(list.addrs, strings.ToLower(strings.TrimSpace(addr)))
+	}
+}
+
+// Contains returns whether addr is in the list.
+func (list *MemorySuppressionList) Contains(addr string) bool {
+	list.mux.RLock()
+	defer list.mux.RUnlock()
+	return list.addrs[strings.ToLower(strings.TrimSpace(addr))]
+}
+
+func suppress(list SuppressionList, addrs []mail.Address) []mail.Address {
+	var res []mail.Address
+	for _, addr := range addrs {
+		if !list.Contains(addr.Address) {
+			res = append(res, addr)
+		}
+	}
+	return res
+}
+
+func suppressGroups(list SuppressionList, groups []letter.Group) []letter.Group {
+	var res []letter.Group
+	for _, g := range groups {
+		addrs := suppress(list, g.Addresses)
+		if len(addrs) == 0 && len(g.Addresses) > 0 {
+			continue
+		}
+		res = append(res, letter.Group{Name: g.Name, Addresses: addrs})
+	}
+	return res
+}
@@ -0,0 +1,109 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"testing"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter"
+	"github.com/bounoable/postdog/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuppression(t *testing.T) {
+	give := letter.Write(
+		letter.From("Bob Belcher", "bob@example.com"),
+		letter.To("Linda Belcher", "linda@example.com"),
+		letter.CC("Jimmy Pesto", "jimmy@pesto.com"),
+		letter.BCC("Tina Belcher", "tina@example.com"),
+		letter.ToGroup("Kids", mail.Address{Name: "Gene Belcher", Address: "gene@example.com"}, mail.Address{Name: "Louise Belcher", Address: "louise@example.com"}),
+	)
+
+	tests := []struct {
+		name          string
+		suppressed    []string
+		wantError     error
+		wantTo        []string
+		wantCC        []string
+		wantBCC       []string
+		wantGroup     []string
+		wantUnchanged bool
+	}{
+		{
+			name:          "nothing suppressed",
+			suppressed:    []string{"jimmy@example.com"},
+			wantUnchanged: true,
+		},
+		{
+			name:       "some suppressed",
+			suppressed: []string{"JIMMY@pesto.com", "tina@example.com", "gene@example.com"},
+			wantTo:     []string{"linda@example.com"},
+			wantGroup:  []string{"louise@example.com"},
+		},
+		{
+			name: "all suppressed",
+			suppressed: []string{
+				"linda@example.com", "jimmy@pesto.com", "tina@example.com",
+				"gene@example.com", "louise@example.com",
+			},
+			wantError: middleware.ErrAllRecipientsSuppressed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			list := middleware.NewSuppressionList(test.suppressed...)
+			_, m, err := postdog.ApplyMiddleware(context.Background(), give, middleware.Suppression(list))
+
+			if test.wantError != nil {
+				assert.True(t, errors.Is(err, test.wantError))
+				return
+			}
+
+			assert.Nil(t, err)
+
+			if test.wantUnchanged {
+				assert.Equal(t, give, m)
+				return
+			}
+
+			l := letter.Expand(m)
+			assert.Equal(t, test.wantTo, addresses(l.To()))
+			assert.Equal(t, test.wantCC, addresses(l.CC()))
+			assert.Equal(t, test.wantBCC, addresses(l.BCC()))
+			assert.Equal(t, test.wantGroup, addresses(l.ToGroups()[0].Addresses))
+		})
+	}
+}
+
+func TestSuppression_groups(t *testing.T) {
+	give := letter.Write(
+		letter.From("Bob Belcher", "bob@example.com"),
+		letter.To("Linda Belcher", "linda@example.com"),
+		letter.ToGroup("Kids", mail.Address{Address: "tina@example.com"}, mail.Address{Address: "gene@example.com"}),
+		letter.CCGroup("Pestos", mail.Address{Address: "jimmy@pesto.com"}),
+	)
+
+	list := middleware.NewSuppressionList("gene@example.com", "jimmy@pesto.com")
+	_, m, err := postdog.ApplyMiddleware(context.Background(), give, middleware.Suppression(list))
+	assert.Nil(t, err)
+
+	l := letter.Expand(m)
+	assert.Equal(t, []letter.Group{{Name: "Kids", Addresses: []mail.Address{{Address: "tina@example.com"}}}}, l.ToGroups())
+	assert.Empty(t, l.CCGroups())
+	assert.Equal(t, []string{"linda@example.com", "tina@example.com"}, addresses(l.Recipients()))
+	assert.NotContains(t, m.RFC(), "Pestos")
+}
+
+func TestMemorySuppressionList(t *testing.T) {
+	list := middleware.NewSuppressionList("bob@example.com")
+	assert.True(t, list.Contains(" BOB@example.com"))
+
+	list.Add("linda@example.com")
+	assert.True(t, list.Contains("linda@example.com"))
+
+	list.Remove("Bob@Example.com")
+	assert.False(t, list.Contains("bob@example.com"))
+}
@@ -49,6 +49,108 @@ func (mr *MockTransportMockRecorder) Send(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockTransport)(nil).Send), arg0, arg1)
 }
 
+// MockRawRFCTransport is a mock of RawRFCTransport interface
+type MockRawRFCTransport struct {
+	ctrl     *gomock.Controller
+	recorder *MockRawRFCTransportMockRecorder
+}
+
+// MockRawRFCTransportMockRecorder is the mock recorder for MockRawRFCTransport
+type MockRawRFCTransportMockRecorder struct {
+	mock *MockRawRFCTransport
+}
+
+// NewMockRawRFCTransport creates a new mock instance
+func NewMockRawRFCTransport(ctrl *gomock.Controller) *MockRawRFCTransport {
+	mock := &MockRawRFCTransport{ctrl: ctrl}
+	mock.recorder = &MockRawRFCTransportMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockRawRFCTransport) EXPECT() *MockRawRFCTransportMockRecorder {
+	return m.recorder
+}
+
+// Send mocks base method
+func (m *MockRawRFCTransport) Send(arg0 context.Context, arg1 postdog.Mail) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Send indicates an expected call of Send
+func (mr *MockRawRFCTransportMockRecorder) Send(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockRawRFCTransport)(nil).Send), arg0, arg1)
+}
+
+// SendsRawRFC mocks base method
+func (m *MockRawRFCTransport) SendsRawRFC() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendsRawRFC")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// SendsRawRFC indicates an expected call of SendsRawRFC
+func (mr *MockRawRFCTransportMockRecorder) SendsRawRFC() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendsRawRFC", reflect.TypeOf((*MockRawRFCTransport)(nil).SendsRawRFC))
+}
+
+// MockClosableTransport is a mock of ClosableTransport interface
+type MockClosableTransport struct {
+	ctrl     *gomock.Controller
+	recorder *MockClosableTransportMockRecorder
+}
+
+// MockClosableTransportMockRecorder is the mock recorder for MockClosableTransport
+type MockClosableTransportMockRecorder struct {
+	mock *MockClosableTransport
+}
+
+// NewMockClosableTransport creates a new mock instance
+func NewMockClosableTransport(ctrl *gomock.Controller) *MockClosableTransport {
+	mock := &MockClosableTransport{ctrl: ctrl}
+	mock.recorder = &MockClosableTransportMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockClosableTransport) EXPECT() *MockClosableTransportMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method
+func (m *MockClosableTransport) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close
+func (mr *MockClosableTransportMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockClosableTransport)(nil).Close))
+}
+
+// Send mocks base method
+func (m *MockClosableTransport) Send(arg0 context.Context, arg1 postdog.Mail) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Send indicates an expected call of Send
+func (mr *MockClosableTransportMockRecorder) Send(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockClosableTransport)(nil).Send), arg0, arg1)
+}
+
 // MockMiddleware is a mock of Middleware interface
 type MockMiddleware struct {
 	ctrl     *gomock.Controller
@@ -159,20 +261,6 @@ func (mr *MockMailMockRecorder) From() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "From", reflect.TypeOf((*MockMail)(nil).From))
 }
 
-// Recipients mocks base method
-func (m *MockMail) Recipients() []mail.Address {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Recipients")
-	ret0, _ := ret[0].([]mail.Address)
-	return ret0
-}
-
-// Recipients indicates an expected call of Recipients
-func (mr *MockMailMockRecorder) Recipients() *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Recipients", reflect.TypeOf((*MockMail)(nil).Recipients))
-}
-
 // RFC mocks base method
 func (m *MockMail) RFC() string {
 	m.ctrl.T.Helper()
@@ -187,6 +275,20 @@ func (mr *MockMailMockRecorder) RFC() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RFC", reflect.TypeOf((*MockMail)(nil).RFC))
 }
 
+// Recipients mocks base method
+func (m *MockMail) Recipients() []mail.Address {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Recipients")
+	ret0, _ := ret[0].([]mail.Address)
+	return ret0
+}
+
+// Recipients indicates an expected call of Recipients
+func (mr *MockMailMockRecorder) Recipients() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Recipients", reflect.TypeOf((*MockMail)(nil).Recipients))
+}
+
 // MockWaiter is a mock of Waiter interface
 type MockWaiter struct {
 	ctrl     *gomock.Controller
@@ -258,3 +360,40 @@ func (mr *MockListenerMockRecorder) Handle(arg0, arg1, arg2 interface{}) *gomock
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Handle", reflect.TypeOf((*MockListener)(nil).Handle), arg0, arg1, arg2)
 }
+
+// MockSyncListener is a mock of SyncListener interface
+type MockSyncListener struct {
+	ctrl     *gomock.Controller
+	recorder *MockSyncListenerMockRecorder
+}
+
+// MockSyncListenerMockRecorder is the mock recorder for MockSyncListener
+type MockSyncListenerMockRecorder struct {
+	mock *MockSyncListener
+}
+
+// NewMockSyncListener creates a new mock instance
+func NewMockSyncListener(ctrl *gomock.Controller) *MockSyncListener {
+	mock := &MockSyncListener{ctrl: ctrl}
+	mock.recorder = &MockSyncListenerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockSyncListener) EXPECT() *MockSyncListenerMockRecorder {
+	return m.recorder
+}
+
+// Handle mocks base method
+func (m *MockSyncListener) Handle(arg0 context.Context, arg1 postdog.Hook, arg2 postdog.Mail) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Handle", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Handle indicates an expected call of Handle
+func (mr *MockSyncListenerMockRecorder) Handle(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Handle", reflect.TypeOf((*MockSyncListener)(nil).Handle), arg0, arg1, arg2)
+}
@@ -5,6 +5,8 @@ package archive
 import (
 	"context"
 	stdctx "context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"time"
@@ -21,7 +23,8 @@ var (
 
 // Store is the underlying store for the Mails.
 type Store interface {
-	// Insert inserts a Mail into the Store.
+	// Insert inserts a Mail into the Store. A Mail with the ID of a stored
+	// Mail replaces the stored Mail.
 	Insert(stdctx.Context, Mail) error
 
 	// Find returns the Mail with the given ID.
@@ -64,54 +67,112 @@ type Printer interface {
 type Option func(*config)
 
 type config struct {
-	logger        Printer
-	insertTimeout time.Duration
+	logger            Printer
+	ctxLogger         func(stdctx.Context, error)
+	insertTimeout     time.Duration
+	insertAttempts    int
+	insertBackoff     func(int) time.Duration
+	synchronous       bool
+	failOnInsertError bool
+	contentHash       bool
+	freezeRFC         bool
+	newID             func() uuid.UUID
+	tsa               *timestampAuthority
 }
 
 // New creates the archive plugin.
+//
+// By default, mails are inserted into the Store asynchronously after they
+// have been sent. Use the Synchronous() option to insert mails before
+// (*postdog.Dog).Send() returns.
 func New(s Store, opts ...Option) postdog.Plugin {
-	var cfg config
+	cfg := config{newID: uuid.New}
 	for _, opt := range opts {
 		opt(&cfg)
 	}
 
-	return postdog.Plugin{
-		postdog.WithHook(postdog.AfterSend, postdog.ListenerFunc(func(
-			ctx stdctx.Context,
-			_ postdog.Hook,
-			pm postdog.Mail,
-		) {
-			sendError := postdog.SendError(ctx)
-			sentAt := postdog.SendTime(ctx)
+	insert := func(ctx stdctx.Context, pm postdog.Mail) error {
+		sendError := postdog.SendError(ctx)
+		sentAt := postdog.SendTime(ctx)
 
-			var errMsg string
-			if sendError != nil {
-				errMsg = sendError.Error()
-			}
+		var errMsg string
+		if sendError != nil {
+			errMsg = sendError.Error()
+		}
 
-			id := MailIDFromContext(ctx)
-			if id == uuid.Nil {
-				id = uuid.New()
-			}
+		id := MailIDFromContext(ctx)
+		if id == uuid.Nil {
+			id = cfg.newID()
+		}
+
+		m := ExpandMail(pm).
+			WithID(id).
+			WithSendError(errMsg).
+			WithSendTime(sentAt)
 
-			m := ExpandMail(pm).
-				WithID(id).
-				WithSendError(errMsg).
-				WithSendTime(sentAt)
+		if cfg.contentHash {
+			m = m.WithContentHash(HashContent(pm.RFC()))
+		}
 
-			var cancel context.CancelFunc
-			if cfg.insertTimeout == 0 {
-				ctx, cancel = context.WithCancel(context.Background())
+		var insertCtx context.Context
+		var cancel context.CancelFunc
+		if cfg.insertTimeout == 0 {
+			insertCtx, cancel = context.WithCancel(context.Background())
+		} else {
+			insertCtx, cancel = context.WithTimeout(context.Background(), cfg.insertTimeout)
+		}
+		defer cancel()
+
+		if cfg.tsa != nil {
+			sum := sha256.Sum256([]byte(pm.RFC()))
+			token, err := cfg.tsa.timestamp(insertCtx, sum[:])
+			if err != nil {
+				cfg.logError(ctx, fmt.Errorf("timestamp mail: %w", err))
 			} else {
-				ctx, cancel = context.WithTimeout(context.Background(), cfg.insertTimeout)
+				m = m.WithTimestampToken(token)
 			}
-			defer cancel()
+		}
 
-			if err := s.Insert(ctx, m); err != nil {
-				cfg.logInsertError(err)
-			}
-		})),
+		if err := cfg.insert(insertCtx, s, m); err != nil {
+			cfg.logInsertError(ctx, err)
+			return err
+		}
+
+		return nil
+	}
+
+	var plugin postdog.Plugin
+	if cfg.freezeRFC || cfg.contentHash {
+		// the RFC body is frozen right before it is passed to the transport,
+		// so that the archived body (and its hash) is the body that is
+		// actually sent
+		plugin = append(plugin, postdog.WithFrozenRFC())
+	}
+
+	if cfg.synchronous {
+		return append(plugin,
+			postdog.WithSyncHook(postdog.AfterSend, postdog.SyncListenerFunc(func(
+				ctx stdctx.Context,
+				_ postdog.Hook,
+				pm postdog.Mail,
+			) error {
+				if err := insert(ctx, pm); err != nil && cfg.failOnInsertError {
+					return fmt.Errorf("archive: %w", err)
+				}
+				return nil
+			})),
+		)
 	}
+
+	return append(plugin,
+		postdog.WithHook(postdog.AfterSend, postdog.ListenerFunc(func(
+			ctx stdctx.Context,
+			_ postdog.Hook,
+			pm postdog.Mail,
+		) {
+			insert(ctx, pm)
+		})),
+	)
 }
 
 // WithLogger returns an Option that sets the error logger.
@@ -121,6 +182,16 @@ func WithLogger(l Printer) Option {
 	}
 }
 
+// WithContextLogger returns an Option that sets a context-aware error logger.
+// The logger receives the Context of the (*postdog.Dog).Send() call, so it can
+// extract request-scoped values like request IDs. It can be used together with
+// WithLogger(), in which case both loggers are called.
+func WithContextLogger(l func(stdctx.Context, error)) Option {
+	return func(cfg *config) {
+		cfg.ctxLogger = l
+	}
+}
+
 // InsertTimeout returns an Option that sets the timeout for inserts.
 func InsertTimeout(d time.Duration) Option {
 	return func(cfg *config) {
@@ -128,8 +199,125 @@ func InsertTimeout(d time.Duration) Option {
 	}
 }
 
-func (cfg *config) logInsertError(err error) {
+// WithInsertRetry returns an Option that retries failed inserts. attempts is
+// the maximum number of insert attempts per mail and backoff returns the
+// duration to wait before the given retry (starting at 1). A nil backoff
+// retries immediately.
+//
+// Retries stop as soon as the insert Context is done, so retries never exceed
+// the timeout set by InsertTimeout(). Every retry is logged through the
+// logger set by WithLogger().
+func WithInsertRetry(attempts int, backoff func(int) time.Duration) Option {
+	return func(cfg *config) {
+		cfg.insertAttempts = attempts
+		cfg.insertBackoff = backoff
+	}
+}
+
+// Synchronous returns an Option that inserts mails into the Store before
+// (*postdog.Dog).Send() returns, instead of inserting them asynchronously.
+// Insert errors are logged but don't fail the send, unless the
+// FailOnInsertError() option is used.
+func Synchronous() Option {
+	return func(cfg *config) {
+		cfg.synchronous = true
+	}
+}
+
+// FailOnInsertError returns an Option that makes (*postdog.Dog).Send() fail
+// if the mail cannot be inserted into the Store. It has no effect without
+// the Synchronous() option, because asynchronous inserts happen after Send()
+// has already returned.
+func FailOnInsertError() Option {
+	return func(cfg *config) {
+		cfg.failOnInsertError = true
+	}
+}
+
+// WithContentHash returns an Option that stores the SHA-256 hash of the RFC
+// body of every mail (see (Mail).ContentHash()), so that modifications of
+// archived mails can be detected. Use query.ContentHash() to find a mail by
+// its hash. The option implies FreezeRFC(), so that the hash is computed over
+// the RFC body that is actually sent.
+func WithContentHash() Option {
+	return func(cfg *config) {
+		cfg.contentHash = true
+	}
+}
+
+// FreezeRFC returns an Option that archives the RFC body that is actually
+// sent. Letters build a new `Message-ID` and `Date` header every time their
+// RFC body is built, so without this option, the archived RFC body of a letter
+// has a different `Message-ID` than the sent one. The option freezes the RFC
+// body of every mail right before it is passed to the transport, after all
+// middlewares have been applied (see postdog.WithFrozenRFC()).
+//
+// Use this option to find archived mails by the `Message-ID` that the
+// recipients (or the webhooks of a mail provider) see, see query.MessageID().
+func FreezeRFC() Option {
+	return func(cfg *config) {
+		cfg.freezeRFC = true
+	}
+}
+
+// WithIDGenerator returns an Option that sets the function that generates the
+// IDs of archived mails. Defaults to uuid.New, which generates random IDs.
+// Use TimeOrderedID to generate IDs that are sortable by their creation time,
+// which improves the index locality of stores like MongoDB. IDs that are
+// provided with WithMailID() take precedence over generated IDs.
+func WithIDGenerator(gen func() uuid.UUID) Option {
+	return func(cfg *config) {
+		cfg.newID = gen
+	}
+}
+
+// HashContent returns the hex-encoded SHA-256 hash of the RFC body rfc. It can
+// be used to verify the content hash of an archived Mail:
+//
+//	valid := archive.HashContent(m.RFC()) == m.ContentHash()
+func HashContent(rfc string) string {
+	sum := sha256.Sum256([]byte(rfc))
+	return hex.EncodeToString(sum[:])
+}
+
+func (cfg *config) insert(ctx stdctx.Context, s Store, m Mail) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = s.Insert(ctx, m); err == nil || attempt >= cfg.insertAttempts || ctx.Err() != nil {
+			return err
+		}
+
+		var wait time.Duration
+		if cfg.insertBackoff != nil {
+			wait = cfg.insertBackoff(attempt)
+		}
+
+		if cfg.logger != nil {
+			cfg.logger.Print(fmt.Sprintf(
+				"Failed to insert mail into store (attempt %d of %d): %s. Retrying in %s.\n",
+				attempt, cfg.insertAttempts, err.Error(), wait,
+			))
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func (cfg *config) logInsertError(ctx stdctx.Context, err error) {
+	cfg.logError(ctx, fmt.Errorf("insert mail into store: %w", err))
+}
+
+func (cfg *config) logError(ctx stdctx.Context, err error) {
+	if cfg.ctxLogger != nil {
+		cfg.ctxLogger(ctx, err)
+	}
 	if cfg.logger != nil {
-		cfg.logger.Print(fmt.Sprintf("Failed to insert mail into store: %s\n", err.Error()))
+		cfg.logger.Print(fmt.Sprintf("Failed to %s\n", err.Error()))
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/mail"
 	"testing"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 	mock_postdog "github.com/bounoable/postdog/mocks"
 	"github.com/bounoable/postdog/plugin/archive"
 	mock_archive "github.com/bounoable/postdog/plugin/archive/mocks"
+	"github.com/bounoable/postdog/send"
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
 	. "github.com/smartystreets/goconvey/convey"
@@ -134,6 +136,314 @@ func TestNew(t *testing.T) {
 				})
 			}))
 
+			Convey("Given that the Store fails to insert mails and a context logger", WithFailingStoreInsert(s, func() {
+				type ctxKey string
+				logged := make(chan contextError, 1)
+				a := archive.New(s, archive.WithContextLogger(func(ctx context.Context, err error) {
+					logged <- contextError{ctx, err}
+				}))
+
+				tr := newMockTransport(ctrl)
+				Convey("Given a Transport that doesn't fail to send", WithTransportSend(tr, func() {
+					dog := postdog.New(postdog.WithTransport("test", tr), a)
+
+					Convey("When I send a Mail with a Context that carries a value", func() {
+						ctx := context.WithValue(context.Background(), ctxKey("requestID"), "abc")
+						err := dog.Send(ctx, mockLetter)
+
+						Convey("It shouldn't fail", func() {
+							<-logged
+							So(err, ShouldBeNil)
+						})
+
+						Convey("The insert error should be logged with the send Context", func() {
+							l := <-logged
+							So(errors.Is(l.err, mockInsertError), ShouldBeTrue)
+							So(l.ctx.Value(ctxKey("requestID")), ShouldEqual, "abc")
+						})
+					})
+				}))
+			}))
+
+			Convey("Given a synchronous archive Plugin", func() {
+				a := archive.New(s, archive.Synchronous())
+				tr := newMockTransport(ctrl)
+
+				Convey("Given a Transport that doesn't fail to send", WithTransportSend(tr, func() {
+					dog := postdog.New(postdog.WithTransport("test", tr), a)
+
+					Convey("When I send a Mail", WithStoreInsert(s, func(storedMail <-chan postdog.Mail) {
+						err := dog.Send(context.Background(), mockLetter)
+
+						Convey("It shouldn't fail", func() {
+							So(err, ShouldBeNil)
+						})
+
+						Convey("The mail should have been stored before Send() returned", func() {
+							So(storedMail, ShouldHaveLength, 1)
+						})
+					}))
+
+					Convey("When I send a Mail and the Store fails to insert it", WithFailingStoreInsert(s, func() {
+						err := dog.Send(context.Background(), mockLetter)
+
+						Convey("It shouldn't fail", func() {
+							So(err, ShouldBeNil)
+						})
+					}))
+				}))
+			})
+
+			Convey("Given an archive Plugin with an ID generator", func() {
+				id := uuid.New()
+				a := archive.New(s, archive.Synchronous(), archive.WithIDGenerator(func() uuid.UUID { return id }))
+				tr := newMockTransport(ctrl)
+
+				Convey("Given a Transport that doesn't fail to send", WithTransportSend(tr, func() {
+					dog := postdog.New(postdog.WithTransport("test", tr), a)
+
+					Convey("When I send a Mail", WithStoreInsert(s, func(storedMail <-chan postdog.Mail) {
+						err := dog.Send(context.Background(), mockLetter)
+
+						Convey("It shouldn't fail", func() {
+							So(err, ShouldBeNil)
+						})
+
+						Convey("The stored mail should have the generated ID", func() {
+							So(archive.ExpandMail(<-storedMail).ID(), ShouldEqual, id)
+						})
+					}))
+
+					Convey("When I send a Mail with a mail ID", WithStoreInsert(s, func(storedMail <-chan postdog.Mail) {
+						ctxID := uuid.New()
+						err := dog.Send(archive.WithMailID(context.Background(), ctxID), mockLetter)
+
+						Convey("It shouldn't fail", func() {
+							So(err, ShouldBeNil)
+						})
+
+						Convey("The stored mail should have the ID of the Context", func() {
+							So(archive.ExpandMail(<-storedMail).ID(), ShouldEqual, ctxID)
+						})
+					}))
+				}))
+			})
+
+			Convey("Given a synchronous archive Plugin that fails on insert errors", WithFailingStoreInsert(s, func() {
+				a := archive.New(s, archive.Synchronous(), archive.FailOnInsertError())
+				tr := newMockTransport(ctrl)
+
+				Convey("Given a Transport that doesn't fail to send", WithTransportSend(tr, func() {
+					dog := postdog.New(postdog.WithTransport("test", tr), a)
+
+					Convey("When I send a Mail", func() {
+						err := dog.Send(context.Background(), mockLetter)
+
+						Convey("It should fail with the insert error", func() {
+							So(errors.Is(err, mockInsertError), ShouldBeTrue)
+						})
+					})
+				}))
+			}))
+
+			Convey("Given that the Store fails to insert the first 2 mails", func() {
+				var inserts int
+				s.EXPECT().
+					Insert(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(context.Context, postdog.Mail) error {
+						inserts++
+						if inserts <= 2 {
+							return mockInsertError
+						}
+						return nil
+					}).
+					AnyTimes()
+
+				Convey("Given a synchronous archive with 3 insert attempts", func() {
+					logger := make(loggerChan, 2)
+					a := archive.New(
+						s,
+						archive.Synchronous(),
+						archive.FailOnInsertError(),
+						archive.WithLogger(logger),
+						archive.WithInsertRetry(3, func(int) time.Duration { return time.Millisecond }),
+					)
+					tr := newMockTransport(ctrl)
+
+					Convey("When I send a Mail", WithTransportSend(tr, func() {
+						dog := postdog.New(postdog.WithTransport("test", tr), a)
+						err := dog.Send(context.Background(), mockLetter)
+
+						Convey("It shouldn't fail", func() {
+							So(err, ShouldBeNil)
+						})
+
+						Convey("The insert should have been retried twice", func() {
+							So(inserts, ShouldEqual, 3)
+							So(<-logger, ShouldContainSubstring, "attempt 1 of 3")
+							So(<-logger, ShouldContainSubstring, "attempt 2 of 3")
+						})
+					}))
+				})
+
+				Convey("Given a synchronous archive with 2 insert attempts", func() {
+					a := archive.New(
+						s,
+						archive.Synchronous(),
+						archive.FailOnInsertError(),
+						archive.WithInsertRetry(2, nil),
+					)
+					tr := newMockTransport(ctrl)
+
+					Convey("When I send a Mail", WithTransportSend(tr, func() {
+						dog := postdog.New(postdog.WithTransport("test", tr), a)
+						err := dog.Send(context.Background(), mockLetter)
+
+						Convey("It should fail with the insert error", func() {
+							So(errors.Is(err, mockInsertError), ShouldBeTrue)
+							So(inserts, ShouldEqual, 2)
+						})
+					}))
+				})
+			})
+
+			Convey("Given that the Store always fails to insert mails", func() {
+				var inserts int
+				s.EXPECT().
+					Insert(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(context.Context, postdog.Mail) error {
+						inserts++
+						return mockInsertError
+					}).
+					AnyTimes()
+
+				Convey("Given a synchronous archive with an InsertTimeout of 50 milliseconds and 100 insert attempts", func() {
+					a := archive.New(
+						s,
+						archive.Synchronous(),
+						archive.FailOnInsertError(),
+						archive.InsertTimeout(50*time.Millisecond),
+						archive.WithInsertRetry(100, func(int) time.Duration { return 20 * time.Millisecond }),
+					)
+					tr := newMockTransport(ctrl)
+
+					Convey("When I send a Mail", WithTransportSend(tr, func() {
+						dog := postdog.New(postdog.WithTransport("test", tr), a)
+						start := time.Now()
+						err := dog.Send(context.Background(), mockLetter)
+
+						Convey("Retries should stop when the insert timeout is exceeded", func() {
+							So(errors.Is(err, mockInsertError), ShouldBeTrue)
+							So(time.Since(start), ShouldBeLessThan, 200*time.Millisecond)
+							So(inserts, ShouldBeBetweenOrEqual, 2, 4)
+						})
+					}))
+				})
+			})
+
+			Convey("Given a synchronous archive that stores content hashes", func() {
+				a := archive.New(s, archive.Synchronous(), archive.WithContentHash())
+				tr := newMockTransport(ctrl)
+
+				var sent postdog.Mail
+				tr.EXPECT().
+					Send(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, pm postdog.Mail) error {
+						sent = pm
+						return nil
+					})
+
+				Convey("When I send a Mail", WithStoreInsert(s, func(storedMail <-chan postdog.Mail) {
+					dog := postdog.New(postdog.WithTransport("test", tr), a)
+					err := dog.Send(context.Background(), mockLetter)
+
+					Convey("It shouldn't fail", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("The stored mail should contain the hash of the sent RFC body", func() {
+						m := archive.ExpandMail(<-storedMail)
+						So(m.ContentHash(), ShouldHaveLength, 64)
+						So(m.ContentHash(), ShouldEqual, archive.HashContent(sent.RFC()))
+						So(m.ContentHash(), ShouldEqual, archive.HashContent(m.RFC()))
+					})
+				}))
+			})
+
+			Convey("Given a synchronous archive that freezes RFC bodies", func() {
+				a := archive.New(s, archive.Synchronous(), archive.FreezeRFC())
+				tr := newMockTransport(ctrl)
+
+				var sent postdog.Mail
+				tr.EXPECT().
+					Send(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, pm postdog.Mail) error {
+						sent = pm
+						return nil
+					})
+
+				Convey("When I send a Mail", WithStoreInsert(s, func(storedMail <-chan postdog.Mail) {
+					dog := postdog.New(postdog.WithTransport("test", tr), a)
+					err := dog.Send(context.Background(), mockLetter)
+
+					Convey("It shouldn't fail", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("The stored mail should have the Message-ID of the sent mail", func() {
+						m := archive.ExpandMail(<-storedMail)
+						So(m.MessageID(), ShouldNotBeEmpty)
+						So(m.MessageID(), ShouldEqual, archive.ParseMessageID(sent.RFC()))
+						So(m.RFC(), ShouldEqual, sent.RFC())
+					})
+				}))
+			})
+
+			Convey("Given a synchronous archive that stores content hashes and transport middlewares", func() {
+				a := archive.New(s, archive.Synchronous(), archive.WithContentHash())
+				tr := newMockTransport(ctrl)
+
+				var sent postdog.Mail
+				tr.EXPECT().
+					Send(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, pm postdog.Mail) error {
+						sent = pm
+						return nil
+					})
+
+				Convey("When I send a wrapped Mail", WithStoreInsert(s, func(storedMail <-chan postdog.Mail) {
+					dog := postdog.New(
+						postdog.WithTransport("test", tr),
+						postdog.WithTransportMiddleware("test", postdog.MiddlewareFunc(func(
+							ctx context.Context,
+							pm postdog.Mail,
+							next postdog.NextMiddleware,
+						) (postdog.Mail, error) {
+							return next(ctx, letter.Expand(pm).WithText("Modified."))
+						})),
+						a,
+					)
+					err := dog.Send(
+						context.Background(),
+						postdog.WithSubject(mockLetter, "Wrapped"),
+						send.From(mail.Address{Name: "Tina Belcher", Address: "tina@example.com"}),
+					)
+
+					Convey("It shouldn't fail", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("The stored mail should contain the sent RFC body and its hash", func() {
+						m := archive.ExpandMail(<-storedMail)
+						So(m.RFC(), ShouldEqual, sent.RFC())
+						So(m.MessageID(), ShouldEqual, archive.ParseMessageID(sent.RFC()))
+						So(m.ContentHash(), ShouldEqual, archive.HashContent(sent.RFC()))
+						So(m.Subject(), ShouldEqual, "Wrapped")
+						So(m.RFC(), ShouldContainSubstring, "tina@example.com")
+					})
+				}))
+			})
+
 			Convey("Given that the Store takes 3 seconds to insert a mail", WithDelayedStoreInserts(s, 3*time.Second, func(<-chan postdog.Mail) {
 				Convey("Given an archive with an InsertTimeout of 1 second", func() {
 					logger := make(loggerChan, 1)
@@ -234,6 +544,11 @@ func WithDelayedTransportError(tr *mock_postdog.MockTransport, delay time.Durati
 
 type loggerChan chan string
 
+type contextError struct {
+	ctx context.Context
+	err error
+}
+
 func (lc loggerChan) Print(v ...interface{}) {
 	lc <- fmt.Sprint(v...)
 }
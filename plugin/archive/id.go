@@ -0,0 +1,30 @@
+package archive
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TimeOrderedID returns a version 7 UUID, which starts with the current Unix
+// time in milliseconds followed by random bits, so that IDs sort by their
+// creation time. It can be used as the ID generator of the archive plugin:
+//
+//	archive.New(store, archive.WithIDGenerator(archive.TimeOrderedID))
+func TimeOrderedID() uuid.UUID {
+	var id uuid.UUID
+	if _, err := rand.Read(id[6:]); err != nil {
+		return uuid.New()
+	}
+
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(time.Now().UnixNano()/int64(time.Millisecond)))
+	copy(id[:6], ms[2:])
+
+	id[6] = id[6]&0x0f | 0x70 // version 7
+	id[8] = id[8]&0x3f | 0x80 // RFC 4122 variant
+
+	return id
+}
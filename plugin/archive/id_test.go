@@ -0,0 +1,21 @@
+package archive_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bounoable/postdog/plugin/archive"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeOrderedID(t *testing.T) {
+	a := archive.TimeOrderedID()
+	time.Sleep(2 * time.Millisecond)
+	b := archive.TimeOrderedID()
+
+	assert.Equal(t, uuid.Version(7), a.Version())
+	assert.Equal(t, uuid.RFC4122, a.Variant())
+	assert.NotEqual(t, a, b)
+	assert.True(t, a.String() < b.String())
+}
@@ -1,6 +1,9 @@
 package archive
 
 import (
+	"encoding/base64"
+	"net/mail"
+	"strings"
 	"time"
 
 	"github.com/bounoable/postdog"
@@ -13,14 +16,18 @@ import (
 type Mail struct {
 	letter.Letter
 
-	id        uuid.UUID
-	sentAt    time.Time
-	sendError string
+	id             uuid.UUID
+	sentAt         time.Time
+	sendError      string
+	contentHash    string
+	timestampToken []byte
 }
 
 // ExpandMail takes a postdog.Mail and builds a Mail from it. If pm has a
 // SendError() method, the error will be added to the Mail. If pm has a
-// SentAt() method, the time will be added as the send time.
+// SentAt() method, the time will be added as the send time. If pm has a
+// ContentHash() method, the hash will be added as the content hash. If pm has
+// a TimestampToken() method, the token will be added as the timestamp token.
 func ExpandMail(pm postdog.Mail) Mail {
 	if m, ok := pm.(Mail); ok {
 		return m
@@ -40,6 +47,14 @@ func ExpandMail(pm postdog.Mail) Mail {
 		m.sentAt = timeMail.SentAt()
 	}
 
+	if hashMail, ok := pm.(interface{ ContentHash() string }); ok {
+		m.contentHash = hashMail.ContentHash()
+	}
+
+	if tsMail, ok := pm.(interface{ TimestampToken() []byte }); ok {
+		m.timestampToken = tsMail.TimestampToken()
+	}
+
 	return m
 }
 
@@ -76,12 +91,63 @@ func (m Mail) WithSendError(err string) Mail {
 	return m
 }
 
+// ContentHash returns the hex-encoded SHA-256 hash of the RFC body that was
+// sent. An empty string means that no hash has been computed for the mail.
+// See WithContentHash().
+func (m Mail) ContentHash() string {
+	return m.contentHash
+}
+
+// WithContentHash returns a copy of m with it's content hash set to hash.
+func (m Mail) WithContentHash(hash string) Mail {
+	m.contentHash = hash
+	return m
+}
+
+// TimestampToken returns the DER-encoded RFC 3161 timestamp token of the mail,
+// or nil if the mail has not been timestamped. See WithTimestampAuthority()
+// and VerifyTimestamp().
+func (m Mail) TimestampToken() []byte {
+	return m.timestampToken
+}
+
+// WithTimestampToken returns a copy of m with it's timestamp token set to
+// token.
+func (m Mail) WithTimestampToken(token []byte) Mail {
+	m.timestampToken = token
+	return m
+}
+
+// MessageID returns the `Message-ID` of the RFC body of m without the angle
+// brackets, or an empty string if the body has no `Message-ID`. Letters whose
+// RFC body is not frozen return a new `Message-ID` on every call (see
+// FreezeRFC()).
+func (m Mail) MessageID() string {
+	return ParseMessageID(m.RFC())
+}
+
+// ParseMessageID returns the `Message-ID` header of the RFC body rfc without
+// the angle brackets, or an empty string if rfc has no `Message-ID`.
+func ParseMessageID(rfc string) string {
+	msg, err := mail.ReadMessage(strings.NewReader(rfc))
+	if err != nil {
+		return ""
+	}
+	return normalizeMessageID(msg.Header.Get("Message-Id"))
+}
+
+func normalizeMessageID(id string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(id), "<"), ">")
+}
+
 // Map maps m to a map[string]interface{}.
 func (m Mail) Map(opts ...mapper.Option) map[string]interface{} {
 	res := m.Letter.Map(opts...)
 	res["id"] = m.id.String()
 	res["sendError"] = m.sendError
 	res["sentAt"] = m.sentAt.Format(time.RFC3339)
+	res["contentHash"] = m.contentHash
+	res["timestampToken"] = base64.StdEncoding.EncodeToString(m.timestampToken)
 	return res
 }
 
@@ -96,6 +162,14 @@ func (m *Mail) Parse(mm map[string]interface{}) {
 	if sendError, ok := mm["sendError"].(string); ok {
 		m.sendError = sendError
 	}
+	if contentHash, ok := mm["contentHash"].(string); ok {
+		m.contentHash = contentHash
+	}
+	if token, ok := mm["timestampToken"].(string); ok && token != "" {
+		if b, err := base64.StdEncoding.DecodeString(token); err == nil {
+			m.timestampToken = b
+		}
+	}
 	if sentAt, ok := mm["sentAt"].(string); ok {
 		if t, err := time.Parse(time.RFC3339, sentAt); err == nil {
 			m.sentAt = t.Round(0)
@@ -77,6 +77,17 @@ func TestMail_WithSendTime(t *testing.T) {
 	assert.Equal(t, sa, m.SentAt())
 }
 
+func TestMail_MessageID(t *testing.T) {
+	m := ExpandMail(letter.Write(letter.Text("Hello.")).WithRFCOptions(rfc.WithMessageID("<abc@example.com>")))
+	assert.Equal(t, "abc@example.com", m.MessageID())
+
+	m = ExpandMail(letter.Write(letter.RFC("Message-ID:  <def@example.com>\r\nSubject: Hi.\r\n\r\nHello.")))
+	assert.Equal(t, "def@example.com", m.MessageID())
+
+	m = ExpandMail(letter.Write(letter.RFC("Subject: Hi.\r\n\r\nHello.")))
+	assert.Equal(t, "", m.MessageID())
+}
+
 func TestMail_Map(t *testing.T) {
 	mockID := uuid.New()
 	mockSendError := errors.New("send error")
@@ -106,14 +117,16 @@ func TestMail_Map(t *testing.T) {
 					letter.HTML("<p>Hello.</p>"),
 					letter.Attach("attach1", []byte{1, 2, 3}, letter.AttachmentType("text/plain")),
 				).WithRFCOptions(rfcOpts...),
-			).WithID(mockID).WithSendError(mockSendError.Error()).WithSendTime(mockSendTime),
+			).WithID(mockID).WithSendError(mockSendError.Error()).WithSendTime(mockSendTime).WithContentHash("abc"),
 			want: func(m Mail) map[string]interface{} {
 				return merge(
 					m.Letter.Map(),
 					map[string]interface{}{
-						"id":        mockID.String(),
-						"sendError": mockSendError.Error(),
-						"sentAt":    mockSendTime.Format(time.RFC3339),
+						"id":             mockID.String(),
+						"sendError":      mockSendError.Error(),
+						"sentAt":         mockSendTime.Format(time.RFC3339),
+						"contentHash":    "abc",
+						"timestampToken": "",
 					},
 				)
 			},
@@ -140,9 +153,11 @@ func TestMail_Map(t *testing.T) {
 				return merge(
 					m.Letter.Map(mapper.WithoutAttachmentContent()),
 					map[string]interface{}{
-						"id":        mockID.String(),
-						"sendError": mockSendError.Error(),
-						"sentAt":    mockSendTime.Format(time.RFC3339),
+						"id":             mockID.String(),
+						"sendError":      mockSendError.Error(),
+						"sentAt":         mockSendTime.Format(time.RFC3339),
+						"contentHash":    "",
+						"timestampToken": "",
 					},
 				)
 			},
@@ -174,9 +189,10 @@ func TestMail_Parse(t *testing.T) {
 				"address": "tina@example.com",
 			},
 		},
-		"id":        mockID.String(),
-		"sendError": "send error",
-		"sentAt":    now.Format(time.RFC3339),
+		"id":          mockID.String(),
+		"sendError":   "send error",
+		"sentAt":      now.Format(time.RFC3339),
+		"contentHash": "abc",
 	}
 
 	var m Mail
@@ -189,6 +205,7 @@ func TestMail_Parse(t *testing.T) {
 	assert.Equal(t, mockID, m.ID())
 	assert.Equal(t, "send error", m.SendError())
 	assert.True(t, now.Equal(m.SentAt()))
+	assert.Equal(t, "abc", m.ContentHash())
 }
 
 type basicMail struct {
@@ -7,6 +7,7 @@ import (
 	"net/mail"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/bounoable/postdog/letter"
@@ -16,8 +17,11 @@ import (
 	"github.com/google/uuid"
 )
 
-// Store is an in-memory mail store.
+// Store is an in-memory mail store, e.g. for local development and tests. It
+// supports all filters of query.Query except the full-text search Input and is
+// safe for concurrent use.
 type Store struct {
+	mux   sync.RWMutex
 	mails []archive.Mail
 }
 
@@ -26,14 +30,25 @@ func NewStore() *Store {
 	return &Store{}
 }
 
-// Insert inserts m into s.
+// Insert inserts m into s. If there's already a stored mail with the same ID
+// as m, m replaces the stored mail.
 func (s *Store) Insert(ctx context.Context, m archive.Mail) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	for i, stored := range s.mails {
+		if stored.ID() == m.ID() {
+			s.mails[i] = m
+			return nil
+		}
+	}
 	s.mails = append(s.mails, m)
 	return nil
 }
 
 // Find returns the archive.Mail with the given id.
 func (s *Store) Find(ctx context.Context, id uuid.UUID) (archive.Mail, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
 	for _, m := range s.mails {
 		if m.ID() == id {
 			return m, nil
@@ -44,19 +59,29 @@ func (s *Store) Find(ctx context.Context, id uuid.UUID) (archive.Mail, error) {
 
 // Query returns a query.Cursor that returns the mails in the Store that match the query.Query q.
 func (s *Store) Query(_ context.Context, q query.Query) (archive.Cursor, error) {
+	s.mux.RLock()
 	var mails []archive.Mail
 	for _, m := range s.mails {
 		if filter(m, q) {
 			mails = append(mails, m)
 		}
 	}
+	s.mux.RUnlock()
 	mails = sortMails(mails, q)
 	mails = paginate(mails, q)
+	if q.WithoutAttachmentContent {
+		mails = withoutAttachmentContent(mails)
+	}
+	if len(q.Fields) > 0 {
+		mails = withFields(mails, q)
+	}
 	return cursor.New(mails...), nil
 }
 
 // Remove removes the given archive.Mail m from the Store s.
 func (s *Store) Remove(_ context.Context, m archive.Mail) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
 	for i, c := range s.mails {
 		if c.ID() == m.ID() {
 			s.mails = append(s.mails[:i], s.mails[i+1:]...)
@@ -66,6 +91,43 @@ func (s *Store) Remove(_ context.Context, m archive.Mail) error {
 	return nil
 }
 
+func withoutAttachmentContent(mails []archive.Mail) []archive.Mail {
+	res := make([]archive.Mail, len(mails))
+	for i, m := range mails {
+		ats := make([]letter.Attachment, len(m.Attachments()))
+		for j, at := range m.Attachments() {
+			at.A.Size = at.Size()
+			at.A.Content = nil
+			ats[j] = at
+		}
+		m.Letter = m.Letter.WithAttachments(ats...)
+		res[i] = m
+	}
+	return res
+}
+
+// withFields returns copies of mails that only contain the fields that are
+// selected by q.
+func withFields(mails []archive.Mail, q query.Query) []archive.Mail {
+	res := make([]archive.Mail, len(mails))
+	for i, m := range mails {
+		mm := m.Map()
+		for key := range mm {
+			if !q.Selects(query.Field(key)) {
+				delete(mm, key)
+			}
+		}
+		var partial archive.Mail
+		partial.Parse(mm)
+		if q.Selects(query.FieldSentAt) {
+			// Map() formats the send time with a precision of seconds
+			partial = partial.WithSendTime(m.SentAt())
+		}
+		res[i] = partial
+	}
+	return res
+}
+
 func filter(pm archive.Mail, q query.Query) bool {
 	m := archive.ExpandMail(pm)
 
@@ -106,6 +168,22 @@ func filter(pm archive.Mail, q query.Query) bool {
 		}
 	}
 
+	if len(q.ContentHashes) > 0 {
+		if !containsString(q.ContentHashes, m.ContentHash()) {
+			return false
+		}
+	}
+
+	if len(q.MessageIDs) > 0 {
+		if !containsString(q.MessageIDs, m.MessageID()) {
+			return false
+		}
+	}
+
+	if !filterSendTime(m.SentAt(), q.SendTime) {
+		return false
+	}
+
 	// if len(q.RFC) > 0 {
 	// 	if !containsAnySubstring(m.RFC(), q.RFC) {
 	// 		return false
@@ -137,6 +215,16 @@ func filter(pm archive.Mail, q query.Query) bool {
 	// }
 
 	attachments := m.Attachments()
+	if q.Attachment.Has != nil && *q.Attachment.Has != (len(attachments) > 0) {
+		return false
+	}
+
+	if len(q.Attachment.Counts) > 0 {
+		if !inAnyRange(len(attachments), q.Attachment.Counts) {
+			return false
+		}
+	}
+
 	if len(q.Attachment.Filenames) > 0 {
 		if !containsAnyAttachmentFilename(attachments, q.Attachment.Filenames) {
 			return false
@@ -170,6 +258,49 @@ func filter(pm archive.Mail, q query.Query) bool {
 	return true
 }
 
+func filterSendTime(t time.Time, f query.SendTimeFilter) bool {
+	if len(f.Exact) > 0 {
+		var found bool
+		for _, exact := range f.Exact {
+			if t.Equal(exact) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(f.Before) > 0 {
+		var found bool
+		for _, before := range f.Before {
+			if t.Before(before) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(f.After) > 0 {
+		var found bool
+		for _, after := range f.After {
+			if t.After(after) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
 func containsAnyAddress(addrs []mail.Address, search []mail.Address) bool {
 	for _, given := range addrs {
 		for _, want := range search {
@@ -198,6 +329,15 @@ func containsAnySubstring(s string, ss []string) bool {
 	return false
 }
 
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 func containsAnyAttachmentFilename(ats []letter.Attachment, filenames []string) bool {
 	for _, at := range ats {
 		for _, name := range filenames {
@@ -220,6 +360,15 @@ func containsAnyAttachmentSize(ats []letter.Attachment, sizes []int) bool {
 	return false
 }
 
+func inAnyRange(n int, ranges [][2]int) bool {
+	for _, r := range ranges {
+		if r[0] <= n && r[1] >= n {
+			return true
+		}
+	}
+	return false
+}
+
 func containsAnyAttachmentSizeRange(ats []letter.Attachment, ranges [][2]int) bool {
 	for _, at := range ats {
 		for _, r := range ranges {
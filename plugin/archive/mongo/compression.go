@@ -0,0 +1,72 @@
+package mongo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+)
+
+// compress moves the text, HTML and RFC body of m into their gzip-compressed
+// fields.
+func (m *dbmail) compress() (err error) {
+	if m.TextGzip, err = gzipString(m.Text); err != nil {
+		return err
+	}
+	if m.HTMLGzip, err = gzipString(m.HTML); err != nil {
+		return err
+	}
+	if m.RFCGzip, err = gzipString(m.RFC); err != nil {
+		return err
+	}
+	m.Text, m.HTML, m.RFC = "", "", ""
+	m.Compressed = true
+	return nil
+}
+
+// decompress restores the text, HTML and RFC body of m from their
+// gzip-compressed fields. It does nothing if m is not compressed.
+func (m *dbmail) decompress() (err error) {
+	if !m.Compressed {
+		return nil
+	}
+	if m.Text, err = gunzipString(m.TextGzip); err != nil {
+		return err
+	}
+	if m.HTML, err = gunzipString(m.HTMLGzip); err != nil {
+		return err
+	}
+	if m.RFC, err = gunzipString(m.RFCGzip); err != nil {
+		return err
+	}
+	m.TextGzip, m.HTMLGzip, m.RFCGzip = nil, nil, nil
+	m.Compressed = false
+	return nil
+}
+
+func gzipString(s string) ([]byte, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(s)); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func gunzipString(b []byte) (string, error) {
+	if len(b) == 0 {
+		return "", nil
+	}
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	s, err := ioutil.ReadAll(r)
+	return string(s), err
+}
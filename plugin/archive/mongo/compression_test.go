@@ -0,0 +1,29 @@
+package mongo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBMail_compress(t *testing.T) {
+	html := strings.Repeat("<p>Hello.</p>", 1000)
+	m := dbmail{Subject: "Hi.", Text: "Hello.", HTML: html, RFC: "Subject: Hi.\r\n\r\nHello."}
+
+	assert.Nil(t, m.compress())
+	assert.True(t, m.Compressed)
+	assert.Empty(t, m.Text)
+	assert.Empty(t, m.HTML)
+	assert.Empty(t, m.RFC)
+	assert.Less(t, len(m.HTMLGzip), len(html))
+
+	assert.Nil(t, m.decompress())
+	assert.Equal(t, dbmail{Subject: "Hi.", Text: "Hello.", HTML: html, RFC: "Subject: Hi.\r\n\r\nHello."}, m)
+}
+
+func TestDBMail_decompress_uncompressed(t *testing.T) {
+	m := dbmail{Text: "Hello.", HTML: "<p>Hello.</p>"}
+	assert.Nil(t, m.decompress())
+	assert.Equal(t, dbmail{Text: "Hello.", HTML: "<p>Hello.</p>"}, m)
+}
@@ -33,3 +33,10 @@ func TestWithoutAttachmentContent(t *testing.T) {
 	WithoutAttachmentContent(true)(&s)
 	assert.Equal(t, true, s.withoutAttachmentContent)
 }
+
+func TestWithCompression(t *testing.T) {
+	var s Store
+	assert.Equal(t, false, s.compress)
+	WithCompression(true)(&s)
+	assert.Equal(t, true, s.compress)
+}
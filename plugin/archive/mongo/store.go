@@ -25,6 +25,7 @@ type Store struct {
 	collectionName           string
 	wantIndexes              bool
 	withoutAttachmentContent bool
+	compress                 bool
 	col                      *mongo.Collection
 }
 
@@ -46,6 +47,18 @@ type dbmail struct {
 	RFC         string       `bson:"rfc"`
 	SendError   string       `bson:"sendError"`
 	SentAt      time.Time    `bson:"sentAt"`
+	ContentHash string       `bson:"contentHash"`
+	MessageID   string       `bson:"messageId"`
+
+	// TimestampToken is the DER-encoded RFC 3161 timestamp token of the mail.
+	TimestampToken []byte `bson:"timestampToken,omitempty"`
+
+	// Compressed is true if the text, HTML and RFC body are stored
+	// gzip-compressed in TextGzip, HTMLGzip and RFCGzip.
+	Compressed bool   `bson:"compressed,omitempty"`
+	TextGzip   []byte `bson:"textGzip,omitempty"`
+	HTMLGzip   []byte `bson:"htmlGzip,omitempty"`
+	RFCGzip    []byte `bson:"rfcGzip,omitempty"`
 }
 
 type address struct {
@@ -61,6 +74,22 @@ type attachment struct {
 	Header      textproto.MIMEHeader `bson:"header"`
 }
 
+// option returns the letter.Option that adds at to a letter. The stored header
+// is used as-is so that e.g. the original `Content-ID` is preserved. Headers
+// are only generated for attachments that have been stored without a
+// `Content-Type` header.
+func (at attachment) option() letter.Option {
+	if at.Header.Get("Content-Type") == "" {
+		return letter.Attach(
+			at.Filename,
+			at.Content,
+			letter.AttachmentType(at.ContentType),
+			letter.AttachmentSize(at.Size),
+		)
+	}
+	return letter.AttachWithHeader(at.Filename, at.Content, at.Header, letter.AttachmentSize(at.Size))
+}
+
 type cursor struct {
 	cur     *mongo.Cursor
 	current archive.Mail
@@ -115,8 +144,27 @@ func WithoutAttachmentContent(ac bool) Option {
 	}
 }
 
+// WithCompression returns an Option that gzip-compresses the text, HTML and
+// RFC body of mails before they are stored in the database. Compressed mails
+// are decompressed transparently when they are read, and mails that have been
+// stored without compression can still be read.
+//
+// The full-text search of query.Input only searches the subject of compressed
+// mails, because their text and HTML content is not indexed by MongoDB.
+func WithCompression(c bool) Option {
+	return func(s *Store) {
+		s.compress = c
+	}
+}
+
 // Insert stores m into the database. If there's already a stored mail with the
 // same ID as m, m will override the previously stored mail.
+//
+// The Message-ID of m is stored in the indexed `messageId` field. When the
+// indexes are created with CreateIndexes(), the Message-ID must be unique, so
+// inserting a mail with a different ID but the same Message-ID as a stored mail
+// fails with a duplicate key error. Mails without a Message-ID are not
+// affected by the uniqueness constraint.
 func (s *Store) Insert(ctx context.Context, m archive.Mail) error {
 	attachments := make([]attachment, len(m.Attachments()))
 	for i, at := range m.Attachments() {
@@ -133,6 +181,7 @@ func (s *Store) Insert(ctx context.Context, m archive.Mail) error {
 		}
 	}
 
+	rfc := m.RFC()
 	dbm := dbmail{
 		ID:          m.ID(),
 		From:        rmapAddress(m.From()),
@@ -145,9 +194,19 @@ func (s *Store) Insert(ctx context.Context, m archive.Mail) error {
 		Subject:     m.Subject(),
 		Text:        m.Text(),
 		HTML:        m.HTML(),
-		RFC:         m.RFC(),
+		RFC:         rfc,
 		SendError:   m.SendError(),
 		SentAt:      m.SentAt(),
+		ContentHash: m.ContentHash(),
+		MessageID:   archive.ParseMessageID(rfc),
+
+		TimestampToken: m.TimestampToken(),
+	}
+
+	if s.compress {
+		if err := dbm.compress(); err != nil {
+			return fmt.Errorf("compress: %w", err)
+		}
 	}
 
 	if _, err := s.col.ReplaceOne(ctx, bson.M{"id": m.ID()}, dbm, options.Replace().SetUpsert(true)); err != nil {
@@ -170,6 +229,7 @@ func (s *Store) Query(ctx context.Context, q query.Query) (archive.Cursor, error
 	opts := options.Find()
 	opts = withSorting(opts, q)
 	opts = withPagination(opts, q)
+	opts = withProjection(opts, q)
 	cur, err := s.col.Find(ctx, filter, opts)
 	if err != nil {
 		return nil, fmt.Errorf("mongo: %w", err)
@@ -195,6 +255,13 @@ func (s *Store) createIndexes(ctx context.Context) error {
 		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
 		{Keys: bson.D{{Key: "sentAt", Value: 1}}},
 		{Keys: bson.D{{Key: "subject", Value: 1}}},
+		{Keys: bson.D{{Key: "contentHash", Value: 1}}},
+		{
+			Keys: bson.D{{Key: "messageId", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.D{{Key: "messageId", Value: bson.D{{Key: "$gt", Value: ""}}}}),
+		},
 		{Keys: bson.D{{Key: "from.name", Value: 1}}},
 		{Keys: bson.D{{Key: "from.address", Value: 1}}},
 		{Keys: bson.D{{Key: "recipients.name", Value: 1}}},
@@ -234,9 +301,15 @@ func (cur *cursor) Next(ctx context.Context) bool {
 		return false
 	}
 
+	if cur.err = mail.decompress(); cur.err != nil {
+		cur.err = fmt.Errorf("decompress: %w", cur.err)
+		cur.current = archive.Mail{}
+		return false
+	}
+
 	attachments := make([]letter.Option, len(mail.Attachments))
 	for i, at := range mail.Attachments {
-		attachments[i] = letter.Attach(at.Filename, at.Content, letter.AttachmentType(at.ContentType))
+		attachments[i] = at.option()
 	}
 
 	cur.current = archive.
@@ -253,7 +326,9 @@ func (cur *cursor) Next(ctx context.Context) bool {
 		}, attachments...)...)).
 		WithID(mail.ID).
 		WithSendError(mail.SendError).
-		WithSendTime(mail.SentAt)
+		WithSendTime(mail.SentAt).
+		WithContentHash(mail.ContentHash).
+		WithTimestampToken(mail.TimestampToken)
 
 	return true
 }
@@ -294,14 +369,13 @@ func decode(res *mongo.SingleResult) (archive.Mail, error) {
 		return archive.Mail{}, fmt.Errorf("decode: %w", err)
 	}
 
+	if err := m.decompress(); err != nil {
+		return archive.Mail{}, fmt.Errorf("decompress: %w", err)
+	}
+
 	var attachments []letter.Option
 	for _, at := range m.Attachments {
-		attachments = append(attachments, letter.Attach(
-			at.Filename,
-			at.Content,
-			letter.AttachmentType(at.ContentType),
-			letter.AttachmentSize(at.Size),
-		))
+		attachments = append(attachments, at.option())
 	}
 
 	opts := append([]letter.Option{
@@ -320,7 +394,9 @@ func decode(res *mongo.SingleResult) (archive.Mail, error) {
 		ExpandMail(letter.Write(opts...)).
 		WithID(m.ID).
 		WithSendError(m.SendError).
-		WithSendTime(m.SentAt), nil
+		WithSendTime(m.SentAt).
+		WithContentHash(m.ContentHash).
+		WithTimestampToken(m.TimestampToken), nil
 }
 
 func mapAddress(addr address) mail.Address {
@@ -354,6 +430,16 @@ func newFilter(q query.Query) bson.D {
 		filter = withFilter(filter, []string{"subject"}, regexInValues(q.Subjects))
 	}
 
+	if len(q.ContentHashes) > 0 {
+		filter = append(filter, bson.E{Key: "contentHash", Value: inValues(q.ContentHashes)})
+	}
+
+	if len(q.MessageIDs) > 0 {
+		// mails that have been stored before the messageId field existed
+		// don't match
+		filter = append(filter, bson.E{Key: "messageId", Value: inValues(q.MessageIDs)})
+	}
+
 	// if len(q.Texts) > 0 {
 	// 	filter = withFilter(filter, []string{"text"}, regexInValues(q.Texts))
 	// }
@@ -393,6 +479,23 @@ func newFilter(q query.Query) bson.D {
 		filter = withAddressesFilter(filter, "bcc", q.BCC...)
 	}
 
+	if q.Attachment.Has != nil {
+		// matches empty, null and missing attachment arrays if Has is false
+		filter = append(filter, bson.E{Key: "attachments.0", Value: bson.D{{Key: "$exists", Value: *q.Attachment.Has}}})
+	}
+
+	if len(q.Attachment.Counts) > 0 {
+		size := bson.D{{Key: "$size", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$attachments", bson.A{}}}}}}
+		or := make(bson.A, len(q.Attachment.Counts))
+		for i, rang := range q.Attachment.Counts {
+			or[i] = bson.D{{Key: "$and", Value: bson.A{
+				bson.D{{Key: "$gte", Value: bson.A{size, rang[0]}}},
+				bson.D{{Key: "$lte", Value: bson.A{size, rang[1]}}},
+			}}}
+		}
+		filter = append(filter, bson.E{Key: "$expr", Value: bson.D{{Key: "$or", Value: or}}})
+	}
+
 	if len(q.Attachment.Filenames) > 0 {
 		filter = withFilter(filter, []string{"attachments.filename"}, regexInValues(q.Attachment.Filenames))
 	}
@@ -576,6 +679,48 @@ func withSorting(opts *options.FindOptions, q query.Query) *options.FindOptions
 	return opts
 }
 
+func withProjection(opts *options.FindOptions, q query.Query) *options.FindOptions {
+	if len(q.Fields) == 0 {
+		if q.WithoutAttachmentContent {
+			return opts.SetProjection(bson.D{{Key: "attachments.content", Value: 0}})
+		}
+		return opts
+	}
+
+	projection := bson.D{{Key: "id", Value: 1}}
+	var compressed bool
+	for _, field := range q.Fields {
+		if field == query.FieldID {
+			continue
+		}
+
+		switch field {
+		case query.FieldText, query.FieldHTML, query.FieldRFC:
+			projection = append(projection, bson.E{Key: string(field) + "Gzip", Value: 1})
+			if !compressed {
+				projection = append(projection, bson.E{Key: "compressed", Value: 1})
+				compressed = true
+			}
+		}
+
+		if field == query.FieldAttachments && q.WithoutAttachmentContent {
+			// inclusion and exclusion can't be mixed in a projection
+			projection = append(
+				projection,
+				bson.E{Key: "attachments.filename", Value: 1},
+				bson.E{Key: "attachments.contentType", Value: 1},
+				bson.E{Key: "attachments.size", Value: 1},
+				bson.E{Key: "attachments.header", Value: 1},
+			)
+			continue
+		}
+
+		projection = append(projection, bson.E{Key: string(field), Value: 1})
+	}
+
+	return opts.SetProjection(projection)
+}
+
 func withPagination(opts *options.FindOptions, q query.Query) *options.FindOptions {
 	if q.Pagination.Page == 0 {
 		return opts
@@ -51,6 +51,37 @@ func TestStore(t *testing.T) {
 	}, test.RoundTime(time.Millisecond))
 }
 
+func TestStore_withCompression(t *testing.T) {
+	if testing.Short() {
+		t.Skip("[plugin/archive]: Skipping mongodb store test.")
+	}
+
+	var counter int32
+
+	test.Store(t, func() archive.Store {
+		client, err := connect(context.Background())
+		if err != nil {
+			panic(err)
+		}
+
+		count := atomic.AddInt32(&counter, 1)
+
+		s, err := mongostore.NewStore(
+			context.Background(),
+			client,
+			mongostore.Database(fmt.Sprintf("postdog_compressed_%d", count)),
+			mongostore.Collection(fmt.Sprintf("mails_%d", count)),
+			mongostore.CreateIndexes(false),
+			mongostore.WithCompression(true),
+		)
+		if err != nil {
+			panic(err)
+		}
+
+		return s
+	}, test.RoundTime(time.Millisecond))
+}
+
 var once sync.Once
 
 func connect(ctx context.Context) (*mongo.Client, error) {
@@ -3,6 +3,7 @@ package query
 import (
 	"fmt"
 	"net/mail"
+	"strings"
 	"time"
 )
 
@@ -15,6 +16,39 @@ const (
 	SortSubject
 )
 
+const (
+	// FieldID is the ID of a mail. It is always returned, even if it's not selected.
+	FieldID = Field("id")
+	// FieldFrom is the sender of a mail.
+	FieldFrom = Field("from")
+	// FieldRecipients are the recipients of a mail.
+	FieldRecipients = Field("recipients")
+	// FieldTo are the `To` recipients of a mail.
+	FieldTo = Field("to")
+	// FieldCC are the `Cc` recipients of a mail.
+	FieldCC = Field("cc")
+	// FieldBCC are the `Bcc` recipients of a mail.
+	FieldBCC = Field("bcc")
+	// FieldReplyTo are the `Reply-To` addresses of a mail.
+	FieldReplyTo = Field("replyTo")
+	// FieldSubject is the subject of a mail.
+	FieldSubject = Field("subject")
+	// FieldText is the text content of a mail.
+	FieldText = Field("text")
+	// FieldHTML is the HTML content of a mail.
+	FieldHTML = Field("html")
+	// FieldRFC is the RFC body of a mail.
+	FieldRFC = Field("rfc")
+	// FieldAttachments are the attachments of a mail.
+	FieldAttachments = Field("attachments")
+	// FieldSendError is the send error of a mail.
+	FieldSendError = Field("sendError")
+	// FieldSentAt is the send time of a mail.
+	FieldSentAt = Field("sentAt")
+	// FieldContentHash is the content hash of a mail.
+	FieldContentHash = Field("contentHash")
+)
+
 const (
 	// SortAsc sorts in ascending order.
 	SortAsc = SortDirection(iota)
@@ -30,6 +64,10 @@ type Query struct {
 	BCC        []mail.Address
 	Recipients []mail.Address
 	Subjects   []string
+	// ContentHashes are hex-encoded SHA-256 hashes of RFC bodies.
+	ContentHashes []string
+	// MessageIDs are `Message-ID`s without angle brackets.
+	MessageIDs []string
 	// Texts         []string
 	// HTML          []string
 	// RFC           []string
@@ -40,8 +78,19 @@ type Query struct {
 	SortDirection SortDirection
 	Pagination    Pagination
 	Input         string
+
+	// WithoutAttachmentContent makes stores return the queried mails without
+	// the contents of their attachments. See WithoutAttachmentContent().
+	WithoutAttachmentContent bool
+
+	// Fields are the fields that stores return for the queried mails. If
+	// empty, all fields are returned. See Fields().
+	Fields []Field
 }
 
+// Field is a field of an archived mail.
+type Field string
+
 // SendTimeFilter is the query filter for the send date.
 type SendTimeFilter struct {
 	Exact  []time.Time
@@ -51,6 +100,12 @@ type SendTimeFilter struct {
 
 // AttachmentFilter is the query filter for attachments.
 type AttachmentFilter struct {
+	// Has filters mails by whether they have any attachments. A nil value
+	// doesn't filter. See HasAttachments().
+	Has *bool
+	// Counts are inclusive ranges for the number of attachments of a mail.
+	// See AttachmentCount().
+	Counts       [][2]int
 	Filenames    []string
 	ContentTypes []string
 	Contents     [][]byte
@@ -129,6 +184,25 @@ func Subject(subjects ...string) Option {
 	}
 }
 
+// ContentHash returns an Option that filters mails by their content hash (see
+// archive.WithContentHash()). The content hash of a mail must be one of hashes.
+func ContentHash(hashes ...string) Option {
+	return func(q *Query) {
+		q.ContentHashes = append(q.ContentHashes, hashes...)
+	}
+}
+
+// MessageID returns an Option that filters mails by the `Message-ID` header of
+// their RFC body. The `Message-ID` of a mail must be one of ids. IDs may be
+// given with or without angle brackets.
+func MessageID(ids ...string) Option {
+	return func(q *Query) {
+		for _, id := range ids {
+			q.MessageIDs = append(q.MessageIDs, strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(id), "<"), ">"))
+		}
+	}
+}
+
 // // Text returns an Option that adds a `Text` filter to a Query.
 // func Text(texts ...string) Option {
 // 	return func(q *Query) {
@@ -190,6 +264,28 @@ func SentBetween(l, r time.Time) Option {
 	}
 }
 
+// HasAttachments returns an Option that filters mails by whether they have
+// attachments. If has is true, only mails with at least one attachment match;
+// if has is false, only mails without attachments match.
+func HasAttachments(has bool) Option {
+	return func(q *Query) {
+		q.Attachment.Has = &has
+	}
+}
+
+// AttachmentCount returns an Option that filters mails by their number of
+// attachments, which must be in the inclusive range (min, max). If min is
+// greater than max, the range is normalized by swapping them. If multiple
+// ranges are provided, the number of attachments must be in any of them.
+func AttachmentCount(min, max int) Option {
+	if min > max {
+		min, max = max, min
+	}
+	return func(q *Query) {
+		q.Attachment.Counts = append(q.Attachment.Counts, [2]int{min, max})
+	}
+}
+
 // AttachmentFilename returns an Option that adds an attachment filter to a Query.
 // It filters attachments by their filename.
 func AttachmentFilename(names ...string) Option {
@@ -208,8 +304,12 @@ func AttachmentSize(sizes ...int) Option {
 
 // AttachmentSizeRange returns an Option that adds an attachment filter to a Query.
 // It filters attachments by their file size, where the attachment's file size
-// must be in the inclusive range (min, max).
+// must be in the inclusive range (min, max). If min is greater than max, the
+// range is normalized by swapping them.
 func AttachmentSizeRange(min, max int) Option {
+	if min > max {
+		min, max = max, min
+	}
 	return func(q *Query) {
 		q.Attachment.Size.Ranges = append(q.Attachment.Size.Ranges, [2]int{min, max})
 	}
@@ -238,6 +338,43 @@ func Input(input string) Option {
 	}
 }
 
+// WithoutAttachmentContent returns an Option that makes stores return the
+// queried mails without the contents of their attachments. The filenames,
+// sizes, content types and headers of the attachments are still returned.
+// Use this option for list views to avoid loading all attachments into memory.
+func WithoutAttachmentContent() Option {
+	return func(q *Query) {
+		q.WithoutAttachmentContent = true
+	}
+}
+
+// Fields returns an Option that selects the fields that stores return for the
+// queried mails, e.g. for index pages that only show the subject and sender:
+//
+//	query.Fields(query.FieldSubject, query.FieldFrom, query.FieldSentAt)
+//
+// Fields that are not selected are returned as zero values. The ID of a mail
+// (FieldID) is always returned. Mails that have been queried with selected
+// fields are incomplete and must not be used to resend a mail.
+func Fields(fields ...Field) Option {
+	return func(q *Query) {
+		q.Fields = append(q.Fields, fields...)
+	}
+}
+
+// Selects determines if q selects the field f.
+func (q Query) Selects(f Field) bool {
+	if len(q.Fields) == 0 || f == FieldID {
+		return true
+	}
+	for _, field := range q.Fields {
+		if field == f {
+			return true
+		}
+	}
+	return false
+}
+
 // Sort returns an Option that configures the Sorting of a Query.
 func Sort(by Sorting, dir SortDirection) Option {
 	return func(q *Query) {
@@ -90,6 +90,16 @@ func TestNew(t *testing.T) {
 				Subjects: []string{"Subject 1", "Subject 2", "Subject 3", "Subject 4"},
 			},
 		},
+		{
+			name: "MessageID()",
+			opts: []query.Option{
+				query.MessageID("<abc@example.com>", " def@example.com "),
+				query.MessageID("ghi@example.com"),
+			},
+			want: query.Query{
+				MessageIDs: []string{"abc@example.com", "def@example.com", "ghi@example.com"},
+			},
+		},
 		// {
 		// 	name: "Text()",
 		// 	opts: []query.Option{
@@ -160,6 +170,43 @@ func TestNew(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "AttachmentSizeRange() (inverted range)",
+			opts: []query.Option{
+				query.AttachmentSizeRange(10, 3),
+			},
+			want: query.Query{
+				Attachment: query.AttachmentFilter{
+					Size: query.AttachmentSizeFilter{
+						Ranges: [][2]int{{3, 10}},
+					},
+				},
+			},
+		},
+		{
+			name: "HasAttachments()",
+			opts: []query.Option{
+				query.HasAttachments(true),
+				query.HasAttachments(false),
+			},
+			want: query.Query{
+				Attachment: query.AttachmentFilter{
+					Has: boolPtr(false),
+				},
+			},
+		},
+		{
+			name: "AttachmentCount()",
+			opts: []query.Option{
+				query.AttachmentCount(1, 3),
+				query.AttachmentCount(10, 5),
+			},
+			want: query.Query{
+				Attachment: query.AttachmentFilter{
+					Counts: [][2]int{{1, 3}, {5, 10}},
+				},
+			},
+		},
 		{
 			name: "AttachmentContentType()",
 			opts: []query.Option{
@@ -293,6 +340,16 @@ func TestNew(t *testing.T) {
 				SortDirection: query.SortDesc,
 			},
 		},
+		{
+			name: "Fields()",
+			opts: []query.Option{
+				query.Fields(query.FieldSubject, query.FieldFrom),
+				query.Fields(query.FieldSentAt),
+			},
+			want: query.Query{
+				Fields: []query.Field{query.FieldSubject, query.FieldFrom, query.FieldSentAt},
+			},
+		},
 		{
 			name: "Paginate()",
 			opts: []query.Option{
@@ -313,3 +370,20 @@ func TestNew(t *testing.T) {
 		})
 	}
 }
+
+func TestQuery_Selects(t *testing.T) {
+	q := query.New()
+	assert.True(t, q.Selects(query.FieldSubject))
+	assert.True(t, q.Selects(query.FieldAttachments))
+
+	q = query.New(query.Fields(query.FieldSubject, query.FieldFrom))
+	assert.True(t, q.Selects(query.FieldSubject))
+	assert.True(t, q.Selects(query.FieldFrom))
+	assert.True(t, q.Selects(query.FieldID))
+	assert.False(t, q.Selects(query.FieldAttachments))
+	assert.False(t, q.Selects(query.FieldSentAt))
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
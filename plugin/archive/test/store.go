@@ -1,11 +1,13 @@
 package test
 
 import (
+	"bytes"
 	"context"
 	stdctx "context"
 	"errors"
 	"fmt"
 	"net/mail"
+	"net/textproto"
 	"sort"
 	"testing"
 	"time"
@@ -69,6 +71,29 @@ func Store(t *testing.T, newStore func() archive.Store, opts ...StoreTestOption)
 					So(err, ShouldBeNil)
 				})
 			})
+
+			Convey("When I insert a mail with the ID of a stored mail", func() {
+				id := uuid.New()
+				err := s.Insert(stdctx.Background(), mockMail.WithID(id).WithSendError("failed"))
+				So(err, ShouldBeNil)
+
+				m := mockMail.WithID(id).WithSendTime(now.Add(time.Minute).Round(cfg.roundTime))
+				err = s.Insert(stdctx.Background(), m)
+
+				Convey("It shouldn't fail", func() {
+					So(err, ShouldBeNil)
+				})
+
+				Convey("The stored mail should be replaced", func() {
+					found, err := s.Find(stdctx.Background(), id)
+					So(err, ShouldBeNil)
+					So(found, shouldResembleMail, m)
+
+					cur, err := s.Query(stdctx.Background(), query.New())
+					So(err, ShouldBeNil)
+					So(drain(cur), ShouldHaveLength, 1)
+				})
+			})
 		})
 
 		Convey("Find()", func() {
@@ -108,6 +133,65 @@ func Store(t *testing.T, newStore func() archive.Store, opts ...StoreTestOption)
 						})
 					})
 				})
+
+				Convey("When I insert a mail with custom attachment headers", func() {
+					header := make(textproto.MIMEHeader)
+					header.Set("Content-Type", `image/png; name="logo.png"`)
+					header.Set("Content-Disposition", `inline; filename="logo.png"`)
+					header.Set("Content-ID", "<logo@example.com>")
+					header.Set("Content-Transfer-Encoding", "base64")
+
+					id := uuid.New()
+					m := archive.ExpandMail(letter.Write(
+						letter.From("Bob Belcher", "bob@example.com"),
+						letter.To("Linda Belcher", "linda@example.com"),
+						letter.AttachWithHeader("logo.png", []byte{1, 2, 3}, header),
+					).WithRFCOptions(rfc.WithMessageID("<logo@example.com>"))).WithID(id)
+					err := s.Insert(stdctx.Background(), m)
+
+					Convey("It shouldn't fail", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("When I call Find() with the mail's ID", func() {
+						found, err := s.Find(stdctx.Background(), id)
+
+						Convey("It shouldn't fail", func() {
+							So(err, ShouldBeNil)
+						})
+
+						Convey("The attachment headers should be preserved", func() {
+							So(found.Attachments(), ShouldHaveLength, 1)
+							So(found.Attachments()[0].Header(), ShouldResemble, m.Attachments()[0].Header())
+						})
+					})
+				})
+
+				Convey("When I insert a mail with a custom RFC body", func() {
+					id := uuid.New()
+					m := archive.ExpandMail(letter.Write(
+						letter.From("Bob Belcher", "bob@example.com"),
+						letter.To("Linda Belcher", "linda@example.com"),
+						letter.RFC("Message-ID: <custom@example.com>\r\nSubject: Custom\r\n\r\nHello."),
+					)).WithID(id)
+					err := s.Insert(stdctx.Background(), m)
+
+					Convey("It shouldn't fail", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("When I call Find() with the mail's ID", func() {
+						found, err := s.Find(stdctx.Background(), id)
+
+						Convey("It shouldn't fail", func() {
+							So(err, ShouldBeNil)
+						})
+
+						Convey("The Message-ID of the custom RFC body should be preserved", func() {
+							So(found.MessageID(), ShouldEqual, "custom@example.com")
+						})
+					})
+				})
 			})
 		})
 
@@ -409,6 +493,21 @@ func Store(t *testing.T, newStore func() archive.Store, opts ...StoreTestOption)
 					})
 				})
 
+				Convey("When I query for attachments by an inverted file size range", func() {
+					cur, err := s.Query(stdctx.Background(), query.New(
+						query.AttachmentSizeRange(10, 3),
+					))
+
+					Convey("It shouldn't fail", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("Cursor should return the mails", func() {
+						mails := drain(cur)
+						So(mails, shouldResembleMails, mockMails[:len(mockMails)-1])
+					})
+				})
+
 				Convey("When I query for attachments by content type", func() {
 					cur, err := s.Query(stdctx.Background(), query.New(
 						query.AttachmentContentType("text/html"),
@@ -452,6 +551,102 @@ func Store(t *testing.T, newStore func() archive.Store, opts ...StoreTestOption)
 					})
 				})
 
+				Convey("When I query without attachment content", func() {
+					cur, err := s.Query(stdctx.Background(), query.New(
+						query.AttachmentContent([]byte{1, 2, 3}),
+						query.WithoutAttachmentContent(),
+					))
+
+					Convey("It shouldn't fail", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("Cursor should return the mail without attachment contents", func() {
+						mails := drain(cur)
+						So(mails, ShouldHaveLength, 1)
+						So(mails[0].ID(), ShouldEqual, mockMails[0].ID())
+						So(letter.Equal(mails[0].Letter, mockMails[0].Letter, letter.IgnoreRFC(), letter.IgnoreAttachmentContent(), letter.IgnoreGeneratedHeaders()), ShouldBeTrue)
+
+						ats := mails[0].Attachments()
+						So(ats, ShouldHaveLength, len(mockMails[0].Attachments()))
+						for i, at := range ats {
+							So(at.Content(), ShouldBeEmpty)
+							So(at.Size(), ShouldEqual, mockMails[0].Attachments()[i].Size())
+						}
+					})
+
+					Convey("The stored mail should still have its attachment contents", func() {
+						m, err := s.Find(stdctx.Background(), mockMails[0].ID())
+						So(err, ShouldBeNil)
+						So(m, shouldResembleMail, mockMails[0])
+					})
+				})
+
+				Convey("When I query with selected fields", func() {
+					cur, err := s.Query(stdctx.Background(), query.New(
+						query.Subject("Subject 2"),
+						query.Fields(query.FieldSubject, query.FieldFrom, query.FieldSentAt),
+					))
+
+					Convey("It shouldn't fail", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("Cursor should return the selected fields of the mail", func() {
+						mails := drain(cur)
+						So(mails, ShouldHaveLength, 1)
+						So(mails[0].ID(), ShouldEqual, mockMails[1].ID())
+						So(mails[0].Subject(), ShouldEqual, mockMails[1].Subject())
+						So(mails[0].From(), ShouldResemble, mockMails[1].From())
+						So(mails[0].SentAt().Equal(mockMails[1].SentAt()), ShouldBeTrue)
+					})
+
+					Convey("Fields that are not selected should be zero values", func() {
+						mails := drain(cur)
+						So(mails, ShouldHaveLength, 1)
+						So(mails[0].To(), ShouldBeEmpty)
+						So(mails[0].Recipients(), ShouldBeEmpty)
+						So(mails[0].Text(), ShouldBeEmpty)
+						So(mails[0].HTML(), ShouldBeEmpty)
+						So(mails[0].Attachments(), ShouldBeEmpty)
+					})
+				})
+
+				Convey("When I query the content hash of a mail", func() {
+					cur, err := s.Query(stdctx.Background(), query.New(
+						query.ContentHash(mockMails[1].ContentHash()),
+					))
+
+					Convey("It shouldn't fail", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("Cursor should return the correct mail", func() {
+						mails := drain(cur)
+						So(mails, ShouldHaveLength, 1)
+						So(mails[0], shouldResembleMail, mockMails[1])
+					})
+				})
+
+				Convey("When I query the Message-IDs of mails", func() {
+					cur, err := s.Query(stdctx.Background(), query.New(
+						query.MessageID("<mail2@example.com>", "mail3@example.com"),
+						query.Sort(query.SortSendTime, query.SortAsc),
+					))
+
+					Convey("It shouldn't fail", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("Cursor should return the correct mails", func() {
+						mails := drain(cur)
+						So(mails, ShouldHaveLength, 2)
+						So(mails[0], shouldResembleMail, mockMails[1])
+						So(mails[1], shouldResembleMail, mockMails[2])
+						So(mails[0].MessageID(), ShouldEqual, "mail2@example.com")
+					})
+				})
+
 				testSorting(s, mockMails)
 			}))
 
@@ -495,6 +690,161 @@ func Store(t *testing.T, newStore func() archive.Store, opts ...StoreTestOption)
 			// 	})
 			// }))
 
+			Convey("Given a Store with 3 mails with different send times", withFilledStore(newStore, 3, cfg.roundTime, func(s archive.Store, mockMails []archive.Mail) {
+				Convey("When I query mails sent at a specific time", func() {
+					cur, err := s.Query(stdctx.Background(), query.New(
+						query.SentAt(mockMails[1].SentAt()),
+					))
+
+					Convey("It shouldn't fail", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("Cursor should return the correct mail", func() {
+						mails := drain(cur)
+						So(mails, ShouldHaveLength, 1)
+						So(mails[0], shouldResembleMail, mockMails[1])
+					})
+				})
+
+				Convey("When I query mails sent before a specific time", func() {
+					cur, err := s.Query(stdctx.Background(), query.New(
+						query.SentBefore(mockMails[1].SentAt()),
+					))
+
+					Convey("It shouldn't fail", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("Cursor should return the correct mail", func() {
+						mails := drain(cur)
+						So(mails, ShouldHaveLength, 1)
+						So(mails[0], shouldResembleMail, mockMails[0])
+					})
+				})
+
+				Convey("When I query mails sent after a specific time", func() {
+					cur, err := s.Query(stdctx.Background(), query.New(
+						query.SentAfter(mockMails[1].SentAt()),
+					))
+
+					Convey("It shouldn't fail", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("Cursor should return the correct mail", func() {
+						mails := drain(cur)
+						So(mails, ShouldHaveLength, 1)
+						So(mails[0], shouldResembleMail, mockMails[2])
+					})
+				})
+
+				Convey("When I query mails sent between two times", func() {
+					cur, err := s.Query(stdctx.Background(), query.New(
+						query.SentBetween(mockMails[1].SentAt(), mockMails[2].SentAt()),
+						query.Sort(query.SortSendTime, query.SortAsc),
+					))
+
+					Convey("It shouldn't fail", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("Cursor should return the correct mails", func() {
+						So(drain(cur), shouldResembleMails, mockMails[1:])
+					})
+				})
+			}))
+
+			Convey("Given a Store with mails with and without attachments", withFilledStore(newStore, 2, cfg.roundTime, func(s archive.Store, mockMails []archive.Mail) {
+				withoutAttachments := archive.ExpandMail(letter.Write(
+					letter.From("Bob Belcher", "bob@example.com"),
+					letter.To("Linda Belcher", "linda@example.com"),
+					letter.Subject("No attachments"),
+				).WithRFCOptions(rfc.WithMessageID("<no-attachments@example.com>"))).WithID(uuid.New())
+				So(s.Insert(stdctx.Background(), withoutAttachments), ShouldBeNil)
+
+				Convey("When I query mails with attachments", func() {
+					cur, err := s.Query(stdctx.Background(), query.New(
+						query.HasAttachments(true),
+						query.Sort(query.SortSendTime, query.SortAsc),
+					))
+
+					Convey("It shouldn't fail", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("Cursor should return the mails with attachments", func() {
+						So(drain(cur), shouldResembleMails, mockMails)
+					})
+				})
+
+				Convey("When I query mails without attachments", func() {
+					cur, err := s.Query(stdctx.Background(), query.New(
+						query.HasAttachments(false),
+					))
+
+					Convey("It shouldn't fail", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("Cursor should return the mail without attachments", func() {
+						mails := drain(cur)
+						So(mails, ShouldHaveLength, 1)
+						So(mails[0], shouldResembleMail, withoutAttachments)
+					})
+				})
+			}))
+
+			Convey("Given a Store with mails with different numbers of attachments", func() {
+				s := newStore()
+				mails := make([]archive.Mail, 4)
+				for i := range mails {
+					opts := []letter.Option{
+						letter.From("Bob Belcher", "bob@example.com"),
+						letter.To("Linda Belcher", "linda@example.com"),
+						letter.Subject(fmt.Sprintf("%d attachments", i)),
+					}
+					for j := 0; j < i; j++ {
+						opts = append(opts, letter.Attach(fmt.Sprintf("Attachment %d", j+1), []byte{byte(j + 1)}))
+					}
+					mails[i] = archive.ExpandMail(
+						letter.Write(opts...).WithRFCOptions(rfc.WithMessageID(fmt.Sprintf("<attachments%d@example.com>", i))),
+					).WithID(uuid.New())
+					So(s.Insert(stdctx.Background(), mails[i]), ShouldBeNil)
+				}
+
+				Convey("When I query mails with 2-3 attachments", func() {
+					cur, err := s.Query(stdctx.Background(), query.New(
+						query.AttachmentCount(2, 3),
+						query.Sort(query.SortSubject, query.SortAsc),
+					))
+
+					Convey("It shouldn't fail", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("Cursor should return the correct mails", func() {
+						So(drain(cur), shouldResembleMails, mails[2:])
+					})
+				})
+
+				Convey("When I query mails with 0 or 3 attachments", func() {
+					cur, err := s.Query(stdctx.Background(), query.New(
+						query.AttachmentCount(0, 0),
+						query.AttachmentCount(3, 3),
+						query.Sort(query.SortSubject, query.SortAsc),
+					))
+
+					Convey("It shouldn't fail", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("Cursor should return the correct mails", func() {
+						So(drain(cur), shouldResembleMails, []archive.Mail{mails[0], mails[3]})
+					})
+				})
+			})
+
 			Convey("Given a Store with 30 mails", withFilledStore(newStore, 30, cfg.roundTime, func(s archive.Store, mockMails []archive.Mail) {
 				Convey("When I query with pagination", func() {
 					cur, err := s.Query(context.Background(), query.New(
@@ -651,7 +1001,7 @@ func makeMails(count int, roundTime time.Duration) []archive.Mail {
 		for i := range content {
 			content[i] = byte(i + 1)
 		}
-		mails[i] = archive.ExpandMail(
+		m := archive.ExpandMail(
 			letter.Write(
 				letter.From(fmt.Sprintf("Sender %d", i+1), fmt.Sprintf("sender%d@example.com", i+1)),
 				letter.To(fmt.Sprintf("Recipient %d", i+1), fmt.Sprintf("rcpt%d@example.com", i+1)),
@@ -660,8 +1010,9 @@ func makeMails(count int, roundTime time.Duration) []archive.Mail {
 				letter.Subject(fmt.Sprintf("Subject %d", i+1)),
 				letter.Content(fmt.Sprintf("Content %d", i+1), fmt.Sprintf("<p>Content %d</p>", i+1)),
 				letter.Attach(fmt.Sprintf("Attachment %d", i+1), content, letter.AttachmentType(contentType)),
-			).WithRFCOptions(rfc.WithClock(clock), rfc.WithMessageID("foobar")),
+			).WithRFCOptions(rfc.WithClock(clock), rfc.WithMessageID(fmt.Sprintf("<mail%d@example.com>", i+1))),
 		).WithID(uuid.New()).WithSendTime(time.Now().UTC().Add(time.Duration(i) * time.Minute).Round(roundTime))
+		mails[i] = m.WithContentHash(archive.HashContent(m.RFC()))
 	}
 	return mails
 }
@@ -716,17 +1067,7 @@ func shouldResembleMail(actual interface{}, expected ...interface{}) string {
 		return fmt.Sprintf("expected should be an archive.Mail, but is %T", am)
 	}
 
-	if am.ID() != em.ID() {
-		return fmt.Sprintf("mail ids not equal")
-	}
-
-	amm := am.Map()
-	delete(amm, "rfc")
-
-	emm := em.Map()
-	delete(emm, "rfc")
-
-	return ShouldResemble(amm, emm)
+	return diffMail(am, em)
 }
 
 func shouldResembleMails(actual interface{}, expected ...interface{}) string {
@@ -744,19 +1085,44 @@ func shouldResembleMails(actual interface{}, expected ...interface{}) string {
 		return fmt.Sprintf("expected should be an []archive.Mail, but is %T", ams)
 	}
 
-	amms := make([]map[string]interface{}, len(ams))
-	for i, am := range ams {
-		amm := am.Map()
-		delete(amm, "rfc")
-		amms[i] = amm
+	if len(ams) != len(ems) {
+		return fmt.Sprintf("expected %d mails, but got %d", len(ems), len(ams))
+	}
+
+	for i := range ams {
+		if diff := diffMail(ams[i], ems[i]); diff != "" {
+			return fmt.Sprintf("mail %d: %s", i, diff)
+		}
+	}
+
+	return ""
+}
+
+func diffMail(am, em archive.Mail) string {
+	if am.ID() != em.ID() {
+		return fmt.Sprintf("mail ids not equal")
+	}
+
+	if am.SendError() != em.SendError() {
+		return fmt.Sprintf("send errors not equal: %q != %q", am.SendError(), em.SendError())
+	}
+
+	if am.ContentHash() != em.ContentHash() {
+		return fmt.Sprintf("content hashes not equal: %q != %q", am.ContentHash(), em.ContentHash())
+	}
+
+	if !bytes.Equal(am.TimestampToken(), em.TimestampToken()) {
+		return fmt.Sprintf("timestamp tokens not equal")
+	}
+
+	// A zero-value Mail generates a random Message-ID on every RFC() call.
+	if em.ID() != uuid.Nil && am.MessageID() != em.MessageID() {
+		return fmt.Sprintf("message ids not equal: %q != %q", am.MessageID(), em.MessageID())
 	}
 
-	emms := make([]map[string]interface{}, len(ems))
-	for i, em := range ems {
-		emm := em.Map()
-		delete(emm, "rfc")
-		emms[i] = emm
+	if !am.SentAt().Truncate(time.Second).Equal(em.SentAt().Truncate(time.Second)) {
+		return fmt.Sprintf("send times not equal: %s != %s", am.SentAt(), em.SentAt())
 	}
 
-	return ShouldResemble(amms, emms)
+	return letter.Diff(am.Letter, em.Letter, letter.IgnoreRFC())
 }
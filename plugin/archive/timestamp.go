@@ -0,0 +1,266 @@
+package archive
+
+import (
+	"bytes"
+	stdctx "context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mozilla.org/pkcs7"
+)
+
+var (
+	// ErrNoTimestamp means a Mail has no timestamp token.
+	ErrNoTimestamp = errors.New("no timestamp token")
+	// ErrInvalidTimestamp means the timestamp token of a Mail is invalid or
+	// doesn't match the RFC body of the Mail.
+	ErrInvalidTimestamp = errors.New("invalid timestamp token")
+)
+
+var (
+	oidSHA256             = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	maxTimestampRespBytes = int64(1 << 20)
+)
+
+// TimestampOption is an option for WithTimestampAuthority().
+type TimestampOption func(*timestampAuthority)
+
+type timestampAuthority struct {
+	url    string
+	client *http.Client
+}
+
+// RFC 3161, section 2.4.1
+type timeStampReq struct {
+	Version        int
+	MessageImprint messageImprint
+	Nonce          *big.Int `asn1:"optional"`
+	CertReq        bool     `asn1:"optional,default:false"`
+}
+
+type messageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+// RFC 3161, section 2.4.2
+type timeStampResp struct {
+	Status         pkiStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+type pkiStatusInfo struct {
+	Status       int
+	StatusString []string       `asn1:"optional"`
+	FailInfo     asn1.BitString `asn1:"optional"`
+}
+
+type tstInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint messageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time `asn1:"generalized"`
+	Accuracy       accuracy  `asn1:"optional"`
+	Ordering       bool      `asn1:"optional,default:false"`
+	Nonce          *big.Int  `asn1:"optional"`
+	// the optional tsa name and extensions are not needed
+}
+
+type accuracy struct {
+	Seconds int `asn1:"optional"`
+	Millis  int `asn1:"optional,tag:0"`
+	Micros  int `asn1:"optional,tag:1"`
+}
+
+// WithTimestampAuthority returns an Option that obtains an RFC 3161 timestamp
+// token for every archived mail from the Time Stamping Authority (TSA) at
+// tsaURL, e.g. "https://freetsa.org/tsr". The token is a signature of the TSA
+// over the SHA-256 hash of the RFC body of the mail and the time at which the
+// TSA received the hash, and can be used as tamper-evident proof that the
+// archived mail existed at that time (see (Mail).TimestampToken() and
+// VerifyTimestamp()). The option implies WithContentHash(), so the stored
+// content hash is the hash that has been timestamped.
+//
+// The timestamp is requested before the mail is inserted into the Store and
+// within the insert timeout (see InsertTimeout()). If the request fails, the
+// error is logged and the mail is inserted without a timestamp token.
+func WithTimestampAuthority(tsaURL string, opts ...TimestampOption) Option {
+	tsa := timestampAuthority{url: tsaURL, client: http.DefaultClient}
+	for _, opt := range opts {
+		opt(&tsa)
+	}
+	return func(cfg *config) {
+		cfg.tsa = &tsa
+		cfg.contentHash = true
+	}
+}
+
+// TimestampClient returns a TimestampOption that sets the HTTP client that is
+// used to request timestamps. Defaults to http.DefaultClient.
+func TimestampClient(c *http.Client) TimestampOption {
+	return func(tsa *timestampAuthority) {
+		tsa.client = c
+	}
+}
+
+// VerifyTimestamp verifies the timestamp token of m (see
+// WithTimestampAuthority()) and returns the time at which the token has been
+// issued. It returns an error that unwraps to ErrNoTimestamp if m has no
+// token, or to ErrInvalidTimestamp if the signature of the token is invalid,
+// if the token hasn't been issued by a certificate for time stamping or if the
+// timestamped hash doesn't match the RFC body of m, i.e. the mail has been
+// modified after it was archived.
+//
+// If roots is not nil, the certificate chain of the TSA must lead to one of
+// the roots, which is verified at the time of the timestamp, so that tokens
+// remain verifiable after the TSA certificate expired. If roots is nil, only
+// the signature is verified, which doesn't prove that the token has been
+// issued by a trusted TSA.
+func VerifyTimestamp(m Mail, roots *x509.CertPool) (time.Time, error) {
+	if len(m.timestampToken) == 0 {
+		return time.Time{}, ErrNoTimestamp
+	}
+
+	p7, err := pkcs7.Parse(m.timestampToken)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: parse token: %v", ErrInvalidTimestamp, err)
+	}
+
+	var info tstInfo
+	if _, err := asn1.Unmarshal(p7.Content, &info); err != nil {
+		return time.Time{}, fmt.Errorf("%w: parse TSTInfo: %v", ErrInvalidTimestamp, err)
+	}
+
+	if roots == nil {
+		err = p7.Verify()
+	} else {
+		err = p7.VerifyWithChainAtTime(roots, info.GenTime)
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %v", ErrInvalidTimestamp, err)
+	}
+
+	if signer := p7.GetOnlySigner(); signer == nil || !hasExtKeyUsage(signer, x509.ExtKeyUsageTimeStamping) {
+		return time.Time{}, fmt.Errorf("%w: signer is not a time stamping authority", ErrInvalidTimestamp)
+	}
+
+	sum := sha256.Sum256([]byte(m.RFC()))
+	if !info.MessageImprint.HashAlgorithm.Algorithm.Equal(oidSHA256) ||
+		!bytes.Equal(info.MessageImprint.HashedMessage, sum[:]) {
+		return time.Time{}, fmt.Errorf("%w: hash doesn't match the RFC body", ErrInvalidTimestamp)
+	}
+
+	return info.GenTime, nil
+}
+
+// timestamp requests a timestamp token for the SHA-256 digest from the TSA.
+func (tsa *timestampAuthority) timestamp(ctx stdctx.Context, digest []byte) ([]byte, error) {
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+
+	imprint := messageImprint{
+		HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue},
+		HashedMessage: digest,
+	}
+
+	body, err := asn1.Marshal(timeStampReq{
+		Version:        1,
+		MessageImprint: imprint,
+		Nonce:          nonce,
+		// the token must contain the TSA certificate to be verifiable
+		CertReq: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tsa.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/timestamp-query")
+
+	resp, err := tsa.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tsa responded with %s", resp.Status)
+	}
+
+	raw, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxTimestampRespBytes))
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	return parseTimestampResponse(raw, imprint, nonce)
+}
+
+// parseTimestampResponse returns the timestamp token of the TimeStampResp raw
+// and checks that it timestamps imprint and contains nonce.
+func parseTimestampResponse(raw []byte, imprint messageImprint, nonce *big.Int) ([]byte, error) {
+	var resp timeStampResp
+	if _, err := asn1.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("parse response: %w", err)
+	}
+
+	// 0 = granted, 1 = grantedWithMods
+	if resp.Status.Status > 1 {
+		msg := fmt.Sprintf("tsa rejected the request with status %d", resp.Status.Status)
+		if len(resp.Status.StatusString) > 0 {
+			msg += ": " + strings.Join(resp.Status.StatusString, "; ")
+		}
+		return nil, errors.New(msg)
+	}
+
+	token := resp.TimeStampToken.FullBytes
+	if len(token) == 0 {
+		return nil, errors.New("response has no timestamp token")
+	}
+
+	p7, err := pkcs7.Parse(token)
+	if err != nil {
+		return nil, fmt.Errorf("parse token: %w", err)
+	}
+
+	var info tstInfo
+	if _, err := asn1.Unmarshal(p7.Content, &info); err != nil {
+		return nil, fmt.Errorf("parse TSTInfo: %w", err)
+	}
+
+	if !info.MessageImprint.HashAlgorithm.Algorithm.Equal(imprint.HashAlgorithm.Algorithm) ||
+		!bytes.Equal(info.MessageImprint.HashedMessage, imprint.HashedMessage) {
+		return nil, errors.New("token timestamps another hash")
+	}
+
+	if info.Nonce == nil || info.Nonce.Cmp(nonce) != 0 {
+		return nil, errors.New("token has a different nonce")
+	}
+
+	return token, nil
+}
+
+func hasExtKeyUsage(cert *x509.Certificate, usage x509.ExtKeyUsage) bool {
+	for _, u := range cert.ExtKeyUsage {
+		if u == usage {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package archive
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bounoable/postdog/letter"
+	"github.com/stretchr/testify/assert"
+	"go.mozilla.org/pkcs7"
+)
+
+func TestWithTimestampAuthority(t *testing.T) {
+	genTime := time.Now().UTC().Truncate(time.Second)
+	srv, roots := newFakeTSA(t, genTime)
+	defer srv.Close()
+
+	var cfg config
+	WithTimestampAuthority(srv.URL, TimestampClient(srv.Client()))(&cfg)
+	assert.True(t, cfg.contentHash)
+
+	m := ExpandMail(letter.Write(
+		letter.From("Bob Belcher", "bob@example.com"),
+		letter.To("Linda Belcher", "linda@example.com"),
+		letter.Text("Hello."),
+		letter.RFC("Subject: Hi.\r\n\r\nHello."),
+	))
+
+	sum := sha256.Sum256([]byte(m.RFC()))
+	token, err := cfg.tsa.timestamp(context.Background(), sum[:])
+	assert.Nil(t, err)
+	m = m.WithTimestampToken(token)
+
+	ts, err := VerifyTimestamp(m, roots)
+	assert.Nil(t, err)
+	assert.True(t, genTime.Equal(ts))
+
+	ts, err = VerifyTimestamp(m, nil)
+	assert.Nil(t, err)
+	assert.True(t, genTime.Equal(ts))
+
+	var parsed Mail
+	parsed.Parse(m.Map())
+	assert.Equal(t, token, parsed.TimestampToken())
+
+	modified := ExpandMail(letter.Write(letter.RFC("Subject: Hi.\r\n\r\nBye."))).WithTimestampToken(token)
+	_, err = VerifyTimestamp(modified, roots)
+	assert.True(t, errors.Is(err, ErrInvalidTimestamp))
+
+	_, err = VerifyTimestamp(m, x509.NewCertPool())
+	assert.True(t, errors.Is(err, ErrInvalidTimestamp))
+
+	_, err = VerifyTimestamp(m.WithTimestampToken(nil), roots)
+	assert.True(t, errors.Is(err, ErrNoTimestamp))
+}
+
+func TestWithTimestampAuthority_rejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := asn1.Marshal(timeStampResp{Status: pkiStatusInfo{Status: 2, StatusString: []string{"bad request"}}})
+		w.Write(b)
+	}))
+	defer srv.Close()
+
+	var cfg config
+	WithTimestampAuthority(srv.URL)(&cfg)
+
+	sum := sha256.Sum256([]byte("Subject: Hi.\r\n\r\nHello."))
+	token, err := cfg.tsa.timestamp(context.Background(), sum[:])
+	assert.Nil(t, token)
+	assert.EqualError(t, err, "tsa rejected the request with status 2: bad request")
+}
+
+// newFakeTSA returns a server that issues timestamp tokens for genTime, and a
+// pool that contains the certificate of the server.
+func newFakeTSA(t *testing.T, genTime time.Time) (*httptest.Server, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Fake TSA"},
+		NotBefore:             genTime.Add(-time.Hour),
+		NotAfter:              genTime.Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var req timeStampReq
+		if _, err := asn1.Unmarshal(body, &req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		info, err := asn1.Marshal(tstInfo{
+			Version:        1,
+			Policy:         asn1.ObjectIdentifier{1, 2, 3},
+			MessageImprint: req.MessageImprint,
+			SerialNumber:   big.NewInt(1),
+			GenTime:        genTime,
+			Nonce:          req.Nonce,
+		})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		sd, err := pkcs7.NewSignedData(info)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		sd.SetDigestAlgorithm(pkcs7.OIDDigestAlgorithmSHA256)
+		if err := sd.AddSigner(cert, key, pkcs7.SignerInfoConfig{}); err != nil {
+			t.Error(err)
+			return
+		}
+		token, err := sd.Finish()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		resp, err := asn1.Marshal(timeStampResp{TimeStampToken: asn1.RawValue{FullBytes: token}})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/timestamp-reply")
+		w.Write(resp)
+	}))
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+
+	return srv, roots
+}
@@ -0,0 +1,51 @@
+// Package devredirect provides a plugin that redirects all mails to a single
+// address in non-production environments.
+package devredirect
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter"
+	"github.com/bounoable/postdog/middleware"
+)
+
+// New returns a Plugin that redirects all mails to the address to and
+// annotates their subject with the original recipients. If enabled is false,
+// the returned Plugin does nothing, so mails pass through unchanged.
+//
+// Example:
+//
+//	dog := postdog.New(
+//		devredirect.New(
+//			mail.Address{Address: "dev@example.com"},
+//			os.Getenv("APP_ENV") != "production",
+//		),
+//	)
+func New(to mail.Address, enabled bool) postdog.Plugin {
+	if !enabled {
+		return postdog.Plugin{}
+	}
+
+	return postdog.Plugin{
+		postdog.WithMiddleware(
+			postdog.MiddlewareFunc(annotateSubject),
+			middleware.OverrideRecipients(to),
+		),
+	}
+}
+
+func annotateSubject(ctx context.Context, m postdog.Mail, next postdog.NextMiddleware) (postdog.Mail, error) {
+	l, _ := letter.AsLetter(m)
+
+	rcpts := l.Recipients()
+	addrs := make([]string, len(rcpts))
+	for i, rcpt := range rcpts {
+		addrs[i] = rcpt.Address
+	}
+
+	return next(ctx, l.WithSubject(fmt.Sprintf("[redirected from %s] %s", strings.Join(addrs, ", "), l.Subject())))
+}
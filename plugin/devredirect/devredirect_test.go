@@ -0,0 +1,69 @@
+package devredirect_test
+
+import (
+	"context"
+	"net/mail"
+	"testing"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter"
+	mock_postdog "github.com/bounoable/postdog/mocks"
+	"github.com/bounoable/postdog/plugin/devredirect"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	to := mail.Address{Name: "Dev", Address: "dev@example.com"}
+	give := letter.Write(
+		letter.From("Bob Belcher", "bob@example.com"),
+		letter.To("Linda Belcher", "linda@example.com"),
+		letter.CC("Tina Belcher", "tina@example.com"),
+		letter.Subject("Hi."),
+	)
+
+	tests := []struct {
+		name    string
+		enabled bool
+		want    letter.Letter
+	}{
+		{
+			name:    "enabled",
+			enabled: true,
+			want: letter.Write(
+				letter.From("Bob Belcher", "bob@example.com"),
+				letter.ToAddress(to),
+				letter.Subject("[redirected from linda@example.com, tina@example.com] Hi."),
+			),
+		},
+		{
+			name:    "disabled",
+			enabled: false,
+			want:    give,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			var sent postdog.Mail
+			tr := mock_postdog.NewMockTransport(ctrl)
+			tr.EXPECT().
+				Send(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, m postdog.Mail) error {
+					sent = m
+					return nil
+				})
+
+			dog := postdog.New(
+				postdog.WithTransport("test", tr),
+				devredirect.New(to, test.enabled),
+			)
+
+			assert.Nil(t, dog.Send(context.Background(), give))
+			assert.Equal(t, test.want, letter.Expand(sent))
+		})
+	}
+}
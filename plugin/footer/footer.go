@@ -0,0 +1,133 @@
+// Package footer provides a plugin that appends a footer to every mail.
+package footer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/internal/fold"
+	"github.com/bounoable/postdog/letter"
+)
+
+// Option is a footer option.
+type Option func(*config)
+
+// Data is passed to the footer templates.
+type Data struct {
+	// Now is the time at which the footer is rendered.
+	Now time.Time
+	// Year is the year of Now.
+	Year int
+}
+
+type config struct {
+	now func() time.Time
+}
+
+type footer struct {
+	cfg  config
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// New returns a Plugin that appends textFooter to the text body and htmlFooter
+// to the HTML body of every mail. If a mail has no text body, the text footer
+// is not added, and the same goes for the HTML body. Empty footers are ignored.
+//
+// The footers are parsed as templates (text/template and html/template) and
+// executed with a Data value, so that they can include e.g. the current year:
+//
+//	footer.New("(c) {{ .Year }} Example Inc.", "<p>&copy; {{ .Year }} Example Inc.</p>")
+//
+// The HTML footer is inserted before the closing </body> tag if the HTML body
+// has one. A footer is not added if the body already contains it, so mails
+// that pass through the middleware twice don't get the footer twice.
+//
+// New panics if a footer is not a valid template. Use TryNew to get an error.
+func New(textFooter, htmlFooter string, opts ...Option) postdog.Plugin {
+	p, err := TryNew(textFooter, htmlFooter, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// TryNew does the same as New, but returns an error instead of panicking if a
+// footer is not a valid template.
+func TryNew(textFooter, htmlFooter string, opts ...Option) (postdog.Plugin, error) {
+	f := footer{cfg: config{now: time.Now}}
+	for _, opt := range opts {
+		opt(&f.cfg)
+	}
+
+	var err error
+	if textFooter != "" {
+		if f.text, err = texttemplate.New("text").Parse(textFooter); err != nil {
+			return nil, fmt.Errorf("parse text footer: %w", err)
+		}
+	}
+	if htmlFooter != "" {
+		if f.html, err = htmltemplate.New("html").Parse(htmlFooter); err != nil {
+			return nil, fmt.Errorf("parse html footer: %w", err)
+		}
+	}
+
+	return postdog.Plugin{
+		postdog.WithMiddleware(postdog.MiddlewareFunc(f.handle)),
+	}, nil
+}
+
+// Clock returns an Option that specifies the function that provides the time
+// that is passed to the footer templates. Defaults to time.Now.
+func Clock(now func() time.Time) Option {
+	return func(cfg *config) {
+		cfg.now = now
+	}
+}
+
+func (f footer) handle(ctx context.Context, m postdog.Mail, next postdog.NextMiddleware) (postdog.Mail, error) {
+	l, _ := letter.AsLetter(m)
+	now := f.cfg.now()
+	data := Data{Now: now, Year: now.Year()}
+
+	if text := l.Text(); text != "" && f.text != nil {
+		var buf bytes.Buffer
+		if err := f.text.Execute(&buf, data); err != nil {
+			return m, fmt.Errorf("execute text footer: %w", err)
+		}
+		l = l.WithText(appendText(text, buf.String()))
+	}
+
+	if html := l.HTML(); html != "" && f.html != nil {
+		var buf bytes.Buffer
+		if err := f.html.Execute(&buf, data); err != nil {
+			return m, fmt.Errorf("execute html footer: %w", err)
+		}
+		l = l.WithHTML(appendHTML(html, buf.String()))
+	}
+
+	return next(ctx, l)
+}
+
+func appendText(body, footer string) string {
+	if strings.Contains(body, footer) {
+		return body
+	}
+	return strings.TrimRight(body, "\r\n") + "\n\n" + footer
+}
+
+func appendHTML(body, footer string) string {
+	if strings.Contains(body, footer) {
+		return body
+	}
+	if i := fold.LastIndex(body, "</body>"); i >= 0 {
+		return body[:i] + footer + body[i:]
+	}
+	return body + footer
+}
@@ -0,0 +1,110 @@
+package footer_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter"
+	mock_postdog "github.com/bounoable/postdog/mocks"
+	"github.com/bounoable/postdog/plugin/footer"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	clock := footer.Clock(func() time.Time {
+		return time.Date(2020, time.March, 1, 0, 0, 0, 0, time.UTC)
+	})
+
+	tests := []struct {
+		name       string
+		textFooter string
+		htmlFooter string
+		give       letter.Letter
+		passes     int
+		wantText   string
+		wantHTML   string
+	}{
+		{
+			name:       "text and html",
+			textFooter: "Example Inc.",
+			htmlFooter: "<p>Example Inc.</p>",
+			give:       letter.Write(letter.Content("Hello.", "<p>Hello.</p>")),
+			wantText:   "Hello.\n\nExample Inc.",
+			wantHTML:   "<p>Hello.</p><p>Example Inc.</p>",
+		},
+		{
+			name:       "text only",
+			textFooter: "Example Inc.",
+			htmlFooter: "<p>Example Inc.</p>",
+			give:       letter.Write(letter.Text("Hello.\n")),
+			wantText:   "Hello.\n\nExample Inc.",
+		},
+		{
+			name:       "html only",
+			textFooter: "Example Inc.",
+			htmlFooter: "<p>Example Inc.</p>",
+			give:       letter.Write(letter.HTML("<html><body><p>Hello.</p></body></html>")),
+			wantHTML:   "<html><body><p>Hello.</p><p>Example Inc.</p></body></html>",
+		},
+		{
+			name:       "html with non-ascii characters",
+			textFooter: "Example Inc.",
+			htmlFooter: "<p>Example Inc.</p>",
+			give:       letter.Write(letter.HTML("<HTML><BODY><p>ȺȺȺȺ ȺȺȺȺ</p></BODY></HTML>")),
+			wantHTML:   "<HTML><BODY><p>ȺȺȺȺ ȺȺȺȺ</p><p>Example Inc.</p></BODY></HTML>",
+		},
+		{
+			name:       "template",
+			textFooter: "(c) {{ .Year }} Example Inc.",
+			htmlFooter: "<p>&copy; {{ .Year }} Example Inc.</p>",
+			give:       letter.Write(letter.Content("Hello.", "<p>Hello.</p>")),
+			wantText:   "Hello.\n\n(c) 2020 Example Inc.",
+			wantHTML:   "<p>Hello.</p><p>&copy; 2020 Example Inc.</p>",
+		},
+		{
+			name:       "passes twice",
+			textFooter: "Example Inc.",
+			htmlFooter: "<p>Example Inc.</p>",
+			give:       letter.Write(letter.Content("Hello.", "<p>Hello.</p>")),
+			passes:     2,
+			wantText:   "Hello.\n\nExample Inc.",
+			wantHTML:   "<p>Hello.</p><p>Example Inc.</p>",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			var sent postdog.Mail
+			tr := mock_postdog.NewMockTransport(ctrl)
+			tr.EXPECT().
+				Send(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, m postdog.Mail) error {
+					sent = m
+					return nil
+				})
+
+			opts := []postdog.Option{postdog.WithTransport("test", tr)}
+			for i := 0; i < test.passes || i < 1; i++ {
+				opts = append(opts, footer.New(test.textFooter, test.htmlFooter, clock))
+			}
+			dog := postdog.New(opts...)
+
+			assert.Nil(t, dog.Send(context.Background(), test.give))
+
+			l := letter.Expand(sent)
+			assert.Equal(t, test.wantText, l.Text())
+			assert.Equal(t, test.wantHTML, l.HTML())
+		})
+	}
+}
+
+func TestTryNew(t *testing.T) {
+	_, err := footer.TryNew("{{ .Year", "")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,175 @@
+// Package mjml provides a plugin that compiles MJML (https://mjml.io) to HTML.
+package mjml
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter"
+)
+
+// DefaultCacheSize is the default number of compiled templates that are
+// cached by the plugin.
+const DefaultCacheSize = 100
+
+// A Compiler compiles MJML to HTML.
+type Compiler interface {
+	Compile(ctx context.Context, mjml string) (string, error)
+}
+
+// CompilerFunc allows a function to be used as a Compiler.
+type CompilerFunc func(context.Context, string) (string, error)
+
+// Option is an MJML option.
+type Option func(*plugin)
+
+type plugin struct {
+	compiler  Compiler
+	cacheSize int
+
+	mux   sync.Mutex
+	cache map[[sha256.Size]byte]*list.Element
+	lru   *list.List
+}
+
+type cacheEntry struct {
+	key  [sha256.Size]byte
+	html string
+}
+
+// New returns a Plugin that compiles the HTML body of every mail that is
+// written in MJML (i.e. that starts with an <mjml> tag) with c and replaces it
+// with the compiled HTML. Mails with a regular HTML body are not modified.
+//
+// Compiled HTML is cached by the hash of the MJML source, so a template that
+// is rendered with the same data is only compiled once. See CacheSize().
+//
+// Add the plugin before plugins that modify the HTML body, e.g. the footer
+// plugin, so that they receive the compiled HTML.
+func New(c Compiler, opts ...Option) postdog.Plugin {
+	p := &plugin{
+		compiler:  c,
+		cacheSize: DefaultCacheSize,
+		cache:     make(map[[sha256.Size]byte]*list.Element),
+		lru:       list.New(),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return postdog.Plugin{
+		postdog.WithMiddleware(postdog.MiddlewareFunc(p.handle)),
+	}
+}
+
+// CacheSize returns an Option that sets the maximum number of compiled
+// templates that are cached. When the cache is full, the least recently used
+// template is removed. A size of 0 disables the cache. Defaults to
+// DefaultCacheSize.
+func CacheSize(n int) Option {
+	return func(p *plugin) {
+		p.cacheSize = n
+	}
+}
+
+// Binary returns a Compiler that compiles MJML with the MJML command-line
+// tool at path (e.g. "mjml" or "./node_modules/.bin/mjml"). The MJML source is
+// passed through stdin and the HTML is read from stdout. Additional args are
+// passed to the tool, e.g. "--config.minify", "true".
+func Binary(path string, args ...string) Compiler {
+	return CompilerFunc(func(ctx context.Context, mjml string) (string, error) {
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, path, append([]string{"-i", "-s"}, args...)...)
+		cmd.Stdin = strings.NewReader(mjml)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return "", fmt.Errorf("%w: %s", err, msg)
+			}
+			return "", err
+		}
+		return stdout.String(), nil
+	})
+}
+
+// Compile compiles mjml by calling fn.
+func (fn CompilerFunc) Compile(ctx context.Context, mjml string) (string, error) {
+	return fn(ctx, mjml)
+}
+
+// IsMJML determines if html is an MJML document.
+func IsMJML(html string) bool {
+	html = strings.TrimSpace(html)
+	if strings.HasPrefix(html, "<?xml") {
+		if i := strings.Index(html, "?>"); i >= 0 {
+			html = strings.TrimSpace(html[i+2:])
+		}
+	}
+	return strings.HasPrefix(strings.ToLower(html), "<mjml")
+}
+
+func (p *plugin) handle(ctx context.Context, m postdog.Mail, next postdog.NextMiddleware) (postdog.Mail, error) {
+	l, _ := letter.AsLetter(m)
+	if !IsMJML(l.HTML()) {
+		return next(ctx, m)
+	}
+
+	html, err := p.compile(ctx, l.HTML())
+	if err != nil {
+		return m, fmt.Errorf("mjml: compile: %w", err)
+	}
+
+	return next(ctx, l.WithHTML(html))
+}
+
+func (p *plugin) compile(ctx context.Context, mjml string) (string, error) {
+	if p.cacheSize <= 0 {
+		return p.compiler.Compile(ctx, mjml)
+	}
+
+	key := sha256.Sum256([]byte(mjml))
+	if html, ok := p.cached(key); ok {
+		return html, nil
+	}
+
+	html, err := p.compiler.Compile(ctx, mjml)
+	if err != nil {
+		return "", err
+	}
+	p.store(key, html)
+
+	return html, nil
+}
+
+func (p *plugin) cached(key [sha256.Size]byte) (string, bool) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	el, ok := p.cache[key]
+	if !ok {
+		return "", false
+	}
+	p.lru.MoveToFront(el)
+	return el.Value.(cacheEntry).html, true
+}
+
+func (p *plugin) store(key [sha256.Size]byte, html string) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	if el, ok := p.cache[key]; ok {
+		p.lru.MoveToFront(el)
+		return
+	}
+	p.cache[key] = p.lru.PushFront(cacheEntry{key: key, html: html})
+	for p.lru.Len() > p.cacheSize {
+		oldest := p.lru.Back()
+		p.lru.Remove(oldest)
+		delete(p.cache, oldest.Value.(cacheEntry).key)
+	}
+}
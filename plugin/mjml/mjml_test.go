@@ -0,0 +1,128 @@
+package mjml_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter"
+	mock_postdog "github.com/bounoable/postdog/mocks"
+	"github.com/bounoable/postdog/plugin/mjml"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+const template = `<mjml><mj-body><mj-text>%s</mj-text></mj-body></mjml>`
+
+func TestNew(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var compiled int
+	compiler := mjml.CompilerFunc(func(_ context.Context, src string) (string, error) {
+		compiled++
+		return "<html>" + src + "</html>", nil
+	})
+
+	var sent []letter.Letter
+	tr := mock_postdog.NewMockTransport(ctrl)
+	tr.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, m postdog.Mail) error {
+		sent = append(sent, letter.Expand(m))
+		return nil
+	}).Times(4)
+
+	dog := postdog.New(postdog.WithTransport("test", tr), mjml.New(compiler))
+
+	mjmlLetter := letter.Write(letter.Content("Hello.", strings.Replace(template, "%s", "Hello.", 1)))
+	assert.Nil(t, dog.Send(context.Background(), mjmlLetter))
+	assert.Nil(t, dog.Send(context.Background(), mjmlLetter))
+	assert.Nil(t, dog.Send(context.Background(), mjmlLetter.WithHTML(strings.Replace(template, "%s", "Bye.", 1))))
+	assert.Nil(t, dog.Send(context.Background(), letter.Write(letter.HTML("<p>Hello.</p>"))))
+
+	assert.Equal(t, 2, compiled)
+	assert.Equal(t, "<html>"+strings.Replace(template, "%s", "Hello.", 1)+"</html>", sent[0].HTML())
+	assert.Equal(t, "Hello.", sent[0].Text())
+	assert.Equal(t, sent[0].HTML(), sent[1].HTML())
+	assert.Equal(t, "<html>"+strings.Replace(template, "%s", "Bye.", 1)+"</html>", sent[2].HTML())
+	assert.Equal(t, "<p>Hello.</p>", sent[3].HTML())
+}
+
+func TestCacheSize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var compiled int
+	compiler := mjml.CompilerFunc(func(_ context.Context, src string) (string, error) {
+		compiled++
+		return src, nil
+	})
+
+	tr := mock_postdog.NewMockTransport(ctrl)
+	tr.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	hello := letter.Write(letter.HTML(strings.Replace(template, "%s", "Hello.", 1)))
+	bye := letter.Write(letter.HTML(strings.Replace(template, "%s", "Bye.", 1)))
+
+	dog := postdog.New(postdog.WithTransport("test", tr), mjml.New(compiler, mjml.CacheSize(1)))
+	for _, l := range []letter.Letter{hello, hello, bye, hello} {
+		assert.Nil(t, dog.Send(context.Background(), l))
+	}
+	assert.Equal(t, 3, compiled)
+
+	compiled = 0
+	dog = postdog.New(postdog.WithTransport("test", tr), mjml.New(compiler, mjml.CacheSize(0)))
+	for _, l := range []letter.Letter{hello, hello} {
+		assert.Nil(t, dog.Send(context.Background(), l))
+	}
+	assert.Equal(t, 2, compiled)
+}
+
+func TestNew_error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockError := errors.New("mock error")
+	compiler := mjml.CompilerFunc(func(context.Context, string) (string, error) {
+		return "", mockError
+	})
+
+	tr := mock_postdog.NewMockTransport(ctrl)
+	dog := postdog.New(postdog.WithTransport("test", tr), mjml.New(compiler))
+
+	err := dog.Send(context.Background(), letter.Write(letter.HTML(strings.Replace(template, "%s", "Hello.", 1))))
+	assert.True(t, errors.Is(err, mockError))
+}
+
+func TestIsMJML(t *testing.T) {
+	assert.True(t, mjml.IsMJML(template))
+	assert.True(t, mjml.IsMJML("\n  <MJML>"))
+	assert.True(t, mjml.IsMJML(`<?xml version="1.0"?>`+"\n<mjml>"))
+	assert.False(t, mjml.IsMJML("<html><body></body></html>"))
+	assert.False(t, mjml.IsMJML(""))
+}
+
+func TestBinary(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not supported on windows")
+	}
+
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "mjml")
+	script := "#!/bin/sh\n" +
+		"[ \"$1 $2 $3\" = \"-i -s --config.minify\" ] || { echo \"invalid args: $*\" >&2; exit 1; }\n" +
+		"sed 's/mjml/html/g'\n"
+	assert.Nil(t, ioutil.WriteFile(bin, []byte(script), 0755))
+
+	html, err := mjml.Binary(bin, "--config.minify").Compile(context.Background(), "<mjml></mjml>")
+	assert.Nil(t, err)
+	assert.Equal(t, "<html></html>", html)
+
+	_, err = mjml.Binary(bin).Compile(context.Background(), "<mjml></mjml>")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid args: -i -s")
+}
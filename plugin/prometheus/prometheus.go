@@ -0,0 +1,115 @@
+// Package prometheus provides a plugin that records Prometheus metrics of sent mails.
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// StatusSuccess is the `status` label of mails that have been sent successfully.
+	StatusSuccess = "success"
+	// StatusError is the `status` label of mails that failed to send.
+	StatusError = "error"
+)
+
+const ctxStart = ctxKey("start")
+
+type ctxKey string
+
+type metrics struct {
+	sends           *prometheus.CounterVec
+	duration        *prometheus.HistogramVec
+	attachmentBytes *prometheus.HistogramVec
+}
+
+// New returns a Plugin that records the following metrics and registers them
+// with reg. If reg is nil, prometheus.DefaultRegisterer is used.
+//
+//	postdog_sends_total{transport,status}         counter
+//	postdog_send_duration_seconds{transport}      histogram
+//	postdog_attachment_bytes{transport}           histogram
+//
+// The `status` label is either StatusSuccess or StatusError. The send
+// duration is measured from the end of the global middlewares until the
+// transport returns, so it includes the transport and final middlewares (see
+// postdog.WithTransportMiddleware() and postdog.WithFinalMiddleware()). The
+// attachment bytes are the total size of the attachments of a mail.
+//
+// If the metrics are already registered with reg (e.g. because New has been
+// called for multiple *postdog.Dogs), the registered metrics are reused.
+func New(reg prometheus.Registerer) postdog.Plugin {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+
+	m := metrics{
+		sends: register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "postdog_sends_total",
+			Help: "Number of sent mails.",
+		}, []string{"transport", "status"})).(*prometheus.CounterVec),
+		duration: register(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "postdog_send_duration_seconds",
+			Help:    "Duration of mail sends.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"transport"})).(*prometheus.HistogramVec),
+		attachmentBytes: register(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "postdog_attachment_bytes",
+			Help:    "Total size of the attachments of sent mails.",
+			Buckets: prometheus.ExponentialBuckets(1024, 4, 10),
+		}, []string{"transport"})).(*prometheus.HistogramVec),
+	}
+
+	return postdog.Plugin{
+		// run after all other global middlewares, so that they're not measured
+		postdog.WithMiddlewarePriority(math.MinInt32, postdog.MiddlewareFunc(start)),
+		postdog.WithSyncHook(postdog.AfterSend, postdog.SyncListenerFunc(m.record)),
+	}
+}
+
+func register(reg prometheus.Registerer, c prometheus.Collector) prometheus.Collector {
+	if err := reg.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			return are.ExistingCollector
+		}
+		panic(err)
+	}
+	return c
+}
+
+func start(ctx context.Context, m postdog.Mail, next postdog.NextMiddleware) (postdog.Mail, error) {
+	return next(context.WithValue(ctx, ctxStart, time.Now()), m)
+}
+
+func (m metrics) record(ctx context.Context, _ postdog.Hook, pm postdog.Mail) error {
+	transport := postdog.SentVia(ctx)
+
+	status := StatusSuccess
+	if postdog.SendError(ctx) != nil {
+		status = StatusError
+	}
+	m.sends.WithLabelValues(transport, status).Inc()
+
+	if start, ok := ctx.Value(ctxStart).(time.Time); ok {
+		end := postdog.SendTime(ctx)
+		if end.IsZero() {
+			end = time.Now()
+		}
+		m.duration.WithLabelValues(transport).Observe(end.Sub(start).Seconds())
+	}
+
+	var size int
+	for _, at := range letter.Expand(pm).Attachments() {
+		size += at.Size()
+	}
+	m.attachmentBytes.WithLabelValues(transport).Observe(float64(size))
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package prometheus_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter"
+	mock_postdog "github.com/bounoable/postdog/mocks"
+	postdogprom "github.com/bounoable/postdog/plugin/prometheus"
+	"github.com/bounoable/postdog/send"
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	errSend := errors.New("send error")
+
+	primary := mock_postdog.NewMockTransport(ctrl)
+	primary.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	fallback := mock_postdog.NewMockTransport(ctrl)
+	fallback.EXPECT().Send(gomock.Any(), gomock.Any()).Return(errSend)
+
+	reg := prometheus.NewRegistry()
+	dog := postdog.New(
+		postdog.WithTransport("primary", primary),
+		postdog.WithTransport("fallback", fallback),
+		postdogprom.New(reg),
+	)
+
+	let := letter.Write(
+		letter.From("Bob Belcher", "bob@example.com"),
+		letter.To("Linda Belcher", "linda@example.com"),
+		letter.Attach("attach-1", make([]byte, 100)),
+		letter.Attach("attach-2", make([]byte, 50)),
+	)
+
+	assert.Nil(t, dog.Send(context.Background(), let))
+	assert.Nil(t, dog.Send(context.Background(), let, send.Use("primary")))
+	assert.True(t, errors.Is(dog.Send(context.Background(), let, send.Use("fallback")), errSend))
+
+	families, err := reg.Gather()
+	assert.Nil(t, err)
+
+	sends := metric(families, "postdog_sends_total", map[string]string{"transport": "primary", "status": postdogprom.StatusSuccess})
+	assert.Equal(t, float64(2), sends.GetCounter().GetValue())
+
+	sends = metric(families, "postdog_sends_total", map[string]string{"transport": "fallback", "status": postdogprom.StatusError})
+	assert.Equal(t, float64(1), sends.GetCounter().GetValue())
+
+	assert.Nil(t, metric(families, "postdog_sends_total", map[string]string{"transport": "fallback", "status": postdogprom.StatusSuccess}))
+
+	duration := metric(families, "postdog_send_duration_seconds", map[string]string{"transport": "primary"})
+	assert.Equal(t, uint64(2), duration.GetHistogram().GetSampleCount())
+
+	attachments := metric(families, "postdog_attachment_bytes", map[string]string{"transport": "primary"})
+	assert.Equal(t, uint64(2), attachments.GetHistogram().GetSampleCount())
+	assert.Equal(t, float64(300), attachments.GetHistogram().GetSampleSum())
+}
+
+func TestNew_alreadyRegistered(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	assert.NotPanics(t, func() {
+		postdogprom.New(reg)
+		postdogprom.New(reg)
+	})
+}
+
+// metric returns the metric of the family name that has the given labels, or
+// nil if there's no such metric.
+func metric(families []*dto.MetricFamily, name string, labels map[string]string) *dto.Metric {
+	for _, fam := range families {
+		if fam.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, m := range fam.GetMetric() {
+			if len(m.GetLabel()) != len(labels) {
+				continue
+			}
+			for _, l := range m.GetLabel() {
+				if labels[l.GetName()] != l.GetValue() {
+					continue metrics
+				}
+			}
+			return m
+		}
+	}
+	return nil
+}
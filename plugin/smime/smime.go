@@ -0,0 +1,258 @@
+// Package smime provides a plugin that signs mails with S/MIME (RFC 8551).
+package smime
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter"
+	"go.mozilla.org/pkcs7"
+)
+
+var (
+	// ErrNotSigned means a mail is not signed with S/MIME.
+	ErrNotSigned = errors.New("mail is not signed")
+)
+
+// Option is an S/MIME option.
+type Option func(*signer)
+
+type signer struct {
+	cert         *x509.Certificate
+	key          crypto.PrivateKey
+	intermediate []*x509.Certificate
+}
+
+// New returns a Plugin that signs every mail with the certificate cert and
+// the private key key. The plugin adds a final middleware (see
+// postdog.WithFinalMiddleware()) that signs the RFC body of a mail right
+// before it is sent, so that changes by all other middlewares, including
+// transport middlewares, are included in the signature.
+//
+// The signed mail has the `multipart/signed` structure: the first part is the
+// original body together with its `Content-*` headers and the second part is
+// the detached `application/pkcs7-signature` (SHA-256). All other headers of
+// the mail (`From`, `To`, `Subject` etc.) are kept as the headers of the
+// signed mail and are not covered by the signature.
+func New(cert *x509.Certificate, key crypto.PrivateKey, opts ...Option) postdog.Plugin {
+	s := signer{cert: cert, key: key}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return postdog.Plugin{
+		postdog.WithFinalMiddleware(postdog.MiddlewareFunc(s.middleware)),
+	}
+}
+
+// WithIntermediates returns an Option that adds intermediate certificates of
+// the signing certificate to the signature, so that recipients can verify the
+// certificate chain.
+func WithIntermediates(certs ...*x509.Certificate) Option {
+	return func(s *signer) {
+		s.intermediate = append(s.intermediate, certs...)
+	}
+}
+
+func (s signer) middleware(ctx context.Context, m postdog.Mail, next postdog.NextMiddleware) (postdog.Mail, error) {
+	signed, err := Sign(m.RFC(), s.cert, s.key, s.intermediate...)
+	if err != nil {
+		return m, fmt.Errorf("smime: %w", err)
+	}
+
+	if l, ok := m.(letter.Letter); ok {
+		return next(ctx, l.WithRFC(signed))
+	}
+
+	return next(ctx, postdog.RawMail(m.From(), m.Recipients(), signed))
+}
+
+// Sign signs the RFC body rfc with the certificate cert and the private key
+// key and returns the signed RFC body. See New() for the structure of the
+// signed body.
+func Sign(rfc string, cert *x509.Certificate, key crypto.PrivateKey, intermediates ...*x509.Certificate) (string, error) {
+	headers, body := split(canonicalize(rfc))
+	outer, content := partitionHeaders(headers)
+	if len(content) == 0 {
+		content = []string{"Content-Type: text/plain; charset=us-ascii"}
+	}
+
+	entity := strings.Join(content, "\r\n") + "\r\n\r\n" + body
+
+	sd, err := pkcs7.NewSignedData([]byte(entity))
+	if err != nil {
+		return "", fmt.Errorf("init signed data: %w", err)
+	}
+	sd.SetDigestAlgorithm(pkcs7.OIDDigestAlgorithmSHA256)
+	if err := sd.AddSignerChain(cert, key, intermediates, pkcs7.SignerInfoConfig{}); err != nil {
+		return "", fmt.Errorf("add signer: %w", err)
+	}
+	sd.Detach()
+
+	sig, err := sd.Finish()
+	if err != nil {
+		return "", fmt.Errorf("sign: %w", err)
+	}
+
+	bd := boundary()
+	lines := append(
+		outer,
+		"MIME-Version: 1.0",
+		fmt.Sprintf(`Content-Type: multipart/signed; protocol="application/pkcs7-signature"; micalg=sha-256; boundary="%s"`, bd),
+		"",
+		"--"+bd,
+		entity,
+		"--"+bd,
+		`Content-Type: application/pkcs7-signature; name="smime.p7s"`,
+		"Content-Transfer-Encoding: base64",
+		`Content-Disposition: attachment; filename="smime.p7s"`,
+		"",
+		fold(base64.StdEncoding.EncodeToString(sig), 76),
+		"--"+bd+"--",
+		"",
+	)
+
+	return strings.Join(lines, "\r\n"), nil
+}
+
+// Verify verifies the S/MIME signature of the signed RFC body rfc and returns
+// the certificate of the signer. The certificate chain is verified against
+// roots; if roots is nil, the system certificate pool is used. Verify returns
+// ErrNotSigned if rfc is not a `multipart/signed` mail.
+func Verify(rfc string, roots *x509.CertPool) (*x509.Certificate, error) {
+	headers, body := split(canonicalize(rfc))
+
+	var params map[string]string
+	for _, h := range unfold(headers) {
+		if name, value := headerField(h); strings.EqualFold(name, "Content-Type") {
+			var mediaType string
+			var err error
+			if mediaType, params, err = mime.ParseMediaType(value); err != nil {
+				return nil, fmt.Errorf("parse content-type: %w", err)
+			}
+			if mediaType != "multipart/signed" {
+				return nil, ErrNotSigned
+			}
+		}
+	}
+	if params["boundary"] == "" {
+		return nil, ErrNotSigned
+	}
+
+	delim := "--" + params["boundary"]
+	parts := strings.Split(body, "\r\n"+delim)
+	if len(parts) < 3 || !strings.HasPrefix(parts[0], delim+"\r\n") {
+		return nil, fmt.Errorf("%w: malformed multipart body", ErrNotSigned)
+	}
+	entity := strings.TrimPrefix(parts[0], delim+"\r\n")
+
+	_, sigBody := split(strings.TrimPrefix(parts[1], "\r\n"))
+	der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(sigBody), ""))
+	if err != nil {
+		return nil, fmt.Errorf("decode signature: %w", err)
+	}
+
+	p7, err := pkcs7.Parse(der)
+	if err != nil {
+		return nil, fmt.Errorf("parse signature: %w", err)
+	}
+	p7.Content = []byte(entity)
+
+	if roots == nil {
+		if roots, err = x509.SystemCertPool(); err != nil {
+			return nil, fmt.Errorf("load system certificates: %w", err)
+		}
+	}
+
+	if err := p7.VerifyWithChain(roots); err != nil {
+		return nil, fmt.Errorf("verify: %w", err)
+	}
+
+	return p7.GetOnlySigner(), nil
+}
+
+// canonicalize converts all line endings of rfc to CRLF.
+func canonicalize(rfc string) string {
+	rfc = strings.ReplaceAll(rfc, "\r\n", "\n")
+	return strings.ReplaceAll(rfc, "\n", "\r\n")
+}
+
+// split splits rfc into its header lines and its body.
+func split(rfc string) ([]string, string) {
+	i := strings.Index(rfc, "\r\n\r\n")
+	if i < 0 {
+		return strings.Split(strings.TrimSuffix(rfc, "\r\n"), "\r\n"), ""
+	}
+	return strings.Split(rfc[:i], "\r\n"), rfc[i+4:]
+}
+
+// partitionHeaders splits the header lines into the headers of the outer
+// mail and the `Content-*` headers of the signed entity. `MIME-Version` is
+// dropped because the signed mail gets its own. Folded lines stay with their
+// header.
+func partitionHeaders(headers []string) (outer, content []string) {
+	var inContent, drop bool
+	for _, line := range headers {
+		if line == "" {
+			continue
+		}
+		if line[0] != ' ' && line[0] != '\t' {
+			name, _ := headerField(line)
+			inContent = strings.HasPrefix(strings.ToLower(name), "content-")
+			drop = strings.EqualFold(name, "MIME-Version")
+		}
+		switch {
+		case drop:
+		case inContent:
+			content = append(content, line)
+		default:
+			outer = append(outer, line)
+		}
+	}
+	return
+}
+
+// unfold joins folded header lines.
+func unfold(headers []string) []string {
+	var res []string
+	for _, line := range headers {
+		if len(res) > 0 && line != "" && (line[0] == ' ' || line[0] == '\t') {
+			res[len(res)-1] += line
+			continue
+		}
+		res = append(res, line)
+	}
+	return res
+}
+
+func headerField(line string) (name, value string) {
+	i := strings.Index(line, ":")
+	if i < 0 {
+		return line, ""
+	}
+	return strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+}
+
+func fold(s string, width int) string {
+	var lines []string
+	for len(s) > width {
+		lines = append(lines, s[:width])
+		s = s[width:]
+	}
+	lines = append(lines, s)
+	return strings.Join(lines, "\r\n")
+}
+
+func boundary() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return "smime_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,148 @@
+package smime_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter"
+	"github.com/bounoable/postdog/middleware"
+	mock_postdog "github.com/bounoable/postdog/mocks"
+	"github.com/bounoable/postdog/plugin/smime"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cert, key, pool := newCertificate(t)
+
+	var sent postdog.Mail
+	tr := mock_postdog.NewMockTransport(ctrl)
+	tr.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, m postdog.Mail) error {
+		sent = m
+		return nil
+	})
+
+	dog := postdog.New(postdog.WithTransport("test", tr), smime.New(cert, key))
+
+	let := letter.Write(
+		letter.From("Bob Belcher", "bob@example.com"),
+		letter.To("Linda Belcher", "linda@example.com"),
+		letter.Subject("Signed"),
+		letter.Content("Hello.", "<p>Hello.</p>"),
+		letter.Attach("attach-1", []byte{1, 2, 3}),
+	)
+	assert.Nil(t, dog.Send(context.Background(), let))
+
+	rfc := sent.RFC()
+	assert.Contains(t, rfc, `Content-Type: multipart/signed; protocol="application/pkcs7-signature"; micalg=sha-256;`)
+	assert.Contains(t, rfc, `Content-Type: application/pkcs7-signature; name="smime.p7s"`)
+	assert.Contains(t, rfc, "\r\nSubject: ")
+	assert.Equal(t, 1, strings.Count(rfc, "MIME-Version: 1.0"))
+
+	signer, err := smime.Verify(rfc, pool)
+	assert.Nil(t, err)
+	assert.Equal(t, cert.Raw, signer.Raw)
+}
+
+func TestNew_transportMiddleware(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cert, key, pool := newCertificate(t)
+
+	var sent postdog.Mail
+	tr := mock_postdog.NewMockTransport(ctrl)
+	tr.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, m postdog.Mail) error {
+		sent = m
+		return nil
+	})
+
+	dog := postdog.New(
+		postdog.WithTransport("test", tr),
+		smime.New(cert, key),
+		postdog.WithTransportMiddleware("test", middleware.DefaultReplyTo(mail.Address{Address: "support@example.com"})),
+	)
+
+	let := letter.Write(
+		letter.From("Bob Belcher", "bob@example.com"),
+		letter.To("Linda Belcher", "linda@example.com"),
+		letter.Text("Hello."),
+	)
+	assert.Nil(t, dog.Send(context.Background(), let))
+
+	rfc := sent.RFC()
+	assert.Contains(t, rfc, "\r\nReply-To: <support@example.com>\r\n")
+
+	_, err := smime.Verify(rfc, pool)
+	assert.Nil(t, err)
+}
+
+func TestVerify_tampered(t *testing.T) {
+	cert, key, pool := newCertificate(t)
+
+	signed, err := smime.Sign(letter.Write(
+		letter.From("Bob Belcher", "bob@example.com"),
+		letter.Text("Pay 10 dollars."),
+	).RFC(), cert, key)
+	assert.Nil(t, err)
+
+	_, err = smime.Verify(signed, pool)
+	assert.Nil(t, err)
+
+	_, err = smime.Verify(strings.Replace(signed, "Content-Transfer-Encoding: base64", "Content-Transfer-Encoding: 7bit", 1), pool)
+	assert.NotNil(t, err)
+}
+
+func TestVerify_notSigned(t *testing.T) {
+	_, _, pool := newCertificate(t)
+	_, err := smime.Verify(letter.Write(letter.Text("Hello.")).RFC(), pool)
+	assert.True(t, errors.Is(err, smime.ErrNotSigned))
+}
+
+func newCertificate(t *testing.T) (*x509.Certificate, *ecdsa.PrivateKey, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	tmpl := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Bob Belcher"},
+		EmailAddresses:        []string{"bob@example.com"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageEmailProtection},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return cert, key, pool
+}
@@ -0,0 +1,149 @@
+// Package tracking provides a plugin that adds open and click tracking to the
+// HTML body of mails.
+package tracking
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/internal/fold"
+	"github.com/bounoable/postdog/letter"
+	xhtml "golang.org/x/net/html"
+)
+
+type tracker struct {
+	baseURL string
+	id      func(postdog.Mail) string
+}
+
+// New returns a Plugin that adds open and click tracking to the HTML body of
+// every mail. idFunc returns the tracking ID of a mail; mails with an empty ID
+// and mails without an HTML body are not modified.
+//
+// Every `<a href="...">` link is rewritten to
+//
+//	baseURL/click?id=<id>&url=<original URL>
+//
+// and a tracking pixel that loads baseURL/open?id=<id> is inserted before the
+// closing </body> tag, or appended if the body has none. Only http and https
+// links are rewritten, so e.g. mailto:, tel: and anchor links are kept.
+// Unsubscribe links (links whose URL contains "unsubscribe"), links with a
+// `data-notrack` attribute and links that already point to baseURL are kept as
+// well.
+//
+// The HTML body is rewritten token by token, so the markup outside of the
+// rewritten links is kept byte for byte, even if the HTML is malformed.
+func New(baseURL string, idFunc func(postdog.Mail) string) postdog.Plugin {
+	t := tracker{baseURL: strings.TrimRight(baseURL, "/"), id: idFunc}
+	return postdog.Plugin{
+		postdog.WithMiddleware(postdog.MiddlewareFunc(t.handle)),
+	}
+}
+
+func (t tracker) handle(ctx context.Context, m postdog.Mail, next postdog.NextMiddleware) (postdog.Mail, error) {
+	l, _ := letter.AsLetter(m)
+	if l.HTML() == "" {
+		return next(ctx, m)
+	}
+
+	id := t.id(m)
+	if id == "" {
+		return next(ctx, m)
+	}
+
+	body, err := t.wrapLinks(l.HTML(), id)
+	if err != nil {
+		return m, fmt.Errorf("tracking: %w", err)
+	}
+
+	return next(ctx, l.WithHTML(t.appendPixel(body, id)))
+}
+
+// wrapLinks rewrites the links of body to the click tracking URL.
+func (t tracker) wrapLinks(body, id string) (string, error) {
+	var sb strings.Builder
+	z := xhtml.NewTokenizer(strings.NewReader(body))
+	for {
+		tt := z.Next()
+		if tt == xhtml.ErrorToken {
+			if err := z.Err(); err != io.EOF {
+				return "", err
+			}
+			return sb.String(), nil
+		}
+
+		// Token() unescapes the attributes in the buffer of Raw()
+		raw := append([]byte(nil), z.Raw()...)
+		if tt != xhtml.StartTagToken && tt != xhtml.SelfClosingTagToken {
+			sb.Write(raw)
+			continue
+		}
+
+		tok := z.Token()
+		if tok.Data != "a" || !t.rewrite(&tok, id) {
+			sb.Write(raw)
+			continue
+		}
+		sb.WriteString(tok.String())
+	}
+}
+
+// rewrite replaces the href attribute of the link tok with the click tracking
+// URL and returns whether tok has been modified.
+func (t tracker) rewrite(tok *xhtml.Token, id string) bool {
+	href := -1
+	for i, attr := range tok.Attr {
+		switch strings.ToLower(attr.Key) {
+		case "data-notrack":
+			return false
+		case "href":
+			href = i
+		}
+	}
+	if href < 0 || !t.trackable(tok.Attr[href].Val) {
+		return false
+	}
+
+	tok.Attr[href].Val = t.clickURL(id, strings.TrimSpace(tok.Attr[href].Val))
+	return true
+}
+
+func (t tracker) trackable(link string) bool {
+	link = strings.TrimSpace(link)
+	lower := strings.ToLower(link)
+	if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+		return false
+	}
+	if strings.Contains(lower, "unsubscribe") {
+		return false
+	}
+	return !strings.HasPrefix(link, t.baseURL+"/")
+}
+
+func (t tracker) clickURL(id, link string) string {
+	return fmt.Sprintf("%s/click?id=%s&url=%s", t.baseURL, url.QueryEscape(id), url.QueryEscape(link))
+}
+
+func (t tracker) openURL(id string) string {
+	return fmt.Sprintf("%s/open?id=%s", t.baseURL, url.QueryEscape(id))
+}
+
+// appendPixel inserts the tracking pixel before the closing </body> tag of
+// body, or appends it if body has none. The pixel is not added twice.
+func (t tracker) appendPixel(body, id string) string {
+	src := html.EscapeString(t.openURL(id))
+	if strings.Contains(body, src) {
+		return body
+	}
+
+	pixel := fmt.Sprintf(`<img src="%s" width="1" height="1" alt="" style="border:0;">`, src)
+	if i := fold.LastIndex(body, "</body>"); i >= 0 {
+		return body[:i] + pixel + body[i:]
+	}
+	return body + pixel
+}
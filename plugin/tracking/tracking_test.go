@@ -0,0 +1,92 @@
+package tracking_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter"
+	"github.com/bounoable/postdog/plugin/tracking"
+	"github.com/stretchr/testify/assert"
+)
+
+const pixel = `<img src="https://t.example.com/open?id=abc" width="1" height="1" alt="" style="border:0;">`
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		give     letter.Letter
+		id       string
+		wantHTML string
+	}{
+		{
+			name:     "links & pixel",
+			give:     letter.Write(letter.HTML(`<html><body><a class="btn" href="https://example.com/a?b=c&amp;d=e">A</a></body></html>`)),
+			id:       "abc",
+			wantHTML: `<html><body><a class="btn" href="https://t.example.com/click?id=abc&amp;url=https%3A%2F%2Fexample.com%2Fa%3Fb%3Dc%26d%3De">A</a>` + pixel + `</body></html>`,
+		},
+		{
+			name: "skipped links",
+			give: letter.Write(letter.HTML(
+				`<a href="mailto:bob@example.com">Mail</a>` +
+					`<a href="tel:123">Call</a>` +
+					`<a href="#top">Top</a>` +
+					`<a href="https://example.com/Unsubscribe?u=1">Unsubscribe</a>` +
+					`<a data-notrack href="https://example.com">Untracked</a>` +
+					`<a href="https://t.example.com/click?id=abc&amp;url=x">Tracked</a>` +
+					`<a name="anchor">Anchor</a>`,
+			)),
+			id: "abc",
+			wantHTML: `<a href="mailto:bob@example.com">Mail</a>` +
+				`<a href="tel:123">Call</a>` +
+				`<a href="#top">Top</a>` +
+				`<a href="https://example.com/Unsubscribe?u=1">Unsubscribe</a>` +
+				`<a data-notrack href="https://example.com">Untracked</a>` +
+				`<a href="https://t.example.com/click?id=abc&amp;url=x">Tracked</a>` +
+				`<a name="anchor">Anchor</a>` + pixel,
+		},
+		{
+			name:     "malformed html",
+			give:     letter.Write(letter.HTML(`<p>Hi<div><a href='https://example.com'>A</b><!-- <a href="https://example.com"> --><script>var a = "<a href='https://example.com'>";</script>`)),
+			id:       "abc",
+			wantHTML: `<p>Hi<div><a href="https://t.example.com/click?id=abc&amp;url=https%3A%2F%2Fexample.com">A</b><!-- <a href="https://example.com"> --><script>var a = "<a href='https://example.com'>";</script>` + pixel,
+		},
+		{
+			name:     "uppercase body tag after characters that change length when lowercased",
+			give:     letter.Write(letter.HTML(`<HTML><BODY><p>İİİİ ẞ</p></BODY></HTML>`)),
+			id:       "abc",
+			wantHTML: `<HTML><BODY><p>İİİİ ẞ</p>` + pixel + `</BODY></HTML>`,
+		},
+		{
+			name:     "without id",
+			give:     letter.Write(letter.HTML(`<a href="https://example.com">A</a>`)),
+			wantHTML: `<a href="https://example.com">A</a>`,
+		},
+		{
+			name: "without html",
+			give: letter.Write(letter.Text("https://example.com")),
+			id:   "abc",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var gotMail postdog.Mail
+			dog := postdog.New(tracking.New("https://t.example.com/", func(m postdog.Mail) string {
+				gotMail = m
+				return test.id
+			}))
+
+			_, m, err := postdog.ApplyMiddleware(context.Background(), test.give, dog.Middlewares()...)
+			assert.Nil(t, err)
+			assert.Equal(t, test.wantHTML, letter.Expand(m).HTML())
+			if test.give.HTML() != "" {
+				assert.Equal(t, test.give, gotMail)
+			}
+
+			_, m, err = postdog.ApplyMiddleware(context.Background(), m, dog.Middlewares()...)
+			assert.Nil(t, err)
+			assert.Equal(t, test.wantHTML, letter.Expand(m).HTML(), "rewriting twice should not change the body")
+		})
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"errors"
 	"fmt"
 	"net/mail"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,11 +20,22 @@ const (
 	BeforeSend = Hook(iota + 1)
 	// AfterSend is the Hook that's called after a mail has been sent.
 	AfterSend
+	// TransportSelected is the Hook that's called when the transport for a
+	// mail has been selected, before the middlewares are applied. Listeners
+	// receive the mail as it was passed to Send(); the name of the selected
+	// transport can be retrieved with SentVia(). The order of the Hooks of a
+	// single send is TransportSelected, BeforeSend, AfterSend.
+	TransportSelected
 )
 
 const (
-	ctxSendError = ctxKey("sendError")
-	ctxSendTime  = ctxKey("sendTime")
+	ctxSendError    = ctxKey("sendError")
+	ctxSendTime     = ctxKey("sendTime")
+	ctxSendDuration = ctxKey("sendDuration")
+	ctxRawRFC       = ctxKey("rawRFC")
+	ctxSentVia      = ctxKey("sentVia")
+	ctxSendConfig   = ctxKey("sendConfig")
+	ctxRequestID    = ctxKey("requestID")
 )
 
 var (
@@ -30,6 +43,16 @@ var (
 	ErrNoTransport = errors.New("no transport")
 	// ErrUnconfiguredTransport means a transport with a specific name is not configured.
 	ErrUnconfiguredTransport = errors.New("unconfigured transport")
+	// ErrUnconfiguredGroup means a transport group with a specific name is not configured.
+	ErrUnconfiguredGroup = errors.New("unconfigured transport group")
+	// ErrSkipSend can be returned by a Middleware to skip sending a mail.
+	// (*Dog).Send() doesn't return an error for skipped mails. The
+	// TransportSelected Hook has already been called for skipped mails, but
+	// the BeforeSend and AfterSend Hooks are not called.
+	ErrSkipSend = errors.New("skip send")
+	// ErrHookTimeout means a SyncListener didn't return within the hook
+	// timeout (see WithHookTimeout()).
+	ErrHookTimeout = errors.New("hook timeout")
 )
 
 // A Dog can send mails through one of multiple configured transports.
@@ -37,8 +60,15 @@ type Dog struct {
 	mux              sync.RWMutex
 	transports       map[string]Transport
 	defaultTransport string
-	middlewares      []Middleware
+	middlewares      []prioritizedMiddleware
+	trMiddlewares    map[string][]Middleware
+	finalMiddlewares []Middleware
+	balancers        map[string]*weightedBalancer
+	backoffs         []BackoffWaiter
 	hooks            map[Hook][]Listener
+	syncHooks        map[Hook][]SyncListener
+	hookTimeout      time.Duration
+	freezeRFC        bool
 }
 
 // A Transport is responsible for actually sending mails.
@@ -46,6 +76,35 @@ type Transport interface {
 	Send(context.Context, Mail) error
 }
 
+// A RawRFCTransport is a Transport that reports whether it sends the raw RFC
+// body of a Mail as-is (e.g. SMTP) or whether it builds its own message from
+// the structured fields of the Mail (e.g. an HTTP API of a mail provider).
+//
+// Transports may optionally implement this interface. Middlewares that modify
+// the RFC body in a way that must be preserved (e.g. DKIM signing) should
+// check SendsRawRFC() or RawRFC() before doing so. Transports that don't
+// implement RawRFCTransport are assumed to not send the raw RFC body.
+type RawRFCTransport interface {
+	Transport
+
+	SendsRawRFC() bool
+}
+
+// A ClosableTransport is a Transport that holds resources (e.g. connections)
+// that must be released when the Transport is no longer used. Transports may
+// optionally implement this interface; (*Dog).Close() closes them.
+type ClosableTransport interface {
+	Transport
+
+	Close() error
+}
+
+// CloseError is returned by (*Dog).Close() if transports fail to close.
+type CloseError struct {
+	// Errors maps transport names to the errors returned by their Close() method.
+	Errors map[string]error
+}
+
 // Middleware is called on every Send(), allowing manipulation of mails before they are passed to the Transport.
 type Middleware interface {
 	Handle(context.Context, Mail, NextMiddleware) (Mail, error)
@@ -95,13 +154,30 @@ type Listener interface {
 // ListenerFunc allows functions to be used as Listeners.
 type ListenerFunc func(context.Context, Hook, Mail)
 
+// SyncListener is a callback for a Hook that is called synchronously.
+// A SyncListener that returns a non-nil error fails the (*Dog).Send() call.
+type SyncListener interface {
+	Handle(context.Context, Hook, Mail) error
+}
+
+// SyncListenerFunc allows functions to be used as SyncListeners.
+type SyncListenerFunc func(context.Context, Hook, Mail) error
+
 type ctxKey string
 
+type prioritizedMiddleware struct {
+	Middleware
+	priority int
+}
+
 // New returns a new *Dog.
 func New(opts ...Option) *Dog {
 	dog := Dog{
-		transports: make(map[string]Transport),
-		hooks:      make(map[Hook][]Listener),
+		transports:    make(map[string]Transport),
+		trMiddlewares: make(map[string][]Middleware),
+		balancers:     make(map[string]*weightedBalancer),
+		hooks:         make(map[Hook][]Listener),
+		syncHooks:     make(map[Hook][]SyncListener),
 	}
 	for _, opt := range opts {
 		opt.Apply(&dog)
@@ -117,9 +193,48 @@ func WithTransport(name string, tr Transport) OptionFunc {
 }
 
 // WithMiddleware returns an OptionFunc that adds the middleware mws to a *Dog.
+// The middlewares are added with the default priority of 0.
+// See WithMiddlewarePriority().
 func WithMiddleware(mws ...Middleware) OptionFunc {
+	return WithMiddlewarePriority(0, mws...)
+}
+
+// WithMiddlewarePriority returns an OptionFunc that adds the middleware mws
+// with the given priority to a *Dog.
+//
+// Middlewares with a higher priority run before middlewares with a lower
+// priority, regardless of the order in which they have been added. E.g., a
+// validation middleware could use a high priority to always run first.
+// Middlewares with equal priorities run in the order in which they have been
+// added. WithMiddleware() adds middlewares with a priority of 0. Transport
+// middlewares (see WithTransportMiddleware()) and final middlewares (see
+// WithFinalMiddleware()) run after all prioritized middlewares.
+func WithMiddlewarePriority(priority int, mws ...Middleware) OptionFunc {
+	return func(dog *Dog) {
+		for _, mw := range mws {
+			dog.addMiddleware(priority, mw)
+		}
+	}
+}
+
+// WithTransportMiddleware returns an OptionFunc that adds the middleware mws
+// to a *Dog that only applies to mails that are sent through the transport
+// with the given name. Transport middlewares run after the middlewares that
+// apply to all transports, in the order in which they have been added.
+func WithTransportMiddleware(transport string, mws ...Middleware) OptionFunc {
 	return func(dog *Dog) {
-		dog.middlewares = append(dog.middlewares, mws...)
+		dog.trMiddlewares[transport] = append(dog.trMiddlewares[transport], mws...)
+	}
+}
+
+// WithFinalMiddleware returns an OptionFunc that adds the middleware mws to a
+// *Dog that runs right before a mail is passed to the transport, after all
+// other middlewares including the transport middlewares, e.g. to sign the
+// final RFC body of a mail. Final middlewares run in the order in which they
+// have been added.
+func WithFinalMiddleware(mws ...Middleware) OptionFunc {
+	return func(dog *Dog) {
+		dog.finalMiddlewares = append(dog.finalMiddlewares, mws...)
 	}
 }
 
@@ -135,8 +250,12 @@ func WithMiddlewareFunc(mws ...MiddlewareFunc) OptionFunc {
 // WithRateLimiter returns an OptionFunc that adds a middleware to a *Dog.
 //
 // The middleware will call rl.Wait() for every mail that's sent.
+//
+// If rl implements BackoffWaiter, the *Dog also pauses rl when a Transport
+// returns a *RateLimitError with a RetryAfter duration, so that it honors the
+// `Retry-After` of the provider. See Backoff().
 func WithRateLimiter(rl Waiter) OptionFunc {
-	return WithMiddlewareFunc(func(
+	mw := WithMiddlewareFunc(func(
 		ctx context.Context,
 		m Mail,
 		next NextMiddleware,
@@ -146,6 +265,13 @@ func WithRateLimiter(rl Waiter) OptionFunc {
 		}
 		return next(ctx, m)
 	})
+
+	return func(dog *Dog) {
+		mw(dog)
+		if bw, ok := rl.(BackoffWaiter); ok {
+			dog.backoffs = append(dog.backoffs, bw)
+		}
+	}
 }
 
 // WithHook returns an OptionFunc that adds Listener l for Hook h to a *Dog.
@@ -155,6 +281,47 @@ func WithHook(h Hook, l Listener) OptionFunc {
 	}
 }
 
+// WithSyncHook returns an OptionFunc that adds SyncListener l for Hook h to a *Dog.
+//
+// Unlike Listeners, SyncListeners are called sequentially in the goroutine
+// of the (*Dog).Send() call, in the order they were added. If a BeforeSend
+// SyncListener returns an error, the mail is not sent. If an AfterSend
+// SyncListener returns an error and the transport didn't fail, Send() returns
+// that error. The remaining SyncListeners of a Hook are not called after one
+// of them returned an error.
+func WithSyncHook(h Hook, l SyncListener) OptionFunc {
+	return func(dog *Dog) {
+		dog.syncHooks[h] = append(dog.syncHooks[h], l)
+	}
+}
+
+// WithHookTimeout returns an OptionFunc that limits the time Listeners and
+// SyncListeners may take to handle a Hook to d. Listeners are called with a
+// context that is cancelled after d, and (*Dog).Send() stops waiting for a
+// SyncListener after d and fails with ErrHookTimeout, even if the SyncListener
+// ignores its context. A d of 0 (default) disables the timeout.
+//
+// Independent of the timeout, Listeners are called with a context that is
+// cancelled when the context that has been passed to (*Dog).Send() is
+// cancelled, but not when Send() returns, so that Listeners can stop when the
+// application shuts down.
+func WithHookTimeout(d time.Duration) OptionFunc {
+	return func(dog *Dog) {
+		dog.hookTimeout = d
+	}
+}
+
+// WithFrozenRFC returns an OptionFunc that freezes the RFC body of every mail
+// right before it is passed to the transport, after all middlewares have been
+// applied (see FreezeRFC()). The BeforeSend and AfterSend hooks then receive
+// the RFC body that is actually sent, even for mails that build a new
+// `Message-ID` and `Date` header every time their RFC body is built.
+func WithFrozenRFC() OptionFunc {
+	return func(dog *Dog) {
+		dog.freezeRFC = true
+	}
+}
+
 // SendError returns the error of the last (*Dog).Send() call that has been made using ctx.
 func SendError(ctx context.Context) error {
 	err, _ := ctx.Value(ctxSendError).(error)
@@ -167,6 +334,47 @@ func SendTime(ctx context.Context) time.Time {
 	return t
 }
 
+// SendDuration returns the time the transport took to send the mail of the
+// last (*Dog).Send() call that has been made using ctx.
+func SendDuration(ctx context.Context) time.Duration {
+	d, _ := ctx.Value(ctxSendDuration).(time.Duration)
+	return d
+}
+
+// SentVia returns the name of the transport that is used by the current
+// (*Dog).Send() call, which is either the transport that has been selected
+// with send.Use() or the default transport. Middlewares and hooks can use it
+// e.g. to record per-transport metrics. It returns an empty string if ctx
+// doesn't belong to a send.
+func SentVia(ctx context.Context) string {
+	name, _ := ctx.Value(ctxSentVia).(string)
+	return name
+}
+
+// SendsRawRFC returns whether tr sends the raw RFC body of mails.
+// It returns false if tr doesn't implement RawRFCTransport.
+func SendsRawRFC(tr Transport) bool {
+	if rtr, ok := tr.(RawRFCTransport); ok {
+		return rtr.SendsRawRFC()
+	}
+	return false
+}
+
+// RawRFC returns whether the Transport that is used by the current
+// (*Dog).Send() call sends the raw RFC body of the mail. Middlewares and
+// hooks can use it to adapt to the Transport.
+func RawRFC(ctx context.Context) bool {
+	raw, _ := ctx.Value(ctxRawRFC).(bool)
+	return raw
+}
+
+// sendConfig returns the send.Config of the (*Dog).Send() call that ctx
+// belongs to.
+func sendConfig(ctx context.Context) send.Config {
+	cfg, _ := ctx.Value(ctxSendConfig).(send.Config)
+	return cfg
+}
+
 // ApplyMiddleware applies the Middleware mw on the Mail m.
 func ApplyMiddleware(ctx context.Context, m Mail, mw ...Middleware) (context.Context, Mail, error) {
 	if len(mw) == 0 {
@@ -183,13 +391,40 @@ func ApplyMiddleware(ctx context.Context, m Mail, mw ...Middleware) (context.Con
 	return rctx, m, err
 }
 
-// Use sets the default transport.
+// Middlewares returns the middlewares of dog in the order in which they are
+// applied. See WithMiddlewarePriority().
+func (dog *Dog) Middlewares() []Middleware {
+	mws := make([]Middleware, len(dog.middlewares))
+	for i, mw := range dog.middlewares {
+		mws[i] = mw.Middleware
+	}
+	return mws
+}
+
+// Use sets the default transport. Use doesn't check if the transport has
+// been registered; if it hasn't, Send() returns ErrUnconfiguredTransport for
+// mails that are sent through the default transport. Use UseChecked() to
+// validate the name.
 func (dog *Dog) Use(transport string) {
 	dog.mux.Lock()
 	dog.defaultTransport = transport
 	dog.mux.Unlock()
 }
 
+// UseChecked does the same as Use() but returns ErrUnconfiguredTransport and
+// keeps the current default transport if no transport with the given name has
+// been registered. The default transport is switched atomically: a concurrent
+// Send() call uses either the previous or the new default transport.
+func (dog *Dog) UseChecked(transport string) error {
+	dog.mux.Lock()
+	defer dog.mux.Unlock()
+	if _, ok := dog.transports[transport]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnconfiguredTransport, transport)
+	}
+	dog.defaultTransport = transport
+	return nil
+}
+
 // Send sends the given mail through the default transport.
 //
 // A different transport can be specified with the Use() option:
@@ -198,16 +433,27 @@ func (dog *Dog) Use(transport string) {
 // If the Use() option is used and no transport with the specified name
 // has been registered, Send() will return ErrUnconfiguredTransport.
 //
+// Mails can also be distributed across a group of transports with the
+// UseGroup() option (see WithWeightedBalancer()). If no balancer has been
+// configured for the group, Send() will return ErrUnconfiguredGroup.
+//
 // If the Use() option is not used, the default transport will be used instead.
 // The default transport is automatically the first transport that has been
 // registered and can be overriden by calling dog.Use("transport-name").
 // If there's no default transport available, Send() will return ErrNoTransport.
+//
+// If the mail has a TryRFC() method (e.g. a letter.Letter) that fails, e.g.
+// because a text part can't be transcoded to the charset of the mail (see
+// rfc.WithCharset()), the mail is not sent and Send() returns the error.
 func (dog *Dog) Send(ctx context.Context, m Mail, opts ...send.Option) error {
 	return dog.SendConfig(ctx, m, send.Configure(opts...))
 }
 
 // SendConfig does the same as Send() but accepts a send.Config instead of send.Options.
 func (dog *Dog) SendConfig(ctx context.Context, m Mail, cfg send.Config) error {
+	parent := ctx
+	hookCtx := func() context.Context { return hookContext{Context: parent, values: ctx} }
+
 	var cancel context.CancelFunc
 	if cfg.Timeout == 0 {
 		ctx, cancel = context.WithCancel(ctx)
@@ -216,62 +462,258 @@ func (dog *Dog) SendConfig(ctx context.Context, m Mail, cfg send.Config) error {
 	}
 	defer cancel()
 
-	tr, err := dog.transport(cfg.Transport)
+	transport := cfg.Transport
+	if transport == "" && cfg.Group != "" {
+		var err error
+		if transport, err = dog.balance(cfg.Group); err != nil {
+			return err
+		}
+	}
+
+	name, tr, err := dog.resolveTransport(transport)
 	if err != nil {
 		return err
 	}
+	ctx = context.WithValue(ctx, ctxRawRFC, SendsRawRFC(tr))
+	ctx = context.WithValue(ctx, ctxSentVia, name)
+	ctx = context.WithValue(ctx, ctxSendConfig, cfg)
+
+	dog.callHooks(hookCtx(), TransportSelected, m)
+	if err = dog.callSyncHooks(ctx, TransportSelected, m); err != nil {
+		return fmt.Errorf("hook: %w", err)
+	}
+
+	if ctx, m, err = ApplyMiddleware(ctx, m, dog.transportMiddlewares(name)...); err != nil {
+		if errors.Is(err, ErrSkipSend) {
+			return nil
+		}
+		return fmt.Errorf("middleware: %w", err)
+	}
+
+	if cfg.From.Address != "" {
+		m = WithFrom(m, cfg.From)
+	}
+
+	if err = tryRFC(m); err != nil {
+		return fmt.Errorf("rfc: %w", err)
+	}
 
-	if ctx, m, err = ApplyMiddleware(ctx, m, dog.middlewares...); err != nil {
+	if ctx, m, err = ApplyMiddleware(ctx, m, dog.finalMiddlewares...); err != nil {
+		if errors.Is(err, ErrSkipSend) {
+			return nil
+		}
 		return fmt.Errorf("middleware: %w", err)
 	}
 
-	dog.callHooks(ctx, BeforeSend, m)
-	defer func() { dog.callHooks(ctx, AfterSend, m) }()
+	if dog.freezeRFC {
+		m = FreezeRFC(m)
+	}
+
+	dog.callHooks(hookCtx(), BeforeSend, m)
+	if err = dog.callSyncHooks(ctx, BeforeSend, m); err != nil {
+		return fmt.Errorf("hook: %w", err)
+	}
+	defer func() { dog.callHooks(hookCtx(), AfterSend, m) }()
 
+	start := time.Now()
 	err = tr.Send(ctx, m)
-	ctx = withSendTime(ctx, time.Now())
+	end := time.Now()
+	ctx = withSendTime(ctx, end)
+	ctx = withSendDuration(ctx, end.Sub(start))
 	if err != nil {
+		dog.backoff(end, err)
 		ctx = withSendError(ctx, err)
+		dog.callSyncHooks(ctx, AfterSend, m)
 		return fmt.Errorf("transport: %w", err)
 	}
 
+	if err = dog.callSyncHooks(ctx, AfterSend, m); err != nil {
+		return fmt.Errorf("hook: %w", err)
+	}
+
 	return nil
 }
 
+// tryRFC returns the error of the TryRFC() method of m, e.g. a text part that
+// could not be transcoded by a letter.Letter. Mails that wrap other mails are
+// unwrapped until a mail with a TryRFC() method is found.
+func tryRFC(m Mail) error {
+	for m != nil {
+		if tm, ok := m.(interface{ TryRFC() (string, error) }); ok {
+			_, err := tm.TryRFC()
+			return err
+		}
+		wm, ok := m.(interface{ Unwrap() Mail })
+		if !ok {
+			return nil
+		}
+		m = wm.Unwrap()
+	}
+	return nil
+}
+
+// backoff pauses the BackoffWaiters of dog if err is a *RateLimitError with a
+// RetryAfter duration.
+func (dog *Dog) backoff(now time.Time, err error) {
+	var rlErr *RateLimitError
+	if !errors.As(err, &rlErr) || rlErr.RetryAfter <= 0 {
+		return
+	}
+	for _, bw := range dog.backoffs {
+		bw.SetNextAllowed(now.Add(rlErr.RetryAfter))
+	}
+}
+
 func (dog *Dog) callHooks(ctx context.Context, h Hook, m Mail) {
 	for _, lis := range dog.listeners(h) {
-		go lis.Handle(ctx, h, m)
+		go func(lis Listener) {
+			ctx, cancel := dog.withHookTimeout(ctx)
+			defer cancel()
+			lis.Handle(ctx, h, m)
+		}(lis)
 	}
 }
 
+func (dog *Dog) callSyncHooks(ctx context.Context, h Hook, m Mail) error {
+	for _, lis := range dog.syncListeners(h) {
+		if err := dog.callSyncHook(ctx, lis, h, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (dog *Dog) callSyncHook(ctx context.Context, lis SyncListener, h Hook, m Mail) error {
+	if dog.hookTimeout <= 0 {
+		return lis.Handle(ctx, h, m)
+	}
+
+	ctx, cancel := dog.withHookTimeout(ctx)
+	defer cancel()
+
+	timer := time.NewTimer(dog.hookTimeout)
+	defer timer.Stop()
+
+	errc := make(chan error, 1)
+	go func() { errc <- lis.Handle(ctx, h, m) }()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-timer.C:
+		return ErrHookTimeout
+	}
+}
+
+func (dog *Dog) withHookTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if dog.hookTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, dog.hookTimeout)
+}
+
 func (dog *Dog) listeners(h Hook) []Listener {
 	dog.mux.RLock()
 	defer dog.mux.RUnlock()
 	return dog.hooks[h]
 }
 
+func (dog *Dog) syncListeners(h Hook) []SyncListener {
+	dog.mux.RLock()
+	defer dog.mux.RUnlock()
+	return dog.syncHooks[h]
+}
+
 // Transport returns either the transport with the given name or an ErrUnconfiguredTransport error.
 func (dog *Dog) Transport(name string) (Transport, error) {
 	return dog.transport(name)
 }
 
+// Close closes all configured transports that implement ClosableTransport.
+// Close should be called when the *Dog is no longer used, e.g. on application
+// shutdown. If transports fail to close, the remaining transports are still
+// closed and Close returns a *CloseError that contains the errors.
+func (dog *Dog) Close() error {
+	dog.mux.RLock()
+	transports := make(map[string]Transport, len(dog.transports))
+	for name, tr := range dog.transports {
+		transports[name] = tr
+	}
+	dog.mux.RUnlock()
+
+	errs := make(map[string]error)
+	for name, tr := range transports {
+		ctr, ok := tr.(ClosableTransport)
+		if !ok {
+			continue
+		}
+		if err := ctr.Close(); err != nil {
+			errs[name] = err
+		}
+	}
+
+	if len(errs) > 0 {
+		return &CloseError{Errors: errs}
+	}
+
+	return nil
+}
+
+// SendsRawRFC returns whether the transport with the given name sends the raw
+// RFC body of mails. See RawRFCTransport.
+func (dog *Dog) SendsRawRFC(transport string) (bool, error) {
+	tr, err := dog.transport(transport)
+	if err != nil {
+		return false, err
+	}
+	return SendsRawRFC(tr), nil
+}
+
 func (dog *Dog) transport(name string) (Transport, error) {
+	_, tr, err := dog.resolveTransport(name)
+	return tr, err
+}
+
+// resolveTransport returns the transport with the given name or the default
+// transport if name is empty, together with the name of the returned transport.
+func (dog *Dog) resolveTransport(name string) (string, Transport, error) {
 	dog.mux.RLock()
 	defer dog.mux.RUnlock()
 
 	if name == "" {
-		if dog.defaultTransport != "" {
-			return dog.transports[dog.defaultTransport], nil
+		if dog.defaultTransport == "" {
+			return "", nil, ErrNoTransport
 		}
-		return nil, ErrNoTransport
+		tr, ok := dog.transports[dog.defaultTransport]
+		if !ok {
+			return "", nil, ErrUnconfiguredTransport
+		}
+		return dog.defaultTransport, tr, nil
 	}
 
 	tr, ok := dog.transports[name]
 	if !ok {
-		return nil, ErrUnconfiguredTransport
+		return "", nil, ErrUnconfiguredTransport
 	}
 
-	return tr, nil
+	return name, tr, nil
+}
+
+// transportMiddlewares returns the middlewares that apply to mails that are
+// sent through the transport with the given name.
+func (dog *Dog) transportMiddlewares(name string) []Middleware {
+	return append(dog.Middlewares(), dog.trMiddlewares[name]...)
+}
+
+// addMiddleware inserts mw after all middlewares with a priority >= priority,
+// so that dog.middlewares is always sorted.
+func (dog *Dog) addMiddleware(priority int, mw Middleware) {
+	i := sort.Search(len(dog.middlewares), func(i int) bool {
+		return dog.middlewares[i].priority < priority
+	})
+	dog.middlewares = append(dog.middlewares, prioritizedMiddleware{})
+	copy(dog.middlewares[i+1:], dog.middlewares[i:])
+	dog.middlewares[i] = prioritizedMiddleware{Middleware: mw, priority: priority}
 }
 
 func (dog *Dog) configureTransport(name string, tr Transport) {
@@ -305,6 +747,23 @@ func (lis ListenerFunc) Handle(ctx context.Context, h Hook, m Mail) {
 	lis(ctx, h, m)
 }
 
+// Handle calls lis(ctx, h, m).
+func (lis SyncListenerFunc) Handle(ctx context.Context, h Hook, m Mail) error {
+	return lis(ctx, h, m)
+}
+
+// hookContext is the context of asynchronous Listeners. It has the values of
+// the context of a send but the cancellation of the context that has been
+// passed to (*Dog).Send().
+type hookContext struct {
+	context.Context
+	values context.Context
+}
+
+func (ctx hookContext) Value(key interface{}) interface{} {
+	return ctx.values.Value(key)
+}
+
 func withSendError(ctx context.Context, err error) context.Context {
 	return context.WithValue(ctx, ctxSendError, err)
 }
@@ -313,6 +772,10 @@ func withSendTime(ctx context.Context, t time.Time) context.Context {
 	return context.WithValue(ctx, ctxSendTime, t)
 }
 
+func withSendDuration(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, ctxSendDuration, d)
+}
+
 func nextMiddlewareFunc(i int, pipeline []Middleware) NextMiddleware {
 	return func(ctx context.Context, let Mail) (Mail, error) {
 		if i >= len(pipeline)-1 {
@@ -321,3 +784,28 @@ func nextMiddlewareFunc(i int, pipeline []Middleware) NextMiddleware {
 		return pipeline[i+1].Handle(ctx, let, nextMiddlewareFunc(i+1, pipeline))
 	}
 }
+
+func (err *CloseError) Error() string {
+	names := make([]string, 0, len(err.Errors))
+	for name := range err.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	msgs := make([]string, len(names))
+	for i, name := range names {
+		msgs[i] = fmt.Sprintf("%s: %s", name, err.Errors[name])
+	}
+
+	return fmt.Sprintf("close transports: %s", strings.Join(msgs, "; "))
+}
+
+// Is determines if one of the errors in err.Errors matches target.
+func (err *CloseError) Is(target error) bool {
+	for _, e := range err.Errors {
+		if errors.Is(e, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -193,6 +193,87 @@ func TestPostdog(t *testing.T) {
 			})
 		})
 
+		Convey("Feature: Close transports", func() {
+			Convey("Given a *postdog.Dog with closable and non-closable transports", func() {
+				closable1 := mock_postdog.NewMockClosableTransport(ctrl)
+				closable2 := mock_postdog.NewMockClosableTransport(ctrl)
+				other := mock_postdog.NewMockTransport(ctrl)
+				dog := postdog.New(
+					postdog.WithTransport("closable1", closable1),
+					postdog.WithTransport("closable2", closable2),
+					postdog.WithTransport("other", other),
+				)
+
+				Convey("When I close the *postdog.Dog", func() {
+					closable1.EXPECT().Close().Return(nil)
+					closable2.EXPECT().Close().Return(nil)
+
+					err := dog.Close()
+
+					Convey("It shouldn't fail", func() {
+						So(err, ShouldBeNil)
+					})
+				})
+
+				Convey("When I close the *postdog.Dog and a transport fails to close", func() {
+					closable1.EXPECT().Close().Return(mockError)
+					closable2.EXPECT().Close().Return(nil)
+
+					err := dog.Close()
+
+					Convey("It should fail with a *CloseError", func() {
+						var closeErr *postdog.CloseError
+						So(errors.As(err, &closeErr), ShouldBeTrue)
+						So(closeErr.Errors, ShouldResemble, map[string]error{"closable1": mockError})
+						So(errors.Is(err, mockError), ShouldBeTrue)
+					})
+				})
+			})
+		})
+
+		Convey("Feature: Raw RFC transports", func() {
+			Convey("Given a *postdog.Dog with a raw and a structured transport", func() {
+				raw := mock_postdog.NewMockRawRFCTransport(ctrl)
+				raw.EXPECT().SendsRawRFC().Return(true).AnyTimes()
+				structured := mock_postdog.NewMockTransport(ctrl)
+				rawCalls := make(chan bool, 1)
+				dog := postdog.New(
+					postdog.WithTransport("raw", raw),
+					postdog.WithTransport("structured", structured),
+					postdog.WithMiddlewareFunc(func(ctx stdctx.Context, m postdog.Mail, next postdog.NextMiddleware) (postdog.Mail, error) {
+						rawCalls <- postdog.RawRFC(ctx)
+						return next(ctx, m)
+					}),
+				)
+
+				Convey("dog.SendsRawRFC() should report whether a transport sends the raw RFC body", func() {
+					isRaw, err := dog.SendsRawRFC("raw")
+					So(err, ShouldBeNil)
+					So(isRaw, ShouldBeTrue)
+
+					isRaw, err = dog.SendsRawRFC("structured")
+					So(err, ShouldBeNil)
+					So(isRaw, ShouldBeFalse)
+				})
+
+				Convey("dog.SendsRawRFC() should fail for an unconfigured transport", func() {
+					_, err := dog.SendsRawRFC("test")
+					So(errors.Is(err, postdog.ErrUnconfiguredTransport), ShouldBeTrue)
+				})
+
+				Convey("Middlewares should be able to check whether the transport sends the raw RFC body", func() {
+					raw.EXPECT().Send(gomock.Any(), mockLetter).Return(nil)
+					structured.EXPECT().Send(gomock.Any(), mockLetter).Return(nil)
+
+					So(dog.Send(stdctx.Background(), mockLetter, send.Use("raw")), ShouldBeNil)
+					So(<-rawCalls, ShouldBeTrue)
+
+					So(dog.Send(stdctx.Background(), mockLetter, send.Use("structured")), ShouldBeNil)
+					So(<-rawCalls, ShouldBeFalse)
+				})
+			})
+		})
+
 		Convey("Feature: Middleware", func() {
 			Convey("Given 3 middlewares that all add a recipient to the mail", func() {
 				mw1 := newMockMiddleware(ctrl, func(m postdog.Mail) postdog.Mail {
@@ -299,6 +380,129 @@ func TestPostdog(t *testing.T) {
 			})
 		})
 
+		Convey("Feature: Middleware priority", func() {
+			Convey("Given middlewares with different priorities", func() {
+				var order []string
+				mw := func(name string) postdog.Middleware {
+					return postdog.MiddlewareFunc(func(ctx stdctx.Context, m postdog.Mail, next postdog.NextMiddleware) (postdog.Mail, error) {
+						order = append(order, name)
+						return next(ctx, m)
+					})
+				}
+
+				tr := mock_postdog.NewMockTransport(ctrl)
+				dog := postdog.New(
+					postdog.WithTransport("test", tr),
+					postdog.WithMiddlewarePriority(-10, mw("sign")),
+					postdog.WithMiddleware(mw("default-1")),
+					postdog.Plugin{
+						postdog.WithMiddleware(mw("default-2")),
+						postdog.WithMiddlewarePriority(10, mw("validate")),
+					},
+					postdog.WithMiddlewarePriority(-10, mw("archive")),
+					postdog.WithMiddleware(mw("default-3")),
+				)
+
+				Convey("Middlewares() should return the middlewares in execution order", func() {
+					So(dog.Middlewares(), ShouldHaveLength, 6)
+				})
+
+				Convey("When I send a mail", func() {
+					tr.EXPECT().Send(gomock.Any(), mockLetter).Return(nil)
+					err := dog.Send(stdctx.Background(), mockLetter)
+
+					Convey("The middlewares should be applied by priority and then by registration order", func() {
+						So(err, ShouldBeNil)
+						So(order, ShouldResemble, []string{"validate", "default-1", "default-2", "default-3", "sign", "archive"})
+					})
+				})
+			})
+		})
+
+		Convey("Feature: RFC errors", func() {
+			Convey("Given a letter with a text that can't be transcoded to its charset", func() {
+				let := letter.Write(
+					letter.From("Bob Belcher", "bob@example.com"),
+					letter.To("Linda Belcher", "linda@example.com"),
+					letter.Text("こんにちは"),
+				).WithRFCOptions(rfc.WithCharset("iso-8859-1"))
+
+				tr := mock_postdog.NewMockTransport(ctrl)
+				dog := postdog.New(postdog.WithTransport("test", tr))
+
+				Convey("When I send the letter", func() {
+					err := dog.Send(stdctx.Background(), postdog.WithSubject(let, "Hi."))
+
+					Convey("It should fail without sending the letter", func() {
+						So(errors.Is(err, rfc.ErrUnrepresentable), ShouldBeTrue)
+					})
+				})
+			})
+		})
+
+		Convey("Feature: Transport middleware", func() {
+			Convey("Given a middleware for one of two transports", func() {
+				var order []string
+				mw := func(name string) postdog.Middleware {
+					return postdog.MiddlewareFunc(func(ctx stdctx.Context, m postdog.Mail, next postdog.NextMiddleware) (postdog.Mail, error) {
+						order = append(order, name)
+						return next(ctx, m)
+					})
+				}
+
+				tr1 := mock_postdog.NewMockTransport(ctrl)
+				tr2 := mock_postdog.NewMockTransport(ctrl)
+				dog := postdog.New(
+					postdog.WithTransport("test", tr1),
+					postdog.WithTransport("test2", tr2),
+					postdog.WithTransportMiddleware("test2", mw("transport")),
+					postdog.WithMiddleware(mw("global")),
+				)
+
+				Convey("When I send a mail through the default transport", func() {
+					tr1.EXPECT().Send(gomock.Any(), mockLetter).Return(nil)
+					err := dog.Send(stdctx.Background(), mockLetter)
+
+					Convey("Only the global middleware should be applied", func() {
+						So(err, ShouldBeNil)
+						So(order, ShouldResemble, []string{"global"})
+					})
+				})
+
+				Convey("When I send a mail through the transport with the middleware", func() {
+					tr2.EXPECT().Send(gomock.Any(), mockLetter).Return(nil)
+					err := dog.Send(stdctx.Background(), mockLetter, send.Use("test2"))
+
+					Convey("The transport middleware should be applied after the global middleware", func() {
+						So(err, ShouldBeNil)
+						So(order, ShouldResemble, []string{"global", "transport"})
+					})
+				})
+
+				Convey("When I make the transport the default transport", func() {
+					dog.Use("test2")
+					tr2.EXPECT().Send(gomock.Any(), mockLetter).Return(nil)
+					err := dog.Send(stdctx.Background(), mockLetter)
+
+					Convey("The transport middleware should be applied", func() {
+						So(err, ShouldBeNil)
+						So(order, ShouldResemble, []string{"global", "transport"})
+					})
+				})
+
+				Convey("When I add a final middleware", func() {
+					postdog.WithFinalMiddleware(mw("final"))(dog)
+					tr2.EXPECT().Send(gomock.Any(), mockLetter).Return(nil)
+					err := dog.Send(stdctx.Background(), mockLetter, send.Use("test2"))
+
+					Convey("The final middleware should be applied after the transport middleware", func() {
+						So(err, ShouldBeNil)
+						So(order, ShouldResemble, []string{"global", "transport", "final"})
+					})
+				})
+			})
+		})
+
 		Convey("Feature: Rate limiting", func() {
 			Convey("Given a Transport", WithMockTransport(ctrl, func(tr *mock_postdog.MockTransport) {
 				tr.EXPECT().
@@ -383,6 +587,176 @@ func TestPostdog(t *testing.T) {
 			}))
 		})
 
+		Convey("Feature: Override sender", func() {
+			Convey("Given a Postdog with a Transport", func() {
+				tr := mock_postdog.NewMockTransport(ctrl)
+				dog := postdog.New(postdog.WithTransport("test", tr))
+
+				Convey("When I send a mail with an overridden sender", func() {
+					from := mail.Address{Name: "Tina Belcher", Address: "tina@example.com"}
+					sent := make(chan postdog.Mail, 1)
+					tr.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(_ stdctx.Context, m postdog.Mail) error {
+						sent <- m
+						return nil
+					})
+
+					err := dog.Send(stdctx.Background(), mockLetter, send.From(from))
+
+					Convey("It shouldn't fail", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("The Transport should receive the overridden sender", func() {
+						m := <-sent
+						So(m.From(), ShouldResemble, from)
+						So(m.RFC(), ShouldContainSubstring, "From: "+from.String()+"\r\n")
+						So(m.RFC(), ShouldNotContainSubstring, "bob@example.com")
+					})
+
+					Convey("The original letter should not be modified", func() {
+						So(mockLetter.From(), ShouldResemble, mail.Address{Name: "Bob Belcher", Address: "bob@example.com"})
+					})
+				})
+			})
+		})
+
+		Convey("Feature: Subject prefix & suffix", func() {
+			Convey("Given a Postdog with a subject prefix and suffix", func() {
+				tr := mock_postdog.NewMockTransport(ctrl)
+				sent := make(chan postdog.Mail, 1)
+				tr.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(_ stdctx.Context, m postdog.Mail) error {
+					sent <- m
+					return nil
+				})
+				dog := postdog.New(
+					postdog.WithTransport("test", tr),
+					postdog.WithSubjectPrefix("[STAGING]"),
+					postdog.WithSubjectSuffix("(test)"),
+				)
+
+				Convey("When I send a mail", func() {
+					err := dog.Send(stdctx.Background(), mockLetter)
+
+					Convey("The subject should be prefixed and suffixed", func() {
+						So(err, ShouldBeNil)
+						m := <-sent
+						So(postdog.Subject(m), ShouldEqual, "[STAGING] Hi. (test)")
+						So(m.RFC(), ShouldContainSubstring, "Subject: [STAGING] Hi. (test)\r\n")
+						So(letter.Expand(m).Subject(), ShouldEqual, "[STAGING] Hi. (test)")
+					})
+				})
+
+				Convey("When I send a mail whose subject is already prefixed and suffixed", func() {
+					err := dog.Send(stdctx.Background(), mockLetter.WithSubject("[STAGING] Hi. (test)"))
+
+					Convey("The subject should not be modified", func() {
+						So(err, ShouldBeNil)
+						So(postdog.Subject(<-sent), ShouldEqual, "[STAGING] Hi. (test)")
+					})
+				})
+
+				Convey("When I send a mail without a subject", func() {
+					err := dog.Send(stdctx.Background(), mockLetter.WithSubject(""))
+
+					Convey("The subject should consist of the prefix and suffix", func() {
+						So(err, ShouldBeNil)
+						So(postdog.Subject(<-sent), ShouldEqual, "[STAGING] (test)")
+					})
+				})
+			})
+		})
+
+		Convey("Feature: Skip send", func() {
+			Convey("Given a Postdog with a middleware that skips every mail", func() {
+				tr := mock_postdog.NewMockTransport(ctrl)
+				lis := mock_postdog.NewMockSyncListener(ctrl)
+				dog := postdog.New(
+					postdog.WithTransport("test", tr),
+					postdog.WithMiddlewareFunc(func(_ stdctx.Context, m postdog.Mail, _ postdog.NextMiddleware) (postdog.Mail, error) {
+						return m, postdog.ErrSkipSend
+					}),
+					postdog.WithSyncHook(postdog.BeforeSend, lis),
+				)
+
+				Convey("When I send a mail", func() {
+					err := dog.Send(stdctx.Background(), mockLetter)
+
+					Convey("It shouldn't fail", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("The mail should not be sent", func() {
+						// tr.Send() & lis.Handle() must not be called
+					})
+				})
+			})
+		})
+
+		Convey("Feature: Throttle", func() {
+			Convey("Given a Postdog that throttles mails by subject", func() {
+				tr := mock_postdog.NewMockTransport(ctrl)
+				now := time.Now()
+				dog := postdog.New(
+					postdog.WithTransport("test", tr),
+					postdog.WithThrottle(func(m postdog.Mail) string {
+						return letter.Expand(m).Subject()
+					}, time.Hour, postdog.ThrottleClock(func() time.Time { return now })),
+				)
+
+				Convey("When I send a mail", func() {
+					tr.EXPECT().Send(gomock.Any(), mockLetter).Return(nil)
+					err := dog.Send(stdctx.Background(), mockLetter)
+					So(err, ShouldBeNil)
+
+					Convey("When I send a mail with the same key within the window", func() {
+						err := dog.Send(stdctx.Background(), mockLetter.WithText("Bye."))
+
+						Convey("The mail should be skipped", func() {
+							So(err, ShouldBeNil)
+						})
+					})
+
+					Convey("When I send a mail with a different key within the window", func() {
+						let := mockLetter.WithSubject("Bye.")
+						tr.EXPECT().Send(gomock.Any(), let).Return(nil)
+						err := dog.Send(stdctx.Background(), let)
+
+						Convey("The mail should be sent", func() {
+							So(err, ShouldBeNil)
+						})
+					})
+
+					Convey("When I send a mail with the same key after the window", func() {
+						now = now.Add(time.Hour)
+						tr.EXPECT().Send(gomock.Any(), mockLetter).Return(nil)
+						err := dog.Send(stdctx.Background(), mockLetter)
+
+						Convey("The mail should be sent", func() {
+							So(err, ShouldBeNil)
+						})
+					})
+				})
+			})
+
+			Convey("Given a Postdog with a failing ThrottleStore", func() {
+				tr := mock_postdog.NewMockTransport(ctrl)
+				dog := postdog.New(
+					postdog.WithTransport("test", tr),
+					postdog.WithThrottle(func(postdog.Mail) string {
+						return "key"
+					}, time.Hour, postdog.WithThrottleStore(failingThrottleStore{})),
+				)
+
+				Convey("When I send a mail", func() {
+					err := dog.Send(stdctx.Background(), mockLetter)
+
+					Convey("It should fail", func() {
+						So(errors.Is(err, mockError), ShouldBeTrue)
+					})
+				})
+			})
+		})
+
 		Convey("Feature: Hooks > BeforeSend", func() {
 			Convey("Given a Transport that takes 50 milliseconds to send a Mail", WithDelayedTransport(ctrl, 50*time.Millisecond, func(tr *mock_postdog.MockTransport) {
 				Convey("Given a single Hook", func() {
@@ -536,6 +910,95 @@ func TestPostdog(t *testing.T) {
 				})
 			}))
 
+			Convey("Given multiple Transports and a Listener that needs the name of the used Transport", func() {
+				tr1 := mock_postdog.NewMockTransport(ctrl)
+				tr2 := mock_postdog.NewMockTransport(ctrl)
+				tr1.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+				tr2.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+				gotName := make(chan string, 1)
+				lis := mock_postdog.NewMockListener(ctrl)
+				lis.EXPECT().
+					Handle(gomock.Any(), postdog.AfterSend, mockLetter).
+					Do(func(ctx stdctx.Context, _ postdog.Hook, _ postdog.Mail) {
+						gotName <- postdog.SentVia(ctx)
+					}).
+					AnyTimes()
+
+				dog := postdog.New(
+					postdog.WithTransport("test1", tr1),
+					postdog.WithTransport("test2", tr2),
+					postdog.WithHook(postdog.AfterSend, lis),
+				)
+
+				Convey("When I send a Mail through the default Transport", func() {
+					err := dog.Send(stdctx.Background(), mockLetter)
+
+					Convey("It shouldn't fail", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("The Listener should have received the name of the default Transport", func() {
+						So(<-gotName, ShouldEqual, "test1")
+					})
+				})
+
+				Convey("When I send a Mail through a specific Transport", func() {
+					err := dog.Send(stdctx.Background(), mockLetter, send.Use("test2"))
+
+					Convey("It shouldn't fail", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("The Listener should have received the name of that Transport", func() {
+						So(<-gotName, ShouldEqual, "test2")
+					})
+				})
+			})
+
+			Convey("Given a SyncListener for the TransportSelected Hook", func() {
+				tr1 := mock_postdog.NewMockTransport(ctrl)
+				tr2 := mock_postdog.NewMockTransport(ctrl)
+				tr2.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil)
+
+				var events []string
+				record := func(event string) postdog.SyncListenerFunc {
+					return func(ctx stdctx.Context, _ postdog.Hook, _ postdog.Mail) error {
+						events = append(events, event+":"+postdog.SentVia(ctx))
+						return nil
+					}
+				}
+
+				dog := postdog.New(
+					postdog.WithTransport("test1", tr1),
+					postdog.WithTransport("test2", tr2),
+					postdog.WithMiddlewareFunc(func(ctx stdctx.Context, m postdog.Mail, next postdog.NextMiddleware) (postdog.Mail, error) {
+						events = append(events, "middleware")
+						return next(ctx, m)
+					}),
+					postdog.WithSyncHook(postdog.TransportSelected, record("transportSelected")),
+					postdog.WithSyncHook(postdog.BeforeSend, record("beforeSend")),
+					postdog.WithSyncHook(postdog.AfterSend, record("afterSend")),
+				)
+
+				Convey("When I send a Mail", func() {
+					err := dog.Send(stdctx.Background(), mockLetter, send.Use("test2"))
+
+					Convey("It shouldn't fail", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("The Hook should be called with the selected Transport before the middlewares", func() {
+						So(events, ShouldResemble, []string{
+							"transportSelected:test2",
+							"middleware",
+							"beforeSend:test2",
+							"afterSend:test2",
+						})
+					})
+				})
+			})
+
 			Convey("Given a Transport that fails to send Mails", WithErrorTransport(ctrl, func(tr *mock_postdog.MockTransport) {
 				Convey("Given a Listener that needs the send error", func() {
 					gotError := make(chan error, 1)
@@ -568,6 +1031,69 @@ func TestPostdog(t *testing.T) {
 			}))
 		})
 
+		Convey("Feature: Sync hooks", func() {
+			Convey("Given a Transport", WithMockTransport(ctrl, func(tr *mock_postdog.MockTransport) {
+				Convey("Given a BeforeSend SyncListener that fails", func() {
+					dog := postdog.New(
+						postdog.WithTransport("test", tr),
+						postdog.WithSyncHook(postdog.BeforeSend, postdog.SyncListenerFunc(func(stdctx.Context, postdog.Hook, postdog.Mail) error {
+							return mockError
+						})),
+					)
+
+					Convey("When I send a Mail", func() {
+						err := dog.Send(stdctx.Background(), mockLetter)
+
+						Convey("It should fail with the listener error", func() {
+							So(errors.Is(err, mockError), ShouldBeTrue)
+						})
+					})
+				})
+
+				Convey("Given an AfterSend SyncListener that needs the send time", func() {
+					var sentAt time.Time
+					dog := postdog.New(
+						postdog.WithTransport("test", tr),
+						postdog.WithSyncHook(postdog.AfterSend, postdog.SyncListenerFunc(func(ctx stdctx.Context, _ postdog.Hook, _ postdog.Mail) error {
+							sentAt = postdog.SendTime(ctx)
+							return nil
+						})),
+					)
+
+					Convey("When I send a Mail", func() {
+						tr.EXPECT().Send(gomock.Any(), mockLetter).Return(nil)
+						err := dog.Send(stdctx.Background(), mockLetter)
+
+						Convey("It shouldn't fail", func() {
+							So(err, ShouldBeNil)
+						})
+
+						Convey("The listener should have been called before Send() returned", func() {
+							So(sentAt.IsZero(), ShouldBeFalse)
+						})
+					})
+				})
+
+				Convey("Given an AfterSend SyncListener that fails", func() {
+					dog := postdog.New(
+						postdog.WithTransport("test", tr),
+						postdog.WithSyncHook(postdog.AfterSend, postdog.SyncListenerFunc(func(stdctx.Context, postdog.Hook, postdog.Mail) error {
+							return mockError
+						})),
+					)
+
+					Convey("When I send a Mail", func() {
+						tr.EXPECT().Send(gomock.Any(), mockLetter).Return(nil)
+						err := dog.Send(stdctx.Background(), mockLetter)
+
+						Convey("It should fail with the listener error", func() {
+							So(errors.Is(err, mockError), ShouldBeTrue)
+						})
+					})
+				})
+			}))
+		})
+
 		Convey("Feature: Plugins", func() {
 			Convey("Given some Options that are Middleware options", func() {
 				var wg sync.WaitGroup
@@ -627,6 +1153,12 @@ func TestPostdog(t *testing.T) {
 	})
 }
 
+type failingThrottleStore struct{}
+
+func (failingThrottleStore) Seen(stdctx.Context, string, time.Time, time.Duration) (bool, error) {
+	return false, mockError
+}
+
 func WithMockTransport(ctrl *gomock.Controller, fn func(*mock_postdog.MockTransport)) func() {
 	return func() {
 		fn(newMockTransport(ctrl))
@@ -23,6 +23,8 @@ var (
 	ErrCanceled = errors.New("job canceled")
 	// ErrFinished means a job has already been finished and can therefore not be canceled.
 	ErrFinished = errors.New("job already finished")
+	// ErrQueueFull means a mail could not be dispatched because the queue buffer is full (see RejectWhenFull()).
+	ErrQueueFull = errors.New("queue full")
 )
 
 // Queue is the mailer queue.
@@ -30,10 +32,15 @@ type Queue struct {
 	mailer     Mailer
 	bufferSize int
 	workers    int
-
-	mux  sync.Mutex
-	jobs chan *Job
-	done chan struct{}
+	deadLetter func(context.Context, *Job)
+	rejectFull bool
+
+	mux     sync.Mutex
+	jobs    chan *Job
+	done    chan struct{}
+	stop    chan struct{}
+	senders *sync.WaitGroup
+	timers  map[*time.Timer]struct{}
 }
 
 // Mailer is an interface for *postdog.Dog.
@@ -51,6 +58,7 @@ type Job struct {
 	dispatchedAt time.Time
 	finishedAt   time.Time
 	done         chan struct{}
+	deadLetter   func(context.Context, *Job)
 
 	mux sync.RWMutex
 	err error
@@ -82,14 +90,59 @@ func Workers(w int) Option {
 	}
 }
 
+// WithDeadLetter returns an Option that sets the dead-letter sink of a
+// *Queue. sink is called for every job that fails to send its mail, after
+// j.Err() has been set and before j.Done() is closed, so that failed jobs can
+// be stored for later inspection or replay. Canceled jobs are not passed to
+// sink.
+//
+// sink receives a new context.Context, because the job's context may already
+// be canceled or expired; use j.Context() to access the values of the
+// dispatch context.
+func WithDeadLetter(sink func(context.Context, *Job)) Option {
+	return func(q *Queue) {
+		q.deadLetter = sink
+	}
+}
+
+// RejectWhenFull returns an Option that makes Dispatch() return ErrQueueFull
+// immediately if the buffer of a *Queue is full and all workers are busy,
+// instead of blocking until the mail can be queued or the context is
+// canceled. This lets callers like HTTP handlers fail fast and respond with
+// an error (e.g. 503 Service Unavailable) instead of holding the request open.
+//
+// The trade-off is that bursts that exceed the buffer are rejected even if the
+// workers would catch up shortly after, so the caller has to handle the
+// rejection, e.g. by retrying later. Use Buffer() to absorb expected bursts.
+func RejectWhenFull() Option {
+	return func(q *Queue) {
+		q.rejectFull = true
+	}
+}
+
+// Buffer returns the buffer size of q.
+func (q *Queue) Buffer() int {
+	return q.bufferSize
+}
+
+// Workers returns the worker count of q.
+func (q *Queue) Workers() int {
+	return q.workers
+}
+
 // Start the queue workers in a new goroutine.
 func (q *Queue) Start() error {
-	if q.started() {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	if q.jobs != nil {
 		return ErrStarted
 	}
 	q.jobs = make(chan *Job, q.bufferSize)
 	q.done = make(chan struct{})
-	go q.run()
+	q.stop = make(chan struct{})
+	q.senders = new(sync.WaitGroup)
+	q.timers = make(map[*time.Timer]struct{})
+	go q.run(q.jobs, q.done)
 	return nil
 }
 
@@ -104,18 +157,18 @@ func (q *Queue) started() bool {
 	return q.jobs != nil
 }
 
-func (q *Queue) run() {
+func (q *Queue) run(jobs <-chan *Job, done chan<- struct{}) {
 	var wg sync.WaitGroup
 	wg.Add(q.workers)
 	go func() {
 		wg.Wait()
-		close(q.done)
+		close(done)
 	}()
 
 	for i := 0; i < q.workers; i++ {
 		go func() {
 			defer wg.Done()
-			for job := range q.jobs {
+			for job := range jobs {
 				err := q.mailer.SendConfig(job.ctx, job.mail, job.cfg.Send)
 				job.finish(err)
 			}
@@ -125,22 +178,35 @@ func (q *Queue) run() {
 
 // Stop the queue. If the queue has not been started yet, Stop() returns
 // ErrNotStarted. If ctx is canceled before the remaining jobs have been
-// processed, Stop() returns ctx.Err().
+// processed, Stop() returns ctx.Err(). Mails that have been scheduled with
+// Schedule() and are not yet due are discarded.
+//
+// The queue stops accepting mails as soon as Stop() is called: Dispatch()
+// calls that are waiting for free space in the buffer fail with ErrNotStarted.
 func (q *Queue) Stop(ctx context.Context) error {
-	if !q.started() {
+	q.mux.Lock()
+	if q.jobs == nil {
+		q.mux.Unlock()
 		return ErrNotStarted
 	}
 
-	q.mux.Lock()
-	defer q.mux.Unlock()
+	for t := range q.timers {
+		t.Stop()
+	}
+
+	jobs, done, senders := q.jobs, q.done, q.senders
+	close(q.stop)
+	q.jobs, q.done, q.stop, q.senders, q.timers = nil, nil, nil, nil, nil
+	q.mux.Unlock()
+
+	// jobs must not be closed while a Dispatch() call is sending to it
+	senders.Wait()
+	close(jobs)
 
-	close(q.jobs)
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
-	case <-q.done:
-		q.jobs = nil
-		q.done = nil
+	case <-done:
 		return nil
 	}
 }
@@ -152,34 +218,111 @@ func (q *Queue) Dispatch(ctx context.Context, m postdog.Mail, opts ...dispatch.O
 
 // DispatchConfig does the same as Dispatch() but accepts a dispatch.Config instead if dispatch.Options.
 func (q *Queue) DispatchConfig(ctx context.Context, m postdog.Mail, cfg dispatch.Config) (*Job, error) {
-	if !q.started() {
+	q.mux.Lock()
+	if q.jobs == nil {
+		q.mux.Unlock()
 		return nil, ErrNotStarted
 	}
+	jobs, stop, senders := q.jobs, q.stop, q.senders
+	senders.Add(1)
+	q.mux.Unlock()
+	defer senders.Done()
+
+	j := q.newJob(ctx, m, cfg)
+
+	if q.rejectFull {
+		select {
+		case jobs <- j:
+			j.dispatchedAt = time.Now()
+			return j, nil
+		default:
+			j.cancel()
+			return nil, ErrQueueFull
+		}
+	}
 
-	var cancel context.CancelFunc
+	select {
+	case <-j.ctx.Done():
+		j.cancel()
+		return nil, j.ctx.Err()
+	case <-stop:
+		j.cancel()
+		return nil, ErrNotStarted
+	case jobs <- j:
+		j.dispatchedAt = time.Now()
+		return j, nil
+	}
+}
 
+func (q *Queue) newJob(ctx context.Context, m postdog.Mail, cfg dispatch.Config) *Job {
+	var cancel context.CancelFunc
 	if cfg.Timeout == 0 {
 		ctx, cancel = context.WithCancel(ctx)
 	} else {
 		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
 	}
 
-	j := &Job{
-		ctx:    ctx,
-		cancel: cancel,
-		mail:   m,
-		cfg:    cfg,
-		done:   make(chan struct{}),
+	return &Job{
+		ctx:        ctx,
+		cancel:     cancel,
+		mail:       m,
+		cfg:        cfg,
+		done:       make(chan struct{}),
+		deadLetter: q.deadLetter,
 	}
+}
 
-	select {
-	case <-ctx.Done():
-		cancel()
-		return nil, ctx.Err()
-	case q.jobs <- j:
-		j.dispatchedAt = time.Now()
-		return j, nil
+// Schedule dispatches m at the time at, so that q can be used as the
+// postdog.Scheduler of postdog.WithSendWindow(). m is sent with the given
+// send.Config when it is due. If at is not in the future, m is dispatched
+// immediately. If the queue has not been started yet, Schedule() returns
+// ErrNotStarted.
+//
+// Scheduled mails are kept in memory until they are due and are discarded
+// when the queue is stopped. Because the context of the caller is usually
+// canceled long before the mail is due, the mail is dispatched with a new
+// context.Context, so the values of ctx are not available to the job. Use
+// ScheduleConfig() to pass a dispatch.Config for the job.
+//
+// If a due mail can't be dispatched, e.g. because the buffer is full (see
+// RejectWhenFull()), its job fails and is passed to the dead-letter sink of q
+// (see WithDeadLetter()).
+func (q *Queue) Schedule(ctx context.Context, m postdog.Mail, at time.Time, cfg send.Config) error {
+	return q.ScheduleConfig(ctx, m, at, dispatch.Config{Send: cfg})
+}
+
+// ScheduleConfig does the same as Schedule() but accepts a dispatch.Config
+// that is used to dispatch m when it is due.
+func (q *Queue) ScheduleConfig(ctx context.Context, m postdog.Mail, at time.Time, cfg dispatch.Config) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
+
+	q.mux.Lock()
+	defer q.mux.Unlock()
+
+	if q.jobs == nil {
+		return ErrNotStarted
+	}
+
+	var t *time.Timer
+	t = time.AfterFunc(time.Until(at), func() {
+		q.mux.Lock()
+		_, scheduled := q.timers[t]
+		delete(q.timers, t)
+		q.mux.Unlock()
+
+		if !scheduled {
+			return
+		}
+
+		if _, err := q.DispatchConfig(context.Background(), m, cfg); err != nil {
+			q.newJob(context.Background(), m, cfg).finish(fmt.Errorf("dispatch scheduled mail: %w", err))
+		}
+	})
+	q.timers[t] = struct{}{}
+
+	return nil
 }
 
 // Context returns the job's context that has been passed to the (*Queue).Dispatch() method.
@@ -250,6 +393,17 @@ func (j *Job) Cancel(ctx context.Context) error {
 func (j *Job) finish(err error) {
 	defer close(j.done)
 	defer j.cancel()
+
+	j.setResult(err)
+
+	if j.deadLetter != nil {
+		if err := j.Err(); err != nil && !errors.Is(err, ErrCanceled) {
+			j.deadLetter(context.Background(), j)
+		}
+	}
+}
+
+func (j *Job) setResult(err error) {
 	j.mux.Lock()
 	defer j.mux.Unlock()
 	j.finishedAt = time.Now()
@@ -330,6 +330,199 @@ func TestQueue(t *testing.T) {
 			})
 		}))
 
+		Convey("Given a Mailer that always fails to send mails", WithErrorMailer(ctrl, func(m *mock_queue.MockMailer) {
+			Convey("Given a started *Queue with a dead-letter sink that uses that Mailer", func() {
+				deadLetters := make(chan *queue.Job, 1)
+				sinkCtxErrs := make(chan error, 1)
+				q := queue.New(m, queue.WithDeadLetter(func(ctx context.Context, j *queue.Job) {
+					sinkCtxErrs <- ctx.Err()
+					deadLetters <- j
+				}))
+				q.Start()
+
+				Convey("When I dispatch a mail", func() {
+					job, err := q.Dispatch(context.Background(), mockLetter)
+					So(err, ShouldBeNil)
+					<-job.Done()
+
+					Convey("The sink should receive the failed job", func() {
+						So(deadLetters, ShouldHaveLength, 1)
+						dead := <-deadLetters
+						So(dead, ShouldEqual, job)
+						So(errors.Is(dead.Err(), mockError), ShouldBeTrue)
+						So(dead.Mail(), ShouldResemble, mockLetter)
+						So(<-sinkCtxErrs, ShouldBeNil)
+					})
+				})
+			})
+		}))
+
+		Convey("Given a Mailer that sends mails", WithConfigMailer(ctrl, func(m *mock_queue.MockMailer, usedConfig <-chan send.Config) {
+			Convey("Given a started *Queue with a dead-letter sink that uses that Mailer", func() {
+				deadLetters := make(chan *queue.Job, 1)
+				q := queue.New(m, queue.WithDeadLetter(func(_ context.Context, j *queue.Job) {
+					deadLetters <- j
+				}))
+				q.Start()
+
+				Convey("When I dispatch a mail", func() {
+					job, err := q.Dispatch(context.Background(), mockLetter)
+					So(err, ShouldBeNil)
+					<-usedConfig
+					<-job.Done()
+
+					Convey("The sink should not receive the job", func() {
+						So(job.Err(), ShouldBeNil)
+						So(deadLetters, ShouldHaveLength, 0)
+					})
+				})
+			})
+		}))
+
+		Convey("Given a Mailer that sends scheduled mails", WithConfigMailer(ctrl, func(m *mock_queue.MockMailer, usedConfig <-chan send.Config) {
+			Convey("Given a started *Queue that uses that Mailer", func() {
+				q := queue.New(m)
+				q.Start()
+
+				Convey("When I schedule a mail 20 milliseconds from now", func() {
+					scheduledAt := time.Now()
+					cfg := send.Configure(send.Use("test"), send.Timeout(time.Minute))
+					err := q.Schedule(context.Background(), mockLetter, scheduledAt.Add(20*time.Millisecond), cfg)
+
+					Convey("The mail should be sent after ~20 milliseconds with the send.Config", func() {
+						So(err, ShouldBeNil)
+						So(<-usedConfig, ShouldResemble, cfg)
+						So(time.Since(scheduledAt), ShouldBeGreaterThanOrEqualTo, 20*time.Millisecond)
+					})
+				})
+			})
+		}))
+
+		Convey("Given a started *Queue with a Mailer that must not be called", func() {
+			q := queue.New(mock_queue.NewMockMailer(ctrl))
+			q.Start()
+
+			Convey("When I schedule a mail and stop the queue before the mail is due", func() {
+				err := q.Schedule(context.Background(), mockLetter, time.Now().Add(20*time.Millisecond), send.Config{})
+				So(err, ShouldBeNil)
+				So(q.Stop(context.Background()), ShouldBeNil)
+
+				Convey("The mail should not be sent", func() {
+					<-time.After(40 * time.Millisecond)
+				})
+			})
+		})
+
+		Convey("Given a *Queue that has not been started", func() {
+			q := queue.New(mock_queue.NewMockMailer(ctrl))
+
+			Convey("Schedule() should fail with ErrNotStarted", func() {
+				err := q.Schedule(context.Background(), mockLetter, time.Now(), send.Config{})
+				So(errors.Is(err, queue.ErrNotStarted), ShouldBeTrue)
+			})
+		})
+
+		Convey("Given a Mailer that blocks until it is released", func() {
+			started := make(chan struct{}, 2)
+			release := make(chan struct{})
+			m := mock_queue.NewMockMailer(ctrl)
+			m.EXPECT().
+				SendConfig(gomock.Any(), mockLetter, gomock.Any()).
+				DoAndReturn(func(context.Context, postdog.Mail, send.Config) error {
+					started <- struct{}{}
+					<-release
+					return nil
+				}).
+				Times(2)
+
+			Convey("Given a started *Queue with a buffer of 1 that rejects mails when it is full", func() {
+				deadLetters := make(chan *queue.Job, 1)
+				q := queue.New(m, queue.Buffer(1), queue.RejectWhenFull(), queue.WithDeadLetter(func(_ context.Context, j *queue.Job) {
+					deadLetters <- j
+				}))
+				q.Start()
+				Reset(func() {
+					close(release)
+					q.Stop(context.Background())
+				})
+
+				Convey("When I dispatch more mails than the worker and the buffer can take", func() {
+					_, err := q.Dispatch(context.Background(), mockLetter)
+					So(err, ShouldBeNil)
+					<-started
+
+					_, err = q.Dispatch(context.Background(), mockLetter)
+					So(err, ShouldBeNil)
+
+					start := time.Now()
+					job, err := q.Dispatch(context.Background(), mockLetter)
+
+					Convey("Dispatch() should fail immediately with ErrQueueFull", func() {
+						So(job, ShouldBeNil)
+						So(errors.Is(err, queue.ErrQueueFull), ShouldBeTrue)
+						So(time.Since(start), ShouldBeLessThan, 10*time.Millisecond)
+					})
+				})
+
+				Convey("When a scheduled mail is due while the buffer is full", func() {
+					_, err := q.Dispatch(context.Background(), mockLetter)
+					So(err, ShouldBeNil)
+					<-started
+
+					_, err = q.Dispatch(context.Background(), mockLetter)
+					So(err, ShouldBeNil)
+
+					So(q.Schedule(context.Background(), mockLetter, time.Now(), send.Config{}), ShouldBeNil)
+
+					Convey("The job of the mail should be passed to the dead-letter sink", func() {
+						j := <-deadLetters
+						So(errors.Is(j.Err(), queue.ErrQueueFull), ShouldBeTrue)
+						So(j.Mail(), ShouldResemble, mockLetter)
+					})
+				})
+			})
+		})
+
+		Convey("Given a Mailer that blocks the only worker", func() {
+			started := make(chan struct{}, 1)
+			release := make(chan struct{})
+			m := mock_queue.NewMockMailer(ctrl)
+			m.EXPECT().
+				SendConfig(gomock.Any(), mockLetter, gomock.Any()).
+				DoAndReturn(func(context.Context, postdog.Mail, send.Config) error {
+					started <- struct{}{}
+					<-release
+					return nil
+				})
+
+			Convey("Given a started, unbuffered *Queue that uses that Mailer", func() {
+				q := queue.New(m)
+				q.Start()
+
+				_, err := q.Dispatch(context.Background(), mockLetter)
+				So(err, ShouldBeNil)
+				<-started
+
+				Convey("When I stop the queue while a mail is waiting to be dispatched", func() {
+					dispatched := make(chan error, 1)
+					go func() {
+						_, err := q.Dispatch(context.Background(), mockLetter)
+						dispatched <- err
+					}()
+					<-time.After(10 * time.Millisecond)
+
+					stopped := make(chan error, 1)
+					go func() { stopped <- q.Stop(context.Background()) }()
+
+					Convey("Dispatch() should fail with ErrNotStarted", func() {
+						So(errors.Is(<-dispatched, queue.ErrNotStarted), ShouldBeTrue)
+						close(release)
+						So(<-stopped, ShouldBeNil)
+					})
+				})
+			})
+		})
+
 		Convey("Given a Mailer that counts send.Options passed to it", WithConfigMailer(ctrl, func(m *mock_queue.MockMailer, usedConfig <-chan send.Config) {
 			Convey("Given a started *Queue that uses that Mailer", func() {
 				q := queue.New(m)
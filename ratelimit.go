@@ -0,0 +1,105 @@
+package postdog
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateLimitError can be returned by Transports when the provider rejected a
+// mail because of rate limiting, e.g. with an HTTP 429 response. If the
+// provider specified when sends are allowed again (e.g. with a `Retry-After`
+// header), RetryAfter should be set to that duration, so that the Dog can
+// pause the rate limiters that implement BackoffWaiter.
+type RateLimitError struct {
+	// RetryAfter is the duration after which the provider accepts mails
+	// again. Zero means unknown.
+	RetryAfter time.Duration
+	// Err is the underlying error, e.g. the error response of the provider.
+	Err error
+}
+
+// A BackoffWaiter is a Waiter that can be paused. When a Transport of a Dog
+// returns a *RateLimitError with a RetryAfter duration, the Dog calls
+// SetNextAllowed() on every BackoffWaiter that has been added with
+// WithRateLimiter(), so that further sends wait until the provider accepts
+// mails again instead of being rejected, too.
+//
+// Use Backoff() to add this behaviour to an existing Waiter.
+type BackoffWaiter interface {
+	Waiter
+
+	// SetNextAllowed pauses the Waiter until t: calls to Wait() should block
+	// until t has passed.
+	SetNextAllowed(t time.Time)
+}
+
+type backoffWaiter struct {
+	rl Waiter
+
+	mux         sync.Mutex
+	nextAllowed time.Time
+}
+
+// Backoff returns a BackoffWaiter that waits until the time that has been set
+// with SetNextAllowed() has passed, and then calls rl.Wait(). SetNextAllowed()
+// never shortens a pause that is already in effect. If rl is nil, the returned
+// Waiter only implements the pause, so that a Dog without a rate limiter can
+// still honor the RetryAfter of a *RateLimitError:
+//
+//	dog := postdog.New(
+//		postdog.WithTransport("api", tr),
+//		postdog.WithRateLimiter(postdog.Backoff(rate.NewLimiter(10, 1))),
+//	)
+func Backoff(rl Waiter) BackoffWaiter {
+	return &backoffWaiter{rl: rl}
+}
+
+// Error returns the error message of err.
+func (err *RateLimitError) Error() string {
+	msg := "rate limited"
+	if err.RetryAfter > 0 {
+		msg = fmt.Sprintf("%s (retry after %s)", msg, err.RetryAfter)
+	}
+	if err.Err != nil {
+		msg = fmt.Sprintf("%s: %s", msg, err.Err)
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (err *RateLimitError) Unwrap() error {
+	return err.Err
+}
+
+func (w *backoffWaiter) Wait(ctx context.Context) error {
+	if d := w.pause(); d > 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	if w.rl == nil {
+		return nil
+	}
+	return w.rl.Wait(ctx)
+}
+
+func (w *backoffWaiter) SetNextAllowed(t time.Time) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	if t.After(w.nextAllowed) {
+		w.nextAllowed = t
+	}
+}
+
+func (w *backoffWaiter) pause() time.Duration {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	return time.Until(w.nextAllowed)
+}
@@ -0,0 +1,70 @@
+package postdog_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bounoable/postdog"
+	mock_postdog "github.com/bounoable/postdog/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRateLimiter_retryAfter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	providerErr := errors.New("429 Too Many Requests")
+	tr := mock_postdog.NewMockTransport(ctrl)
+	gomock.InOrder(
+		tr.EXPECT().Send(gomock.Any(), gomock.Any()).Return(&postdog.RateLimitError{
+			RetryAfter: 50 * time.Millisecond,
+			Err:        providerErr,
+		}),
+		tr.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil),
+	)
+
+	rl := mock_postdog.NewMockWaiter(ctrl)
+	rl.EXPECT().Wait(gomock.Any()).Return(nil).Times(2)
+
+	dog := postdog.New(
+		postdog.WithTransport("test", tr),
+		postdog.WithRateLimiter(postdog.Backoff(rl)),
+	)
+
+	err := dog.Send(context.Background(), mockMail())
+	var rlErr *postdog.RateLimitError
+	assert.True(t, errors.As(err, &rlErr))
+	assert.True(t, errors.Is(err, providerErr))
+
+	start := time.Now()
+	assert.Nil(t, dog.Send(context.Background(), mockMail()))
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(40*time.Millisecond))
+}
+
+func TestBackoff(t *testing.T) {
+	w := postdog.Backoff(nil)
+
+	start := time.Now()
+	assert.Nil(t, w.Wait(context.Background()))
+	assert.Less(t, int64(time.Since(start)), int64(10*time.Millisecond))
+
+	w.SetNextAllowed(time.Now().Add(50 * time.Millisecond))
+	// an earlier time doesn't shorten the pause
+	w.SetNextAllowed(time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.True(t, errors.Is(w.Wait(ctx), context.DeadlineExceeded))
+
+	assert.Nil(t, w.Wait(context.Background()))
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(40*time.Millisecond))
+}
+
+func TestRateLimitError_Error(t *testing.T) {
+	err := &postdog.RateLimitError{RetryAfter: 30 * time.Second, Err: errors.New("quota exceeded")}
+	assert.Equal(t, "rate limited (retry after 30s): quota exceeded", err.Error())
+	assert.Equal(t, "rate limited", (&postdog.RateLimitError{}).Error())
+}
@@ -0,0 +1,56 @@
+package postdog
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// DefaultRequestIDHeader is the default header of WithRequestID().
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// ContextWithRequestID returns a copy of ctx that carries the request ID id.
+// The middleware of WithRequestID() uses the request ID of the context of a
+// Send() call instead of generating a new one.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxRequestID, id)
+}
+
+// RequestID returns the request ID of ctx, or an empty string if ctx carries
+// no request ID. Hooks can use it to correlate their logs with the header
+// that has been added by WithRequestID().
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(ctxRequestID).(string)
+	return id
+}
+
+// WithRequestID returns an Option that adds a Middleware which adds a request
+// ID to every mail, so that the mail can be correlated with the application
+// request that sent it, e.g. when debugging with the mail provider. The ID is
+// taken from the context of the Send() call (see ContextWithRequestID()) or
+// generated by gen, and is added as the header `headerName` to the RFC body
+// of the mail (see WithHeader()). If headerName is empty,
+// DefaultRequestIDHeader is used. If gen is nil, random UUIDs are generated.
+//
+// The ID is also added to the context that is passed to the remaining
+// middlewares, the Transport and the BeforeSend and AfterSend hooks, where it
+// can be retrieved with RequestID(). The TransportSelected hook is called
+// before the middlewares and therefore only receives IDs that are provided by
+// the context of the Send() call.
+func WithRequestID(headerName string, gen func() string) OptionFunc {
+	if headerName == "" {
+		headerName = DefaultRequestIDHeader
+	}
+	if gen == nil {
+		gen = func() string { return uuid.New().String() }
+	}
+
+	return WithMiddlewareFunc(func(ctx context.Context, m Mail, next NextMiddleware) (Mail, error) {
+		id := RequestID(ctx)
+		if id == "" {
+			id = gen()
+			ctx = ContextWithRequestID(ctx, id)
+		}
+		return next(ctx, WithHeader(m, headerName, id))
+	})
+}
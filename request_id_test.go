@@ -0,0 +1,122 @@
+package postdog_test
+
+import (
+	"context"
+	"net/mail"
+	"testing"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter"
+	"github.com/bounoable/postdog/middleware"
+	mock_postdog "github.com/bounoable/postdog/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRequestID(t *testing.T) {
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		headerName string
+		wantHeader string
+		wantID     string
+	}{
+		{
+			name:       "generated",
+			ctx:        context.Background(),
+			wantHeader: "X-Request-ID",
+			wantID:     "generated-id",
+		},
+		{
+			name:       "from context",
+			ctx:        postdog.ContextWithRequestID(context.Background(), "request-id"),
+			headerName: "X-Correlation-ID",
+			wantHeader: "X-Correlation-ID",
+			wantID:     "request-id",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			var sent postdog.Mail
+			var trID string
+			tr := mock_postdog.NewMockTransport(ctrl)
+			tr.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, m postdog.Mail) error {
+				sent = m
+				trID = postdog.RequestID(ctx)
+				return nil
+			})
+
+			var hookID string
+			dog := postdog.New(
+				postdog.WithTransport("test", tr),
+				postdog.WithRequestID(test.headerName, func() string { return "generated-id" }),
+				postdog.WithSyncHook(postdog.AfterSend, postdog.SyncListenerFunc(func(ctx context.Context, _ postdog.Hook, _ postdog.Mail) error {
+					hookID = postdog.RequestID(ctx)
+					return nil
+				})),
+			)
+
+			assert.Nil(t, dog.Send(test.ctx, mockMail()))
+			assert.Contains(t, sent.RFC(), test.wantHeader+": "+test.wantID+"\r\n")
+			assert.Equal(t, test.wantID, trID)
+			assert.Equal(t, test.wantID, hookID)
+		})
+	}
+}
+
+func TestWithRequestID_defaultGenerator(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var ids []string
+	tr := mock_postdog.NewMockTransport(ctrl)
+	tr.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, m postdog.Mail) error {
+		ids = append(ids, postdog.RequestID(ctx))
+		return nil
+	}).Times(2)
+
+	dog := postdog.New(postdog.WithTransport("test", tr), postdog.WithRequestID("", nil))
+	assert.Nil(t, dog.Send(context.Background(), mockMail()))
+	assert.Nil(t, dog.Send(context.Background(), mockMail()))
+
+	assert.Len(t, ids, 2)
+	assert.NotEmpty(t, ids[0])
+	assert.NotEqual(t, ids[0], ids[1])
+}
+
+func TestWithRequestID_laterMiddlewares(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var sent postdog.Mail
+	tr := mock_postdog.NewMockTransport(ctrl)
+	tr.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, m postdog.Mail) error {
+		sent = m
+		return nil
+	})
+
+	dog := postdog.New(
+		postdog.WithTransport("test", tr),
+		postdog.WithRequestID("", func() string { return "generated-id" }),
+		postdog.WithMiddlewareFunc(func(ctx context.Context, m postdog.Mail, next postdog.NextMiddleware) (postdog.Mail, error) {
+			return next(ctx, letter.Expand(m).WithText("Modified."))
+		}),
+		postdog.WithTransportMiddleware("test", middleware.DefaultReplyTo(mail.Address{Address: "reply@example.com"})),
+	)
+
+	assert.Nil(t, dog.Send(context.Background(), letter.Write(
+		letter.From("Bob Belcher", "bob@example.com"),
+		letter.To("Linda Belcher", "linda@example.com"),
+		letter.Text("Hello."),
+	)))
+
+	l := letter.Expand(sent)
+	assert.Equal(t, "Modified.", l.Text())
+	assert.Equal(t, []mail.Address{{Address: "reply@example.com"}}, l.ReplyTo())
+	assert.Contains(t, sent.RFC(), "\r\nX-Request-ID: generated-id\r\n")
+	assert.Contains(t, sent.RFC(), "\r\nReply-To: <reply@example.com>\r\n")
+}
@@ -1,6 +1,9 @@
 package send
 
-import "time"
+import (
+	"net/mail"
+	"time"
+)
 
 // Option is a send option.
 type Option func(*Config)
@@ -8,7 +11,9 @@ type Option func(*Config)
 // Config is the send config.
 type Config struct {
 	Transport string
+	Group     string
 	Timeout   time.Duration
+	From      mail.Address
 }
 
 // Configure builds Config from opts.
@@ -27,9 +32,26 @@ func Use(transport string) Option {
 	}
 }
 
+// UseGroup sets the name of the transport group whose balancer selects the
+// transport for sending a Mail (see postdog.WithWeightedBalancer()). If a
+// transport is also set with Use(), that transport is used instead.
+func UseGroup(group string) Option {
+	return func(cfg *Config) {
+		cfg.Group = group
+	}
+}
+
 // Timeout returns an Option that adds a timeout a send.
 func Timeout(dur time.Duration) Option {
 	return func(cfg *Config) {
 		cfg.Timeout = dur
 	}
 }
+
+// From returns an Option that overrides the sender of a Mail for a single send.
+// The original Mail is not modified.
+func From(addr mail.Address) Option {
+	return func(cfg *Config) {
+		cfg.From = addr
+	}
+}
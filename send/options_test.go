@@ -1,6 +1,7 @@
 package send_test
 
 import (
+	"net/mail"
 	"testing"
 	"time"
 
@@ -14,8 +15,20 @@ func TestUse(t *testing.T) {
 	assert.Equal(t, "test", cfg.Transport)
 }
 
+func TestUseGroup(t *testing.T) {
+	var cfg send.Config
+	send.UseGroup("test")(&cfg)
+	assert.Equal(t, "test", cfg.Group)
+}
+
 func TestTimeout(t *testing.T) {
 	var cfg send.Config
 	send.Timeout(1234 * time.Millisecond)(&cfg)
 	assert.Equal(t, 1234*time.Millisecond, cfg.Timeout)
 }
+
+func TestFrom(t *testing.T) {
+	var cfg send.Config
+	send.From(mail.Address{Name: "Bob Belcher", Address: "bob@example.com"})(&cfg)
+	assert.Equal(t, mail.Address{Name: "Bob Belcher", Address: "bob@example.com"}, cfg.From)
+}
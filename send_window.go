@@ -0,0 +1,128 @@
+package postdog
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bounoable/postdog/send"
+)
+
+// A Scheduler sends a mail at a later time, e.g. through a queue (see
+// (*queue.Queue).Schedule()). cfg is the send.Config of the (*Dog).Send()
+// call, which should be used to send the mail when it is due.
+type Scheduler interface {
+	Schedule(ctx context.Context, m Mail, at time.Time, cfg send.Config) error
+}
+
+// SchedulerFunc allows a function to be used as a Scheduler.
+type SchedulerFunc func(context.Context, Mail, time.Time, send.Config) error
+
+// SendWindowOption is an option for WithSendWindow().
+type SendWindowOption func(*sendWindow)
+
+type sendWindow struct {
+	tzFunc    func(Mail) *time.Location
+	start     time.Duration
+	end       time.Duration
+	scheduler Scheduler
+	now       func() time.Time
+}
+
+// WithSendWindow returns an OptionFunc that adds a middleware to a *Dog that
+// only sends mails within a daily send window in the timezone of their
+// recipient, e.g. during business hours. The window starts start after and
+// ends end after midnight in the timezone that is returned by tzFunc:
+//
+//	dog := postdog.New(
+//		postdog.WithSendWindow(func(m postdog.Mail) *time.Location {
+//			return users.Timezone(m.Recipients()[0])
+//		}, 9*time.Hour, 17*time.Hour, q),
+//	)
+//
+// If end is before start, the window spans midnight. Mails for which tzFunc
+// returns nil are sent immediately.
+//
+// A mail that is sent outside of its window is not sent. Instead, it is
+// passed to s to be sent at the start of the next window, and Send() returns
+// without an error (see ErrSkipSend). The Scheduler should send the mail
+// through the same *Dog with the send.Config of the Send() call (e.g. the
+// transport that has been selected with send.Use()), so that it passes the
+// middlewares again when it is due. If s fails to schedule the mail, Send()
+// returns the error.
+func WithSendWindow(tzFunc func(Mail) *time.Location, start, end time.Duration, s Scheduler, opts ...SendWindowOption) OptionFunc {
+	w := sendWindow{
+		tzFunc:    tzFunc,
+		start:     start,
+		end:       end,
+		scheduler: s,
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(&w)
+	}
+	return WithMiddlewareFunc(w.handle)
+}
+
+// SendWindowClock returns a SendWindowOption that sets the function that
+// returns the current time.
+func SendWindowClock(now func() time.Time) SendWindowOption {
+	return func(w *sendWindow) {
+		w.now = now
+	}
+}
+
+// NextSendWindow returns t if t is within the daily send window from start to
+// end (see WithSendWindow()) in the timezone loc, or the start of the next
+// window otherwise.
+func NextSendWindow(t time.Time, loc *time.Location, start, end time.Duration) time.Time {
+	t = t.In(loc)
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	sinceMidnight := t.Sub(midnight)
+
+	if end < start {
+		if sinceMidnight >= start || sinceMidnight < end {
+			return t
+		}
+		return addClock(midnight, start)
+	}
+
+	if sinceMidnight >= start && sinceMidnight < end {
+		return t
+	}
+	if sinceMidnight < start {
+		return addClock(midnight, start)
+	}
+	return addClock(midnight.AddDate(0, 0, 1), start)
+}
+
+// addClock returns the time d after midnight on the day of midnight, using
+// the wall clock, so that daylight saving time transitions don't shift the
+// window.
+func addClock(midnight time.Time, d time.Duration) time.Time {
+	return time.Date(midnight.Year(), midnight.Month(), midnight.Day(), 0, 0, 0, int(d), midnight.Location())
+}
+
+// Schedule schedules m by calling fn.
+func (fn SchedulerFunc) Schedule(ctx context.Context, m Mail, at time.Time, cfg send.Config) error {
+	return fn(ctx, m, at, cfg)
+}
+
+func (w sendWindow) handle(ctx context.Context, m Mail, next NextMiddleware) (Mail, error) {
+	loc := w.tzFunc(m)
+	if loc == nil {
+		return next(ctx, m)
+	}
+
+	now := w.now()
+	at := NextSendWindow(now, loc, w.start, w.end)
+	if at.Equal(now.In(loc)) {
+		return next(ctx, m)
+	}
+
+	if err := w.scheduler.Schedule(ctx, m, at, sendConfig(ctx)); err != nil {
+		return m, fmt.Errorf("send window: schedule: %w", err)
+	}
+
+	return m, ErrSkipSend
+}
@@ -0,0 +1,183 @@
+package postdog_test
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"testing"
+	"time"
+
+	"github.com/bounoable/postdog"
+	mock_postdog "github.com/bounoable/postdog/mocks"
+	"github.com/bounoable/postdog/send"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithSendWindow(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("load location: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		now         time.Time
+		loc         *time.Location
+		start       time.Duration
+		end         time.Duration
+		wantSend    bool
+		wantSchedAt time.Time
+	}{
+		{
+			name:     "within window",
+			now:      time.Date(2020, 6, 1, 10, 0, 0, 0, berlin),
+			loc:      berlin,
+			start:    9 * time.Hour,
+			end:      17 * time.Hour,
+			wantSend: true,
+		},
+		{
+			name:        "before window",
+			now:         time.Date(2020, 6, 1, 6, 30, 0, 0, berlin),
+			loc:         berlin,
+			start:       9 * time.Hour,
+			end:         17 * time.Hour,
+			wantSchedAt: time.Date(2020, 6, 1, 9, 0, 0, 0, berlin),
+		},
+		{
+			name:        "after window",
+			now:         time.Date(2020, 6, 1, 17, 0, 0, 0, berlin),
+			loc:         berlin,
+			start:       9 * time.Hour,
+			end:         17 * time.Hour,
+			wantSchedAt: time.Date(2020, 6, 2, 9, 0, 0, 0, berlin),
+		},
+		{
+			name:        "recipient timezone",
+			now:         time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC),
+			loc:         berlin,
+			start:       9 * time.Hour,
+			end:         14 * time.Hour,
+			wantSchedAt: time.Date(2020, 6, 2, 9, 0, 0, 0, berlin),
+		},
+		{
+			name:     "window spans midnight",
+			now:      time.Date(2020, 6, 1, 23, 0, 0, 0, berlin),
+			loc:      berlin,
+			start:    22 * time.Hour,
+			end:      6 * time.Hour,
+			wantSend: true,
+		},
+		{
+			name:        "outside window that spans midnight",
+			now:         time.Date(2020, 6, 1, 12, 0, 0, 0, berlin),
+			loc:         berlin,
+			start:       22 * time.Hour,
+			end:         6 * time.Hour,
+			wantSchedAt: time.Date(2020, 6, 1, 22, 0, 0, 0, berlin),
+		},
+		{
+			name:     "no timezone",
+			now:      time.Date(2020, 6, 1, 3, 0, 0, 0, berlin),
+			start:    9 * time.Hour,
+			end:      17 * time.Hour,
+			wantSend: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			tr := mock_postdog.NewMockTransport(ctrl)
+			if test.wantSend {
+				tr.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil)
+			}
+
+			var scheduled []time.Time
+			dog := postdog.New(
+				postdog.WithTransport("test", tr),
+				postdog.WithSendWindow(
+					func(postdog.Mail) *time.Location { return test.loc },
+					test.start,
+					test.end,
+					postdog.SchedulerFunc(func(_ context.Context, _ postdog.Mail, at time.Time, _ send.Config) error {
+						scheduled = append(scheduled, at)
+						return nil
+					}),
+					postdog.SendWindowClock(func() time.Time { return test.now }),
+				),
+			)
+
+			assert.Nil(t, dog.Send(context.Background(), mockMail()))
+
+			if test.wantSend {
+				assert.Empty(t, scheduled)
+				return
+			}
+			if assert.Len(t, scheduled, 1) {
+				assert.True(t, test.wantSchedAt.Equal(scheduled[0]), "scheduled at %v; want %v", scheduled[0], test.wantSchedAt)
+			}
+		})
+	}
+}
+
+func TestWithSendWindow_sendConfig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var scheduled send.Config
+	dog := postdog.New(
+		postdog.WithTransport("a", mock_postdog.NewMockTransport(ctrl)),
+		postdog.WithTransport("b", mock_postdog.NewMockTransport(ctrl)),
+		postdog.WithSendWindow(
+			func(postdog.Mail) *time.Location { return time.UTC },
+			9*time.Hour,
+			17*time.Hour,
+			postdog.SchedulerFunc(func(_ context.Context, _ postdog.Mail, _ time.Time, cfg send.Config) error {
+				scheduled = cfg
+				return nil
+			}),
+			postdog.SendWindowClock(func() time.Time { return time.Date(2020, 6, 1, 20, 0, 0, 0, time.UTC) }),
+		),
+	)
+
+	from := mail.Address{Name: "Tina Belcher", Address: "tina@example.com"}
+	assert.Nil(t, dog.Send(context.Background(), mockMail(), send.Use("b"), send.From(from), send.Timeout(time.Minute)))
+	assert.Equal(t, send.Configure(send.Use("b"), send.From(from), send.Timeout(time.Minute)), scheduled)
+}
+
+func TestWithSendWindow_scheduleError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockError := errors.New("mock error")
+	dog := postdog.New(
+		postdog.WithTransport("test", mock_postdog.NewMockTransport(ctrl)),
+		postdog.WithSendWindow(
+			func(postdog.Mail) *time.Location { return time.UTC },
+			9*time.Hour,
+			17*time.Hour,
+			postdog.SchedulerFunc(func(context.Context, postdog.Mail, time.Time, send.Config) error {
+				return mockError
+			}),
+			postdog.SendWindowClock(func() time.Time { return time.Date(2020, 6, 1, 20, 0, 0, 0, time.UTC) }),
+		),
+	)
+
+	assert.True(t, errors.Is(dog.Send(context.Background(), mockMail()), mockError))
+}
+
+func TestNextSendWindow_daylightSavingTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("load location: %v", err)
+	}
+
+	// clocks are set forward at 2am on 2020-03-29
+	now := time.Date(2020, 3, 28, 18, 0, 0, 0, berlin)
+	next := postdog.NextSendWindow(now, berlin, 9*time.Hour, 17*time.Hour)
+	assert.True(t, time.Date(2020, 3, 29, 9, 0, 0, 0, berlin).Equal(next), "got %v", next)
+}
@@ -0,0 +1,44 @@
+package postdog
+
+import (
+	"context"
+	"strings"
+)
+
+// WithSubjectPrefix returns an Option that adds a Middleware which prefixes the
+// subject of every mail with prefix. Prefix and subject are separated by a
+// space. Mails whose subject already starts with prefix are not modified, and
+// mails without a subject get just the prefix as their subject.
+func WithSubjectPrefix(prefix string) OptionFunc {
+	return WithMiddlewareFunc(func(ctx context.Context, m Mail, next NextMiddleware) (Mail, error) {
+		subject := Subject(m)
+		if prefix == "" || strings.HasPrefix(subject, prefix) {
+			return next(ctx, m)
+		}
+		return next(ctx, WithSubject(m, joinSubject(prefix, subject)))
+	})
+}
+
+// WithSubjectSuffix returns an Option that adds a Middleware which appends
+// suffix to the subject of every mail. Subject and suffix are separated by a
+// space. Mails whose subject already ends with suffix are not modified, and
+// mails without a subject get just the suffix as their subject.
+func WithSubjectSuffix(suffix string) OptionFunc {
+	return WithMiddlewareFunc(func(ctx context.Context, m Mail, next NextMiddleware) (Mail, error) {
+		subject := Subject(m)
+		if suffix == "" || strings.HasSuffix(subject, suffix) {
+			return next(ctx, m)
+		}
+		return next(ctx, WithSubject(m, joinSubject(subject, suffix)))
+	})
+}
+
+func joinSubject(l, r string) string {
+	if l == "" {
+		return r
+	}
+	if r == "" {
+		return l
+	}
+	return strings.TrimRight(l, " ") + " " + strings.TrimLeft(r, " ")
+}
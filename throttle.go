@@ -0,0 +1,113 @@
+package postdog
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// A ThrottleStore records when mails with a specific key have been sent.
+type ThrottleStore interface {
+	// Seen returns whether key has been recorded within the duration window
+	// before now. If it hasn't, Seen records key at now. Implementations must
+	// perform the lookup and the recording atomically.
+	Seen(ctx context.Context, key string, now time.Time, window time.Duration) (bool, error)
+}
+
+// ThrottleOption is an option for WithThrottle().
+type ThrottleOption func(*throttle)
+
+type throttle struct {
+	keyFunc func(Mail) string
+	window  time.Duration
+	store   ThrottleStore
+	now     func() time.Time
+}
+
+// WithThrottle returns an OptionFunc that adds a middleware to a *Dog that
+// sends at most one mail per key within the duration window. The key of a
+// mail is determined by keyFunc (e.g. user ID + notification type), so unlike
+// deduplication, mails with different content are throttled if their keys
+// collide. Mails with an empty key are never throttled.
+//
+// Throttled mails are skipped using ErrSkipSend. A key is recorded when a
+// mail passes the middleware, even if the Transport fails to send the mail.
+//
+// Keys are recorded in memory by default. Use the WithThrottleStore() option
+// to share the records between multiple *Dogs or processes.
+func WithThrottle(keyFunc func(Mail) string, window time.Duration, opts ...ThrottleOption) OptionFunc {
+	t := throttle{
+		keyFunc: keyFunc,
+		window:  window,
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(&t)
+	}
+	if t.store == nil {
+		t.store = NewMemoryThrottleStore()
+	}
+	return WithMiddlewareFunc(t.handle)
+}
+
+// WithThrottleStore returns a ThrottleOption that records keys in s.
+func WithThrottleStore(s ThrottleStore) ThrottleOption {
+	return func(t *throttle) {
+		t.store = s
+	}
+}
+
+// ThrottleClock returns a ThrottleOption that sets the function that returns
+// the current time.
+func ThrottleClock(now func() time.Time) ThrottleOption {
+	return func(t *throttle) {
+		t.now = now
+	}
+}
+
+func (t throttle) handle(ctx context.Context, m Mail, next NextMiddleware) (Mail, error) {
+	key := t.keyFunc(m)
+	if key == "" {
+		return next(ctx, m)
+	}
+
+	seen, err := t.store.Seen(ctx, key, t.now(), t.window)
+	if err != nil {
+		return m, fmt.Errorf("throttle: %w", err)
+	}
+
+	if seen {
+		return m, ErrSkipSend
+	}
+
+	return next(ctx, m)
+}
+
+// NewMemoryThrottleStore returns an in-memory ThrottleStore.
+func NewMemoryThrottleStore() ThrottleStore {
+	return &memoryThrottleStore{seen: make(map[string]time.Time)}
+}
+
+type memoryThrottleStore struct {
+	mux  sync.Mutex
+	seen map[string]time.Time
+}
+
+func (s *memoryThrottleStore) Seen(_ context.Context, key string, now time.Time, window time.Duration) (bool, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	for k, t := range s.seen {
+		if now.Sub(t) >= window {
+			delete(s.seen, k)
+		}
+	}
+
+	if _, ok := s.seen[key]; ok {
+		return true, nil
+	}
+	s.seen[key] = now
+
+	return false, nil
+}
@@ -0,0 +1,37 @@
+package filesystem
+
+import (
+	"context"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/config"
+)
+
+type factoryConfig struct {
+	Dir string `config:"dir"`
+}
+
+// Factory accepts configuration as a map[string]interface{} and instantiates
+// the filesystem transport from it.
+//
+// Example configuration:
+//
+//	cfg := map[string]interface{}{
+//	  "dir": "./mails",
+//	}
+//
+// The "dir" is required.
+//
+// If the configuration is invalid, Factory returns a *config.InvalidConfigError.
+func Factory(_ context.Context, cfg map[string]interface{}) (postdog.Transport, error) {
+	var fcfg factoryConfig
+	if err := config.Decode(cfg, &fcfg); err != nil {
+		return nil, err
+	}
+
+	if fcfg.Dir == "" {
+		return nil, &config.InvalidConfigError{Key: "dir", Err: config.ErrMissingValue}
+	}
+
+	return Transport(Dir(fcfg.Dir)), nil
+}
@@ -0,0 +1,79 @@
+// Package filesystem provides a Transport that saves mails as .eml files.
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/bounoable/postdog"
+)
+
+var counter uint64
+
+// Option is an option for the filesystem transport.
+type Option func(*transport)
+
+type transport struct {
+	dir      string
+	filename func(postdog.Mail) string
+}
+
+// Transport returns a Transport that saves the raw RFC body of every mail as
+// an .eml file, which can be opened in mail clients like Thunderbird. By
+// default, the files are saved in the current working directory and named
+// after the time at which the mail is sent, e.g.
+// `20201016T120000.000000000Z-1.eml`.
+func Transport(opts ...Option) postdog.Transport {
+	tr := transport{dir: ".", filename: defaultFilename}
+	for _, opt := range opts {
+		opt(&tr)
+	}
+	return tr
+}
+
+// Dir returns an Option that sets the directory in which the mails are saved.
+// The directory is created if it doesn't exist.
+func Dir(path string) Option {
+	return func(tr *transport) {
+		tr.dir = path
+	}
+}
+
+// FilenameFunc returns an Option that sets the function that returns the
+// filename of a mail. The filename is relative to the directory of Dir(). An
+// existing file with the same name is overwritten.
+func FilenameFunc(fn func(postdog.Mail) string) Option {
+	return func(tr *transport) {
+		tr.filename = fn
+	}
+}
+
+func (tr transport) Send(ctx context.Context, m postdog.Mail) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(tr.dir, 0755); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+
+	path := filepath.Join(tr.dir, tr.filename(m))
+	if err := ioutil.WriteFile(path, []byte(m.RFC()), 0644); err != nil {
+		return fmt.Errorf("write file: %w", err)
+	}
+
+	return nil
+}
+
+func defaultFilename(postdog.Mail) string {
+	return fmt.Sprintf(
+		"%s-%d.eml",
+		time.Now().UTC().Format("20060102T150405.000000000Z"),
+		atomic.AddUint64(&counter, 1),
+	)
+}
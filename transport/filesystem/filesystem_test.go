@@ -0,0 +1,88 @@
+package filesystem_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/config"
+	"github.com/bounoable/postdog/transport/filesystem"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransport(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mails")
+	tr := filesystem.Transport(filesystem.Dir(dir))
+
+	assert.Nil(t, tr.Send(context.Background(), mockMail()))
+	assert.Nil(t, tr.Send(context.Background(), mockMail()))
+
+	files, err := ioutil.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Len(t, files, 2)
+
+	for _, f := range files {
+		assert.True(t, strings.HasSuffix(f.Name(), ".eml"), f.Name())
+
+		b, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		assert.Nil(t, err)
+		assert.Equal(t, "Subject: Hi.\r\n\r\nHello.", string(b))
+	}
+}
+
+func TestFilenameFunc(t *testing.T) {
+	dir := t.TempDir()
+	tr := filesystem.Transport(filesystem.Dir(dir), filesystem.FilenameFunc(func(m postdog.Mail) string {
+		return postdog.Subject(m) + ".eml"
+	}))
+
+	assert.Nil(t, tr.Send(context.Background(), mockMail()))
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "Hi..eml"))
+	assert.Nil(t, err)
+	assert.Equal(t, "Subject: Hi.\r\n\r\nHello.", string(b))
+}
+
+func TestTransport_writeError(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	assert.Nil(t, ioutil.WriteFile(file, nil, 0644))
+
+	err := filesystem.Transport(filesystem.Dir(file)).Send(context.Background(), mockMail())
+	assert.NotNil(t, err)
+
+	err = filesystem.Transport(filesystem.Dir(t.TempDir()), filesystem.FilenameFunc(func(postdog.Mail) string {
+		return filepath.Join("missing", "mail.eml")
+	})).Send(context.Background(), mockMail())
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
+
+func TestFactory(t *testing.T) {
+	dir := t.TempDir()
+
+	tr, err := filesystem.Factory(context.Background(), map[string]interface{}{"dir": dir})
+	assert.Nil(t, err)
+	assert.Nil(t, tr.Send(context.Background(), mockMail()))
+
+	files, err := ioutil.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Len(t, files, 1)
+
+	_, err = filesystem.Factory(context.Background(), map[string]interface{}{})
+	var cfgErr *config.InvalidConfigError
+	assert.True(t, errors.As(err, &cfgErr))
+	assert.Equal(t, "dir", cfgErr.Key)
+}
+
+func mockMail() postdog.Mail {
+	return postdog.RawMail(
+		mail.Address{Address: "bob@example.com"},
+		[]mail.Address{{Address: "linda@example.com"}},
+		"Subject: Hi.\r\n\r\nHello.",
+	)
+}
@@ -5,8 +5,15 @@ import (
 	"os"
 
 	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/config"
 )
 
+type factoryConfig struct {
+	Credentials string   `config:"credentials"`
+	Scopes      []string `config:"scopes"`
+	JWTSubject  string   `config:"jwtSubject"`
+}
+
 // Factory accepts configuration as a map[string]interface{} and instantiates the Gmail transport from it.
 //
 // Example configuration:
@@ -15,25 +22,32 @@ import (
 //     "scopes": []string{gmail.MailGoogleComScope},
 //     "jwtSubject": "bob@example.com",
 //   }
+//
+// If "credentials" is not provided, the `GMAIL_CREDENTIALS` environment
+// variable is used as the path to the credentials file.
 func Factory(_ context.Context, cfg map[string]interface{}) (postdog.Transport, error) {
+	var fcfg factoryConfig
+	if err := config.Decode(cfg, &fcfg); err != nil {
+		return nil, err
+	}
+
 	var opts []Option
 	var jwtOpts []JWTConfigOption
 
-	if scopes, ok := cfg["scopes"].([]string); ok {
-		opts = append(opts, Scopes(scopes...))
+	if len(fcfg.Scopes) > 0 {
+		opts = append(opts, Scopes(fcfg.Scopes...))
 	}
 
-	if jwtSubject, ok := cfg["jwtSubject"].(string); ok {
-		jwtOpts = append(jwtOpts, JWTSubject(jwtSubject))
+	if fcfg.JWTSubject != "" {
+		jwtOpts = append(jwtOpts, JWTSubject(fcfg.JWTSubject))
 	}
 
-	credentials, ok := cfg["credentials"].(string)
-	if !ok {
-		if creds := os.Getenv("GMAIL_CREDENTIALS"); creds == "" {
+	if fcfg.Credentials == "" {
+		if fcfg.Credentials = os.Getenv("GMAIL_CREDENTIALS"); fcfg.Credentials == "" {
 			return nil, ErrNoCredentials
 		}
 	}
-	opts = append(opts, CredentialsFile(credentials, jwtOpts...))
+	opts = append(opts, CredentialsFile(fcfg.Credentials, jwtOpts...))
 
 	return Transport(opts...), nil
 }
@@ -8,17 +8,24 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/bounoable/postdog"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"golang.org/x/oauth2/jwt"
 	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
 )
 
+// DefaultTokenRefreshBackoff is the default minimum duration between two
+// credential refreshes. See WithTokenRefreshOnError().
+const DefaultTokenRefreshBackoff = 30 * time.Second
+
 var (
 	// ErrNoCredentials means no credentials are provided to initialize the Gmail service.
 	ErrNoCredentials = errors.New("no credentials provided")
@@ -49,7 +56,10 @@ func Transport(opts ...Option) postdog.Transport {
 		opts = append([]Option{CredentialsFile(credsPath)}, opts...)
 	}
 
-	t := transport{newSender: newGmailSender}
+	t := transport{
+		newSender:      newGmailSender,
+		refreshBackoff: DefaultTokenRefreshBackoff,
+	}
 	for _, opt := range opts {
 		opt(&t)
 	}
@@ -75,6 +85,10 @@ type transport struct {
 	newSender      func(context.Context, oauth2.TokenSource, ...option.ClientOption) (Sender, error)
 	tokenSource    oauth2.TokenSource
 	newTokenSource func(context.Context, ...string) (oauth2.TokenSource, error)
+
+	refreshOnError bool
+	refreshBackoff time.Duration
+	lastRefresh    time.Time
 }
 
 // Sender wraps the *gmail.UsersMessagesService.Send().Do() method(s).
@@ -191,6 +205,31 @@ func CredentialsFile(path string, opts ...JWTConfigOption) Option {
 	})
 }
 
+// WithTokenRefreshOnError returns an Option that enables credential refreshes.
+// If enabled and Send() fails because of an authentication error, the cached
+// Sender and token source are dropped, so that the next call to Send() sets up
+// the Gmail service again (e.g. by reading a rotated credentials file).
+// Errors that aren't authentication errors never cause a refresh.
+//
+// Refreshes happen at most once per DefaultTokenRefreshBackoff. Use the
+// TokenRefreshBackoff() option to change the backoff.
+//
+// A Sender that has been provided with WithSender() is never dropped, and a
+// token source that has been provided with WithTokenSource() is reused.
+func WithTokenRefreshOnError(refresh bool) Option {
+	return func(t *transport) {
+		t.refreshOnError = refresh
+	}
+}
+
+// TokenRefreshBackoff returns an Option that sets the minimum duration
+// between two credential refreshes. See WithTokenRefreshOnError().
+func TokenRefreshBackoff(d time.Duration) Option {
+	return func(t *transport) {
+		t.refreshBackoff = d
+	}
+}
+
 // JWTSubject returns an Option that sets the `subject` field of the JWT config.
 func JWTSubject(subject string) JWTConfigOption {
 	return func(cfg *jwt.Config) {
@@ -203,15 +242,58 @@ func (tr *transport) Send(ctx context.Context, m postdog.Mail) error {
 		return err
 	}
 
-	if err := tr.sender.Send("me", &gmail.Message{
+	tr.RLock()
+	sender := tr.sender
+	tr.RUnlock()
+
+	if err := sender.Send("me", &gmail.Message{
 		Raw: base64.URLEncoding.EncodeToString([]byte(m.RFC())),
 	}); err != nil {
+		if tr.refreshOnError && isAuthError(err) {
+			tr.refresh(sender)
+		}
 		return fmt.Errorf("gmail: %w", err)
 	}
 
 	return nil
 }
 
+// refresh drops the cached Sender (if it's still the Sender that failed) and
+// the token source, so that the next call to ensure() sets them up again.
+func (tr *transport) refresh(failed Sender) {
+	tr.Lock()
+	defer tr.Unlock()
+
+	if tr.sender != failed || time.Since(tr.lastRefresh) < tr.refreshBackoff {
+		return
+	}
+
+	if tr.newTokenSource == nil && tr.tokenSource == nil {
+		// the Sender has been provided through WithSender()
+		return
+	}
+
+	tr.lastRefresh = time.Now()
+	tr.sender = nil
+	if tr.newTokenSource != nil {
+		tr.tokenSource = nil
+	}
+}
+
+func isAuthError(err error) bool {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == http.StatusUnauthorized
+	}
+	var retrieveErr *oauth2.RetrieveError
+	return errors.As(err, &retrieveErr)
+}
+
+// SendsRawRFC returns true because the Gmail API receives the raw RFC body.
+func (tr *transport) SendsRawRFC() bool {
+	return true
+}
+
 func (tr *transport) ensure(ctx context.Context) error {
 	if tr.initialized() {
 		return nil
@@ -234,6 +316,10 @@ func (tr *transport) init(ctx context.Context) error {
 	tr.Lock()
 	defer tr.Unlock()
 
+	if tr.sender != nil {
+		return nil
+	}
+
 	if tr.tokenSource == nil && tr.newTokenSource != nil {
 		ts, err := tr.newTokenSource(ctx, tr.scopes...)
 		if err != nil {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/base64"
 	"errors"
+	"net/http"
 	"testing"
 	"time"
 
@@ -17,6 +18,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/oauth2"
 	ggmail "google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
 )
 
@@ -117,6 +119,101 @@ func TestTransport(t *testing.T) {
 				})
 			})
 		})
+
+		Convey("Feature: Token refresh on error", func() {
+			authErr := &googleapi.Error{Code: http.StatusUnauthorized}
+			otherErr := &googleapi.Error{Code: http.StatusBadRequest}
+
+			sender1 := mock_gmail.NewMockSender(ctrl)
+			sender2 := mock_gmail.NewMockSender(ctrl)
+			senders := []gmail.Sender{sender1, sender2}
+
+			var tokenSourceCalls int
+			newTransport := func(opts ...gmail.Option) postdog.Transport {
+				return gmail.Transport(append([]gmail.Option{
+					gmail.WithTokenSourceFactory(func(context.Context, ...string) (oauth2.TokenSource, error) {
+						tokenSourceCalls++
+						return oauth2.StaticTokenSource(&oauth2.Token{}), nil
+					}),
+					gmail.WithSenderFactory(func(context.Context, oauth2.TokenSource, ...option.ClientOption) (gmail.Sender, error) {
+						s := senders[0]
+						senders = senders[1:]
+						return s, nil
+					}),
+				}, opts...)...)
+			}
+
+			Convey("Scenario: the sender fails with an authentication error", func() {
+				sender1.EXPECT().Send("me", gomock.Any()).Return(authErr)
+				expectSend(t, sender2, mockLetter)
+
+				tr := newTransport(gmail.WithTokenRefreshOnError(true))
+
+				Convey("When I send two mails", func() {
+					err1 := tr.Send(context.Background(), mockLetter)
+					err2 := tr.Send(context.Background(), mockLetter)
+
+					Convey("The first send should fail", func() {
+						So(errors.Is(err1, authErr), ShouldBeTrue)
+					})
+
+					Convey("The second send should use a new sender", func() {
+						So(err2, ShouldBeNil)
+						So(tokenSourceCalls, ShouldEqual, 2)
+					})
+				})
+			})
+
+			Convey("Scenario: the sender fails with another error", func() {
+				sender1.EXPECT().Send("me", gomock.Any()).Return(otherErr).Times(2)
+
+				tr := newTransport(gmail.WithTokenRefreshOnError(true))
+
+				Convey("When I send two mails", func() {
+					tr.Send(context.Background(), mockLetter)
+					err := tr.Send(context.Background(), mockLetter)
+
+					Convey("The sender should not be refreshed", func() {
+						So(errors.Is(err, otherErr), ShouldBeTrue)
+						So(tokenSourceCalls, ShouldEqual, 1)
+					})
+				})
+			})
+
+			Convey("Scenario: refreshes are disabled", func() {
+				sender1.EXPECT().Send("me", gomock.Any()).Return(authErr).Times(2)
+
+				tr := newTransport()
+
+				Convey("When I send two mails", func() {
+					tr.Send(context.Background(), mockLetter)
+					err := tr.Send(context.Background(), mockLetter)
+
+					Convey("The sender should not be refreshed", func() {
+						So(errors.Is(err, authErr), ShouldBeTrue)
+						So(tokenSourceCalls, ShouldEqual, 1)
+					})
+				})
+			})
+
+			Convey("Scenario: the refreshed sender fails within the backoff", func() {
+				sender1.EXPECT().Send("me", gomock.Any()).Return(authErr)
+				sender2.EXPECT().Send("me", gomock.Any()).Return(authErr).Times(2)
+
+				tr := newTransport(gmail.WithTokenRefreshOnError(true), gmail.TokenRefreshBackoff(time.Minute))
+
+				Convey("When I send three mails", func() {
+					tr.Send(context.Background(), mockLetter)
+					tr.Send(context.Background(), mockLetter)
+					err := tr.Send(context.Background(), mockLetter)
+
+					Convey("The sender should be refreshed only once", func() {
+						So(errors.Is(err, authErr), ShouldBeTrue)
+						So(tokenSourceCalls, ShouldEqual, 2)
+					})
+				})
+			})
+		})
 	})
 }
 
@@ -0,0 +1,85 @@
+package gmail
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sync"
+
+	"github.com/bounoable/postdog"
+	"google.golang.org/api/gmail/v1"
+)
+
+// Recorder is a Sender that records the sent messages instead of sending them
+// to the Gmail API. Use it with WithSender() or TestTransport() to test code
+// that uses the Gmail transport without credentials or network access.
+type Recorder struct {
+	mux      sync.RWMutex
+	messages []RecordedMessage
+	err      error
+}
+
+// RecordedMessage is a message that has been sent to a Recorder.
+type RecordedMessage struct {
+	UserID  string
+	Message *gmail.Message
+}
+
+// TestTransport returns a Gmail transport that sends mails to rec instead of
+// the Gmail API. The transport doesn't need any credentials.
+func TestTransport(rec *Recorder, opts ...Option) postdog.Transport {
+	return Transport(append(opts, WithSender(rec))...)
+}
+
+// NewRecorder returns a new *Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// FailWith makes subsequent calls to Send() fail with err. A nil error makes
+// Send() succeed again. Messages are not recorded while Send() fails.
+func (rec *Recorder) FailWith(err error) {
+	rec.mux.Lock()
+	defer rec.mux.Unlock()
+	rec.err = err
+}
+
+// Send records msg.
+func (rec *Recorder) Send(userID string, msg *gmail.Message) error {
+	rec.mux.Lock()
+	defer rec.mux.Unlock()
+	if rec.err != nil {
+		return rec.err
+	}
+	rec.messages = append(rec.messages, RecordedMessage{UserID: userID, Message: msg})
+	return nil
+}
+
+// Messages returns the recorded messages.
+func (rec *Recorder) Messages() []RecordedMessage {
+	rec.mux.RLock()
+	defer rec.mux.RUnlock()
+	res := make([]RecordedMessage, len(rec.messages))
+	copy(res, rec.messages)
+	return res
+}
+
+// RFC returns the decoded raw RFC bodies of the recorded messages.
+func (rec *Recorder) RFC() ([]string, error) {
+	msgs := rec.Messages()
+	res := make([]string, len(msgs))
+	for i, msg := range msgs {
+		b, err := base64.URLEncoding.DecodeString(msg.Message.Raw)
+		if err != nil {
+			return res, fmt.Errorf("decode message %d: %w", i, err)
+		}
+		res[i] = string(b)
+	}
+	return res, nil
+}
+
+// Reset removes the recorded messages.
+func (rec *Recorder) Reset() {
+	rec.mux.Lock()
+	defer rec.mux.Unlock()
+	rec.messages = nil
+}
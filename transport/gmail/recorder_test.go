@@ -0,0 +1,74 @@
+package gmail_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bounoable/postdog/letter"
+	"github.com/bounoable/postdog/letter/rfc"
+	"github.com/bounoable/postdog/transport/gmail"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRecorder(t *testing.T) {
+	Convey("Recorder", t, func() {
+		now := time.Now()
+		clock := rfc.ClockFunc(func() time.Time { return now })
+
+		mockLetter := letter.Write(
+			letter.From("Bob Belcher", "bob@example.com"),
+			letter.To("Linda Belcher", "linda@example.com"),
+			letter.Subject("Hi."),
+			letter.Text("Hello."),
+		).WithRFCOptions(rfc.WithClock(clock), rfc.WithMessageID("<id@example.com>"))
+
+		Convey("Given a test transport", func() {
+			rec := gmail.NewRecorder()
+			tr := gmail.TestTransport(rec)
+
+			Convey("When I send a mail", func() {
+				err := tr.Send(context.Background(), mockLetter)
+
+				Convey("It shouldn't fail", func() {
+					So(err, ShouldBeNil)
+				})
+
+				Convey("The message should be recorded", func() {
+					msgs := rec.Messages()
+					So(msgs, ShouldHaveLength, 1)
+					So(msgs[0].UserID, ShouldEqual, "me")
+				})
+
+				Convey("The recorded RFC body should be the RFC body of the mail", func() {
+					bodies, err := rec.RFC()
+					So(err, ShouldBeNil)
+					So(bodies, ShouldResemble, []string{mockLetter.RFC()})
+				})
+
+				Convey("When I reset the recorder", func() {
+					rec.Reset()
+
+					Convey("No messages should be recorded", func() {
+						So(rec.Messages(), ShouldBeEmpty)
+					})
+				})
+			})
+
+			Convey("When the recorder fails", func() {
+				mockError := errors.New("mock error")
+				rec.FailWith(mockError)
+				err := tr.Send(context.Background(), mockLetter)
+
+				Convey("Send() should fail", func() {
+					So(errors.Is(err, mockError), ShouldBeTrue)
+				})
+
+				Convey("No messages should be recorded", func() {
+					So(rec.Messages(), ShouldBeEmpty)
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,39 @@
+package maildir
+
+import (
+	"context"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/config"
+)
+
+type factoryConfig struct {
+	Dir      string `config:"dir"`
+	Hostname string `config:"hostname"`
+}
+
+// Factory accepts configuration as a map[string]interface{} and instantiates
+// the maildir transport from it.
+//
+// Example configuration:
+//
+//	cfg := map[string]interface{}{
+//	  "dir": "/var/mail/dev",
+//	}
+//
+// The "dir" is required. The optional "hostname" overrides the hostname that
+// is used in the filenames of the mails.
+//
+// If the configuration is invalid, Factory returns a *config.InvalidConfigError.
+func Factory(_ context.Context, cfg map[string]interface{}) (postdog.Transport, error) {
+	var fcfg factoryConfig
+	if err := config.Decode(cfg, &fcfg); err != nil {
+		return nil, err
+	}
+
+	if fcfg.Dir == "" {
+		return nil, &config.InvalidConfigError{Key: "dir", Err: config.ErrMissingValue}
+	}
+
+	return Transport(fcfg.Dir, Hostname(fcfg.Hostname)), nil
+}
@@ -0,0 +1,127 @@
+// Package maildir provides a Transport that delivers mails into a Maildir.
+package maildir
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/bounoable/postdog"
+)
+
+var counter uint64
+
+// Option is an option for the maildir transport.
+type Option func(*transport)
+
+type transport struct {
+	dir      string
+	hostname string
+}
+
+// Transport returns a Transport that writes the RFC body of every mail as a
+// file into the Maildir dir. The `tmp`, `new` and `cur` subdirectories of dir
+// are created if they don't exist.
+//
+// Every mail is first written and synced to `tmp` and then atomically moved
+// to `new`, so that readers of the Maildir never see partially written mails.
+// Filenames have the form `<seconds>.M<microseconds>P<pid>Q<counter>R<random>.<hostname>`,
+// which is unique across processes and hosts. Line endings are converted to
+// LF, as is conventional for Maildir files.
+func Transport(dir string, opts ...Option) postdog.Transport {
+	tr := transport{dir: dir}
+	for _, opt := range opts {
+		opt(&tr)
+	}
+	if tr.hostname == "" {
+		tr.hostname, _ = os.Hostname()
+	}
+	tr.hostname = strings.NewReplacer("/", `\057`, ":", `\072`).Replace(tr.hostname)
+	return tr
+}
+
+// Hostname returns an Option that sets the hostname that is used in the
+// filenames of the mails. Defaults to os.Hostname().
+func Hostname(h string) Option {
+	return func(tr *transport) {
+		tr.hostname = h
+	}
+}
+
+func (tr transport) Send(ctx context.Context, m postdog.Mail) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(tr.dir, sub), 0700); err != nil {
+			return fmt.Errorf("create %s directory: %w", sub, err)
+		}
+	}
+
+	name, err := tr.filename()
+	if err != nil {
+		return fmt.Errorf("generate filename: %w", err)
+	}
+
+	tmp := filepath.Join(tr.dir, "tmp", name)
+	if err := writeFile(tmp, strings.ReplaceAll(m.RFC(), "\r\n", "\n")); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, filepath.Join(tr.dir, "new", name)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("move mail to new: %w", err)
+	}
+
+	return nil
+}
+
+func (tr transport) filename() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	return fmt.Sprintf(
+		"%d.M%dP%dQ%dR%s.%s",
+		now.Unix(),
+		now.Nanosecond()/1000,
+		os.Getpid(),
+		atomic.AddUint64(&counter, 1),
+		hex.EncodeToString(b),
+		tr.hostname,
+	), nil
+}
+
+func writeFile(path, content string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("create file: %w", err)
+	}
+
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		os.Remove(path)
+		return fmt.Errorf("write file: %w", err)
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(path)
+		return fmt.Errorf("sync file: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("close file: %w", err)
+	}
+
+	return nil
+}
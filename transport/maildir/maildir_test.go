@@ -0,0 +1,86 @@
+package maildir_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/mail"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/config"
+	"github.com/bounoable/postdog/transport/maildir"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransport(t *testing.T) {
+	dir := t.TempDir()
+	tr := maildir.Transport(dir, maildir.Hostname("mail:host/1"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Nil(t, tr.Send(context.Background(), mockMail()))
+		}()
+	}
+	wg.Wait()
+
+	tmp, err := ioutil.ReadDir(filepath.Join(dir, "tmp"))
+	assert.Nil(t, err)
+	assert.Empty(t, tmp)
+
+	cur, err := ioutil.ReadDir(filepath.Join(dir, "cur"))
+	assert.Nil(t, err)
+	assert.Empty(t, cur)
+
+	files, err := ioutil.ReadDir(filepath.Join(dir, "new"))
+	assert.Nil(t, err)
+	assert.Len(t, files, 20)
+
+	for _, f := range files {
+		assert.True(t, strings.HasSuffix(f.Name(), `.mail\072host\0571`), f.Name())
+
+		b, err := ioutil.ReadFile(filepath.Join(dir, "new", f.Name()))
+		assert.Nil(t, err)
+		assert.Equal(t, "Subject: Hi.\n\nHello.", string(b))
+	}
+}
+
+func TestTransport_canceled(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := maildir.Transport(dir).Send(ctx, mockMail())
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestFactory(t *testing.T) {
+	dir := t.TempDir()
+
+	tr, err := maildir.Factory(context.Background(), map[string]interface{}{"dir": dir})
+	assert.Nil(t, err)
+	assert.Nil(t, tr.Send(context.Background(), mockMail()))
+
+	files, err := ioutil.ReadDir(filepath.Join(dir, "new"))
+	assert.Nil(t, err)
+	assert.Len(t, files, 1)
+
+	_, err = maildir.Factory(context.Background(), map[string]interface{}{})
+	var cfgErr *config.InvalidConfigError
+	assert.True(t, errors.As(err, &cfgErr))
+	assert.Equal(t, "dir", cfgErr.Key)
+}
+
+func mockMail() postdog.Mail {
+	return postdog.RawMail(
+		mail.Address{Address: "bob@example.com"},
+		[]mail.Address{{Address: "linda@example.com"}},
+		"Subject: Hi.\r\n\r\nHello.",
+	)
+}
@@ -0,0 +1,34 @@
+package smtp
+
+import "context"
+
+const (
+	ctxPort        = ctxKey("port")
+	ctxImplicitTLS = ctxKey("implicitTLS")
+)
+
+type ctxKey string
+
+// WithPort returns a new Context that carries the given port. Mails that are
+// sent with that Context are sent to that port instead of the port of the
+// transport.
+func WithPort(ctx context.Context, port int) context.Context {
+	return context.WithValue(ctx, ctxPort, port)
+}
+
+// WithImplicitTLS returns a new Context that carries the given implicit TLS
+// setting. Mails that are sent with that Context use that setting instead of
+// the ImplicitTLS() option of the transport.
+func WithImplicitTLS(ctx context.Context, enabled bool) context.Context {
+	return context.WithValue(ctx, ctxImplicitTLS, enabled)
+}
+
+func portFromContext(ctx context.Context) int {
+	port, _ := ctx.Value(ctxPort).(int)
+	return port
+}
+
+func implicitTLSFromContext(ctx context.Context) (bool, bool) {
+	enabled, ok := ctx.Value(ctxImplicitTLS).(bool)
+	return enabled, ok
+}
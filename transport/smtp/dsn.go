@@ -0,0 +1,134 @@
+package smtp
+
+import (
+	"errors"
+	"fmt"
+	"net/textproto"
+	"strings"
+
+	"github.com/emersion/go-smtp"
+)
+
+// Values for the `NOTIFY` parameter of DSN requests (RFC 3461).
+const (
+	// NotifySuccess requests a DSN when the mail has been delivered.
+	NotifySuccess = "SUCCESS"
+	// NotifyFailure requests a DSN when the mail could not be delivered.
+	NotifyFailure = "FAILURE"
+	// NotifyDelay requests a DSN when the delivery of the mail is delayed.
+	NotifyDelay = "DELAY"
+	// NotifyNever requests that no DSN is sent. It must not be combined with
+	// other values.
+	NotifyNever = "NEVER"
+)
+
+// Values for the `RET` parameter of DSN requests (RFC 3461).
+const (
+	// ReturnFull requests that the full mail is returned with a failure DSN.
+	ReturnFull = "FULL"
+	// ReturnHeaders requests that only the headers of the mail are returned
+	// with a failure DSN.
+	ReturnHeaders = "HDRS"
+)
+
+var (
+	// ErrDSNNotSupported means the server doesn't support the DSN extension.
+	ErrDSNNotSupported = errors.New("server doesn't support DSN")
+)
+
+type dsn struct {
+	notify []string
+	ret    string
+	strict bool
+}
+
+// WithDSN returns an Option that requests delivery status notifications
+// (RFC 3461) for every sent mail. notify contains the conditions under which
+// a DSN should be sent for a recipient (NotifySuccess, NotifyFailure,
+// NotifyDelay or NotifyNever) and is sent with every `RCPT TO` command. ret
+// (ReturnFull or ReturnHeaders) specifies how much of the mail is returned
+// with a failure DSN and is sent with the `MAIL FROM` command. Empty values
+// are omitted, so that the server's defaults apply.
+//
+// The parameters are only sent if the server advertises the DSN extension;
+// otherwise the mail is sent without them, unless StrictDSN() is used.
+// DSNs are only requested by transports that have been created by Transport().
+func WithDSN(notify []string, ret string) Option {
+	return func(tr *transport) {
+		if tr.dsn == nil {
+			tr.dsn = &dsn{}
+		}
+		tr.dsn.notify = notify
+		tr.dsn.ret = ret
+	}
+}
+
+// StrictDSN returns an Option that makes Send() fail with ErrDSNNotSupported
+// if DSNs are requested (see WithDSN()) but the server doesn't support the
+// DSN extension.
+func StrictDSN(strict bool) Option {
+	return func(tr *transport) {
+		if tr.dsn == nil {
+			tr.dsn = &dsn{}
+		}
+		tr.dsn.strict = strict
+	}
+}
+
+func (d *dsn) requested() bool {
+	return d != nil && (len(d.notify) > 0 || d.ret != "")
+}
+
+// mail issues the `MAIL FROM` command with the `RET` parameter. go-smtp
+// doesn't support the DSN parameters, so the commands are written to the
+// connection directly.
+func (d *dsn) mail(c *smtp.Client, from string) error {
+	if ok, _ := c.Extension("DSN"); !ok {
+		if d.strict {
+			return fmt.Errorf("smtp: %w", ErrDSNNotSupported)
+		}
+		return c.Mail(from, nil)
+	}
+
+	cmd := fmt.Sprintf("MAIL FROM:<%s>", from)
+	if ok, _ := c.Extension("8BITMIME"); ok {
+		cmd += " BODY=8BITMIME"
+	}
+	if d.ret != "" {
+		cmd += " RET=" + strings.ToUpper(d.ret)
+	}
+	return command(c, cmd)
+}
+
+// rcpt issues the `RCPT TO` command with the `NOTIFY` parameter.
+func (d *dsn) rcpt(c *smtp.Client, to string) error {
+	if ok, _ := c.Extension("DSN"); !ok || len(d.notify) == 0 {
+		return c.Rcpt(to)
+	}
+	return command(c, fmt.Sprintf("RCPT TO:<%s> NOTIFY=%s", to, strings.ToUpper(strings.Join(d.notify, ","))))
+}
+
+// command sends cmd to the server and reads the reply, which must have the
+// code 250.
+func command(c *smtp.Client, cmd string) error {
+	if strings.ContainsAny(cmd, "\r\n") {
+		return errors.New("smtp: a line must not contain CR or LF")
+	}
+
+	id, err := c.Text.Cmd("%s", cmd)
+	if err != nil {
+		return err
+	}
+	c.Text.StartResponse(id)
+	defer c.Text.EndResponse(id)
+
+	if _, _, err = c.Text.ReadResponse(250); err != nil {
+		var protoErr *textproto.Error
+		if errors.As(err, &protoErr) {
+			return &smtp.SMTPError{Code: protoErr.Code, Message: protoErr.Msg}
+		}
+		return err
+	}
+
+	return nil
+}
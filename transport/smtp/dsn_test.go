@@ -0,0 +1,123 @@
+package smtp_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bounoable/postdog/letter"
+	"github.com/bounoable/postdog/transport/smtp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithDSN(t *testing.T) {
+	let := letter.Write(
+		letter.From("Bob Belcher", "bob@example.com"),
+		letter.To("Linda Belcher", "linda@example.com"),
+		letter.CC("Tina Belcher", "tina@example.com"),
+	)
+
+	srv, port := newDSNTestServer(t)
+	tr := smtp.Transport(
+		"127.0.0.1", port, "", "",
+		smtp.WithDSN([]string{smtp.NotifySuccess, smtp.NotifyFailure, smtp.NotifyDelay}, smtp.ReturnHeaders),
+		smtp.StrictDSN(true),
+	)
+	assert.Nil(t, tr.Send(context.Background(), let))
+
+	cmds := srv.Commands()
+	assert.Contains(t, cmds, "MAIL FROM:<bob@example.com> RET=HDRS")
+	assert.Contains(t, cmds, "RCPT TO:<linda@example.com> NOTIFY=SUCCESS,FAILURE,DELAY")
+	assert.Contains(t, cmds, "RCPT TO:<tina@example.com> NOTIFY=SUCCESS,FAILURE,DELAY")
+}
+
+func TestWithDSN_notSupported(t *testing.T) {
+	let := letter.Write(
+		letter.From("Bob Belcher", "bob@example.com"),
+		letter.To("Linda Belcher", "linda@example.com"),
+	)
+	dsn := smtp.WithDSN([]string{smtp.NotifyFailure}, smtp.ReturnFull)
+
+	srv, port := newTestServer(t)
+	tr := smtp.Transport("127.0.0.1", port, "", "", dsn)
+	assert.Nil(t, tr.Send(context.Background(), let))
+	assert.Len(t, srv.Sessions(), 1)
+	assert.Equal(t, []string{"linda@example.com"}, srv.Sessions()[0].To)
+
+	tr = smtp.Transport("127.0.0.1", port, "", "", dsn, smtp.StrictDSN(true))
+	err := tr.Send(context.Background(), let)
+	assert.True(t, errors.Is(err, smtp.ErrDSNNotSupported))
+	assert.Len(t, srv.Sessions(), 1)
+}
+
+// dsnTestServer is a minimal SMTP server that advertises the DSN extension
+// and records the received commands.
+type dsnTestServer struct {
+	mux  sync.Mutex
+	cmds []string
+}
+
+func newDSNTestServer(t *testing.T) (*dsnTestServer, int) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	srv := &dsnTestServer{}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go srv.serve(conn)
+		}
+	}()
+
+	return srv, l.Addr().(*net.TCPAddr).Port
+}
+
+func (srv *dsnTestServer) Commands() []string {
+	srv.mux.Lock()
+	defer srv.mux.Unlock()
+	return srv.cmds
+}
+
+func (srv *dsnTestServer) serve(conn net.Conn) {
+	c := textproto.NewConn(conn)
+	defer c.Close()
+
+	c.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return
+		}
+
+		srv.mux.Lock()
+		srv.cmds = append(srv.cmds, line)
+		srv.mux.Unlock()
+
+		switch verb := strings.ToUpper(strings.Fields(line)[0]); verb {
+		case "EHLO":
+			c.PrintfLine("250-localhost")
+			c.PrintfLine("250 DSN")
+		case "DATA":
+			c.PrintfLine("354 go ahead")
+			if _, err := c.ReadDotBytes(); err != nil {
+				return
+			}
+			c.PrintfLine("250 ok")
+		case "QUIT":
+			c.PrintfLine("221 bye")
+			return
+		default:
+			c.PrintfLine("250 ok")
+		}
+	}
+}
@@ -2,11 +2,25 @@ package smtp
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"strings"
 
 	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/config"
 )
 
-// Factory accepts configuration as a map[string]interface{} and instantiates the SMTP transport from it.
+type factoryConfig struct {
+	Host         string `config:"host"`
+	Port         int    `config:"port"`
+	Username     string `config:"username"`
+	Password     string `config:"password"`
+	PasswordFile string `config:"passwordFile"`
+	TLS          string `config:"tls"`
+}
+
+// Factory accepts configuration as a map[string]interface{} and instantiates
+// the SMTP transport from it.
 //
 // Example configuration:
 //   cfg := map[string]interface{}{
@@ -14,20 +28,56 @@ import (
 //     "port": 587,
 //     "username": "abcdef123456",
 //     "password": "654321fedcba",
+//     "tls": "starttls",
 //   }
 //
-// Default host is "localhost". Default port is 587.
+// Instead of the "password", a "passwordFile" may be provided, from which the
+// password is read (see WithPasswordFile()). If both are provided, the
+// "passwordFile" takes precedence.
+//
+// The "host" is required. Default port is 587. The "tls" key accepts
+// "starttls" (default), "implicit" (see ImplicitTLS()) or "none", or a
+// boolean. Values may also be provided as strings, so that they can be filled
+// from `${VAR}` placeholders.
+//
+// If the configuration is invalid, Factory returns a *config.InvalidConfigError.
 func Factory(_ context.Context, cfg map[string]interface{}) (postdog.Transport, error) {
-	host, ok := cfg["host"].(string)
-	if !ok {
-		host = "localhost"
+	fcfg := factoryConfig{Port: 587}
+	if err := config.Decode(cfg, &fcfg); err != nil {
+		return nil, err
+	}
+
+	if fcfg.Host == "" {
+		return nil, &config.InvalidConfigError{Key: "host", Err: config.ErrMissingValue}
 	}
-	port, ok := cfg["port"].(int)
-	if !ok {
-		port = 587
+
+	var opts []Option
+	if strings.EqualFold(fcfg.TLS, "implicit") {
+		opts = append(opts, ImplicitTLS(true))
+	} else {
+		mode, err := parseTLSMode(fcfg.TLS)
+		if err != nil {
+			return nil, &config.InvalidConfigError{Key: "tls", Err: err}
+		}
+		opts = append(opts, WithTLSMode(mode))
+	}
+
+	if fcfg.PasswordFile != "" {
+		if _, err := os.Stat(fcfg.PasswordFile); err != nil {
+			return nil, &config.InvalidConfigError{Key: "passwordFile", Err: err}
+		}
+		opts = append(opts, WithPasswordFile(fcfg.PasswordFile))
 	}
-	username, _ := cfg["username"].(string)
-	password, _ := cfg["password"].(string)
 
-	return Transport(host, port, username, password), nil
+	return Transport(fcfg.Host, fcfg.Port, fcfg.Username, fcfg.Password, opts...), nil
+}
+
+func parseTLSMode(v string) (TLSMode, error) {
+	switch strings.ToLower(v) {
+	case "", "true", string(StartTLS):
+		return StartTLS, nil
+	case "false", string(NoTLS):
+		return NoTLS, nil
+	}
+	return "", fmt.Errorf("unknown tls mode %q", v)
 }
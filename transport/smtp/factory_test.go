@@ -3,17 +3,22 @@ package smtp
 import (
 	"context"
 	"errors"
+	"os"
+	"strconv"
 	"testing"
 
+	"github.com/bounoable/postdog/config"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestFactory(t *testing.T) {
 	type wantConfig struct {
-		host     string
-		port     int
-		username string
-		password string
+		host        string
+		port        int
+		username    string
+		password    string
+		tlsMode     TLSMode
+		implicitTLS bool
 	}
 
 	tests := []struct {
@@ -21,6 +26,7 @@ func TestFactory(t *testing.T) {
 		config     map[string]interface{}
 		wantConfig wantConfig
 		wantError  error
+		invalidKey string
 	}{
 		{
 			name: "full config",
@@ -35,22 +41,101 @@ func TestFactory(t *testing.T) {
 				port:     587,
 				username: "user",
 				password: "pass",
+				tlsMode:  StartTLS,
 			},
 		},
 		{
-			name: "default host = localhost",
+			name: "password file",
+			config: map[string]interface{}{
+				"host":         "smtp.mailtrap.io",
+				"username":     "user",
+				"password":     "pass",
+				"passwordFile": "./testdata/password",
+			},
+			wantConfig: wantConfig{
+				host:     "smtp.mailtrap.io",
+				port:     587,
+				username: "user",
+				password: "filepass",
+				tlsMode:  StartTLS,
+			},
+		},
+		{
+			name: "missing password file",
+			config: map[string]interface{}{
+				"host":         "smtp.mailtrap.io",
+				"username":     "user",
+				"passwordFile": "./testdata/missing",
+			},
+			wantError:  os.ErrNotExist,
+			invalidKey: "passwordFile",
+		},
+		{
+			name: "implicit tls",
+			config: map[string]interface{}{
+				"host": "smtp.mailtrap.io",
+				"port": 465,
+				"tls":  "implicit",
+			},
+			wantConfig: wantConfig{
+				host:        "smtp.mailtrap.io",
+				port:        465,
+				tlsMode:     StartTLS,
+				implicitTLS: true,
+			},
+		},
+		{
+			name: "missing host",
 			config: map[string]interface{}{
 				"port":     25,
 				"username": "user",
 				"password": "pass",
 			},
+			wantError:  config.ErrMissingValue,
+			invalidKey: "host",
+		},
+		{
+			name: "string values",
+			config: map[string]interface{}{
+				"host": "smtp.mailtrap.io",
+				"port": "25",
+				"tls":  "none",
+			},
 			wantConfig: wantConfig{
-				host:     "localhost",
-				port:     25,
-				username: "user",
-				password: "pass",
+				host:    "smtp.mailtrap.io",
+				port:    25,
+				tlsMode: NoTLS,
 			},
 		},
+		{
+			name: "boolean tls",
+			config: map[string]interface{}{
+				"host": "smtp.mailtrap.io",
+				"tls":  false,
+			},
+			wantConfig: wantConfig{
+				host:    "smtp.mailtrap.io",
+				port:    587,
+				tlsMode: NoTLS,
+			},
+		},
+		{
+			name: "invalid port",
+			config: map[string]interface{}{
+				"host": "smtp.mailtrap.io",
+				"port": "abc",
+			},
+			wantError:  strconv.ErrSyntax,
+			invalidKey: "port",
+		},
+		{
+			name: "invalid tls mode",
+			config: map[string]interface{}{
+				"host": "smtp.mailtrap.io",
+				"tls":  "sometimes",
+			},
+			invalidKey: "tls",
+		},
 		{
 			name: "default port = 587",
 			config: map[string]interface{}{
@@ -63,6 +148,7 @@ func TestFactory(t *testing.T) {
 				port:     587,
 				username: "user",
 				password: "pass",
+				tlsMode:  StartTLS,
 			},
 		},
 	}
@@ -70,17 +156,60 @@ func TestFactory(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			tr, err := Factory(context.Background(), test.config)
-			assert.True(t, errors.Is(err, test.wantError))
 
-			smtpTrans, ok := tr.(*transport)
-			assert.True(t, ok)
+			if test.invalidKey != "" {
+				if test.wantError != nil {
+					assert.True(t, errors.Is(err, test.wantError))
+				}
 
-			if test.wantError == nil {
-				assert.Equal(t, test.wantConfig.host, smtpTrans.host)
-				assert.Equal(t, test.wantConfig.port, smtpTrans.port)
-				assert.Equal(t, test.wantConfig.username, smtpTrans.username)
-				assert.Equal(t, test.wantConfig.password, smtpTrans.password)
+				var cfgErr *config.InvalidConfigError
+				assert.True(t, errors.As(err, &cfgErr))
+				assert.Equal(t, test.invalidKey, cfgErr.Key)
+				return
 			}
+
+			assert.Nil(t, err)
+
+			smtpTrans, ok := tr.(*transport)
+			assert.True(t, ok)
+			assert.Equal(t, test.wantConfig.host, smtpTrans.host)
+			assert.Equal(t, test.wantConfig.port, smtpTrans.port)
+			assert.Equal(t, test.wantConfig.username, smtpTrans.username)
+			assert.Equal(t, test.wantConfig.password, smtpTrans.password)
+			assert.Equal(t, test.wantConfig.tlsMode, smtpTrans.tlsMode)
+			assert.Equal(t, test.wantConfig.implicitTLS, smtpTrans.implicitTLS)
 		})
 	}
 }
+
+func TestFactory_config(t *testing.T) {
+	os.Setenv("SMTP_HOST", "smtp.mailtrap.io")
+	os.Setenv("SMTP_PORT", "2525")
+	os.Setenv("SMTP_USERNAME", "user")
+	os.Setenv("SMTP_PASSWORD", "pass")
+	os.Setenv("SMTP_TLS", "none")
+
+	cfg, err := config.File("./testdata/config.yml")
+	assert.Nil(t, err)
+
+	dog, err := cfg.Dog(context.Background(), config.WithTransportFactory("smtp", config.TransportFactoryFunc(Factory)))
+	assert.Nil(t, err)
+
+	tr, err := dog.Transport("smtp")
+	assert.Nil(t, err)
+
+	smtpTrans, ok := tr.(*transport)
+	assert.True(t, ok)
+	assert.Equal(t, "smtp.mailtrap.io", smtpTrans.host)
+	assert.Equal(t, 2525, smtpTrans.port)
+	assert.Equal(t, "user", smtpTrans.username)
+	assert.Equal(t, "pass", smtpTrans.password)
+	assert.Equal(t, NoTLS, smtpTrans.tlsMode)
+
+	os.Setenv("SMTP_HOST", "")
+	cfg, err = config.File("./testdata/config.yml")
+	assert.Nil(t, err)
+
+	_, err = cfg.Dog(context.Background(), config.WithTransportFactory("smtp", config.TransportFactoryFunc(Factory)))
+	assert.True(t, errors.Is(err, config.ErrMissingValue))
+}
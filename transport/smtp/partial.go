@@ -0,0 +1,56 @@
+package smtp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PartialSendError is returned by the SMTP transport if the server rejected
+// some, but not all recipients of a mail. The mail has still been sent to the
+// Accepted recipients, so callers may decide to treat the error as success:
+//
+//	var perr *smtp.PartialSendError
+//	if errors.As(err, &perr) {
+//		log.Printf("could not send mail to %v", perr.Rejected)
+//		err = nil
+//	}
+//
+// If the server rejects all recipients, the mail isn't sent and the transport
+// returns the rejection of the first recipient instead.
+type PartialSendError struct {
+	// Accepted are the recipients that have been accepted by the server.
+	Accepted []string
+	// Rejected are the recipients that have been rejected by the server.
+	Rejected []RecipientError
+}
+
+// RecipientError is the rejection of a single recipient.
+type RecipientError struct {
+	// Recipient is the address of the rejected recipient.
+	Recipient string
+	// Err is the response of the server, usually a *smtp.SMTPError of the
+	// github.com/emersion/go-smtp package.
+	Err error
+}
+
+func (err *PartialSendError) Error() string {
+	rejected := make([]string, len(err.Rejected))
+	for i, rerr := range err.Rejected {
+		rejected[i] = rerr.Error()
+	}
+	return fmt.Sprintf(
+		"smtp: %d of %d recipients rejected: %s",
+		len(err.Rejected),
+		len(err.Rejected)+len(err.Accepted),
+		strings.Join(rejected, "; "),
+	)
+}
+
+func (err RecipientError) Error() string {
+	return fmt.Sprintf("%s: %s", err.Recipient, err.Err)
+}
+
+// Unwrap returns the response of the server.
+func (err RecipientError) Unwrap() error {
+	return err.Err
+}
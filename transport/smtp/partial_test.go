@@ -0,0 +1,60 @@
+package smtp_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bounoable/postdog/letter"
+	"github.com/bounoable/postdog/transport/smtp"
+	gosmtp "github.com/emersion/go-smtp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartialSendError(t *testing.T) {
+	srv, port := newTestServer(t)
+	srv.RejectRecipients = []string{"tina@example.com"}
+
+	tr := smtp.Transport("127.0.0.1", port, "", "")
+	err := tr.Send(context.Background(), letter.Write(
+		letter.From("Bob Belcher", "bob@example.com"),
+		letter.To("Linda Belcher", "linda@example.com"),
+		letter.To("Tina Belcher", "tina@example.com"),
+		letter.Text("Hello."),
+	))
+
+	var perr *smtp.PartialSendError
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, []string{"linda@example.com"}, perr.Accepted)
+	assert.Len(t, perr.Rejected, 1)
+	assert.Equal(t, "tina@example.com", perr.Rejected[0].Recipient)
+
+	var serr *gosmtp.SMTPError
+	assert.True(t, errors.As(perr.Rejected[0], &serr))
+	assert.Equal(t, 550, serr.Code)
+
+	sessions := srv.Sessions()
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, []string{"linda@example.com"}, sessions[0].To)
+	assert.NotEmpty(t, sessions[0].Body)
+}
+
+func TestPartialSendError_allRejected(t *testing.T) {
+	srv, port := newTestServer(t)
+	srv.RejectRecipients = []string{"linda@example.com"}
+
+	tr := smtp.Transport("127.0.0.1", port, "", "")
+	err := tr.Send(context.Background(), letter.Write(
+		letter.From("Bob Belcher", "bob@example.com"),
+		letter.To("Linda Belcher", "linda@example.com"),
+	))
+
+	var perr *smtp.PartialSendError
+	assert.False(t, errors.As(err, &perr))
+
+	var serr *gosmtp.SMTPError
+	assert.True(t, errors.As(err, &serr))
+	assert.Equal(t, 550, serr.Code)
+
+	assert.Nil(t, srv.Sessions()[0].Body)
+}
@@ -0,0 +1,156 @@
+package smtp_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-smtp"
+)
+
+// testServer is an SMTP server that records the received mails.
+type testServer struct {
+	*smtp.Server
+
+	mux      sync.Mutex
+	sessions []*testSession
+
+	// RejectRecipients are the recipients that are rejected with a 550 reply.
+	RejectRecipients []string
+}
+
+type testSession struct {
+	srv      *testServer
+	Hostname string
+	TLS      bool
+	From     string
+	To       []string
+	Body     []byte
+}
+
+type testBackend struct {
+	srv *testServer
+}
+
+func newTestServer(t *testing.T) (*testServer, int) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	return serveTest(t, l)
+}
+
+// newTLSTestServer returns a test server that only accepts implicit TLS
+// connections and a *tls.Config that trusts its certificate.
+func newTLSTestServer(t *testing.T) (*testServer, int, *tls.Config) {
+	cert, pool := newTestCertificate(t)
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv, port := serveTest(t, l)
+	return srv, port, &tls.Config{RootCAs: pool, ServerName: "127.0.0.1"}
+}
+
+func newTestCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	tmpl := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+
+	parsed, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(parsed)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
+
+func serveTest(t *testing.T, l net.Listener) (*testServer, int) {
+	srv := &testServer{}
+	srv.Server = smtp.NewServer(testBackend{srv})
+	srv.Domain = "localhost"
+	srv.ReadTimeout = 5 * time.Second
+	srv.WriteTimeout = 5 * time.Second
+	srv.AllowInsecureAuth = true
+
+	go srv.Serve(l)
+	t.Cleanup(func() { srv.Close() })
+
+	return srv, l.Addr().(*net.TCPAddr).Port
+}
+
+func (srv *testServer) Sessions() []*testSession {
+	srv.mux.Lock()
+	defer srv.mux.Unlock()
+	return srv.sessions
+}
+
+func (be testBackend) Login(state *smtp.ConnectionState, _, _ string) (smtp.Session, error) {
+	return be.AnonymousLogin(state)
+}
+
+func (be testBackend) AnonymousLogin(state *smtp.ConnectionState) (smtp.Session, error) {
+	sess := &testSession{srv: be.srv, Hostname: state.Hostname, TLS: state.TLS.HandshakeComplete}
+	be.srv.mux.Lock()
+	be.srv.sessions = append(be.srv.sessions, sess)
+	be.srv.mux.Unlock()
+	return sess, nil
+}
+
+func (s *testSession) Reset() {}
+
+func (s *testSession) Logout() error {
+	return nil
+}
+
+func (s *testSession) Mail(from string, _ smtp.MailOptions) error {
+	s.From = from
+	return nil
+}
+
+func (s *testSession) Rcpt(to string) error {
+	for _, rejected := range s.srv.RejectRecipients {
+		if to == rejected {
+			return &smtp.SMTPError{Code: 550, EnhancedCode: smtp.EnhancedCode{5, 1, 1}, Message: "No such user"}
+		}
+	}
+	s.To = append(s.To, to)
+	return nil
+}
+
+func (s *testSession) Data(r io.Reader) error {
+	b, err := ioutil.ReadAll(r)
+	s.Body = b
+	return err
+}
@@ -5,13 +5,33 @@ package smtp
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/bounoable/postdog"
 	"github.com/emersion/go-sasl"
 	"github.com/emersion/go-smtp"
 )
 
+const (
+	// StartTLS upgrades the connection to TLS using the STARTTLS command if
+	// the server supports it. This is the default TLSMode.
+	StartTLS = TLSMode("starttls")
+	// NoTLS never upgrades the connection to TLS.
+	NoTLS = TLSMode("none")
+)
+
+// TLSMode specifies how the transport secures the connection to the server.
+type TLSMode string
+
 // MailSender wraps the smtp.SendMail() function in an interface.
 type MailSender interface {
 	SendMail(addr string, a sasl.Client, from string, to []string, msg []byte) error
@@ -24,38 +44,381 @@ type transport struct {
 	username string
 	password string
 
-	addr string
-	auth sasl.Client
+	addr          string
+	auth          sasl.Client
+	tlsMode       TLSMode
+	tlsConfig     *tls.Config
+	implicitTLS   bool
+	helloHostname string
+
+	passwordFile    string
+	passwordMux     sync.Mutex
+	passwordModTime time.Time
+
+	envelopeFrom       func(postdog.Mail) string
+	envelopeRecipients func(postdog.Mail) []string
+
+	wireLog *wireLog
+	dsn     *dsn
 }
 
-type smtpSender struct{}
+// Option is an option for the SMTP transport.
+type Option func(*transport)
+
+type smtpSender struct {
+	tr          *transport
+	implicitTLS bool
+	ctx         context.Context
+}
 
-// Transport returns an SMTP transport.
-func Transport(host string, port int, username, password string) postdog.Transport {
-	return TransportWithSender(smtpSender{}, host, port, username, password)
+// Transport returns an SMTP transport. The connection to the server is
+// closed when the context of a send is canceled or its deadline is exceeded,
+// so that stalled servers don't block the send.
+func Transport(host string, port int, username, password string, opts ...Option) postdog.Transport {
+	tr := newTransport(nil, host, port, username, password, opts...)
+	tr.sender = smtpSender{tr: tr}
+	return tr
 }
 
 // TransportWithSender returns an SMTP transport and accepts a custom implementation of the smtp.SendMail() function.
-func TransportWithSender(sender MailSender, host string, port int, username, password string) postdog.Transport {
-	return &transport{
-		sender:   sender,
-		host:     host,
-		port:     port,
-		username: username,
-		password: password,
-		addr:     fmt.Sprintf("%s:%d", host, port),
-		auth:     sasl.NewPlainClient("", username, password),
+func TransportWithSender(sender MailSender, host string, port int, username, password string, opts ...Option) postdog.Transport {
+	return newTransport(sender, host, port, username, password, opts...)
+}
+
+func newTransport(sender MailSender, host string, port int, username, password string, opts ...Option) *transport {
+	tr := &transport{
+		sender:             sender,
+		host:               host,
+		port:               port,
+		username:           username,
+		password:           password,
+		addr:               fmt.Sprintf("%s:%d", host, port),
+		tlsMode:            StartTLS,
+		envelopeFrom:       defaultEnvelopeFrom,
+		envelopeRecipients: defaultEnvelopeRecipients,
+	}
+	if username != "" {
+		tr.auth = sasl.NewPlainClient("", username, password)
 	}
+	for _, opt := range opts {
+		opt(tr)
+	}
+	if tr.passwordFile != "" {
+		// errors are returned by Send()
+		tr.authClient()
+	}
+	return tr
 }
 
-func (tr *transport) Send(_ context.Context, m postdog.Mail) error {
-	to := make([]string, len(m.Recipients()))
-	for i, rcpt := range m.Recipients() {
-		to[i] = rcpt.Address
+// WithTLSMode returns an Option that specifies how the connection to the
+// server is secured. Defaults to StartTLS.
+func WithTLSMode(mode TLSMode) Option {
+	return func(tr *transport) {
+		tr.tlsMode = mode
 	}
-	return tr.sender.SendMail(tr.addr, tr.auth, m.From().Address, to, []byte(m.RFC()))
+}
+
+// ImplicitTLS returns an Option that enables implicit TLS (SMTPS, usually on
+// port 465): the connection is wrapped in TLS immediately instead of being
+// upgraded with the STARTTLS command. If implicit TLS is enabled, the TLSMode
+// is ignored. Implicit TLS can be enabled or disabled for a single send with
+// WithImplicitTLS().
+func ImplicitTLS(enabled bool) Option {
+	return func(tr *transport) {
+		tr.implicitTLS = enabled
+	}
+}
+
+// WithTLSConfig returns an Option that specifies the TLS configuration for
+// STARTTLS and implicit TLS connections. By default, the server certificate
+// is verified against the configured host.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(tr *transport) {
+		tr.tlsConfig = cfg
+	}
+}
+
+// WithHelloHostname returns an Option that specifies the hostname that is sent
+// to the server with the EHLO / HELO command. If no hostname is specified, the
+// hostname reported by the operating system is used.
+func WithHelloHostname(name string) Option {
+	return func(tr *transport) {
+		tr.helloHostname = name
+	}
+}
+
+// WithPasswordFile returns an Option that reads the password from the file at
+// path instead of using the password passed to Transport(), e.g. a secret
+// that is mounted as a file. Trailing newlines are removed from the password.
+//
+// The file is read when the transport is created and re-read before a mail is
+// sent if it has been modified since, so that rotated secrets are picked up.
+// If the file cannot be read, Send() returns an error.
+func WithPasswordFile(path string) Option {
+	return func(tr *transport) {
+		tr.passwordFile = path
+	}
+}
+
+// WithEnvelopeFrom returns an Option that sets the function that determines
+// the envelope sender (`MAIL FROM`) of a mail. This allows the envelope sender
+// to differ from the `From` header, e.g. for VERP bounce handling.
+// Defaults to the bounce address of m if it has one (see letter.ReturnPath()),
+// otherwise to m.From().Address. fn replaces this default entirely, so the
+// bounce address of m is ignored unless fn uses it itself.
+func WithEnvelopeFrom(fn func(postdog.Mail) string) Option {
+	return func(tr *transport) {
+		tr.envelopeFrom = fn
+	}
+}
+
+// WithEnvelopeRecipients returns an Option that sets the function that
+// determines the envelope recipients (`RCPT TO`) of a mail. It can be used to
+// filter or override the recipients. Defaults to the distinct addresses of
+// m.Recipients(), compared case-insensitively, so that a recipient that
+// appears multiple times (see letter.AllowDuplicateRecipients()) receives the
+// mail only once.
+func WithEnvelopeRecipients(fn func(postdog.Mail) []string) Option {
+	return func(tr *transport) {
+		tr.envelopeRecipients = fn
+	}
+}
+
+func (tr *transport) Send(ctx context.Context, m postdog.Mail) error {
+	auth, err := tr.authClient()
+	if err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+
+	addr := tr.addr
+	if port := portFromContext(ctx); port != 0 {
+		addr = fmt.Sprintf("%s:%d", tr.host, port)
+	}
+
+	sender := tr.sender
+	if s, ok := sender.(smtpSender); ok {
+		s.ctx = ctx
+		s.implicitTLS = tr.implicitTLS
+		if implicit, ok := implicitTLSFromContext(ctx); ok {
+			s.implicitTLS = implicit
+		}
+		sender = s
+	}
+
+	return sender.SendMail(addr, auth, tr.envelopeFrom(m), tr.envelopeRecipients(m), []byte(m.RFC()))
+}
+
+// authClient returns the SASL client for authentication. If a password file
+// is used, the file is re-read if it has been modified since it was read.
+func (tr *transport) authClient() (sasl.Client, error) {
+	if tr.passwordFile == "" || tr.username == "" {
+		return tr.auth, nil
+	}
+
+	tr.passwordMux.Lock()
+	defer tr.passwordMux.Unlock()
+
+	info, err := os.Stat(tr.passwordFile)
+	if err != nil {
+		return nil, fmt.Errorf("read password file: %w", err)
+	}
+
+	if tr.auth != nil && info.ModTime().Equal(tr.passwordModTime) {
+		return tr.auth, nil
+	}
+
+	b, err := ioutil.ReadFile(tr.passwordFile)
+	if err != nil {
+		return nil, fmt.Errorf("read password file: %w", err)
+	}
+
+	tr.password = strings.TrimRight(string(b), "\r\n")
+	tr.passwordModTime = info.ModTime()
+	tr.auth = sasl.NewPlainClient("", tr.username, tr.password)
+
+	return tr.auth, nil
+}
+
+// SendsRawRFC returns true because SMTP transmits the RFC body as-is.
+func (tr *transport) SendsRawRFC() bool {
+	return true
+}
+
+func (tr *transport) hello() string {
+	if tr.helloHostname != "" {
+		return tr.helloHostname
+	}
+	if name, err := os.Hostname(); err == nil && name != "" {
+		return name
+	}
+	return "localhost"
+}
+
+// defaultEnvelopeFrom returns the return path of m, or the sender address of m
+// if it has no return path. Mails that wrap other mails (e.g. mails that are
+// returned by postdog.WithSubject()) are unwrapped to find the return path.
+func defaultEnvelopeFrom(m postdog.Mail) string {
+	for pm := m; pm != nil; {
+		if rp, ok := pm.(interface{ ReturnPath() string }); ok && rp.ReturnPath() != "" {
+			return rp.ReturnPath()
+		}
+		wm, ok := pm.(interface{ Unwrap() postdog.Mail })
+		if !ok {
+			break
+		}
+		pm = wm.Unwrap()
+	}
+	return m.From().Address
+}
+
+func defaultEnvelopeRecipients(m postdog.Mail) []string {
+	rcpts := m.Recipients()
+	to := make([]string, 0, len(rcpts))
+L:
+	for _, rcpt := range rcpts {
+		for _, addr := range to {
+			if strings.EqualFold(addr, rcpt.Address) {
+				continue L
+			}
+		}
+		to = append(to, rcpt.Address)
+	}
+	return to
+}
+
+func (tr *transport) newTLSConfig() *tls.Config {
+	if tr.tlsConfig != nil {
+		return tr.tlsConfig.Clone()
+	}
+	return &tls.Config{ServerName: tr.host}
 }
 
 func (s smtpSender) SendMail(addr string, a sasl.Client, from string, to []string, msg []byte) error {
-	return smtp.SendMail(addr, a, from, to, bytes.NewReader(msg))
+	ctx := s.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	// the client resets the deadline of the connection for every command, so
+	// the conversation is aborted by closing the connection when ctx is done
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stop:
+		}
+	}()
+
+	if err = s.converse(conn, addr, a, from, to, msg); err != nil && ctx.Err() != nil {
+		return fmt.Errorf("%w: %v", ctx.Err(), err)
+	}
+	return err
+}
+
+func (s smtpSender) converse(conn net.Conn, addr string, a sasl.Client, from string, to []string, msg []byte) error {
+	host, _, _ := net.SplitHostPort(addr)
+	if s.implicitTLS {
+		cfg := s.tr.newTLSConfig()
+		if cfg.ServerName == "" {
+			cfg.ServerName = host
+		}
+		conn = tls.Client(conn, cfg)
+	}
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if s.tr.wireLog != nil {
+		log := s.tr.wireLog.newConn()
+		log.Printf("connected to %s", addr)
+		c.DebugWriter = log
+	}
+
+	if err = c.Hello(s.tr.hello()); err != nil {
+		return err
+	}
+
+	if !s.implicitTLS && s.tr.tlsMode != NoTLS {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err = c.StartTLS(s.tr.newTLSConfig()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if a != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("smtp: server doesn't support AUTH")
+		}
+		if err = c.Auth(a); err != nil {
+			return err
+		}
+	}
+
+	if err = s.tr.mail(c, from); err != nil {
+		return err
+	}
+
+	var result PartialSendError
+	for _, rcpt := range to {
+		if err = s.tr.rcpt(c, rcpt); err != nil {
+			var serr *smtp.SMTPError
+			if !errors.As(err, &serr) {
+				return err
+			}
+			result.Rejected = append(result.Rejected, RecipientError{Recipient: rcpt, Err: serr})
+			continue
+		}
+		result.Accepted = append(result.Accepted, rcpt)
+	}
+	if len(result.Accepted) == 0 && len(result.Rejected) > 0 {
+		return fmt.Errorf("smtp: all recipients rejected: %w", result.Rejected[0].Err)
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(w, bytes.NewReader(msg)); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+
+	if err = c.Quit(); err != nil {
+		return err
+	}
+
+	if len(result.Rejected) > 0 {
+		return &result
+	}
+
+	return nil
+}
+
+func (tr *transport) mail(c *smtp.Client, from string) error {
+	if tr.dsn.requested() {
+		return tr.dsn.mail(c, from)
+	}
+	return c.Mail(from, nil)
+}
+
+func (tr *transport) rcpt(c *smtp.Client, to string) error {
+	if tr.dsn.requested() {
+		return tr.dsn.rcpt(c, to)
+	}
+	return c.Rcpt(to)
 }
@@ -2,13 +2,25 @@ package smtp_test
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/internal/encode"
 	"github.com/bounoable/postdog/letter"
 	"github.com/bounoable/postdog/letter/rfc"
 	"github.com/bounoable/postdog/transport/smtp"
 	mock_smtp "github.com/bounoable/postdog/transport/smtp/mocks"
+	"github.com/emersion/go-sasl"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 )
@@ -27,6 +39,7 @@ func TestTransport_Send(t *testing.T) {
 	tests := []struct {
 		name         string
 		letterOpts   []letter.Option
+		opts         []smtp.Option
 		assertSender func(letter.Letter, *mock_smtp.MockMailSender)
 	}{
 		{
@@ -65,6 +78,87 @@ func TestTransport_Send(t *testing.T) {
 					Return(nil)
 			},
 		},
+		{
+			name: "duplicate recipients",
+			letterOpts: []letter.Option{
+				letter.From("Bob Belcher", "bob@example.com"),
+				letter.To("Linda Belcher", "linda@example.com"),
+				letter.CC("Linda Belcher", "linda@example.com"),
+				letter.BCC("Linda Belcher", "LINDA@example.com"),
+				letter.AllowDuplicateRecipients(),
+			},
+			assertSender: func(let letter.Letter, s *mock_smtp.MockMailSender) {
+				s.EXPECT().
+					SendMail(addr, gomock.Any(), "bob@example.com", []string{"linda@example.com"}, []byte(let.RFC())).
+					Return(nil)
+			},
+		},
+		{
+			name: "custom envelope sender",
+			letterOpts: []letter.Option{
+				letter.From("Bob Belcher", "bob@example.com"),
+				letter.To("Linda Belcher", "linda@example.com"),
+			},
+			opts: []smtp.Option{
+				smtp.WithEnvelopeFrom(func(m postdog.Mail) string {
+					return "bounces+" + strings.Replace(m.Recipients()[0].Address, "@", "=", 1) + "@example.com"
+				}),
+			},
+			assertSender: func(let letter.Letter, s *mock_smtp.MockMailSender) {
+				s.EXPECT().
+					SendMail(addr, gomock.Any(), "bounces+linda=example.com@example.com", []string{"linda@example.com"}, []byte(let.RFC())).
+					Return(nil)
+			},
+		},
+		{
+			name: "return path",
+			letterOpts: []letter.Option{
+				letter.From("Bob Belcher", "bob@example.com"),
+				letter.To("Linda Belcher", "linda@example.com"),
+				letter.ReturnPath("bounces@example.com"),
+			},
+			assertSender: func(let letter.Letter, s *mock_smtp.MockMailSender) {
+				s.EXPECT().
+					SendMail(addr, gomock.Any(), "bounces@example.com", []string{"linda@example.com"}, []byte(let.RFC())).
+					Return(nil)
+			},
+		},
+		{
+			name: "custom envelope sender overrides return path",
+			letterOpts: []letter.Option{
+				letter.From("Bob Belcher", "bob@example.com"),
+				letter.To("Linda Belcher", "linda@example.com"),
+				letter.ReturnPath("bounces@example.com"),
+			},
+			opts: []smtp.Option{
+				smtp.WithEnvelopeFrom(func(m postdog.Mail) string {
+					return "verp@example.com"
+				}),
+			},
+			assertSender: func(let letter.Letter, s *mock_smtp.MockMailSender) {
+				s.EXPECT().
+					SendMail(addr, gomock.Any(), "verp@example.com", []string{"linda@example.com"}, []byte(let.RFC())).
+					Return(nil)
+			},
+		},
+		{
+			name: "custom envelope recipients",
+			letterOpts: []letter.Option{
+				letter.From("Bob Belcher", "bob@example.com"),
+				letter.To("Linda Belcher", "linda@example.com"),
+				letter.BCC("Gene Belcher", "gene@example.com"),
+			},
+			opts: []smtp.Option{
+				smtp.WithEnvelopeRecipients(func(m postdog.Mail) []string {
+					return []string{"tina@example.com"}
+				}),
+			},
+			assertSender: func(let letter.Letter, s *mock_smtp.MockMailSender) {
+				s.EXPECT().
+					SendMail(addr, gomock.Any(), "bob@example.com", []string{"tina@example.com"}, []byte(let.RFC())).
+					Return(nil)
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -81,13 +175,178 @@ func TestTransport_Send(t *testing.T) {
 				test.assertSender(let, s)
 			}
 
-			tr := smtp.TransportWithSender(s, host, port, username, password)
+			tr := smtp.TransportWithSender(s, host, port, username, password, test.opts...)
 			err = tr.Send(context.Background(), let)
 			assert.Nil(t, err)
 		})
 	}
 }
 
+func TestTransport_Send_wrappedReturnPath(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	let := letter.Write(
+		letter.From("Bob Belcher", "bob@example.com"),
+		letter.To("Linda Belcher", "linda@example.com"),
+		letter.ReturnPath("bounces@example.com"),
+	).WithRFCOptions(rfcOpts()...)
+	m := postdog.WithHeader(postdog.WithSubject(let, "Hi."), "X-Request-ID", "foo")
+
+	s := mock_smtp.NewMockMailSender(ctrl)
+	s.EXPECT().
+		SendMail(addr, gomock.Any(), "bounces@example.com", []string{"linda@example.com"}, []byte(m.RFC())).
+		Return(nil)
+
+	tr := smtp.TransportWithSender(s, host, port, username, password)
+	assert.Nil(t, tr.Send(context.Background(), m))
+}
+
+func TestWithHelloHostname(t *testing.T) {
+	osHostname, _ := os.Hostname()
+
+	tests := []struct {
+		name string
+		opts []smtp.Option
+		want string
+	}{
+		{
+			name: "default",
+			want: osHostname,
+		},
+		{
+			name: "custom hostname",
+			opts: []smtp.Option{smtp.WithHelloHostname("mail.example.com")},
+			want: "mail.example.com",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv, port := newTestServer(t)
+
+			tr := smtp.Transport("127.0.0.1", port, "", "", test.opts...)
+			err := tr.Send(context.Background(), letter.Write(
+				letter.From("Bob Belcher", "bob@example.com"),
+				letter.To("Linda Belcher", "linda@example.com"),
+			))
+			assert.Nil(t, err)
+
+			sessions := srv.Sessions()
+			assert.Len(t, sessions, 1)
+			assert.Equal(t, test.want, sessions[0].Hostname)
+			assert.Equal(t, "bob@example.com", sessions[0].From)
+			assert.Equal(t, []string{"linda@example.com"}, sessions[0].To)
+		})
+	}
+}
+
+func TestTransport_Send_stalledServer(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer l.Close()
+
+	// the server accepts connections but never responds
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	port := l.Addr().(*net.TCPAddr).Port
+	let := letter.Write(letter.From("Bob Belcher", "bob@example.com"), letter.To("Linda Belcher", "linda@example.com"))
+
+	for _, implicitTLS := range []bool{false, true} {
+		t.Run(fmt.Sprintf("implicit TLS: %v", implicitTLS), func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			tr := smtp.Transport("127.0.0.1", port, "", "", smtp.ImplicitTLS(implicitTLS))
+			start := time.Now()
+			err := tr.Send(ctx, let)
+
+			assert.True(t, errors.Is(err, context.DeadlineExceeded), "%v", err)
+			assert.Less(t, int64(time.Since(start)), int64(time.Second))
+		})
+	}
+}
+
+func TestImplicitTLS(t *testing.T) {
+	srv, port, tlsConfig := newTLSTestServer(t)
+	let := letter.Write(letter.From("Bob Belcher", "bob@example.com"), letter.To("Linda Belcher", "linda@example.com"))
+
+	tr := smtp.Transport("127.0.0.1", port, "", "", smtp.ImplicitTLS(true), smtp.WithTLSConfig(tlsConfig))
+	assert.Nil(t, tr.Send(context.Background(), let))
+
+	sessions := srv.Sessions()
+	assert.Len(t, sessions, 1)
+	assert.True(t, sessions[0].TLS)
+	assert.Equal(t, []string{"linda@example.com"}, sessions[0].To)
+
+}
+
+func TestWithPort(t *testing.T) {
+	plainSrv, plainPort := newTestServer(t)
+	tlsSrv, tlsPort, tlsConfig := newTLSTestServer(t)
+	let := letter.Write(letter.From("Bob Belcher", "bob@example.com"), letter.To("Linda Belcher", "linda@example.com"))
+
+	tr := smtp.Transport("127.0.0.1", plainPort, "", "", smtp.WithTLSConfig(tlsConfig))
+
+	assert.Nil(t, tr.Send(context.Background(), let))
+	assert.Len(t, plainSrv.Sessions(), 1)
+
+	ctx := smtp.WithImplicitTLS(smtp.WithPort(context.Background(), tlsPort), true)
+	assert.Nil(t, tr.Send(ctx, let))
+	assert.Len(t, plainSrv.Sessions(), 1)
+	assert.Len(t, tlsSrv.Sessions(), 1)
+	assert.True(t, tlsSrv.Sessions()[0].TLS)
+}
+
+func TestWithPasswordFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "postdog-smtp")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "password")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("secret1\n"), 0600))
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var passwords []string
+	sender := mock_smtp.NewMockMailSender(ctrl)
+	sender.EXPECT().
+		SendMail(addr, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ string, a sasl.Client, _ string, _ []string, _ []byte) error {
+			_, ir, err := a.Start()
+			assert.Nil(t, err)
+			passwords = append(passwords, strings.Split(string(ir), "\x00")[2])
+			return nil
+		}).
+		Times(2)
+
+	tr := smtp.TransportWithSender(sender, host, port, username, "", smtp.WithPasswordFile(path))
+	let := letter.Write(letter.From("Bob Belcher", "bob@example.com"), letter.To("Linda Belcher", "linda@example.com"))
+
+	assert.Nil(t, tr.Send(context.Background(), let))
+
+	assert.Nil(t, ioutil.WriteFile(path, []byte("secret2\n"), 0600))
+	future := time.Now().Add(time.Minute)
+	assert.Nil(t, os.Chtimes(path, future, future))
+
+	assert.Nil(t, tr.Send(context.Background(), let))
+	assert.Equal(t, []string{"secret1", "secret2"}, passwords)
+
+	assert.Nil(t, os.Remove(path))
+	assert.True(t, errors.Is(tr.Send(context.Background(), let), os.ErrNotExist))
+}
+
 func rfcOpts() []rfc.Option {
 	now := time.Now()
 	clock := rfc.ClockFunc(func() time.Time { return now })
@@ -97,3 +356,41 @@ func rfcOpts() []rfc.Option {
 		rfc.WithMessageIDFactory(idgen),
 	}
 }
+
+func TestWithWireLog(t *testing.T) {
+	srv, port := newTestServer(t)
+	let := letter.Write(
+		letter.From("Bob Belcher", "bob@example.com"),
+		letter.To("Linda Belcher", "linda@example.com"),
+		letter.Subject("Top secret subject"),
+		letter.Text("Hello."),
+	)
+
+	var log strings.Builder
+	tr := smtp.Transport("127.0.0.1", port, "bob", "wire-secret", smtp.WithTLSMode(smtp.NoTLS), smtp.WithWireLog(&log))
+	assert.Nil(t, tr.Send(context.Background(), let))
+	assert.Len(t, srv.Sessions(), 1)
+
+	lines := strings.Split(log.String(), "\n")
+	assert.Contains(t, lines, "[1] connected to 127.0.0.1:"+strconv.Itoa(port))
+	assert.Contains(t, lines, "[1] C: AUTH PLAIN [redacted]")
+	assert.Contains(t, log.String(), "\n[1] C: MAIL FROM:<bob@example.com>")
+	assert.Contains(t, lines, "[1] C: RCPT TO:<linda@example.com>")
+	assert.Regexp(t, `\n\[1\] C: \[message content, \d+ bytes\]\n\[1\] C: \.\n\[1\] S: 250 `, log.String())
+	assert.Contains(t, lines, "[1] C: QUIT")
+
+	for _, line := range lines {
+		if strings.Contains(line, "250") {
+			assert.True(t, strings.HasPrefix(line, "[1] S: 250"), line)
+		}
+	}
+
+	creds := base64.StdEncoding.EncodeToString([]byte("\x00bob\x00wire-secret"))
+	assert.NotContains(t, log.String(), creds)
+	assert.NotContains(t, log.String(), "wire-secret")
+	assert.NotContains(t, log.String(), "Top secret subject")
+	assert.NotContains(t, log.String(), encode.UTF8("Top secret subject"))
+
+	assert.Nil(t, tr.Send(context.Background(), let))
+	assert.Contains(t, strings.Split(log.String(), "\n"), "[2] C: QUIT")
+}
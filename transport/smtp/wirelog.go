@@ -0,0 +1,125 @@
+package smtp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const redacted = "[redacted]"
+
+// WithWireLog returns an Option that logs the SMTP conversation between the
+// transport and the server to w, e.g. to debug handshake and authentication
+// failures. Every line is prefixed with the number of the connection and the
+// direction (`C:` for commands sent by the transport, `S:` for replies of the
+// server), so that concurrent connections can be told apart:
+//
+//	[3] C: MAIL FROM:<bob@example.com>
+//	[3] S: 250 2.0.0 Roger, accepting mail from <bob@example.com>
+//
+// Credentials of the AUTH command and the following authentication exchange
+// are redacted, and the message content is replaced by its size.
+func WithWireLog(w io.Writer) Option {
+	return func(tr *transport) {
+		tr.wireLog = &wireLog{w: w}
+	}
+}
+
+type wireLog struct {
+	mux   sync.Mutex
+	w     io.Writer
+	conns uint64
+}
+
+// connLog logs the conversation of a single connection.
+type connLog struct {
+	log    *wireLog
+	prefix string
+	buf    []byte
+	inAuth bool
+	inData bool
+	data   int
+}
+
+func (l *wireLog) newConn() *connLog {
+	return &connLog{
+		log:    l,
+		prefix: fmt.Sprintf("[%d]", atomic.AddUint64(&l.conns, 1)),
+	}
+}
+
+func (l *wireLog) print(line string) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	io.WriteString(l.w, line+"\n")
+}
+
+// Printf logs an informational line that isn't part of the conversation.
+func (cl *connLog) Printf(format string, vals ...interface{}) {
+	cl.log.print(fmt.Sprintf("%s %s", cl.prefix, fmt.Sprintf(format, vals...)))
+}
+
+// Write receives both the commands and the replies of the connection.
+func (cl *connLog) Write(b []byte) (int, error) {
+	cl.buf = append(cl.buf, b...)
+	for {
+		i := bytes.IndexByte(cl.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := strings.TrimRight(string(cl.buf[:i]), "\r")
+		cl.buf = cl.buf[i+1:]
+		cl.line(line)
+	}
+	return len(b), nil
+}
+
+func (cl *connLog) line(line string) {
+	if cl.inData {
+		if line != "." {
+			cl.data += len(line) + len("\r\n")
+			return
+		}
+		cl.inData = false
+		cl.log.print(fmt.Sprintf("%s C: [message content, %d bytes]", cl.prefix, cl.data))
+		cl.log.print(fmt.Sprintf("%s C: .", cl.prefix))
+		return
+	}
+
+	if isReply(line) {
+		// 354 starts the message content
+		cl.inData, cl.data = strings.HasPrefix(line, "354"), 0
+		// 334 is a challenge of the authentication exchange
+		cl.inAuth = cl.inAuth && strings.HasPrefix(line, "334")
+		cl.log.print(fmt.Sprintf("%s S: %s", cl.prefix, line))
+		return
+	}
+
+	if cl.inAuth {
+		line = redacted
+	} else if fields := strings.Fields(line); len(fields) > 0 && strings.EqualFold(fields[0], "AUTH") {
+		cl.inAuth = true
+		if len(fields) > 2 {
+			line = strings.Join(append(fields[:2], redacted), " ")
+		}
+	}
+
+	cl.log.print(fmt.Sprintf("%s C: %s", cl.prefix, line))
+}
+
+// isReply determines if line is a reply of the server, which always starts
+// with a three-digit code followed by a space, a hyphen or the end of line.
+func isReply(line string) bool {
+	if len(line) < 3 {
+		return false
+	}
+	for _, r := range line[:3] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return len(line) == 3 || line[3] == ' ' || line[3] == '-'
+}
@@ -0,0 +1,46 @@
+// Package transport provides generic wrappers for postdog.Transports.
+package transport
+
+import (
+	"context"
+	"time"
+
+	"github.com/bounoable/postdog"
+)
+
+type timeoutTransport struct {
+	postdog.Transport
+	timeout time.Duration
+}
+
+// WithTimeout returns a Transport that wraps tr and cancels every send that
+// takes longer than d. If the Context passed to Send() already has an earlier
+// deadline (e.g. from send.Timeout()), that deadline is used instead.
+func WithTimeout(tr postdog.Transport, d time.Duration) postdog.Transport {
+	return &timeoutTransport{
+		Transport: tr,
+		timeout:   d,
+	}
+}
+
+func (tr *timeoutTransport) Send(ctx context.Context, m postdog.Mail) error {
+	if tr.timeout <= 0 {
+		return tr.Transport.Send(ctx, m)
+	}
+	ctx, cancel := context.WithTimeout(ctx, tr.timeout)
+	defer cancel()
+	return tr.Transport.Send(ctx, m)
+}
+
+// SendsRawRFC returns whether the wrapped Transport sends the raw RFC body.
+func (tr *timeoutTransport) SendsRawRFC() bool {
+	return postdog.SendsRawRFC(tr.Transport)
+}
+
+// Close closes the wrapped Transport if it implements postdog.ClosableTransport.
+func (tr *timeoutTransport) Close() error {
+	if ctr, ok := tr.Transport.(postdog.ClosableTransport); ok {
+		return ctr.Close()
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package transport_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bounoable/postdog"
+	"github.com/bounoable/postdog/letter"
+	mock_postdog "github.com/bounoable/postdog/mocks"
+	"github.com/bounoable/postdog/send"
+	"github.com/bounoable/postdog/transport"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTimeout(t *testing.T) {
+	tests := []struct {
+		name        string
+		delay       time.Duration
+		timeout     time.Duration
+		sendTimeout time.Duration
+		wantError   error
+		maxDuration time.Duration
+	}{
+		{
+			name:    "send within timeout",
+			delay:   10 * time.Millisecond,
+			timeout: 50 * time.Millisecond,
+		},
+		{
+			name:        "send exceeds timeout",
+			delay:       100 * time.Millisecond,
+			timeout:     20 * time.Millisecond,
+			wantError:   context.DeadlineExceeded,
+			maxDuration: 60 * time.Millisecond,
+		},
+		{
+			name:        "shorter send timeout wins",
+			delay:       100 * time.Millisecond,
+			timeout:     time.Second,
+			sendTimeout: 20 * time.Millisecond,
+			wantError:   context.DeadlineExceeded,
+			maxDuration: 60 * time.Millisecond,
+		},
+		{
+			name:        "shorter transport timeout wins",
+			delay:       100 * time.Millisecond,
+			timeout:     20 * time.Millisecond,
+			sendTimeout: time.Second,
+			wantError:   context.DeadlineExceeded,
+			maxDuration: 60 * time.Millisecond,
+		},
+		{
+			name:  "zero timeout",
+			delay: 10 * time.Millisecond,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			tr := mock_postdog.NewMockTransport(ctrl)
+			tr.EXPECT().
+				Send(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, _ postdog.Mail) error {
+					select {
+					case <-ctx.Done():
+						return ctx.Err()
+					case <-time.After(test.delay):
+						return nil
+					}
+				})
+
+			dog := postdog.New(postdog.WithTransport("test", transport.WithTimeout(tr, test.timeout)))
+
+			var opts []send.Option
+			if test.sendTimeout > 0 {
+				opts = append(opts, send.Timeout(test.sendTimeout))
+			}
+
+			start := time.Now()
+			err := dog.Send(context.Background(), letter.Write(), opts...)
+
+			if test.wantError == nil {
+				assert.Nil(t, err)
+				return
+			}
+
+			assert.True(t, errors.Is(err, test.wantError))
+			assert.Less(t, int64(time.Since(start)), int64(test.maxDuration))
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package postdog_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bounoable/postdog"
+	"github.com/stretchr/testify/assert"
+)
+
+type countTransport struct {
+	count int64
+}
+
+func (tr *countTransport) Send(context.Context, postdog.Mail) error {
+	atomic.AddInt64(&tr.count, 1)
+	return nil
+}
+
+func TestDog_UseChecked(t *testing.T) {
+	tr1, tr2 := &countTransport{}, &countTransport{}
+	dog := postdog.New(postdog.WithTransport("test1", tr1), postdog.WithTransport("test2", tr2))
+
+	err := dog.UseChecked("test3")
+	assert.True(t, errors.Is(err, postdog.ErrUnconfiguredTransport))
+	assert.Nil(t, dog.Send(context.Background(), mockMail()))
+	assert.Equal(t, int64(1), tr1.count)
+
+	assert.Nil(t, dog.UseChecked("test2"))
+	assert.Nil(t, dog.Send(context.Background(), mockMail()))
+	assert.Equal(t, int64(1), tr2.count)
+}
+
+func TestDog_Use_unconfigured(t *testing.T) {
+	dog := postdog.New(postdog.WithTransport("test", &countTransport{}))
+	dog.Use("typo")
+
+	err := dog.Send(context.Background(), mockMail())
+	assert.True(t, errors.Is(err, postdog.ErrUnconfiguredTransport))
+}
+
+func TestDog_UseChecked_concurrent(t *testing.T) {
+	tr1, tr2 := &countTransport{}, &countTransport{}
+	dog := postdog.New(postdog.WithTransport("test1", tr1), postdog.WithTransport("test2", tr2))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			assert.Nil(t, dog.Send(context.Background(), mockMail()))
+		}()
+		go func(i int) {
+			defer wg.Done()
+			name := "test1"
+			if i%2 == 0 {
+				name = "test2"
+			}
+			assert.Nil(t, dog.UseChecked(name))
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(100), atomic.LoadInt64(&tr1.count)+atomic.LoadInt64(&tr2.count))
+}